@@ -22,6 +22,17 @@ type Clock interface {
 	Now() time.Time
 }
 
+// SettableClock represents a Clock whose current date and time can be changed, which is mostly useful in tests.
+type SettableClock interface {
+	Clock
+
+	// Set the current date and time of the clock.
+	Set(t time.Time)
+
+	// Advance the current date and time of the clock by a given duration.
+	Advance(d time.Duration)
+}
+
 // UTCClock Implementation of a Clock that returns the current time of the system as UTC.
 type UTCClock struct {
 }
@@ -50,6 +61,16 @@ func (f FixedClock) Now() time.Time {
 	return f.CurrentDate
 }
 
+// Set the fixed date of the FixedClock.
+func (f *FixedClock) Set(t time.Time) {
+	f.CurrentDate = t
+}
+
+// Advance the fixed date of the FixedClock by a given duration.
+func (f *FixedClock) Advance(d time.Duration) {
+	f.CurrentDate = f.CurrentDate.Add(d)
+}
+
 // OffsetClock implementation of a Clock that returns a date with a predefined offset.
 type OffsetClock struct {
 	Offset time.Duration
@@ -36,21 +36,65 @@ type Bus interface {
 // InMemoryBus is an implementation of a command.Bus that sends command to handlers in memory.
 // The processing of the commands by their handlers is performed synchronously in the same memory space.
 type InMemoryBus struct {
-	handlers map[PayloadTypeName]Handler
+	handlers     map[PayloadTypeName]Handler
+	middlewares  []Middleware
+	recoverPanic func(recovered any) error
 }
 
+type InMemoryBusOption func(cb *InMemoryBus)
+
+// WithPanicRecovery wraps every handler registered with the bus so that a panic occurring while handling a command is
+// recovered and converted to an error by a given function. This error is then returned by Send as any other error of
+// the handler. Should the function return nil, the panic is silently discarded.
+func WithPanicRecovery(fn func(recovered any) error) InMemoryBusOption {
+	return func(cb *InMemoryBus) {
+		cb.recoverPanic = fn
+	}
+}
+
+// Middleware wraps the handling of commands to implement cross-cutting concerns such as validation, authorization or logging.
+// It receives the next HandlerFunc of the chain and returns a HandlerFunc that can act before and after calling it,
+// or short-circuit the chain by returning without calling it.
+type Middleware func(next HandlerFunc) HandlerFunc
+
 // NewInMemoryBus allows constructing an InMemoryBus.
-func NewInMemoryBus() *InMemoryBus {
+func NewInMemoryBus(opts ...InMemoryBusOption) *InMemoryBus {
 	bus := &InMemoryBus{
 		handlers: map[PayloadTypeName]Handler{},
 	}
+
+	for _, opt := range opts {
+		opt(bus)
+	}
+
 	return bus
 }
 
 func (cb *InMemoryBus) RegisterHandler(t PayloadTypeName, h Handler) {
+	if cb.recoverPanic != nil {
+		h = recoveringHandler(h, cb.recoverPanic)
+	}
 	cb.handlers[t] = h
 }
 
+// recoveringHandler wraps a handler so that its panics are converted to errors by a given function.
+func recoveringHandler(h Handler, recoverPanic func(recovered any) error) Handler {
+	return HandlerFunc(func(ctx context.Context, c Command) (result any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				result, err = nil, recoverPanic(r)
+			}
+		}()
+		return h.Handle(ctx, c)
+	})
+}
+
+// Use adds middleware around the handlers of this bus. Middleware is run in the order it was added,
+// the first one added being the outermost one.
+func (cb *InMemoryBus) Use(mw ...Middleware) {
+	cb.middlewares = append(cb.middlewares, mw...)
+}
+
 func (cb *InMemoryBus) Send(ctx context.Context, c Command) (any, error) {
 	// Handle
 	events, err := cb.handleCommand(ctx, c)
@@ -71,7 +115,12 @@ func (cb *InMemoryBus) handleCommand(ctx context.Context, c Command) (any, error
 		panic(err)
 	}
 
-	events, err := handler.Handle(ctx, c)
+	next := HandlerFunc(handler.Handle)
+	for i := len(cb.middlewares) - 1; i >= 0; i-- {
+		next = cb.middlewares[i](next)
+	}
+
+	events, err := next(ctx, c)
 	if err != nil {
 		err = errors.Wrapf(err, "failed handling command \"%s\"", c.Payload.TypeName())
 		return nil, err
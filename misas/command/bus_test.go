@@ -16,6 +16,7 @@ package command
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -53,3 +54,70 @@ func TestInMemoryBus_Send(t *testing.T) {
 func TestNewInMemoryBus(t *testing.T) {
 	assert.NotNil(t, NewInMemoryBus())
 }
+
+func TestInMemoryBus_Use(t *testing.T) {
+	var calls []string
+	recordingMiddleware := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, c Command) (any, error) {
+				calls = append(calls, name+":before")
+				response, err := next(ctx, c)
+				calls = append(calls, name+":after")
+				return response, err
+			}
+		}
+	}
+
+	bus := NewInMemoryBus()
+	bus.RegisterHandler(runUnitTestCommandTypeName, HandlerFunc(func(ctx context.Context, c Command) (any, error) {
+		calls = append(calls, "handler")
+		return "response", nil
+	}))
+	bus.Use(recordingMiddleware("first"), recordingMiddleware("second"))
+
+	response, err := bus.Send(context.Background(), New(runUnitTestCommandPayload{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "response", response)
+	assert.Equal(t, []string{"first:before", "second:before", "handler", "second:after", "first:after"}, calls)
+}
+
+func TestInMemoryBus_Use_ShortCircuit(t *testing.T) {
+	handled := false
+	bus := NewInMemoryBus()
+	bus.RegisterHandler(runUnitTestCommandTypeName, HandlerFunc(func(ctx context.Context, c Command) (any, error) {
+		handled = true
+		return nil, nil
+	}))
+	bus.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, c Command) (any, error) {
+			return nil, errors.Errorf("command \"%s\" is not authorized", c.Payload.TypeName())
+		}
+	})
+
+	response, err := bus.Send(context.Background(), New(runUnitTestCommandPayload{}))
+	assert.ErrorContains(t, err, "command \"unit_test.run\" is not authorized")
+	assert.Nil(t, response)
+	assert.False(t, handled)
+}
+
+func TestInMemoryBus_Send_WithPanicRecovery(t *testing.T) {
+	bus := NewInMemoryBus(WithPanicRecovery(func(recovered any) error {
+		return errors.Errorf("handler panicked: %v", recovered)
+	}))
+	bus.RegisterHandler(runUnitTestCommandTypeName, HandlerFunc(func(ctx context.Context, c Command) (any, error) {
+		panic("boom")
+	}))
+
+	var response any
+	var err error
+	assert.NotPanics(t, func() {
+		response, err = bus.Send(context.Background(), New(runUnitTestCommandPayload{}))
+	})
+	assert.ErrorContains(t, err, "failed handling command \"unit_test.run\": handler panicked: boom")
+	assert.Nil(t, response)
+
+	// The bus remains usable after a panic.
+	bus.RegisterHandler(runUnitTestCommandTypeName, runUnitTestCommandHandler{})
+	_, err = bus.Send(context.Background(), New(runUnitTestCommandPayload{}))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/morebec/misas-go/misas"
+)
+
+// CorrelatingBusDecorator is a decorator around a Bus propagating correlation and causation IDs through the context
+// of the handling of the commands, so that the events appended during their handling can carry them (see store.CorrelationEnricher).
+// The correlation ID is taken from the context, or else from the metadata of the command, or else generated.
+// The causation ID is the ID of the command.
+type CorrelatingBusDecorator struct {
+	Bus
+}
+
+func NewCorrelatingBusDecorator(b Bus) *CorrelatingBusDecorator {
+	return &CorrelatingBusDecorator{Bus: b}
+}
+
+func (b *CorrelatingBusDecorator) Send(ctx context.Context, c Command) (any, error) {
+	correlationID, found := misas.CorrelationIDFromContext(ctx)
+	if !found {
+		correlationID, _ = c.Metadata.Get(misas.CorrelationIDMetadataKey, nil).(string)
+	}
+	if correlationID == "" {
+		correlationID = uuid.NewString()
+	}
+
+	ctx = misas.ContextWithCorrelationID(ctx, correlationID)
+	if c.ID != "" {
+		ctx = misas.ContextWithCausationID(ctx, c.ID)
+	}
+
+	return b.Bus.Send(ctx, c)
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type unitTestRanEvent struct {
+}
+
+func (u unitTestRanEvent) TypeName() event.PayloadTypeName {
+	return "unit_test.ran"
+}
+
+func TestCorrelatingBusDecorator_Send(t *testing.T) {
+	es := store.NewEnrichingDecorator(store.NewInMemoryEventStore(clock.UTCClock{}), store.CorrelationEnricher())
+	converter := store.NewEventConverter()
+	streamID := store.StreamID("unit_test")
+
+	inner := NewInMemoryBus()
+	inner.RegisterHandler(runUnitTestCommandTypeName, HandlerFunc(func(ctx context.Context, c Command) (any, error) {
+		descriptor, err := converter.ConvertEventToDescriptor(event.New(unitTestRanEvent{}))
+		if err != nil {
+			return nil, err
+		}
+		return nil, es.AppendToStream(ctx, streamID, []store.EventDescriptor{descriptor})
+	}))
+	bus := NewCorrelatingBusDecorator(inner)
+
+	readLastEvent := func() misas.Metadata {
+		slice, err := es.ReadFromStream(context.Background(), streamID, store.FromEnd(), store.InBackwardDirection(), store.WithMaxCount(1))
+		assert.NoError(t, err)
+		if !assert.Len(t, slice.Descriptors, 1) {
+			return nil
+		}
+		return slice.Descriptors[0].Metadata
+	}
+
+	t.Run("correlation ID from context", func(t *testing.T) {
+		cmd := New(runUnitTestCommandPayload{})
+		_, err := bus.Send(misas.ContextWithCorrelationID(context.Background(), "correlation-1"), cmd)
+		assert.NoError(t, err)
+
+		metadata := readLastEvent()
+		assert.Equal(t, "correlation-1", metadata.Get(misas.CorrelationIDMetadataKey, nil))
+		assert.Equal(t, cmd.ID, metadata.Get(misas.CausationIDMetadataKey, nil))
+	})
+
+	t.Run("correlation ID from command metadata", func(t *testing.T) {
+		cmd := NewWithMetadata(runUnitTestCommandPayload{}, misas.Metadata{misas.CorrelationIDMetadataKey: "correlation-2"})
+		_, err := bus.Send(context.Background(), cmd)
+		assert.NoError(t, err)
+
+		metadata := readLastEvent()
+		assert.Equal(t, "correlation-2", metadata.Get(misas.CorrelationIDMetadataKey, nil))
+		assert.Equal(t, cmd.ID, metadata.Get(misas.CausationIDMetadataKey, nil))
+	})
+
+	t.Run("generated correlation ID", func(t *testing.T) {
+		cmd := New(runUnitTestCommandPayload{})
+		_, err := bus.Send(context.Background(), cmd)
+		assert.NoError(t, err)
+
+		metadata := readLastEvent()
+		assert.NotEmpty(t, metadata.Get(misas.CorrelationIDMetadataKey, nil))
+		assert.Equal(t, cmd.ID, metadata.Get(misas.CausationIDMetadataKey, nil))
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"sync"
+)
+
+// IdempotencyKeyMetadataKey is the metadata key under which the idempotency key of a Command can be provided.
+const IdempotencyKeyMetadataKey = "idempotencyKey"
+
+// IdempotencyStore keeps track of the idempotency keys of the commands that were handled, so that commands delivered
+// more than once, as with at-least-once delivery, are only executed once.
+// Keys are reserved before their command is handled rather than checked and recorded after, so that concurrent
+// deliveries of the same command cannot both be executed.
+type IdempotencyStore interface {
+	// TryRecord atomically records a key unless it was already recorded. Returns true if the key was recorded by this
+	// call, or false if it was already recorded, for instance by a concurrent delivery of the same command.
+	TryRecord(ctx context.Context, key string) (bool, error)
+
+	// Release removes a recorded key, so that its command can be handled again, e.g. after its handling failed.
+	Release(ctx context.Context, key string) error
+}
+
+// InMemoryIdempotencyStore is an implementation of an IdempotencyStore keeping the keys in memory, mostly useful in tests.
+type InMemoryIdempotencyStore struct {
+	keys map[string]struct{}
+	mu   sync.Mutex
+}
+
+func NewInMemoryIdempotencyStore() *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{keys: map[string]struct{}{}}
+}
+
+func (s *InMemoryIdempotencyStore) TryRecord(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.keys[key]; found {
+		return false, nil
+	}
+	s.keys[key] = struct{}{}
+	return true, nil
+}
+
+func (s *InMemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInMemoryIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryIdempotencyStore()
+
+	recorded, err := s.TryRecord(ctx, "key-1")
+	assert.NoError(t, err)
+	assert.True(t, recorded)
+
+	recorded, err = s.TryRecord(ctx, "key-1")
+	assert.NoError(t, err)
+	assert.False(t, recorded)
+
+	recorded, err = s.TryRecord(ctx, "key-2")
+	assert.NoError(t, err)
+	assert.True(t, recorded)
+
+	assert.NoError(t, s.Release(ctx, "key-1"))
+	recorded, err = s.TryRecord(ctx, "key-1")
+	assert.NoError(t, err)
+	assert.True(t, recorded)
+}
+
+func TestInMemoryIdempotencyStore_TryRecord_Concurrently(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryIdempotencyStore()
+
+	var wg sync.WaitGroup
+	var nbRecorded int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorded, err := s.TryRecord(ctx, "key-1")
+			assert.NoError(t, err)
+			if recorded {
+				atomic.AddInt32(&nbRecorded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), nbRecorded)
+}
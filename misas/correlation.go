@@ -0,0 +1,51 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package misas
+
+import "context"
+
+// CorrelationIDMetadataKey is the metadata key under which the correlation ID of a message is stored.
+// The correlation ID identifies the whole flow of messages that originated from the same initial message.
+const CorrelationIDMetadataKey = "correlationId"
+
+// CausationIDMetadataKey is the metadata key under which the causation ID of a message is stored.
+// The causation ID is the ID of the message that directly caused this message.
+const CausationIDMetadataKey = "causationId"
+
+type correlationIDContextKey struct{}
+
+type causationIDContextKey struct{}
+
+// ContextWithCorrelationID returns a copy of a context carrying a correlation ID.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by a context, if any.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIDContextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ContextWithCausationID returns a copy of a context carrying a causation ID.
+func ContextWithCausationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, causationIDContextKey{}, id)
+}
+
+// CausationIDFromContext returns the causation ID carried by a context, if any.
+func CausationIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(causationIDContextKey{}).(string)
+	return id, ok && id != ""
+}
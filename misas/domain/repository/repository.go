@@ -0,0 +1,116 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/domain"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
+)
+
+// Aggregate represents an event sourced aggregate that can be loaded and saved by an AggregateRepository.
+type Aggregate interface {
+	domain.EventSourcedAggregate
+
+	// Version returns the version of the aggregate when it was last loaded or saved, or domain.InitialVersion for a new aggregate.
+	Version() domain.Version
+
+	// UncommittedEvents returns the events recorded by the aggregate since it was last loaded or saved.
+	UncommittedEvents() event.List
+
+	// MarkCommitted clears the uncommitted events of the aggregate and sets its version, once it was loaded or saved.
+	MarkCommitted(version domain.Version)
+}
+
+// AggregateRepository is a repository loading and saving aggregates of a given type to the streams of a store.EventStore.
+// Aggregates are loaded by applying the events of their stream in order, and saved by appending their uncommitted events
+// with the version they were loaded at as the expected version of their stream, so that concurrent modifications of an
+// aggregate result in a store.ConcurrencyError.
+type AggregateRepository[T Aggregate] struct {
+	eventStore     store.EventStore
+	eventConverter *store.EventConverter
+	newAggregate   func() T
+}
+
+// NewAggregateRepository creates a new AggregateRepository, newAggregate returning the empty aggregate events are applied to.
+func NewAggregateRepository[T Aggregate](
+	eventStore store.EventStore,
+	eventConverter *store.EventConverter,
+	newAggregate func() T,
+) *AggregateRepository[T] {
+	return &AggregateRepository[T]{
+		eventStore:     eventStore,
+		eventConverter: eventConverter,
+		newAggregate:   newAggregate,
+	}
+}
+
+// Load an aggregate from the events of a stream. Returns an error wrapping a store.StreamNotFoundError if the stream does not exist.
+func (r *AggregateRepository[T]) Load(ctx context.Context, streamID store.StreamID) (T, error) {
+	operationFailed := func(err error) (T, error) {
+		var zero T
+		return zero, errors.Wrapf(err, "failed loading aggregate from stream \"%s\"", streamID)
+	}
+
+	slice, err := r.eventStore.ReadFromStream(ctx, streamID, store.FromStart(), store.InForwardDirection())
+	if err != nil {
+		return operationFailed(err)
+	}
+
+	aggregate := r.newAggregate()
+	version := domain.InitialVersion
+	for _, d := range slice.Descriptors {
+		e, err := r.eventConverter.ConvertDescriptorToEvent(d)
+		if err != nil {
+			return operationFailed(err)
+		}
+		aggregate.Apply(e)
+		version = domain.Version(d.Version)
+	}
+	aggregate.MarkCommitted(version)
+
+	return aggregate, nil
+}
+
+// Save the uncommitted events of an aggregate to a stream, expecting the stream to be at the version of the aggregate.
+func (r *AggregateRepository[T]) Save(ctx context.Context, streamID store.StreamID, aggregate T) error {
+	operationFailed := func(err error) error {
+		return errors.Wrapf(err, "failed saving aggregate to stream \"%s\"", streamID)
+	}
+
+	events := aggregate.UncommittedEvents()
+	if events.IsEmpty() {
+		return nil
+	}
+
+	var descriptors []store.EventDescriptor
+	for _, e := range events {
+		d, err := r.eventConverter.ConvertEventToDescriptor(e)
+		if err != nil {
+			return operationFailed(err)
+		}
+		descriptors = append(descriptors, d)
+	}
+
+	version := aggregate.Version()
+	if err := r.eventStore.AppendToStream(ctx, streamID, descriptors, store.WithExpectedVersion(store.StreamVersion(version))); err != nil {
+		return operationFailed(err)
+	}
+	aggregate.MarkCommitted(version + domain.Version(len(descriptors)))
+
+	return nil
+}
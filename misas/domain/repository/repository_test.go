@@ -0,0 +1,157 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/domain"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type accountOpened struct {
+	AccountID string
+}
+
+func (e accountOpened) TypeName() event.PayloadTypeName {
+	return "account.opened"
+}
+
+type accountCredited struct {
+	Amount int
+}
+
+func (e accountCredited) TypeName() event.PayloadTypeName {
+	return "account.credited"
+}
+
+type account struct {
+	id                string
+	balance           int
+	version           domain.Version
+	uncommittedEvents event.List
+}
+
+func newAccount() *account {
+	return &account{version: domain.InitialVersion}
+}
+
+func openAccount(id string) *account {
+	a := newAccount()
+	a.record(accountOpened{AccountID: id})
+	return a
+}
+
+func (a *account) Credit(amount int) {
+	a.record(accountCredited{Amount: amount})
+}
+
+func (a *account) record(p event.Payload) {
+	e := event.New(p)
+	a.Apply(e)
+	a.uncommittedEvents = append(a.uncommittedEvents, e)
+}
+
+func (a *account) Apply(e event.Event) {
+	switch p := e.Payload.(type) {
+	case accountOpened:
+		a.id = p.AccountID
+	case accountCredited:
+		a.balance += p.Amount
+	}
+}
+
+func (a *account) Version() domain.Version {
+	return a.version
+}
+
+func (a *account) UncommittedEvents() event.List {
+	return a.uncommittedEvents
+}
+
+func (a *account) MarkCommitted(version domain.Version) {
+	a.version = version
+	a.uncommittedEvents = nil
+}
+
+func buildAccountRepository() *AggregateRepository[*account] {
+	converter := store.NewEventConverter()
+	converter.RegisterEventPayload(accountOpened{})
+	converter.RegisterEventPayload(accountCredited{})
+
+	return NewAggregateRepository[*account](store.NewInMemoryEventStore(clock.NewUTCClock()), converter, newAccount)
+}
+
+func TestAggregateRepository_SaveAndLoad(t *testing.T) {
+	ctx := context.Background()
+	r := buildAccountRepository()
+
+	a := openAccount("account#1")
+	a.Credit(50)
+	assert.NoError(t, r.Save(ctx, "account-account#1", a))
+	assert.Equal(t, domain.Version(1), a.Version())
+	assert.Empty(t, a.UncommittedEvents())
+
+	loaded, err := r.Load(ctx, "account-account#1")
+	assert.NoError(t, err)
+	assert.Equal(t, "account#1", loaded.id)
+	assert.Equal(t, 50, loaded.balance)
+	assert.Equal(t, domain.Version(1), loaded.Version())
+
+	loaded.Credit(25)
+	assert.NoError(t, r.Save(ctx, "account-account#1", loaded))
+
+	loaded, err = r.Load(ctx, "account-account#1")
+	assert.NoError(t, err)
+	assert.Equal(t, 75, loaded.balance)
+	assert.Equal(t, domain.Version(2), loaded.Version())
+}
+
+func TestAggregateRepository_Load_StreamNotFound(t *testing.T) {
+	r := buildAccountRepository()
+
+	_, err := r.Load(context.Background(), "account-not-found")
+	assert.True(t, errors.Is(err, store.NewStreamNotFoundError("account-not-found")))
+}
+
+func TestAggregateRepository_Save_ConcurrencyConflict(t *testing.T) {
+	ctx := context.Background()
+	r := buildAccountRepository()
+	assert.NoError(t, r.Save(ctx, "account-account#1", openAccount("account#1")))
+
+	first, err := r.Load(ctx, "account-account#1")
+	assert.NoError(t, err)
+	second, err := r.Load(ctx, "account-account#1")
+	assert.NoError(t, err)
+
+	first.Credit(10)
+	assert.NoError(t, r.Save(ctx, "account-account#1", first))
+
+	second.Credit(20)
+	err = r.Save(ctx, "account-account#1", second)
+	assert.True(t, store.IsConcurrencyError(err))
+
+	loaded, err := r.Load(ctx, "account-account#1")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, loaded.balance)
+
+	// Saving a new aggregate to an existing stream is also a conflict.
+	err = r.Save(ctx, "account-account#1", openAccount("account#1"))
+	assert.True(t, store.IsConcurrencyError(err))
+}
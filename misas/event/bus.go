@@ -17,19 +17,84 @@ package event
 import (
 	"context"
 	"github.com/pkg/errors"
+	"sync"
+	"time"
 )
 
+// DispatchMode indicates how an InMemoryBus dispatches the events to their handlers.
+type DispatchMode string
+
+const (
+	// SynchronousDispatch indicates that handlers are run inline, Send returning the first error of a handler.
+	SynchronousDispatch DispatchMode = "SYNCHRONOUS"
+
+	// AsynchronousDispatch indicates that handlers are run on their own goroutine, Send returning immediately.
+	// The errors of the handlers are passed to the error handler of the InMemoryBus.
+	AsynchronousDispatch DispatchMode = "ASYNCHRONOUS"
+)
+
+// InMemoryBus is an implementation of an event.Bus that sends events to handlers located in memory.
 type InMemoryBus struct {
-	handlers map[PayloadTypeName][]Handler
+	handlers     map[PayloadTypeName][]Handler
+	handlersLock sync.RWMutex
+	mode         DispatchMode
+	handleError  func(ctx context.Context, err error)
+	inFlight     sync.WaitGroup
+	recoverPanic func(recovered any) error
+}
+
+type InMemoryBusOption func(eb *InMemoryBus)
+
+// WithAsynchronousDispatch allows running the handlers on their own goroutine. Since the handlers run after Send has
+// returned, they are given a context carrying the values of the context passed to Send without being affected by its
+// cancellation. The errors of the handlers are passed to a given function along with this context, a nil function
+// discarding them.
+func WithAsynchronousDispatch(errorHandler func(ctx context.Context, err error)) InMemoryBusOption {
+	return func(eb *InMemoryBus) {
+		eb.mode = AsynchronousDispatch
+		eb.handleError = errorHandler
+	}
+}
+
+// WithPanicRecovery wraps every handler registered with the bus so that a panic occurring while handling an event is
+// recovered and converted to an error by a given function. This error is then handled as any other error of the
+// handler, according to the DispatchMode of the bus. Should the function return nil, the panic is silently discarded.
+func WithPanicRecovery(fn func(recovered any) error) InMemoryBusOption {
+	return func(eb *InMemoryBus) {
+		eb.recoverPanic = fn
+	}
 }
 
-func NewInMemoryBus() *InMemoryBus {
-	return &InMemoryBus{handlers: map[PayloadTypeName][]Handler{}}
+func NewInMemoryBus(opts ...InMemoryBusOption) *InMemoryBus {
+	eb := &InMemoryBus{
+		handlers: map[PayloadTypeName][]Handler{},
+		mode:     SynchronousDispatch,
+	}
+
+	for _, opt := range opts {
+		opt(eb)
+	}
+
+	return eb
 }
 
 func (eb *InMemoryBus) Send(ctx context.Context, e Event) error {
 	handlers := eb.resolveHandlers(e.Payload.TypeName())
 
+	if eb.mode == AsynchronousDispatch {
+		handlerCtx := detachedContext{parent: ctx}
+		for _, h := range handlers {
+			eb.inFlight.Add(1)
+			go func(h Handler) {
+				defer eb.inFlight.Done()
+				if err := h.Handle(handlerCtx, e); err != nil && eb.handleError != nil {
+					eb.handleError(handlerCtx, errors.Wrapf(err, "failed handling event \"%s\"", e.Payload.TypeName()))
+				}
+			}(h)
+		}
+		return nil
+	}
+
 	for _, h := range handlers {
 		if err := h.Handle(ctx, e); err != nil {
 			return errors.Wrapf(err, "failed handling event \"%s\"", e.Payload.TypeName())
@@ -39,17 +104,58 @@ func (eb *InMemoryBus) Send(ctx context.Context, e Event) error {
 	return nil
 }
 
+// Wait blocks until the handlers dispatched asynchronously have returned.
+func (eb *InMemoryBus) Wait() {
+	eb.inFlight.Wait()
+}
+
 func (eb *InMemoryBus) RegisterHandler(t PayloadTypeName, h Handler) {
-	if _, found := eb.handlers[t]; !found {
-		eb.handlers[t] = []Handler{}
+	if eb.recoverPanic != nil {
+		h = recoveringHandler(h, eb.recoverPanic)
 	}
+
+	eb.handlersLock.Lock()
+	defer eb.handlersLock.Unlock()
+
 	eb.handlers[t] = append(eb.handlers[t], h)
 }
 
 func (eb *InMemoryBus) resolveHandlers(tn PayloadTypeName) []Handler {
-	if handlers, found := eb.handlers[tn]; !found {
-		return nil
-	} else {
-		return handlers
-	}
+	eb.handlersLock.RLock()
+	defer eb.handlersLock.RUnlock()
+
+	return eb.handlers[tn]
+}
+
+// recoveringHandler wraps a handler so that its panics are converted to errors by a given function.
+func recoveringHandler(h Handler, recoverPanic func(recovered any) error) Handler {
+	return HandlerFunc(func(ctx context.Context, e Event) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(r)
+			}
+		}()
+		return h.Handle(ctx, e)
+	})
+}
+
+// detachedContext is a context.Context that exposes the values of its parent without being affected by its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
 }
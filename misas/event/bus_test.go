@@ -2,7 +2,9 @@ package event
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"sync"
 	"testing"
 )
 
@@ -32,3 +34,128 @@ func TestInMemoryBus_Send(t *testing.T) {
 func TestNewInMemoryBus(t *testing.T) {
 	assert.NotNil(t, NewInMemoryBus())
 }
+
+func TestInMemoryBus_Send_SynchronousError(t *testing.T) {
+	b := NewInMemoryBus()
+
+	secondCalled := false
+	b.RegisterHandler(unitTestFailedTypeName, HandlerFunc(func(ctx context.Context, e Event) error {
+		return errors.New("handler failed")
+	}))
+	b.RegisterHandler(unitTestFailedTypeName, HandlerFunc(func(ctx context.Context, e Event) error {
+		secondCalled = true
+		return nil
+	}))
+
+	err := b.Send(context.Background(), New(unitTestFailed{}))
+	assert.ErrorContains(t, err, "handler failed")
+	assert.False(t, secondCalled)
+}
+
+func TestInMemoryBus_Send_Asynchronous(t *testing.T) {
+	errs := make(chan error, 1)
+	b := NewInMemoryBus(WithAsynchronousDispatch(func(ctx context.Context, err error) {
+		errs <- err
+	}))
+
+	var lock sync.Mutex
+	handled := 0
+	release := make(chan struct{})
+	b.RegisterHandler(unitTestFailedTypeName, HandlerFunc(func(ctx context.Context, e Event) error {
+		<-release
+		lock.Lock()
+		defer lock.Unlock()
+		handled++
+		return nil
+	}))
+	b.RegisterHandler(unitTestFailedTypeName, HandlerFunc(func(ctx context.Context, e Event) error {
+		<-release
+		return errors.New("handler failed")
+	}))
+
+	// Send should return before the handlers have run.
+	err := b.Send(context.Background(), New(unitTestFailed{}))
+	assert.NoError(t, err)
+
+	close(release)
+	b.Wait()
+
+	assert.Equal(t, 1, handled)
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "handler failed")
+	default:
+		t.Fatal("expected a handler error on the errors channel")
+	}
+}
+
+func TestInMemoryBus_Send_AsynchronousDetachedContext(t *testing.T) {
+	type contextKey struct{}
+	handlerErrs := make(chan error, 1)
+	b := NewInMemoryBus(WithAsynchronousDispatch(func(ctx context.Context, err error) {
+		handlerErrs <- err
+	}))
+
+	release := make(chan struct{})
+	b.RegisterHandler(unitTestFailedTypeName, HandlerFunc(func(ctx context.Context, e Event) error {
+		<-release
+		if ctx.Err() != nil {
+			return errors.Wrap(ctx.Err(), "handler context done")
+		}
+		return errors.Errorf("handler failed with value %v", ctx.Value(contextKey{}))
+	}))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "value"))
+	assert.NoError(t, b.Send(ctx, New(unitTestFailed{})))
+
+	// The handler and the error handler must not be affected by the cancellation of the context passed to Send.
+	cancel()
+	close(release)
+	b.Wait()
+
+	select {
+	case err := <-handlerErrs:
+		assert.ErrorContains(t, err, "handler failed with value value")
+	default:
+		t.Fatal("expected the handler error to be passed to the error handler")
+	}
+}
+
+func TestInMemoryBus_Send_WithPanicRecovery(t *testing.T) {
+	recoverPanic := WithPanicRecovery(func(recovered any) error {
+		return errors.Errorf("handler panicked: %v", recovered)
+	})
+	panicking := HandlerFunc(func(ctx context.Context, e Event) error {
+		panic("boom")
+	})
+
+	t.Run("synchronous", func(t *testing.T) {
+		b := NewInMemoryBus(recoverPanic)
+		b.RegisterHandler(unitTestFailedTypeName, panicking)
+
+		var err error
+		assert.NotPanics(t, func() {
+			err = b.Send(context.Background(), New(unitTestFailed{}))
+		})
+		assert.ErrorContains(t, err, "failed handling event \"unit_test.failed\": handler panicked: boom")
+	})
+
+	t.Run("asynchronous", func(t *testing.T) {
+		errs := make(chan error, 1)
+		b := NewInMemoryBus(WithAsynchronousDispatch(func(ctx context.Context, err error) {
+			errs <- err
+		}), recoverPanic)
+		b.RegisterHandler(unitTestFailedTypeName, panicking)
+
+		err := b.Send(context.Background(), New(unitTestFailed{}))
+		assert.NoError(t, err)
+		b.Wait()
+
+		select {
+		case err := <-errs:
+			assert.ErrorContains(t, err, "handler panicked: boom")
+		default:
+			t.Fatal("expected the recovered panic on the errors channel")
+		}
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/morebec/misas-go/misas"
+)
+
+// CorrelatingBusDecorator is a decorator around a Bus propagating correlation and causation IDs through the context
+// of the handling of the events, so that the commands sent and events appended by their handlers remain correlated.
+// The correlation ID is taken from the metadata of the event, or else from the context, or else generated.
+// The causation ID is the ID of the event as found in its "id" metadata.
+type CorrelatingBusDecorator struct {
+	Bus
+}
+
+func NewCorrelatingBusDecorator(b Bus) *CorrelatingBusDecorator {
+	return &CorrelatingBusDecorator{Bus: b}
+}
+
+func (b *CorrelatingBusDecorator) Send(ctx context.Context, e Event) error {
+	correlationID, _ := e.Metadata.Get(misas.CorrelationIDMetadataKey, nil).(string)
+	if correlationID == "" {
+		correlationID, _ = misas.CorrelationIDFromContext(ctx)
+	}
+	if correlationID == "" {
+		correlationID = uuid.NewString()
+	}
+
+	ctx = misas.ContextWithCorrelationID(ctx, correlationID)
+	if eventID, _ := e.Metadata.Get("id", nil).(string); eventID != "" {
+		ctx = misas.ContextWithCausationID(ctx, eventID)
+	}
+
+	return b.Bus.Send(ctx, e)
+}
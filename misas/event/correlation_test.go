@@ -0,0 +1,46 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCorrelatingBusDecorator_Send(t *testing.T) {
+	inner := NewInMemoryBus()
+	var correlationID, causationID string
+	inner.RegisterHandler(unitTestFailedTypeName, HandlerFunc(func(ctx context.Context, e Event) error {
+		correlationID, _ = misas.CorrelationIDFromContext(ctx)
+		causationID, _ = misas.CausationIDFromContext(ctx)
+		return nil
+	}))
+	bus := NewCorrelatingBusDecorator(inner)
+
+	err := bus.Send(
+		misas.ContextWithCorrelationID(context.Background(), "correlation-ctx"),
+		NewWithMetadata(unitTestFailed{}, misas.Metadata{"id": "event-1", misas.CorrelationIDMetadataKey: "correlation-1"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "correlation-1", correlationID)
+	assert.Equal(t, "event-1", causationID)
+
+	err = bus.Send(misas.ContextWithCorrelationID(context.Background(), "correlation-ctx"), New(unitTestFailed{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "correlation-ctx", correlationID)
+	assert.Equal(t, "", causationID)
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processing
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/event/store"
+	"sync"
+)
+
+// CachingCheckpointStoreDecorator decorator around a CheckpointStore keeping the latest checkpoint of each id in memory,
+// so that processors do not query the decorated store on every processing cycle. Saves are written through to the
+// decorated store before being cached, and removals invalidate the cached checkpoint. It assumes it is the only writer
+// of the checkpoints it caches.
+type CachingCheckpointStoreDecorator struct {
+	inner       CheckpointStore
+	checkpoints map[CheckpointID]Checkpoint
+	mu          sync.RWMutex
+}
+
+// NewCachingCheckpointStoreDecorator returns a new caching decorator around a CheckpointStore.
+func NewCachingCheckpointStoreDecorator(inner CheckpointStore) *CachingCheckpointStoreDecorator {
+	return &CachingCheckpointStoreDecorator{inner: inner, checkpoints: map[CheckpointID]Checkpoint{}}
+}
+
+func (c *CachingCheckpointStoreDecorator) Save(ctx context.Context, checkpoint Checkpoint) error {
+	if err := c.inner.Save(ctx, checkpoint); err != nil {
+		return err
+	}
+
+	c.cache(checkpoint)
+
+	return nil
+}
+
+func (c *CachingCheckpointStoreDecorator) FindById(ctx context.Context, id CheckpointID) (*Checkpoint, error) {
+	c.mu.RLock()
+	cached, found := c.checkpoints[id]
+	c.mu.RUnlock()
+	if found {
+		cached = copyCheckpoint(cached)
+		return &cached, nil
+	}
+
+	checkpoint, err := c.inner.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint != nil {
+		c.cache(*checkpoint)
+	}
+
+	return checkpoint, nil
+}
+
+func (c *CachingCheckpointStoreDecorator) Remove(ctx context.Context, id CheckpointID) error {
+	// The cached checkpoint is invalidated even if the removal fails, as the state of the decorated store is then unknown.
+	c.mu.Lock()
+	delete(c.checkpoints, id)
+	c.mu.Unlock()
+
+	return c.inner.Remove(ctx, id)
+}
+
+// cache caches a copy of a checkpoint, so that it is not affected by changes to the positions of the original.
+func (c *CachingCheckpointStoreDecorator) cache(checkpoint Checkpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkpoints[checkpoint.ID] = copyCheckpoint(checkpoint)
+}
+
+// copyCheckpoint returns a copy of a checkpoint that does not share its positions.
+func copyCheckpoint(checkpoint Checkpoint) Checkpoint {
+	if checkpoint.Positions != nil {
+		positions := make(map[store.StreamID]store.Position, len(checkpoint.Positions))
+		for id, p := range checkpoint.Positions {
+			positions[id] = p
+		}
+		checkpoint.Positions = positions
+	}
+	return checkpoint
+}
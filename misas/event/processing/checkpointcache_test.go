@@ -0,0 +1,94 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processing
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// countingCheckpointStore is a CheckpointStore counting the reads made to an InMemoryCheckpointStore.
+type countingCheckpointStore struct {
+	*InMemoryCheckpointStore
+	reads int
+}
+
+func (c *countingCheckpointStore) FindById(ctx context.Context, id CheckpointID) (*Checkpoint, error) {
+	c.reads++
+	return c.InMemoryCheckpointStore.FindById(ctx, id)
+}
+
+func TestCachingCheckpointStoreDecorator_FindById(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingCheckpointStore{InMemoryCheckpointStore: NewInMemoryCheckpointStore()}
+	cs := NewCachingCheckpointStoreDecorator(inner)
+
+	checkpoint := Checkpoint{ID: "unit_test", Position: 5, StreamID: "stream", Positions: map[store.StreamID]store.Position{"stream": 5}}
+	assert.NoError(t, cs.Save(ctx, checkpoint))
+
+	// Reads after a save should hit the cache.
+	for i := 0; i < 3; i++ {
+		found, err := cs.FindById(ctx, "unit_test")
+		assert.NoError(t, err)
+		assert.Equal(t, &checkpoint, found)
+	}
+	assert.Equal(t, 0, inner.reads)
+
+	// The save should have been written through.
+	persisted, err := inner.InMemoryCheckpointStore.FindById(ctx, "unit_test")
+	assert.NoError(t, err)
+	assert.Equal(t, &checkpoint, persisted)
+
+	// Changes to the returned checkpoints should not affect the cache.
+	found, err := cs.FindById(ctx, "unit_test")
+	assert.NoError(t, err)
+	found.Positions["stream"] = 10
+	found, err = cs.FindById(ctx, "unit_test")
+	assert.NoError(t, err)
+	assert.Equal(t, store.Position(5), found.Positions["stream"])
+}
+
+func TestCachingCheckpointStoreDecorator_FindById_CachesInnerReads(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingCheckpointStore{InMemoryCheckpointStore: NewInMemoryCheckpointStore()}
+	assert.NoError(t, inner.Save(ctx, Checkpoint{ID: "unit_test", Position: 5}))
+	cs := NewCachingCheckpointStoreDecorator(inner)
+
+	_, err := cs.FindById(ctx, "unknown")
+	assert.Error(t, err)
+
+	for i := 0; i < 3; i++ {
+		found, err := cs.FindById(ctx, "unit_test")
+		assert.NoError(t, err)
+		assert.Equal(t, store.Position(5), found.Position)
+	}
+	assert.Equal(t, 2, inner.reads)
+}
+
+func TestCachingCheckpointStoreDecorator_Remove(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingCheckpointStore{InMemoryCheckpointStore: NewInMemoryCheckpointStore()}
+	cs := NewCachingCheckpointStoreDecorator(inner)
+
+	assert.NoError(t, cs.Save(ctx, Checkpoint{ID: "unit_test", Position: 5}))
+	assert.NoError(t, cs.Remove(ctx, "unit_test"))
+
+	found, err := cs.FindById(ctx, "unit_test")
+	assert.Error(t, err)
+	assert.Nil(t, found)
+	assert.Equal(t, 1, inner.reads)
+}
@@ -16,17 +16,39 @@ package processing
 
 import (
 	"context"
+	"github.com/morebec/misas-go/misas/command"
 	"github.com/morebec/misas-go/misas/event"
 	"github.com/morebec/misas-go/misas/event/store"
 	"github.com/pkg/errors"
+	"sort"
+	"time"
 )
 
 // ProcessorOptions Represents a set of options that can be passed to an event.Processor to alter its behaviour.
 type ProcessorOptions struct {
-	Name                     string
-	StreamID                 store.StreamID
+	Name     string
+	StreamID store.StreamID
+
+	// StreamIDs are the streams processed by a processor reading a merged feed of multiple streams.
+	// When set, it takes precedence over StreamID.
+	StreamIDs []store.StreamID
+
 	CheckpointCommitStrategy CheckpointCommitStrategy
 	EventTypeNameFilter      *store.TypeNameFilter
+
+	// CatchUpOnly indicates that the processor should stop once it has processed all the events
+	// up to the head of its stream instead of subscribing for live events.
+	CatchUpOnly bool
+
+	// ShutdownTimeout is the maximum amount of time the processor is allowed to spend finishing
+	// the batch of events it is currently processing once it is asked to stop.
+	ShutdownTimeout time.Duration
+
+	// DescriptorTransform is applied to every descriptor before it is passed to the handler of the processor.
+	DescriptorTransform DescriptorTransform
+
+	// BatchTransaction when not nil, begins the transaction in which each batch of events and its checkpoint are processed.
+	BatchTransaction BeginTxFunc
 }
 
 type ProcessorOption func(options *ProcessorOptions)
@@ -37,6 +59,10 @@ func WithFiler(opts ...store.TypeNameFilterOption) ProcessorOption {
 		if len(opts) == 0 {
 			o.EventTypeNameFilter = nil
 		} else {
+			o.EventTypeNameFilter = &store.TypeNameFilter{
+				Mode:           store.Select,
+				EventTypeNames: nil,
+			}
 			for _, opt := range opts {
 				opt(o.EventTypeNameFilter)
 			}
@@ -65,6 +91,50 @@ func WithStreamId(id store.StreamID) ProcessorOption {
 	}
 }
 
+// WithStreamIds allows specifying multiple streams to process as a single feed ordered by sequence number.
+// The position reached in each stream is tracked independently in the Checkpoint of the processor.
+func WithStreamIds(ids ...store.StreamID) ProcessorOption {
+	return func(options *ProcessorOptions) {
+		options.StreamIDs = ids
+	}
+}
+
+// WithCatchUpOnly allows specifying that the processor should only catch up to the head of its stream and then stop.
+// This is useful to build projections as batch jobs rather than long-running services.
+func WithCatchUpOnly() ProcessorOption {
+	return func(options *ProcessorOptions) {
+		options.CatchUpOnly = true
+	}
+}
+
+// WithShutdownTimeout allows specifying how long the processor can take to drain its in-flight batch of events on shutdown.
+func WithShutdownTimeout(d time.Duration) ProcessorOption {
+	return func(options *ProcessorOptions) {
+		options.ShutdownTimeout = d
+	}
+}
+
+// WithDescriptorTransform allows enriching or filtering the descriptors before they are passed to the handler of the processor.
+// Descriptors for which the transform returns false are skipped, their position still being committed to the checkpoint.
+func WithDescriptorTransform(fn DescriptorTransform) ProcessorOption {
+	return func(options *ProcessorOptions) {
+		options.DescriptorTransform = fn
+	}
+}
+
+// WithBatchTransaction allows processing each batch of events along with the saving of its checkpoint in a single transaction.
+// The transaction is rolled back if the handler or the checkpoint store fails on any event of the batch, so that neither
+// the changes of the handler nor the advancement of the checkpoint are kept. The transaction is available to the handler and
+// the checkpoint store through TxFromContext.
+func WithBatchTransaction(begin BeginTxFunc) ProcessorOption {
+	return func(options *ProcessorOptions) {
+		options.BatchTransaction = begin
+	}
+}
+
+// DescriptorTransform transforms a descriptor before it is processed, returning false if the descriptor should be skipped.
+type DescriptorTransform func(d store.RecordedEventDescriptor) (store.RecordedEventDescriptor, bool)
+
 // CheckpointCommitStrategy Represents the commit strategy to use for storing the checkpoints.
 type CheckpointCommitStrategy string
 
@@ -81,10 +151,42 @@ const CommitAfterProcessing CheckpointCommitStrategy = "CommitAfterProcessing"
 type CheckpointID string
 
 // Checkpoint represents a data structure that can be used to determine what was the last processed event in a stream.
+// Processors reading multiple streams track the position reached in each of them in Positions.
 type Checkpoint struct {
-	ID       CheckpointID
-	Position store.Position
-	StreamID store.StreamID
+	ID        CheckpointID
+	Position  store.Position
+	StreamID  store.StreamID
+	Positions map[store.StreamID]store.Position
+}
+
+// PositionInStream returns the position of this checkpoint in a given stream.
+// If no position was recorded for this stream, returns store.Start.
+func (c Checkpoint) PositionInStream(streamID store.StreamID) store.Position {
+	if p, found := c.Positions[streamID]; found {
+		return p
+	}
+
+	if c.StreamID == streamID {
+		return c.Position
+	}
+
+	return store.Start
+}
+
+// WithPositionInStream returns a copy of this checkpoint with the position of a given stream updated.
+func (c Checkpoint) WithPositionInStream(streamID store.StreamID, position store.Position) Checkpoint {
+	positions := make(map[store.StreamID]store.Position, len(c.Positions)+1)
+	for id, p := range c.Positions {
+		positions[id] = p
+	}
+	positions[streamID] = position
+	c.Positions = positions
+
+	if c.StreamID == streamID {
+		c.Position = position
+	}
+
+	return c
 }
 
 // CheckpointStore allows storing checkpoints durably.
@@ -108,6 +210,13 @@ func NewInMemoryCheckpointStore() *InMemoryCheckpointStore {
 }
 
 func (i InMemoryCheckpointStore) Save(_ context.Context, checkpoint Checkpoint) error {
+	if checkpoint.Positions != nil {
+		positions := make(map[store.StreamID]store.Position, len(checkpoint.Positions))
+		for id, p := range checkpoint.Positions {
+			positions[id] = p
+		}
+		checkpoint.Positions = positions
+	}
 	i.checkpoints[checkpoint.ID] = checkpoint
 	return nil
 }
@@ -128,6 +237,9 @@ func (i InMemoryCheckpointStore) Remove(_ context.Context, id CheckpointID) erro
 // Handler represents the type of work a Processor does with an event.
 type Handler func(ctx context.Context, d store.RecordedEventDescriptor) error
 
+// DefaultShutdownTimeout is the default amount of time given to a Processor to drain its in-flight batch on shutdown.
+const DefaultShutdownTimeout = 5 * time.Second
+
 // Processor is a service responsible for subscribing to a given stream of the event store in order to perform work with the events of the stream.
 // It is intended to be run continuously.
 // TODO tests
@@ -158,6 +270,7 @@ func NewProcessor(eventStore store.EventStore, checkpointStore CheckpointStore,
 		StreamID:                 eventStore.GlobalStreamID(),
 		CheckpointCommitStrategy: CommitAfterProcessing,
 		EventTypeNameFilter:      nil,
+		ShutdownTimeout:          DefaultShutdownTimeout,
 	}
 
 	for _, opt := range opts {
@@ -180,16 +293,20 @@ func (p *Processor) Run(ctx context.Context) (err error) {
 		p.running = false
 	}()
 
-	// Subscribe to stream
-	var filterOptions []store.TypeNameFilterOption
-	if p.options.EventTypeNameFilter != nil {
-		if p.options.EventTypeNameFilter.Mode == store.Exclude {
-			filterOptions = append(filterOptions, store.ExcludeEventTypeNames(p.options.EventTypeNameFilter.EventTypeNames...))
-		} else {
-			filterOptions = append(filterOptions, store.SelectEventTypeNames(p.options.EventTypeNameFilter.EventTypeNames...))
+	if p.options.CatchUpOnly {
+		if err := p.processEvents(ctx); err != nil {
+			return errors.Wrap(err, "failed processing events")
 		}
+		return nil
 	}
-	subscription, err := p.eventStore.SubscribeToStream(ctx, p.options.StreamID, store.WithSubscriptionFilter())
+
+	// Subscribe to stream
+	// A processor of multiple streams listens to the global stream to be notified of new events in any of its streams.
+	subscribedStreamID := p.options.StreamID
+	if len(p.options.StreamIDs) != 0 {
+		subscribedStreamID = p.eventStore.GlobalStreamID()
+	}
+	subscription, err := p.eventStore.SubscribeToStream(ctx, subscribedStreamID, store.WithSubscriptionFilter(p.filterOptions()...))
 
 	if err != nil {
 		return errors.Wrap(err, "failed processing events")
@@ -204,6 +321,10 @@ func (p *Processor) Run(ctx context.Context) (err error) {
 	for {
 		select {
 		case _ = <-subscription.EventChannel():
+			// Notifications received concurrently with the cancellation of ctx are not processed.
+			if ctx.Err() != nil {
+				return errors.Wrap(subscription.Close(), "failed processing events")
+			}
 			if err := p.processEvents(ctx); err != nil {
 				return errors.Wrap(err, "failed processing events")
 			}
@@ -224,28 +345,77 @@ func (p *Processor) Reset(ctx context.Context) error {
 }
 
 func (p *Processor) processEvents(ctx context.Context) (err error) {
+	// A cancelled context must not start a new batch: only batches that were already read are drained.
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "failed processing events")
+	}
+
 	// Get checkpoint
 	checkpoint, err := p.fetchCheckpoint(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed updating event processor checkpoint")
 	}
 
-	stream, err := p.eventStore.ReadFromStream(ctx, p.options.StreamID, store.From(checkpoint.Position))
+	descriptors, err := p.readEvents(ctx, checkpoint)
 	if err != nil {
 		return errors.Wrap(err, "failed updating event processor checkpoint")
 	}
 
-	for _, descriptor := range stream.Descriptors {
+	// Once read, the batch is processed using a context that survives the cancellation of ctx for at most
+	// ShutdownTimeout so that in-flight events are drained and their checkpoint committed before stopping.
+	ctx, cancel := p.drainingContext(ctx)
+	defer cancel()
+
+	if p.options.BatchTransaction != nil && len(descriptors) != 0 {
+		return p.processBatchInTransaction(ctx, checkpoint, descriptors)
+	}
+
+	return p.processBatch(ctx, checkpoint, descriptors)
+}
+
+// processBatchInTransaction processes a batch of events in a transaction begun with the BatchTransaction of the processor,
+// committing it once all the events were processed or rolling it back on failure.
+func (p *Processor) processBatchInTransaction(ctx context.Context, checkpoint Checkpoint, descriptors []store.RecordedEventDescriptor) error {
+	tx, err := p.options.BatchTransaction(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed beginning event processor batch transaction")
+	}
+
+	if err := p.processBatch(ContextWithTx(ctx, tx), checkpoint, descriptors); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Wrapf(err, "failed rolling back event processor batch transaction: %s", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed committing event processor batch transaction")
+	}
+
+	return nil
+}
+
+// processBatch processes a batch of events, saving the checkpoint according to the CheckpointCommitStrategy of the processor.
+func (p *Processor) processBatch(ctx context.Context, checkpoint Checkpoint, descriptors []store.RecordedEventDescriptor) error {
+	for _, descriptor := range descriptors {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "failed draining event processor batch before shutdown")
+		}
+
 		// Update position
-		checkpoint.Position = store.Position(descriptor.SequenceNumber)
+		if len(p.options.StreamIDs) != 0 {
+			checkpoint = checkpoint.WithPositionInStream(descriptor.StreamID, store.PositionFromVersion(descriptor.Version))
+		} else {
+			checkpoint.Position = store.PositionFromSequenceNumber(descriptor.SequenceNumber)
+		}
 		if p.options.CheckpointCommitStrategy == CommitBeforeProcessing {
 			if err := p.checkpointStore.Save(ctx, checkpoint); err != nil {
 				return errors.Wrap(err, "failed updating event processor checkpoint")
 			}
 		}
 
-		if err := p.processingFunc(ctx, descriptor); err != nil {
-			return errors.Wrapf(err, "failed processing event %s:%s", descriptor.TypeName, descriptor.ID)
+		if err := p.processDescriptor(ctx, descriptor); err != nil {
+			return err
 		}
 
 		if p.options.CheckpointCommitStrategy == CommitAfterProcessing {
@@ -257,6 +427,114 @@ func (p *Processor) processEvents(ctx context.Context) (err error) {
 	return nil
 }
 
+// processDescriptor passes a descriptor to the handler of the processor, once transformed by the DescriptorTransform if any.
+func (p *Processor) processDescriptor(ctx context.Context, descriptor store.RecordedEventDescriptor) error {
+	if p.options.DescriptorTransform != nil {
+		transformed, ok := p.options.DescriptorTransform(descriptor)
+		if !ok {
+			return nil
+		}
+		descriptor = transformed
+	}
+
+	if err := p.processingFunc(ctx, descriptor); err != nil {
+		return errors.Wrapf(err, "failed processing event %s:%s", descriptor.TypeName, descriptor.ID)
+	}
+
+	return nil
+}
+
+// filterOptions returns the options of the store.TypeNameFilter restricting the events of the processor to its EventTypeNameFilter.
+func (p *Processor) filterOptions() []store.TypeNameFilterOption {
+	if p.options.EventTypeNameFilter == nil {
+		return nil
+	}
+
+	if p.options.EventTypeNameFilter.Mode == store.Exclude {
+		return []store.TypeNameFilterOption{store.ExcludeEventTypeNames(p.options.EventTypeNameFilter.EventTypeNames...)}
+	}
+	return []store.TypeNameFilterOption{store.SelectEventTypeNames(p.options.EventTypeNameFilter.EventTypeNames...)}
+}
+
+// readEvents reads the events to process from the position of the checkpoint, restricted to the EventTypeNameFilter if any.
+// When processing multiple streams, the events of all streams are merged in the order of their sequence number.
+func (p *Processor) readEvents(ctx context.Context, checkpoint Checkpoint) ([]store.RecordedEventDescriptor, error) {
+	filter := store.WithReadingFilter(p.filterOptions()...)
+	if len(p.options.StreamIDs) == 0 {
+		stream, err := p.eventStore.ReadFromStream(ctx, p.options.StreamID, store.From(checkpoint.Position), filter)
+		if err != nil {
+			return nil, err
+		}
+		return stream.Descriptors, nil
+	}
+
+	var descriptors []store.RecordedEventDescriptor
+	for _, streamID := range p.options.StreamIDs {
+		exists, err := p.eventStore.StreamExists(ctx, streamID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		stream, err := p.eventStore.ReadFromStream(ctx, streamID, store.From(checkpoint.PositionInStream(streamID)), filter)
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, stream.Descriptors...)
+	}
+
+	sort.SliceStable(descriptors, func(i, j int) bool {
+		return descriptors[i].SequenceNumber < descriptors[j].SequenceNumber
+	})
+
+	return descriptors, nil
+}
+
+// drainingContext returns a context carrying the values of ctx which is only cancelled once ShutdownTimeout
+// has elapsed after the cancellation of ctx.
+func (p *Processor) drainingContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(detachedContext{parent: ctx})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			timer := time.NewTimer(p.options.ShutdownTimeout)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+				cancel()
+			case <-drainCtx.Done():
+			}
+		case <-drainCtx.Done():
+		}
+	}()
+
+	return drainCtx, cancel
+}
+
+// detachedContext is a context.Context that exposes the values of its parent without being affected by its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
+
 func (p *Processor) fetchCheckpoint(ctx context.Context) (Checkpoint, error) {
 	if p.options.Name == "" {
 		return Checkpoint{}, errors.New("cannot retrieve processor checkpoint: processor without a name")
@@ -292,3 +570,26 @@ func SendToEventBusProcessingHandler(eventConverter *store.EventConverter, bus e
 		return bus.Send(ctx, loaded)
 	}
 }
+
+// SendToCommandBusProcessingHandler Returns a Processing Func that converts the store.RecordedEventDescriptor to an event.Event,
+// translates it to a command.Command using the provided function and sends it to a command.Bus.
+// Events for which the translate function returns false are skipped.
+func SendToCommandBusProcessingHandler(eventConverter *store.EventConverter, bus command.Bus, translate func(e event.Event) (command.Command, bool)) Handler {
+	return func(ctx context.Context, descriptor store.RecordedEventDescriptor) error {
+		loaded, err := eventConverter.ConvertDescriptorToEvent(descriptor)
+		if err != nil {
+			return err
+		}
+
+		cmd, ok := translate(loaded)
+		if !ok {
+			return nil
+		}
+
+		if _, err := bus.Send(ctx, cmd); err != nil {
+			return errors.Wrapf(err, "failed sending command translated from event %s:%s", descriptor.TypeName, descriptor.ID)
+		}
+
+		return nil
+	}
+}
@@ -16,8 +16,12 @@ package processing
 
 import (
 	"context"
+	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/command"
+	"github.com/morebec/misas-go/misas/event"
 	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"reflect"
 	"testing"
@@ -291,7 +295,8 @@ func TestProcessor_Run(t *testing.T) {
 		ctx context.Context
 	}
 	utcClock := clock.NewUTCClock()
-	ctx, _ := context.WithDeadline(context.Background(), utcClock.Now().Add(5*time.Second))
+	ctx, cancel := context.WithDeadline(context.Background(), utcClock.Now().Add(5*time.Second))
+	defer cancel()
 	tests := []struct {
 		name    string
 		fields  fields
@@ -333,3 +338,562 @@ func TestProcessor_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessor_Run_DrainsInFlightBatchOnCancellation(t *testing.T) {
+	tests := []struct {
+		name             string
+		shutdownTimeout  time.Duration
+		wantErr          bool
+		wantPosition     store.Position
+		wantNbProcessed  int
+		blockOnSecondEvt bool
+	}{
+		{
+			name:            "in-flight batch should be fully processed before returning",
+			shutdownTimeout: time.Second,
+			wantErr:         false,
+			wantPosition:    2,
+			wantNbProcessed: 3,
+		},
+		{
+			name:             "checkpoint should reflect completed events when shutdown timeout elapses",
+			shutdownTimeout:  10 * time.Millisecond,
+			wantErr:          true,
+			wantPosition:     0,
+			wantNbProcessed:  1,
+			blockOnSecondEvt: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			eventStore := store.NewInMemoryEventStore(clock.NewUTCClock())
+			err := eventStore.AppendToStream(ctx, "unit-test", []store.EventDescriptor{
+				{ID: store.NewEventID(), TypeName: "unit.test"},
+				{ID: store.NewEventID(), TypeName: "unit.test"},
+				{ID: store.NewEventID(), TypeName: "unit.test"},
+			})
+			assert.NoError(t, err)
+
+			checkpointStore := NewInMemoryCheckpointStore()
+			nbProcessed := 0
+			p := NewProcessor(eventStore, checkpointStore, func(ctx context.Context, d store.RecordedEventDescriptor) error {
+				if d.SequenceNumber == 0 {
+					// Cancel mid-batch.
+					cancel()
+				} else if tt.blockOnSecondEvt {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				nbProcessed++
+				return nil
+			}, WithName("test"), WithShutdownTimeout(tt.shutdownTimeout))
+
+			err = p.Run(ctx)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			checkpoint, err := checkpointStore.FindById(context.Background(), "test")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPosition, checkpoint.Position)
+			assert.Equal(t, tt.wantNbProcessed, nbProcessed)
+		})
+	}
+}
+
+func TestInMemoryCheckpointStore_SaveMultiStreamPositions(t *testing.T) {
+	checkpointStore := NewInMemoryCheckpointStore()
+
+	checkpoint := Checkpoint{ID: "multi", StreamID: "$all"}.
+		WithPositionInStream("users", 3).
+		WithPositionInStream("accounts", 7)
+	err := checkpointStore.Save(context.Background(), checkpoint)
+	assert.NoError(t, err)
+
+	// Changes to the saved checkpoint should not affect the stored one.
+	checkpoint.Positions["users"] = 10
+
+	found, err := checkpointStore.FindById(context.Background(), "multi")
+	assert.NoError(t, err)
+	assert.Equal(t, store.Position(3), found.PositionInStream("users"))
+	assert.Equal(t, store.Position(7), found.PositionInStream("accounts"))
+	assert.Equal(t, store.Start, found.PositionInStream("unknown"))
+}
+
+func TestProcessor_Run_MultipleStreams(t *testing.T) {
+	eventStore := store.NewInMemoryEventStore(clock.NewUTCClock())
+	checkpointStore := NewInMemoryCheckpointStore()
+
+	appendEvent := func(streamID store.StreamID, id store.EventID) {
+		err := eventStore.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+			{ID: id, TypeName: "unit.test"},
+		})
+		assert.NoError(t, err)
+	}
+
+	run := func(nbExpected int) []store.EventID {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var processed []store.EventID
+		p := NewProcessor(eventStore, checkpointStore, func(ctx context.Context, d store.RecordedEventDescriptor) error {
+			processed = append(processed, d.ID)
+			if len(processed) == nbExpected {
+				cancel()
+			}
+			return nil
+		}, WithName("multi"), WithStreamIds("users", "accounts"))
+
+		assert.NoError(t, p.Run(ctx))
+		return processed
+	}
+
+	appendEvent("users", "user-1")
+	appendEvent("other", "other-1")
+	appendEvent("accounts", "account-1")
+	appendEvent("users", "user-2")
+
+	assert.Equal(t, []store.EventID{"user-1", "account-1", "user-2"}, run(3))
+
+	checkpoint, err := checkpointStore.FindById(context.Background(), "multi")
+	assert.NoError(t, err)
+	assert.Equal(t, map[store.StreamID]store.Position{"users": 1, "accounts": 0}, checkpoint.Positions)
+
+	// Resuming should only process the events appended since the last checkpoint.
+	appendEvent("accounts", "account-2")
+	appendEvent("users", "user-3")
+
+	assert.Equal(t, []store.EventID{"account-2", "user-3"}, run(2))
+
+	checkpoint, err = checkpointStore.FindById(context.Background(), "multi")
+	assert.NoError(t, err)
+	assert.Equal(t, map[store.StreamID]store.Position{"users": 2, "accounts": 1}, checkpoint.Positions)
+}
+
+func TestProcessor_Run_CatchUpOnly(t *testing.T) {
+	eventStore := store.NewInMemoryEventStore(clock.NewUTCClock())
+	err := eventStore.AppendToStream(context.Background(), "unit-test", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: "unit.test"},
+		{ID: store.NewEventID(), TypeName: "unit.test"},
+		{ID: store.NewEventID(), TypeName: "unit.test"},
+	})
+	assert.NoError(t, err)
+
+	checkpointStore := NewInMemoryCheckpointStore()
+	nbProcessed := 0
+	p := NewProcessor(eventStore, checkpointStore, func(ctx context.Context, d store.RecordedEventDescriptor) error {
+		nbProcessed++
+		return nil
+	}, WithName("test"), WithCatchUpOnly())
+
+	// The context is never cancelled, Run should return once caught up.
+	err = p.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, nbProcessed)
+
+	checkpoint, err := checkpointStore.FindById(context.Background(), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, store.Position(2), checkpoint.Position)
+
+	// Running again should not reprocess events.
+	err = p.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, nbProcessed)
+}
+
+func TestProcessor_Run_WithDescriptorTransform(t *testing.T) {
+	eventStore := store.NewInMemoryEventStore(clock.NewUTCClock())
+	err := eventStore.AppendToStream(context.Background(), "unit-test", []store.EventDescriptor{
+		{ID: "event#1", TypeName: "unit.test", Metadata: misas.Metadata{"tenantId": "tenant#1"}},
+		{ID: "event#2", TypeName: "unit.test", Metadata: misas.Metadata{}},
+		{ID: "event#3", TypeName: "unit.test", Metadata: misas.Metadata{"tenantId": "tenant#2"}},
+		{ID: "event#4", TypeName: "unit.test", Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	checkpointStore := NewInMemoryCheckpointStore()
+	var processed []store.RecordedEventDescriptor
+	p := NewProcessor(eventStore, checkpointStore, func(ctx context.Context, d store.RecordedEventDescriptor) error {
+		processed = append(processed, d)
+		return nil
+	}, WithName("test"), WithCatchUpOnly(), WithDescriptorTransform(func(d store.RecordedEventDescriptor) (store.RecordedEventDescriptor, bool) {
+		if !d.Metadata.Has("tenantId") {
+			return d, false
+		}
+		d.Payload = store.DescriptorPayload{"tenantId": d.Metadata.Get("tenantId", nil)}
+		return d, true
+	}))
+
+	err = p.Run(context.Background())
+	assert.NoError(t, err)
+
+	if assert.Len(t, processed, 2) {
+		assert.Equal(t, store.EventID("event#1"), processed[0].ID)
+		assert.Equal(t, store.DescriptorPayload{"tenantId": "tenant#1"}, processed[0].Payload)
+		assert.Equal(t, store.EventID("event#3"), processed[1].ID)
+		assert.Equal(t, store.DescriptorPayload{"tenantId": "tenant#2"}, processed[1].Payload)
+	}
+
+	// Skipped events still advance the checkpoint.
+	checkpoint, err := checkpointStore.FindById(context.Background(), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, store.Position(3), checkpoint.Position)
+}
+
+func TestProcessor_Run_CancelledContext(t *testing.T) {
+	eventStore := store.NewInMemoryEventStore(clock.NewUTCClock())
+	err := eventStore.AppendToStream(context.Background(), "unit-test", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: "unit.test"},
+	})
+	assert.NoError(t, err)
+
+	nbProcessed := 0
+	p := NewProcessor(eventStore, NewInMemoryCheckpointStore(), func(ctx context.Context, d store.RecordedEventDescriptor) error {
+		nbProcessed++
+		return nil
+	}, WithName("test"), WithCatchUpOnly())
+
+	// A context cancelled before a batch is read should not start processing it.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = p.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, nbProcessed)
+}
+
+// subscriptionRecordingEventStore is an event store recording the options of the subscriptions made to it, and
+// cancelling a context once subscribed.
+type subscriptionRecordingEventStore struct {
+	store.EventStore
+	options store.SubscribeToStreamOptions
+	cancel  context.CancelFunc
+}
+
+func (s *subscriptionRecordingEventStore) SubscribeToStream(ctx context.Context, streamID store.StreamID, opts ...store.SubscribeToStreamOption) (store.Subscription, error) {
+	s.options = store.BuildSubscribeToStreamOptions(opts)
+	defer s.cancel()
+	return s.EventStore.SubscribeToStream(ctx, streamID, opts...)
+}
+
+func TestProcessor_Run_SubscribesWithFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ProcessorOption
+		expected *store.TypeNameFilter
+	}{
+		{
+			name:     "without filter",
+			expected: nil,
+		},
+		{
+			name:     "selecting event types",
+			opts:     []ProcessorOption{WithFiler(store.SelectEventTypeNames("unit_test.started"))},
+			expected: &store.TypeNameFilter{Mode: store.Select, EventTypeNames: []event.PayloadTypeName{"unit_test.started"}},
+		},
+		{
+			name:     "excluding event types",
+			opts:     []ProcessorOption{WithFiler(store.ExcludeEventTypeNames("unit_test.started"))},
+			expected: &store.TypeNameFilter{Mode: store.Exclude, EventTypeNames: []event.PayloadTypeName{"unit_test.started"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			eventStore := &subscriptionRecordingEventStore{EventStore: store.NewInMemoryEventStore(clock.NewUTCClock()), cancel: cancel}
+
+			p := NewProcessor(eventStore, NewInMemoryCheckpointStore(), func(ctx context.Context, d store.RecordedEventDescriptor) error {
+				return nil
+			}, append([]ProcessorOption{WithName("test")}, tt.opts...)...)
+			_ = p.Run(ctx)
+
+			assert.Equal(t, tt.expected, eventStore.options.EventTypeNameFilter)
+		})
+	}
+}
+
+func TestProcessor_Run_CatchUpWithFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ProcessorOption
+	}{
+		{
+			name: "selecting event types",
+			opts: []ProcessorOption{WithFiler(store.SelectEventTypeNames("unit_test.started"))},
+		},
+		{
+			name: "excluding event types",
+			opts: []ProcessorOption{WithFiler(store.ExcludeEventTypeNames("unit_test.stopped"))},
+		},
+		{
+			name: "selecting event types of multiple streams",
+			opts: []ProcessorOption{WithStreamIds("unit-test"), WithFiler(store.SelectEventTypeNames("unit_test.started"))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The events are appended before the processor starts, so that they are only read by its catch-up.
+			eventStore := store.NewInMemoryEventStore(clock.NewUTCClock())
+			err := eventStore.AppendToStream(context.Background(), "unit-test", []store.EventDescriptor{
+				{ID: store.NewEventID(), TypeName: "unit_test.stopped"},
+				{ID: store.NewEventID(), TypeName: "unit_test.started"},
+				{ID: store.NewEventID(), TypeName: "unit_test.stopped"},
+			})
+			assert.NoError(t, err)
+
+			var processed []event.PayloadTypeName
+			p := NewProcessor(eventStore, NewInMemoryCheckpointStore(), func(ctx context.Context, d store.RecordedEventDescriptor) error {
+				processed = append(processed, d.TypeName)
+				return nil
+			}, append([]ProcessorOption{WithName("test"), WithCatchUpOnly()}, tt.opts...)...)
+
+			assert.NoError(t, p.Run(context.Background()))
+			assert.Equal(t, []event.PayloadTypeName{"unit_test.started"}, processed)
+		})
+	}
+}
+
+// stagingTx is a Tx staging the changes made during a transaction and applying them only once committed.
+type stagingTx struct {
+	staged     []func()
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *stagingTx) stage(change func()) {
+	tx.staged = append(tx.staged, change)
+}
+
+func (tx *stagingTx) Commit() error {
+	for _, change := range tx.staged {
+		change()
+	}
+	tx.committed = true
+	return nil
+}
+
+func (tx *stagingTx) Rollback() error {
+	tx.staged = nil
+	tx.rolledBack = true
+	return nil
+}
+
+// transactionalCheckpointStore is a CheckpointStore saving checkpoints in the Tx of the context, if any.
+type transactionalCheckpointStore struct {
+	*InMemoryCheckpointStore
+}
+
+func (s transactionalCheckpointStore) Save(ctx context.Context, checkpoint Checkpoint) error {
+	if tx, ok := TxFromContext(ctx); ok {
+		tx.(*stagingTx).stage(func() {
+			_ = s.InMemoryCheckpointStore.Save(ctx, checkpoint)
+		})
+		return nil
+	}
+	return s.InMemoryCheckpointStore.Save(ctx, checkpoint)
+}
+
+func TestProcessor_Run_WithBatchTransaction(t *testing.T) {
+	newEventStore := func() *store.InMemoryEventStore {
+		eventStore := store.NewInMemoryEventStore(clock.NewUTCClock())
+		err := eventStore.AppendToStream(context.Background(), "unit-test", []store.EventDescriptor{
+			{ID: "event#1", TypeName: "unit.test"},
+			{ID: "event#2", TypeName: "unit.test"},
+			{ID: "event#3", TypeName: "unit.test"},
+		})
+		assert.NoError(t, err)
+		return eventStore
+	}
+
+	tests := []struct {
+		name               string
+		strategy           CheckpointCommitStrategy
+		failingEventID     store.EventID
+		wantErr            bool
+		wantProjection     []store.EventID
+		wantCheckpointedAt store.Position
+	}{
+		{
+			name:               "batch is committed",
+			strategy:           CommitAfterProcessing,
+			wantErr:            false,
+			wantProjection:     []store.EventID{"event#1", "event#2", "event#3"},
+			wantCheckpointedAt: 2,
+		},
+		{
+			name:               "mid-batch failure is rolled back when committing after processing",
+			strategy:           CommitAfterProcessing,
+			failingEventID:     "event#2",
+			wantErr:            true,
+			wantProjection:     nil,
+			wantCheckpointedAt: store.Start,
+		},
+		{
+			name:               "mid-batch failure is rolled back when committing before processing",
+			strategy:           CommitBeforeProcessing,
+			failingEventID:     "event#2",
+			wantErr:            true,
+			wantProjection:     nil,
+			wantCheckpointedAt: store.Start,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpointStore := transactionalCheckpointStore{NewInMemoryCheckpointStore()}
+			var projection []store.EventID
+			var txs []*stagingTx
+
+			p := NewProcessor(newEventStore(), checkpointStore, func(ctx context.Context, d store.RecordedEventDescriptor) error {
+				if d.ID == tt.failingEventID {
+					return errors.New("handler failed")
+				}
+				tx, ok := TxFromContext(ctx)
+				if !assert.True(t, ok) {
+					return nil
+				}
+				tx.(*stagingTx).stage(func() {
+					projection = append(projection, d.ID)
+				})
+				return nil
+			},
+				WithName("test"),
+				WithCatchUpOnly(),
+				WithCheckpointCommitStrategy(tt.strategy),
+				WithBatchTransaction(func(ctx context.Context) (Tx, error) {
+					tx := &stagingTx{}
+					txs = append(txs, tx)
+					return tx, nil
+				}),
+			)
+
+			err := p.Run(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if assert.Len(t, txs, 1) {
+				assert.Equal(t, !tt.wantErr, txs[0].committed)
+				assert.Equal(t, tt.wantErr, txs[0].rolledBack)
+			}
+			assert.Equal(t, tt.wantProjection, projection)
+
+			checkpoint, err := checkpointStore.FindById(context.Background(), "test")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCheckpointedAt, checkpoint.Position)
+		})
+	}
+}
+
+func TestProcessor_Run_WithBatchTransaction_BeginFailure(t *testing.T) {
+	eventStore := store.NewInMemoryEventStore(clock.NewUTCClock())
+	err := eventStore.AppendToStream(context.Background(), "unit-test", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: "unit.test"},
+	})
+	assert.NoError(t, err)
+
+	checkpointStore := NewInMemoryCheckpointStore()
+	nbProcessed := 0
+	p := NewProcessor(eventStore, checkpointStore, func(ctx context.Context, d store.RecordedEventDescriptor) error {
+		nbProcessed++
+		return nil
+	}, WithName("test"), WithCatchUpOnly(), WithBatchTransaction(func(ctx context.Context) (Tx, error) {
+		return nil, errors.New("connection refused")
+	}))
+
+	err = p.Run(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, nbProcessed)
+
+	checkpoint, err := checkpointStore.FindById(context.Background(), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, store.Start, checkpoint.Position)
+}
+
+const unitTestStartedEventTypeName event.PayloadTypeName = "unit_test.started"
+
+type unitTestStartedEvent struct {
+	Name string
+}
+
+func (e unitTestStartedEvent) TypeName() event.PayloadTypeName {
+	return unitTestStartedEventTypeName
+}
+
+const unitTestFinishedEventTypeName event.PayloadTypeName = "unit_test.finished"
+
+type unitTestFinishedEvent struct{}
+
+func (e unitTestFinishedEvent) TypeName() event.PayloadTypeName {
+	return unitTestFinishedEventTypeName
+}
+
+const runUnitTestCommandTypeName command.PayloadTypeName = "unit_test.run"
+
+type runUnitTestCommand struct {
+	Name string
+}
+
+func (c runUnitTestCommand) TypeName() command.PayloadTypeName {
+	return runUnitTestCommandTypeName
+}
+
+func TestSendToCommandBusProcessingHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		descriptor   store.RecordedEventDescriptor
+		wantCommands []command.Payload
+	}{
+		{
+			name: "mapped event should produce a command on the bus",
+			descriptor: store.RecordedEventDescriptor{
+				ID:       "00",
+				TypeName: unitTestStartedEventTypeName,
+				Payload:  store.DescriptorPayload{"Name": "unit"},
+			},
+			wantCommands: []command.Payload{runUnitTestCommand{Name: "unit"}},
+		},
+		{
+			name: "unmapped event should be ignored",
+			descriptor: store.RecordedEventDescriptor{
+				ID:       "01",
+				TypeName: unitTestFinishedEventTypeName,
+				Payload:  store.DescriptorPayload{},
+			},
+			wantCommands: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := store.NewEventConverter()
+			converter.RegisterEventPayload(unitTestStartedEvent{})
+			converter.RegisterEventPayload(unitTestFinishedEvent{})
+
+			var sentCommands []command.Payload
+			bus := command.NewInMemoryBus()
+			bus.RegisterHandler(runUnitTestCommandTypeName, command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+				sentCommands = append(sentCommands, c.Payload)
+				return nil, nil
+			}))
+
+			handler := SendToCommandBusProcessingHandler(converter, bus, func(e event.Event) (command.Command, bool) {
+				started, ok := e.Payload.(unitTestStartedEvent)
+				if !ok {
+					return command.Command{}, false
+				}
+				return command.New(runUnitTestCommand{Name: started.Name}), true
+			})
+
+			err := handler(context.Background(), tt.descriptor)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCommands, sentCommands)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processing
+
+import "context"
+
+// Tx represents a transaction in which a Processor processes a batch of events and saves its checkpoint.
+// A *sql.Tx satisfies this interface.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// BeginTxFunc begins a new Tx.
+type BeginTxFunc func(ctx context.Context) (Tx, error)
+
+type txContextKey struct{}
+
+// ContextWithTx returns a copy of a context carrying a given Tx.
+func ContextWithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the Tx carried by a context, if any. Handlers and checkpoint stores can use it to take part in the
+// transaction of the batch they are processing.
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(Tx)
+	return tx, ok
+}
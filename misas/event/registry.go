@@ -0,0 +1,64 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+)
+
+// EventRegistry maps the PayloadTypeName of events to the Go types of their Payload, so that a fresh Payload of the right
+// type can be instantiated from a type name, for instance when decoding events.
+type EventRegistry struct {
+	types map[PayloadTypeName]reflect.Type
+}
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{types: map[PayloadTypeName]reflect.Type{}}
+}
+
+// Register registers the type of a Payload under its PayloadTypeName. Pointers are registered as the type they point to.
+// If a type was already registered for the type name, it is left unchanged.
+func (r *EventRegistry) Register(p Payload) *EventRegistry {
+	if _, found := r.types[p.TypeName()]; found {
+		return r
+	}
+
+	typ := reflect.TypeOf(p)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	r.types[p.TypeName()] = typ
+
+	return r
+}
+
+// IsRegistered indicates if a type was registered for a given PayloadTypeName.
+func (r *EventRegistry) IsRegistered(name PayloadTypeName) bool {
+	_, found := r.types[name]
+	return found
+}
+
+// Instantiate returns the zero value of the type registered for a given PayloadTypeName.
+// Returns an error if no type was registered for this name.
+func (r *EventRegistry) Instantiate(name PayloadTypeName) (Payload, error) {
+	typ, found := r.types[name]
+	if !found {
+		return nil, errors.Errorf("no event registered for type name \"%s\"", name)
+	}
+
+	return reflect.New(typ).Elem().Interface().(Payload), nil
+}
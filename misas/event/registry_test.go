@@ -0,0 +1,50 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type unitTestStarted struct {
+	Name string
+}
+
+func (u unitTestStarted) TypeName() PayloadTypeName {
+	return "unit_test.started"
+}
+
+func TestEventRegistry_Instantiate(t *testing.T) {
+	r := NewEventRegistry().
+		Register(unitTestSucceeded{}).
+		Register(&unitTestStarted{Name: "registered"})
+
+	p, err := r.Instantiate(unitTestSucceededTypeName)
+	assert.NoError(t, err)
+	assert.Equal(t, unitTestSucceeded{}, p)
+
+	// A fresh value is instantiated rather than the registered one.
+	p, err = r.Instantiate("unit_test.started")
+	assert.NoError(t, err)
+	assert.Equal(t, unitTestStarted{}, p)
+
+	assert.True(t, r.IsRegistered(unitTestSucceededTypeName))
+	assert.False(t, r.IsRegistered(unitTestFailedTypeName))
+
+	p, err = r.Instantiate(unitTestFailedTypeName)
+	assert.Error(t, err)
+	assert.Nil(t, p)
+}
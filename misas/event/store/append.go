@@ -14,7 +14,10 @@
 
 package store
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/pkg/errors"
+)
 
 type ConcurrencyError struct {
 	StreamID        StreamID
@@ -39,6 +42,42 @@ func NewConcurrencyError(streamID StreamID, expectedVersion StreamVersion, actua
 	}
 }
 
+// DuplicateEventIDError is returned when appending a batch of events containing multiple events with the same ID.
+type DuplicateEventIDError struct {
+	StreamID StreamID
+	EventID  EventID
+}
+
+func (e DuplicateEventIDError) Error() string {
+	return fmt.Sprintf(`cannot append to stream "%s", the event ID "%s" is used by multiple events of the batch`, e.StreamID, e.EventID)
+}
+
+func NewDuplicateEventIDError(streamID StreamID, eventID EventID) error {
+	return DuplicateEventIDError{
+		StreamID: streamID,
+		EventID:  eventID,
+	}
+}
+
+// IsDuplicateEventIDError Indicates if a given error is a DuplicateEventIDError or wraps one.
+func IsDuplicateEventIDError(err error) bool {
+	var duplicateEventIDError DuplicateEventIDError
+	return errors.As(err, &duplicateEventIDError)
+}
+
+// CheckEventIDsUnique returns a DuplicateEventIDError for the first ID used by multiple events of a batch to append to a stream.
+// Event store implementations should call it before writing anything.
+func CheckEventIDsUnique(streamID StreamID, events []EventDescriptor) error {
+	ids := make(map[EventID]struct{}, len(events))
+	for _, e := range events {
+		if _, found := ids[e.ID]; found {
+			return NewDuplicateEventIDError(streamID, e.ID)
+		}
+		ids[e.ID] = struct{}{}
+	}
+	return nil
+}
+
 // AppendToStreamOptions represents options to alter the behaviour of the AppendsToStream function of the event store.
 type AppendToStreamOptions struct {
 	ExpectedVersion *StreamVersion
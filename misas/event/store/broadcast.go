@@ -0,0 +1,235 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+// DefaultSubscriberBufferSize is the default number of events and errors that can be queued for a subscriber of a
+// SubscriptionBroadcaster before it is considered too slow.
+const DefaultSubscriberBufferSize = 1000
+
+// SubscriptionBroadcaster allows fanning out a single Subscription to multiple in-process subscribers so that
+// they can share the same underlying backend listener.
+// Every subscriber receives all the events and errors of the source subscription and can be closed independently.
+// The events and errors are queued for each subscriber so that a slow subscriber does not delay the others. A subscriber
+// whose queue is full is detached from the broadcaster and receives a SlowSubscriberError once it has consumed its queue.
+type SubscriptionBroadcaster struct {
+	source      Subscription
+	bufferSize  int
+	mu          sync.Mutex
+	subscribers map[*broadcastSubscriber]struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// SubscriptionBroadcasterOption represents an option of a SubscriptionBroadcaster.
+type SubscriptionBroadcasterOption func(b *SubscriptionBroadcaster)
+
+// WithSubscriberBufferSize allows specifying the number of events and errors that can be queued for each subscriber.
+func WithSubscriberBufferSize(size int) SubscriptionBroadcasterOption {
+	return func(b *SubscriptionBroadcaster) {
+		b.bufferSize = size
+	}
+}
+
+// broadcastSubscriber represents a Subscription that was obtained from a SubscriptionBroadcaster.
+type broadcastSubscriber struct {
+	subscription Subscription
+	queue        chan broadcastMessage
+	closed       chan struct{}
+	overflowed   chan struct{}
+}
+
+// broadcastMessage represents an event or an error queued for a subscriber.
+type broadcastMessage struct {
+	descriptor RecordedEventDescriptor
+	err        error
+}
+
+// SlowSubscriberError error representing the fact that a subscriber of a SubscriptionBroadcaster was detached
+// because it did not consume its events fast enough.
+type SlowSubscriberError struct {
+	BufferSize int
+}
+
+func (e SlowSubscriberError) Error() string {
+	return fmt.Sprintf("subscriber detached from broadcaster, more than %d events and errors were awaiting consumption", e.BufferSize)
+}
+
+// IsSlowSubscriberError Indicates if a given error is a SlowSubscriberError or wraps one.
+func IsSlowSubscriberError(err error) bool {
+	var slowSubscriberError SlowSubscriberError
+	return errors.As(err, &slowSubscriberError)
+}
+
+// NewSubscriptionBroadcaster returns a new SubscriptionBroadcaster that starts forwarding the events and errors of a source subscription.
+func NewSubscriptionBroadcaster(sub Subscription, opts ...SubscriptionBroadcasterOption) *SubscriptionBroadcaster {
+	b := &SubscriptionBroadcaster{
+		source:      sub,
+		bufferSize:  DefaultSubscriberBufferSize,
+		subscribers: map[*broadcastSubscriber]struct{}{},
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	go b.broadcast()
+
+	return b
+}
+
+// Subscribe returns a new Subscription receiving the events of the source subscription.
+// Closing the returned subscription only detaches it from the broadcaster, the source subscription remains open.
+func (b *SubscriptionBroadcaster) Subscribe() Subscription {
+	closeChannel := make(chan bool, 1)
+	s := &broadcastSubscriber{
+		subscription: *NewSubscription(
+			make(chan RecordedEventDescriptor),
+			make(chan error),
+			closeChannel,
+			b.source.StreamID(),
+			b.source.Options(),
+		),
+		queue:      make(chan broadcastMessage, b.bufferSize),
+		closed:     make(chan struct{}),
+		overflowed: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		select {
+		case <-closeChannel:
+			b.unsubscribe(s)
+			close(s.closed)
+		case <-b.done:
+		}
+	}()
+
+	go b.deliver(s)
+
+	return s.subscription
+}
+
+// Close closes the source subscription and stops broadcasting to the subscribers.
+func (b *SubscriptionBroadcaster) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		err = b.source.Close()
+	})
+	return err
+}
+
+func (b *SubscriptionBroadcaster) unsubscribe(s *broadcastSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, s)
+}
+
+func (b *SubscriptionBroadcaster) snapshot() []*broadcastSubscriber {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subscribers := make([]*broadcastSubscriber, 0, len(b.subscribers))
+	for s := range b.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	return subscribers
+}
+
+func (b *SubscriptionBroadcaster) broadcast() {
+	for {
+		select {
+		case d := <-b.source.EventChannel():
+			b.fanOut(broadcastMessage{descriptor: d})
+		case err := <-b.source.ErrorChannel():
+			b.fanOut(broadcastMessage{err: err})
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// fanOut queues a message for all subscribers without waiting for them to consume it, so that the delivery order is
+// preserved for each subscriber without having one subscriber wait on the others. The subscribers whose queue is full
+// are detached.
+func (b *SubscriptionBroadcaster) fanOut(m broadcastMessage) {
+	for _, s := range b.snapshot() {
+		select {
+		case s.queue <- m:
+		default:
+			// Since messages are only queued by the broadcast goroutine, nothing is queued once overflowed is closed.
+			b.unsubscribe(s)
+			close(s.overflowed)
+		}
+	}
+}
+
+// deliver sends the messages queued for a subscriber to its channels until it is closed or the broadcaster is closed.
+// An overflowed subscriber receives the messages that were queued before receiving a SlowSubscriberError.
+func (b *SubscriptionBroadcaster) deliver(s *broadcastSubscriber) {
+	for {
+		select {
+		case m := <-s.queue:
+			if !b.send(s, m) {
+				return
+			}
+		case <-s.overflowed:
+			for {
+				select {
+				case m := <-s.queue:
+					if !b.send(s, m) {
+						return
+					}
+				default:
+					b.send(s, broadcastMessage{err: SlowSubscriberError{BufferSize: b.bufferSize}})
+					return
+				}
+			}
+		case <-s.closed:
+			return
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// send sends a message to the channel of a subscriber, and indicates if it was sent before the subscriber or the broadcaster was closed.
+func (b *SubscriptionBroadcaster) send(s *broadcastSubscriber, m broadcastMessage) bool {
+	if m.err != nil {
+		select {
+		case s.subscription.errorChannel <- m.err:
+			return true
+		case <-s.closed:
+		case <-b.done:
+		}
+		return false
+	}
+
+	select {
+	case s.subscription.eventChannel <- m.descriptor:
+		return true
+	case <-s.closed:
+	case <-b.done:
+	}
+	return false
+}
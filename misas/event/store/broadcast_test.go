@@ -0,0 +1,91 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func receiveEvent(t *testing.T, s Subscription) RecordedEventDescriptor {
+	select {
+	case d := <-s.EventChannel():
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return RecordedEventDescriptor{}
+	}
+}
+
+func TestSubscriptionBroadcaster(t *testing.T) {
+	closeChannel := make(chan bool, 1)
+	source := *NewSubscription(make(chan RecordedEventDescriptor), make(chan error), closeChannel, "unit-test", SubscribeToStreamOptions{})
+
+	broadcaster := NewSubscriptionBroadcaster(source)
+
+	first := broadcaster.Subscribe()
+	second := broadcaster.Subscribe()
+
+	source.EmitEvent(RecordedEventDescriptor{ID: "evt-1", TypeName: "unit_test.ran"})
+
+	assert.Equal(t, EventID("evt-1"), receiveEvent(t, first).ID)
+	assert.Equal(t, EventID("evt-1"), receiveEvent(t, second).ID)
+
+	// Closing a subscriber should not prevent the others from receiving events.
+	assert.NoError(t, first.Close())
+
+	source.EmitEvent(RecordedEventDescriptor{ID: "evt-2", TypeName: "unit_test.ran"})
+	assert.Equal(t, EventID("evt-2"), receiveEvent(t, second).ID)
+
+	// Closing the broadcaster should close the source subscription.
+	assert.NoError(t, broadcaster.Close())
+	assert.True(t, <-closeChannel)
+}
+
+func TestSubscriptionBroadcaster_StalledSubscriber(t *testing.T) {
+	source := *NewSubscription(make(chan RecordedEventDescriptor), make(chan error), make(chan bool, 1), "unit-test", SubscribeToStreamOptions{})
+
+	broadcaster := NewSubscriptionBroadcaster(source, WithSubscriberBufferSize(2))
+	defer func() {
+		assert.NoError(t, broadcaster.Close())
+	}()
+
+	stalled := broadcaster.Subscribe()
+	active := broadcaster.Subscribe()
+
+	// The stalled subscriber does not consume its events, which should not prevent the active one from receiving them.
+	for _, id := range []EventID{"evt-1", "evt-2", "evt-3", "evt-4"} {
+		source.EmitEvent(RecordedEventDescriptor{ID: id, TypeName: "unit_test.ran"})
+		assert.Equal(t, id, receiveEvent(t, active).ID)
+	}
+
+	// Once its queue is full, the stalled subscriber is detached and receives the queued events followed by an error.
+	var received []EventID
+	for {
+		select {
+		case d := <-stalled.EventChannel():
+			received = append(received, d.ID)
+			continue
+		case err := <-stalled.ErrorChannel():
+			assert.True(t, IsSlowSubscriberError(err))
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for error")
+		}
+		break
+	}
+	// The event being sent when the queue overflowed is delivered in addition to the queued ones.
+	assert.Contains(t, [][]EventID{{"evt-1", "evt-2"}, {"evt-1", "evt-2", "evt-3"}}, received)
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/morebec/go-errors/errors"
+	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/event"
 	"reflect"
 )
@@ -27,11 +28,17 @@ import (
 // Internally it relies on mapping the empty value of an event.Event to its event.PayloadTypeName so that it can read the event.PayloadTypeName
 // of a given RecordedEventDescriptor to have the right in memory representation (struct) of the event.Event.
 type EventConverter struct {
-	events map[event.PayloadTypeName]reflect.Type
+	registry *event.EventRegistry
 }
 
 func NewEventConverter() *EventConverter {
-	ec := &EventConverter{map[event.PayloadTypeName]reflect.Type{}}
+	return NewEventConverterWithRegistry(event.NewEventRegistry())
+}
+
+// NewEventConverterWithRegistry creates an EventConverter using an event.EventRegistry to map type names to the
+// types of events, so that the registrations can be shared with other services.
+func NewEventConverterWithRegistry(registry *event.EventRegistry) *EventConverter {
+	ec := &EventConverter{registry: registry}
 	ec.RegisterEventPayload(StreamTruncatedEvent{})
 	return ec
 }
@@ -80,6 +87,36 @@ func (c *EventConverter) ConvertEventPayloadToDescriptorPayload(p event.Payload)
 	return payload, nil
 }
 
+// VerifyRoundTrip verifies that an event can be converted to a DescriptorPayload and back without losing data, such as
+// unexported fields, fields ignored by their tags or numbers losing precision. Returns an error describing the mismatch
+// otherwise. The event is registered with the converter. Since payloads are compared on their exact values, time values
+// should be in UTC without monotonic clock readings, as provided by clock.UTCClock.
+func (c *EventConverter) VerifyRoundTrip(e event.Event) error {
+	payload, err := c.ConvertEventPayloadToDescriptorPayload(e.Payload)
+	if err != nil {
+		return err
+	}
+
+	converted, err := c.ConvertDescriptorPayloadToEventPayload(payload, e.Payload.TypeName())
+	if err != nil {
+		return err
+	}
+
+	expected := reflect.ValueOf(e.Payload)
+	for expected.Kind() == reflect.Ptr {
+		expected = expected.Elem()
+	}
+
+	if !reflect.DeepEqual(expected.Interface(), converted) {
+		return errors.NewWithMessage(
+			EventConversionErrorCode,
+			fmt.Sprintf("event \"%s\" does not round-trip, expected %#v, got %#v", e.Payload.TypeName(), expected.Interface(), converted),
+		)
+	}
+
+	return nil
+}
+
 // ConvertEventListToDescriptorSlice converts a list of event.Event to a list of EventDescriptor.
 func (c *EventConverter) ConvertEventListToDescriptorSlice(l event.List) []EventDescriptor {
 	var descriptors []EventDescriptor
@@ -106,7 +143,8 @@ func (c *EventConverter) ConvertDescriptorToEvent(d RecordedEventDescriptor) (ev
 		)
 	}
 
-	metadata := d.Metadata
+	// The metadata is copied so that the recorded descriptor is not modified.
+	metadata := copyMetadata(d.Metadata)
 	metadata.Set("id", string(d.ID))
 	metadata.Set("streamId", string(d.StreamID))
 	metadata.Set("sequenceNumber", int64(d.SequenceNumber))
@@ -116,6 +154,18 @@ func (c *EventConverter) ConvertDescriptorToEvent(d RecordedEventDescriptor) (ev
 	return event.NewWithMetadata(p, metadata), nil
 }
 
+// copyMetadata returns a shallow copy of metadata, or nil if it is nil.
+func copyMetadata(m misas.Metadata) misas.Metadata {
+	if m == nil {
+		return nil
+	}
+	c := make(misas.Metadata, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
 // ConvertDescriptorPayloadToEventPayload converts a DescriptorPayload to an event.Payload
 func (c *EventConverter) ConvertDescriptorPayloadToEventPayload(dp DescriptorPayload, t event.PayloadTypeName) (event.Payload, error) {
 	evt, err := c.findPayloadStruct(t)
@@ -163,26 +213,47 @@ func (c *EventConverter) ConvertStreamSliceToEventList(slice StreamSlice) (event
 	return events, nil
 }
 
-// RegisterEventPayload registers an event and its type with this converter.
-func (c *EventConverter) RegisterEventPayload(e event.Payload) *EventConverter {
-	if _, found := c.events[e.TypeName()]; found {
-		return c
-	}
+// ConversionError represents the failure of converting a RecordedEventDescriptor to an event.Event.
+type ConversionError struct {
+	Descriptor RecordedEventDescriptor
+	Err        error
+}
 
-	typ := reflect.TypeOf(e)
-	for typ.Kind() == reflect.Ptr {
-		typ = typ.Elem()
+func (e ConversionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// ConvertSlice converts all the descriptors of a StreamSlice to event.Event. Contrary to ConvertStreamSliceToEventList
+// it does not stop at the first failure: the descriptors that could not be converted are reported as ConversionError
+// while the others are still returned in order.
+func (c *EventConverter) ConvertSlice(slice StreamSlice) ([]event.Event, []ConversionError) {
+	var events []event.Event
+	var conversionErrors []ConversionError
+	for _, d := range slice.Descriptors {
+		e, err := c.ConvertDescriptorToEvent(d)
+		if err != nil {
+			conversionErrors = append(conversionErrors, ConversionError{Descriptor: d, Err: err})
+			continue
+		}
+		events = append(events, e)
 	}
+	return events, conversionErrors
+}
 
-	c.events[e.TypeName()] = typ
+// RegisterEventPayload registers an event and its type with this converter.
+func (c *EventConverter) RegisterEventPayload(e event.Payload) *EventConverter {
+	c.registry.Register(e)
 
 	return c
 }
 
 // findPayloadStruct a pointer to a struct of the event's type to be used for converting.
 func (c *EventConverter) findPayloadStruct(tn event.PayloadTypeName) (event.Payload, error) {
-	evt, found := c.events[tn]
-	if !found {
+	if !c.registry.IsRegistered(tn) {
 		return nil, errors.NewWithMessage(
 			EventConversionErrorCode,
 			fmt.Sprintf("no event registered for type name \"%s\"", tn),
@@ -190,7 +261,10 @@ func (c *EventConverter) findPayloadStruct(tn event.PayloadTypeName) (event.Payl
 	}
 
 	// Create a new instance
-	ret := reflect.ValueOf(reflect.New(evt).Interface()).Elem().Interface().(event.Payload)
+	ret, err := c.registry.Instantiate(tn)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create a pointer since most decoders require pointer values.
 	evtPtr := reflect.New(reflect.TypeOf(ret)).Interface().(event.Payload)
@@ -16,6 +16,7 @@ package store
 
 import (
 	"fmt"
+	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/event"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -138,10 +139,10 @@ func TestEventConverter_ConvertEventToDescriptor(t *testing.T) {
 				TypeName: eventLoadedTypeName,
 				Payload: DescriptorPayload{
 					"AString": "string",
-					"AnInt":   1,
+					"AnInt":   float64(1),
 					"AFloat":  50.25,
 					"ABool":   true,
-					"ARune":   'A',
+					"ARune":   float64('A'),
 					"AMap": map[string]any{
 						"hello": "world",
 					},
@@ -162,7 +163,106 @@ func TestEventConverter_ConvertEventToDescriptor(t *testing.T) {
 			if !tt.wantErr(t, err, fmt.Sprintf("ConvertEventToDescriptor(%v)", tt.args.evt)) {
 				return
 			}
+			// A new ID is generated for every descriptor.
+			assert.NotEmpty(t, got.ID)
+			got.ID = ""
 			assert.Equalf(t, tt.want, got, "ConvertEventToDescriptor(%v)", tt.args.evt)
 		})
 	}
 }
+
+func TestEventConverter_ConvertDescriptorToEvent_Metadata(t *testing.T) {
+	c := NewEventConverter()
+	c.RegisterEventPayload(eventLoaded{})
+	descriptor := RecordedEventDescriptor{
+		ID:       "evt-1",
+		TypeName: eventLoadedTypeName,
+		Payload:  DescriptorPayload{},
+		Metadata: misas.Metadata{"correlationId": "correlation-1"},
+	}
+
+	evt, err := c.ConvertDescriptorToEvent(descriptor)
+	assert.NoError(t, err)
+	assert.Equal(t, "correlation-1", evt.Metadata.Get("correlationId", nil))
+	assert.Equal(t, "evt-1", evt.Metadata.Get("id", nil))
+
+	// Converting should not modify the metadata of the descriptor.
+	assert.Equal(t, misas.Metadata{"correlationId": "correlation-1"}, descriptor.Metadata)
+
+	// The metadata describing the recorded event must not be carried over when converting the event back to a descriptor,
+	// otherwise appending it again would record a stale id, stream id and sequence number.
+	converted, err := c.ConvertEventToDescriptor(evt)
+	assert.NoError(t, err)
+	assert.Nil(t, converted.Metadata)
+}
+
+func TestEventConverter_ConvertSlice(t *testing.T) {
+	converter := NewEventConverter()
+	converter.RegisterEventPayload(eventLoaded{})
+
+	slice := StreamSlice{
+		StreamID: "unit.test",
+		Descriptors: []RecordedEventDescriptor{
+			{ID: "#000", TypeName: eventLoadedTypeName, Payload: DescriptorPayload{"AString": "first"}, StreamID: "unit.test", Version: 0},
+			{ID: "#001", TypeName: "event.unknown", Payload: DescriptorPayload{}, StreamID: "unit.test", Version: 1},
+			{ID: "#002", TypeName: eventLoadedTypeName, Payload: DescriptorPayload{"AString": "third"}, StreamID: "unit.test", Version: 2},
+		},
+	}
+
+	events, conversionErrors := converter.ConvertSlice(slice)
+
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, eventLoaded{AString: "first"}, events[0].Payload)
+		assert.Equal(t, eventLoaded{AString: "third"}, events[1].Payload)
+	}
+
+	if assert.Len(t, conversionErrors, 1) {
+		assert.Equal(t, EventID("#001"), conversionErrors[0].Descriptor.ID)
+		assert.Error(t, conversionErrors[0].Err)
+		assert.ErrorIs(t, conversionErrors[0], conversionErrors[0].Err)
+	}
+}
+
+func TestNewEventConverterWithRegistry(t *testing.T) {
+	registry := event.NewEventRegistry().Register(eventLoaded{})
+	converter := NewEventConverterWithRegistry(registry)
+
+	e, err := converter.ConvertDescriptorToEvent(RecordedEventDescriptor{
+		ID:       "#000",
+		TypeName: eventLoadedTypeName,
+		Payload:  DescriptorPayload{"AString": "registered"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, eventLoaded{AString: "registered"}, e.Payload)
+
+	// Events registered through the converter are registered in the shared registry.
+	assert.True(t, registry.IsRegistered(StreamTruncatedEventTypeName))
+}
+
+type eventWithUnexportedField struct {
+	Name   string
+	secret string
+}
+
+func (e eventWithUnexportedField) TypeName() event.PayloadTypeName {
+	return "event.with_unexported_field"
+}
+
+func TestEventConverter_VerifyRoundTrip(t *testing.T) {
+	converter := NewEventConverter()
+
+	err := converter.VerifyRoundTrip(event.New(eventLoaded{
+		AString: "string",
+		AnInt:   1,
+		AFloat:  50.25,
+		ABool:   true,
+		ARune:   'A',
+		AMap:    map[string]any{"hello": "world"},
+		AList:   []string{"hello", "world"},
+	}))
+	assert.NoError(t, err)
+
+	err = converter.VerifyRoundTrip(event.New(&eventWithUnexportedField{Name: "name", secret: "lost"}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "event.with_unexported_field")
+}
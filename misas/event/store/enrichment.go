@@ -0,0 +1,105 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/event"
+)
+
+// EventDescriptorEnricher is a function allowing to enrich an EventDescriptor before it gets appended to a stream.
+// It can be used to stamp cross-cutting metadata derived from the context or the environment on every event.
+type EventDescriptorEnricher func(ctx context.Context, d EventDescriptor) EventDescriptor
+
+// CorrelationEnricher returns an EventDescriptorEnricher stamping the correlation and causation IDs carried by the context
+// (see misas.ContextWithCorrelationID and misas.ContextWithCausationID) on the metadata of the descriptors.
+// IDs already present in the metadata of a descriptor are left untouched.
+func CorrelationEnricher() EventDescriptorEnricher {
+	return func(ctx context.Context, d EventDescriptor) EventDescriptor {
+		if id, found := misas.CorrelationIDFromContext(ctx); found && !d.Metadata.Has(misas.CorrelationIDMetadataKey) {
+			d.Metadata = d.Metadata.Set(misas.CorrelationIDMetadataKey, id)
+		}
+		if id, found := misas.CausationIDFromContext(ctx); found && !d.Metadata.Has(misas.CausationIDMetadataKey) {
+			d.Metadata = d.Metadata.Set(misas.CausationIDMetadataKey, id)
+		}
+		return d
+	}
+}
+
+// EnrichingEventStoreDecorator decorator around an event store that passes the descriptors to append through a series of enrichers.
+type EnrichingEventStoreDecorator struct {
+	enrichers []EventDescriptorEnricher
+	inner     EventStore
+}
+
+// NewEnrichingDecorator returns a new enriching event store decorator. The enrichers are applied in the order they are provided.
+func NewEnrichingDecorator(inner EventStore, enrichers ...EventDescriptorEnricher) *EnrichingEventStoreDecorator {
+	return &EnrichingEventStoreDecorator{inner: inner, enrichers: enrichers}
+}
+
+func (e EnrichingEventStoreDecorator) GlobalStreamID() StreamID {
+	return e.inner.GlobalStreamID()
+}
+
+func (e EnrichingEventStoreDecorator) AppendToStream(ctx context.Context, streamID StreamID, events []EventDescriptor, opts ...AppendToStreamOption) error {
+	enrichedEvents := make([]EventDescriptor, 0, len(events))
+	for _, d := range events {
+		// Work on a copy of the metadata so that enrichers do not alter the descriptors of the caller.
+		d.Metadata = misas.Metadata{}.Merge(d.Metadata, true)
+		for _, enrich := range e.enrichers {
+			d = enrich(ctx, d)
+		}
+		enrichedEvents = append(enrichedEvents, d)
+	}
+
+	return e.inner.AppendToStream(ctx, streamID, enrichedEvents, opts...)
+}
+
+func (e EnrichingEventStoreDecorator) ReadFromStream(ctx context.Context, streamID StreamID, opts ...ReadFromStreamOption) (StreamSlice, error) {
+	return e.inner.ReadFromStream(ctx, streamID, opts...)
+}
+
+func (e EnrichingEventStoreDecorator) TruncateStream(ctx context.Context, streamID StreamID, opts ...TruncateStreamOption) error {
+	return e.inner.TruncateStream(ctx, streamID, opts...)
+}
+
+func (e EnrichingEventStoreDecorator) DeleteStream(ctx context.Context, id StreamID) error {
+	return e.inner.DeleteStream(ctx, id)
+}
+
+func (e EnrichingEventStoreDecorator) SubscribeToStream(ctx context.Context, streamID StreamID, opts ...SubscribeToStreamOption) (Subscription, error) {
+	return e.inner.SubscribeToStream(ctx, streamID, opts...)
+}
+
+func (e EnrichingEventStoreDecorator) StreamExists(ctx context.Context, id StreamID) (bool, error) {
+	return e.inner.StreamExists(ctx, id)
+}
+
+func (e EnrichingEventStoreDecorator) RenameEventType(ctx context.Context, streamID StreamID, from, to event.PayloadTypeName) (int, error) {
+	return RenameEventType(ctx, e.inner, streamID, from, to)
+}
+
+func (e EnrichingEventStoreDecorator) GetStream(ctx context.Context, id StreamID) (Stream, error) {
+	return e.inner.GetStream(ctx, id)
+}
+
+func (e EnrichingEventStoreDecorator) CountEvents(ctx context.Context, streamID StreamID) (int64, error) {
+	return e.inner.CountEvents(ctx, streamID)
+}
+
+func (e EnrichingEventStoreDecorator) Clear(ctx context.Context) error {
+	return e.inner.Clear(ctx)
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEnrichingEventStoreDecorator_AppendToStream(t *testing.T) {
+	type contextKey string
+
+	inner := NewInMemoryEventStore(clock.UTCClock{})
+	es := NewEnrichingDecorator(
+		inner,
+		func(ctx context.Context, d EventDescriptor) EventDescriptor {
+			d.Metadata = d.Metadata.Set("appVersion", "1.0.0")
+			return d
+		},
+		func(ctx context.Context, d EventDescriptor) EventDescriptor {
+			d.Metadata = d.Metadata.Set("hostname", ctx.Value(contextKey("hostname")))
+			return d
+		},
+	)
+
+	ctx := context.WithValue(context.Background(), contextKey("hostname"), "unit-test-host")
+	descriptor := EventDescriptor{
+		ID:       NewEventID(),
+		TypeName: "unit_test.ran",
+		Metadata: misas.Metadata{"userId": "user-123"},
+	}
+	err := es.AppendToStream(ctx, "unit-test", []EventDescriptor{descriptor})
+	assert.NoError(t, err)
+
+	slice, err := inner.ReadFromStream(ctx, "unit-test", FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, slice.Length())
+	assert.Equal(t, misas.Metadata{
+		"userId":     "user-123",
+		"appVersion": "1.0.0",
+		"hostname":   "unit-test-host",
+	}, slice.First().Metadata)
+
+	// The descriptor of the caller should not be altered.
+	assert.Equal(t, misas.Metadata{"userId": "user-123"}, descriptor.Metadata)
+}
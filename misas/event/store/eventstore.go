@@ -20,6 +20,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/event"
+	"github.com/pkg/errors"
 	"time"
 )
 
@@ -36,6 +37,8 @@ type EventStore interface {
 	AppendToStream(ctx context.Context, streamID StreamID, events []EventDescriptor, opts ...AppendToStreamOption) error
 
 	// ReadFromStream Reads an event stream using a given set of options. If the stream does not exist, an error will be returned.
+	// The events of a stream are ordered by version and the ones of the global stream by sequence number. In both cases,
+	// the sequence number is the final tie-breaker, so that reads are deterministic, even for events recorded at the same time.
 	ReadFromStream(ctx context.Context, streamID StreamID, opts ...ReadFromStreamOption) (StreamSlice, error)
 
 	// TruncateStream Truncates a stream by removing some events in it using a given set of options.
@@ -65,6 +68,10 @@ type EventStore interface {
 	// If the stream does not exist it is returned as an error.
 	GetStream(ctx context.Context, id StreamID) (Stream, error)
 
+	// CountEvents returns the number of events in a stream without reading them.
+	// For the global stream, the total number of events in the store is returned. A stream that does not exist has no events.
+	CountEvents(ctx context.Context, streamID StreamID) (int64, error)
+
 	// Clear this event store
 	Clear(ctx context.Context) error
 }
@@ -72,6 +79,12 @@ type EventStore interface {
 // StreamID represents the EventID of a stream.
 type StreamID string
 
+// UniqueStreamID Generates a new StreamID made of a prefix and a unique suffix.
+// It is mostly useful in tests, to ensure that scenarios sharing an event store never write to the same streams.
+func UniqueStreamID(prefix string) StreamID {
+	return StreamID(fmt.Sprintf("%s-%s", prefix, uuid.NewString()))
+}
+
 // StreamVersion Represents the version of a stream.
 type StreamVersion int64
 
@@ -97,6 +110,18 @@ func NewEventID() EventID {
 // DescriptorPayload represents the payload of an event descriptor.
 type DescriptorPayload map[string]any
 
+// Project returns a copy of this payload containing only some given top level fields. Fields that are not part of the
+// payload are absent from the result.
+func (p DescriptorPayload) Project(fields ...string) DescriptorPayload {
+	projected := DescriptorPayload{}
+	for _, f := range fields {
+		if v, found := p[f]; found {
+			projected[f] = v
+		}
+	}
+	return projected
+}
+
 // EventDescriptor Represents a wrapper around an event to be added to the store.
 type EventDescriptor struct {
 	ID       EventID
@@ -163,9 +188,12 @@ func (s StreamSlice) Select(p func(event RecordedEventDescriptor) bool) []Record
 
 // Reversed returns a copy of this slice with the events in reverse order.
 func (s StreamSlice) Reversed() StreamSlice {
-	for i, j := 0, len(s.Descriptors)-1; i < j; i, j = i+1, j-1 {
-		s.Descriptors[i], s.Descriptors[j] = s.Descriptors[j], s.Descriptors[i]
+	// The descriptors are copied so that the slice they were taken from is left untouched.
+	descriptors := make([]RecordedEventDescriptor, len(s.Descriptors))
+	for i, d := range s.Descriptors {
+		descriptors[len(s.Descriptors)-1-i] = d
 	}
+	s.Descriptors = descriptors
 
 	return s
 }
@@ -189,8 +217,8 @@ func IsStreamNotFoundError(err error) bool {
 	return ok
 }
 
-// IsConcurrencyError Indicates if a given error is a ConcurrencyError
+// IsConcurrencyError Indicates if a given error is a ConcurrencyError or wraps one.
 func IsConcurrencyError(err error) bool {
-	_, ok := err.(ConcurrencyError)
-	return ok
+	var concurrencyError ConcurrencyError
+	return errors.As(err, &concurrencyError)
 }
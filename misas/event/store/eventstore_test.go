@@ -17,6 +17,7 @@ package store
 import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 	"time"
 )
@@ -52,6 +53,35 @@ func TestIsStreamNotFoundError(t *testing.T) {
 	}
 }
 
+func TestIsConcurrencyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "concurrency error",
+			err:  NewConcurrencyError("unit", InitialVersion, 0),
+			want: true,
+		},
+		{
+			name: "wrapped concurrency error",
+			err:  errors.Wrap(NewConcurrencyError("unit", InitialVersion, 0), "failed appending"),
+			want: true,
+		},
+		{
+			name: "other error",
+			err:  errors.New("was not a concurrency error"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, IsConcurrencyError(tt.err), "IsConcurrencyError(%v)", tt.err)
+		})
+	}
+}
+
 func TestStreamSlice_First(t *testing.T) {
 	type fields struct {
 		StreamID    StreamID
@@ -349,6 +379,7 @@ func TestStreamSlice_Reversed(t *testing.T) {
 				Descriptors: tt.fields.Descriptors,
 			}
 			assert.Equalf(t, tt.want, s.Reversed(), "Reversed()")
+			assert.Equal(t, EventID("00"), s.First().ID, "Reversed() should not modify the original slice")
 		})
 	}
 }
@@ -423,3 +454,12 @@ func TestStreamSlice_Select(t *testing.T) {
 		})
 	}
 }
+
+func TestUniqueStreamID(t *testing.T) {
+	first := UniqueStreamID("account")
+	second := UniqueStreamID("account")
+
+	assert.NotEqual(t, first, second)
+	assert.True(t, strings.HasPrefix(string(first), "account-"))
+	assert.True(t, strings.HasPrefix(string(second), "account-"))
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/pkg/errors"
+)
+
+// ForkStreamOptions Options for forking a stream.
+type ForkStreamOptions struct {
+	// PreserveEventIDs indicates if the events of the fork should keep the IDs of the source events.
+	// By default, new IDs are generated.
+	PreserveEventIDs bool
+}
+
+type ForkStreamOption func(options *ForkStreamOptions)
+
+// WithPreservedEventIDs indicates that the events of the fork should keep the IDs of the source events.
+// Note that event stores enforcing unique event IDs will reject such forks.
+func WithPreservedEventIDs() ForkStreamOption {
+	return func(options *ForkStreamOptions) {
+		options.PreserveEventIDs = true
+	}
+}
+
+func BuildForkStreamOptions(opts []ForkStreamOption) ForkStreamOptions {
+	options := &ForkStreamOptions{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return *options
+}
+
+// ForkStream copies all the events of a source stream to a new destination stream.
+// The destination stream has its own versioning starting at the initial version.
+// Fails if the destination stream already exists.
+func ForkStream(ctx context.Context, es EventStore, srcStreamID, dstStreamID StreamID, opts ...ForkStreamOption) error {
+	options := BuildForkStreamOptions(opts)
+
+	exists, err := es.StreamExists(ctx, dstStreamID)
+	if err != nil {
+		return errors.Wrapf(err, "failed forking stream \"%s\" to \"%s\"", srcStreamID, dstStreamID)
+	}
+	if exists {
+		return errors.Errorf("failed forking stream \"%s\" to \"%s\": destination stream already exists", srcStreamID, dstStreamID)
+	}
+
+	slice, err := es.ReadFromStream(ctx, srcStreamID, FromStart(), InForwardDirection())
+	if err != nil {
+		return errors.Wrapf(err, "failed forking stream \"%s\" to \"%s\"", srcStreamID, dstStreamID)
+	}
+
+	descriptors := make([]EventDescriptor, 0, slice.Length())
+	for _, d := range slice.Descriptors {
+		id := NewEventID()
+		if options.PreserveEventIDs {
+			id = d.ID
+		}
+
+		payload := DescriptorPayload{}
+		for k, v := range d.Payload {
+			payload[k] = v
+		}
+
+		descriptors = append(descriptors, EventDescriptor{
+			ID:       id,
+			TypeName: d.TypeName,
+			Payload:  payload,
+			Metadata: misas.Metadata{}.Merge(d.Metadata, true),
+		})
+	}
+
+	if err := es.AppendToStream(ctx, dstStreamID, descriptors, WithExpectedVersion(InitialVersion)); err != nil {
+		return errors.Wrapf(err, "failed forking stream \"%s\" to \"%s\"", srcStreamID, dstStreamID)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestForkStream(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []ForkStreamOption
+		wantPreservedID bool
+	}{
+		{
+			name:            "fork with new event IDs",
+			opts:            nil,
+			wantPreservedID: false,
+		},
+		{
+			name:            "fork with preserved event IDs",
+			opts:            []ForkStreamOption{WithPreservedEventIDs()},
+			wantPreservedID: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			es := NewInMemoryEventStore(clock.UTCClock{})
+			err := es.AppendToStream(ctx, "source", []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.started", Payload: DescriptorPayload{"n": 1}},
+				{ID: "evt-2", TypeName: "unit_test.ran", Payload: DescriptorPayload{"n": 2}},
+				{ID: "evt-3", TypeName: "unit_test.finished", Payload: DescriptorPayload{"n": 3}},
+			})
+			assert.NoError(t, err)
+
+			err = ForkStream(ctx, es, "source", "fork", tt.opts...)
+			assert.NoError(t, err)
+
+			source, err := es.ReadFromStream(ctx, "source", FromStart())
+			assert.NoError(t, err)
+			fork, err := es.ReadFromStream(ctx, "fork", FromStart())
+			assert.NoError(t, err)
+
+			assert.Equal(t, 3, fork.Length())
+			for i, d := range fork.Descriptors {
+				src := source.Descriptors[i]
+				assert.Equal(t, StreamID("fork"), d.StreamID)
+				assert.Equal(t, src.TypeName, d.TypeName)
+				assert.Equal(t, src.Payload, d.Payload)
+				assert.Equal(t, StreamVersion(i), d.Version)
+				assert.Equal(t, tt.wantPreservedID, src.ID == d.ID)
+			}
+
+			// Both streams should be versioned independently.
+			err = es.AppendToStream(ctx, "fork", []EventDescriptor{{ID: "evt-4", TypeName: "unit_test.ran"}}, WithExpectedVersion(2))
+			assert.NoError(t, err)
+			err = es.AppendToStream(ctx, "source", []EventDescriptor{{ID: "evt-5", TypeName: "unit_test.ran"}}, WithExpectedVersion(2))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestForkStream_DestinationExists(t *testing.T) {
+	ctx := context.Background()
+	es := NewInMemoryEventStore(clock.UTCClock{})
+	assert.NoError(t, es.AppendToStream(ctx, "source", []EventDescriptor{{ID: "evt-1", TypeName: "unit_test.ran"}}))
+	assert.NoError(t, es.AppendToStream(ctx, "fork", []EventDescriptor{{ID: "evt-2", TypeName: "unit_test.ran"}}))
+
+	assert.Error(t, ForkStream(ctx, es, "source", "fork"))
+}
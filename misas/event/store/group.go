@@ -0,0 +1,38 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/pkg/errors"
+)
+
+// ReadGroupedByType reads all the events of a stream and groups them by type name, which is mostly useful for
+// diagnostics. Within a group, the events keep the order in which they were read from the stream.
+// If the stream does not exist, an error is returned.
+func ReadGroupedByType(ctx context.Context, es EventStore, streamID StreamID) (map[event.PayloadTypeName][]RecordedEventDescriptor, error) {
+	slice, err := es.ReadFromStream(ctx, streamID, FromStart(), InForwardDirection())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading stream \"%s\" grouped by type", streamID)
+	}
+
+	groups := map[event.PayloadTypeName][]RecordedEventDescriptor{}
+	for _, d := range slice.Descriptors {
+		groups[d.TypeName] = append(groups[d.TypeName], d)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReadGroupedByType(t *testing.T) {
+	ctx := context.Background()
+	es := NewInMemoryEventStore(clock.UTCClock{})
+	err := es.AppendToStream(ctx, "unit_test", []EventDescriptor{
+		{ID: "evt-1", TypeName: "unit_test.started"},
+		{ID: "evt-2", TypeName: "unit_test.passed"},
+		{ID: "evt-3", TypeName: "unit_test.failed"},
+		{ID: "evt-4", TypeName: "unit_test.passed"},
+		{ID: "evt-5", TypeName: "unit_test.passed"},
+	})
+	assert.NoError(t, err)
+	err = es.AppendToStream(ctx, "other_unit_test", []EventDescriptor{
+		{ID: "evt-6", TypeName: "unit_test.passed"},
+	})
+	assert.NoError(t, err)
+
+	groups, err := ReadGroupedByType(ctx, es, "unit_test")
+	assert.NoError(t, err)
+
+	ids := map[event.PayloadTypeName][]EventID{}
+	for typeName, descriptors := range groups {
+		for _, d := range descriptors {
+			assert.Equal(t, typeName, d.TypeName)
+			ids[typeName] = append(ids[typeName], d.ID)
+		}
+	}
+	assert.Equal(t, map[event.PayloadTypeName][]EventID{
+		"unit_test.started": {"evt-1"},
+		"unit_test.passed":  {"evt-2", "evt-4", "evt-5"},
+		"unit_test.failed":  {"evt-3"},
+	}, ids)
+	assert.Len(t, groups["unit_test.passed"], 3)
+
+	t.Run("global stream", func(t *testing.T) {
+		groups, err := ReadGroupedByType(ctx, es, es.GlobalStreamID())
+		assert.NoError(t, err)
+		assert.Len(t, groups["unit_test.passed"], 4)
+	})
+
+	t.Run("stream does not exist", func(t *testing.T) {
+		groups, err := ReadGroupedByType(ctx, es, "not_found")
+		assert.True(t, IsStreamNotFoundError(errors.Cause(err)))
+		assert.Nil(t, groups)
+	})
+}
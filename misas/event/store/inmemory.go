@@ -17,24 +17,63 @@ package store
 import (
 	"context"
 	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/event"
 	"github.com/pkg/errors"
+	"sort"
+	"sync"
 )
 
+// InMemoryEventStore is an implementation of an EventStore keeping the events in memory, mostly useful in tests.
+// It is safe for concurrent use, appends being serialized so that the versions of a stream remain sequential.
 type InMemoryEventStore struct {
 	Clock             clock.Clock
 	events            []RecordedEventDescriptor
 	eventIds          map[EventID]struct{}
 	streamVersionByID map[StreamID]StreamVersion
-	subscriptions     []Subscription
+	mu                sync.RWMutex
+
+	subscriptions     []inMemorySubscription
+	subscriptionsLock sync.Mutex
+	options           InMemoryEventStoreOptions
+}
+
+// inMemorySubscription keeps track of the close channel of a Subscription so the store can stop notifying it once closed.
+type inMemorySubscription struct {
+	Subscription
+	closed <-chan bool
+}
+
+// InMemoryEventStoreOptions represents the options of an InMemoryEventStore.
+type InMemoryEventStoreOptions struct {
+	// BlockingNotify indicates that appends should wait until all subscribers have received the appended events.
+	BlockingNotify bool
+}
+
+type InMemoryEventStoreOption func(options *InMemoryEventStoreOptions)
+
+// WithBlockingNotify makes appends to an InMemoryEventStore wait until every subscriber has received the appended events,
+// or until the context of the append is done. This can be used in scenarios where no event notification can be dropped.
+// Since the events are already persisted when subscribers are notified, an append whose context is done while waiting
+// stops notifying the remaining subscribers and still succeeds.
+func WithBlockingNotify() InMemoryEventStoreOption {
+	return func(options *InMemoryEventStoreOptions) {
+		options.BlockingNotify = true
+	}
 }
 
-func NewInMemoryEventStore(clock clock.Clock) *InMemoryEventStore {
+func NewInMemoryEventStore(clock clock.Clock, opts ...InMemoryEventStoreOption) *InMemoryEventStore {
+	options := InMemoryEventStoreOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return &InMemoryEventStore{
 		Clock:             clock,
 		events:            []RecordedEventDescriptor{},
 		eventIds:          map[EventID]struct{}{},
 		streamVersionByID: map[StreamID]StreamVersion{},
-		subscriptions:     []Subscription{},
+		subscriptions:     []inMemorySubscription{},
+		options:           options,
 	}
 }
 
@@ -42,10 +81,12 @@ func (es *InMemoryEventStore) SubscribeToStream(ctx context.Context, streamID St
 
 	options := BuildSubscribeToStreamOptions(opts)
 	errorChannel := make(chan error)
-	eventChannel := make(chan RecordedEventDescriptor)
+	eventChannel := make(chan RecordedEventDescriptor, options.BufferSize)
 	closeChannel := make(chan bool, 1)
 	subscription := *NewSubscription(eventChannel, errorChannel, closeChannel, streamID, options)
-	es.subscriptions = append(es.subscriptions, subscription)
+	es.subscriptionsLock.Lock()
+	es.subscriptions = append(es.subscriptions, inMemorySubscription{Subscription: subscription, closed: closeChannel})
+	es.subscriptionsLock.Unlock()
 
 	go func() {
 		var filterOptions []TypeNameFilterOption
@@ -82,6 +123,40 @@ func (es *InMemoryEventStore) AppendToStream(ctx context.Context, streamID Strea
 		return errors.New("cannot append to virtual stream")
 	}
 
+	if err := CheckEventIDsUnique(streamID, descriptors); err != nil {
+		return err
+	}
+
+	recordedEvents, err := es.record(streamID, descriptors, options)
+	if err != nil {
+		return err
+	}
+
+	// Notify subscribers
+	if es.options.BlockingNotify {
+		es.notifySubscribers(ctx, recordedEvents)
+		return nil
+	}
+
+	go func() {
+		for _, d := range recordedEvents {
+			for _, sub := range es.subscriptionsSnapshot() {
+				if sub.streamID == es.GlobalStreamID() || sub.streamID == d.StreamID {
+					sub.EmitEvent(d)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// record appends descriptors to a stream, the version of the stream being computed and checked while holding the lock
+// of the store, so that concurrent appends are serialized.
+func (es *InMemoryEventStore) record(streamID StreamID, descriptors []EventDescriptor, options AppendToStreamOptions) ([]RecordedEventDescriptor, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
 	lastSeqNo := SequenceNumber(len(es.events) - 1)
 	nextSeqNo := lastSeqNo
 
@@ -93,14 +168,14 @@ func (es *InMemoryEventStore) AppendToStream(ctx context.Context, streamID Strea
 	// Check concurrency
 	if options.ExpectedVersion != nil {
 		if streamVersion != *options.ExpectedVersion {
-			return NewConcurrencyError(streamID, *options.ExpectedVersion, streamVersion)
+			return nil, NewConcurrencyError(streamID, *options.ExpectedVersion, streamVersion)
 		}
 	}
 
 	var recordedEvents []RecordedEventDescriptor
 	for _, d := range descriptors {
 		if _, found := es.eventIds[d.ID]; found {
-			return errors.Errorf("duplicate event id encountered with \"%s\"", d.ID)
+			return nil, errors.Errorf("duplicate event id encountered with \"%s\"", d.ID)
 		}
 
 		streamVersion++
@@ -122,18 +197,51 @@ func (es *InMemoryEventStore) AppendToStream(ctx context.Context, streamID Strea
 
 	es.streamVersionByID[streamID] = streamVersion
 
-	// Notify subscribers
-	go func() {
-		for _, d := range recordedEvents {
-			for _, sub := range es.subscriptions {
-				if sub.streamID == es.GlobalStreamID() || sub.streamID == d.StreamID {
-					sub.EmitEvent(d)
-				}
-			}
+	return recordedEvents, nil
+}
+
+// subscriptionsSnapshot returns a copy of the current subscriptions, so they can be notified without holding their lock.
+func (es *InMemoryEventStore) subscriptionsSnapshot() []inMemorySubscription {
+	es.subscriptionsLock.Lock()
+	defer es.subscriptionsLock.Unlock()
+
+	return append([]inMemorySubscription(nil), es.subscriptions...)
+}
+
+// removeSubscription removes the subscription having a given close channel.
+func (es *InMemoryEventStore) removeSubscription(closed <-chan bool) {
+	es.subscriptionsLock.Lock()
+	defer es.subscriptionsLock.Unlock()
+
+	var subscriptions []inMemorySubscription
+	for _, sub := range es.subscriptions {
+		if sub.closed != closed {
+			subscriptions = append(subscriptions, sub)
 		}
-	}()
+	}
+	es.subscriptions = subscriptions
+}
 
-	return nil
+// notifySubscribers sends events to the subscribers of their stream, waiting for each subscriber to receive them.
+// Subscriptions that were closed are removed from the store.
+// If ctx is done while waiting, the remaining notifications are dropped rather than reported as an error,
+// as the events were already appended and failing would make callers believe otherwise.
+func (es *InMemoryEventStore) notifySubscribers(ctx context.Context, descriptors []RecordedEventDescriptor) {
+	for _, d := range descriptors {
+		for _, sub := range es.subscriptionsSnapshot() {
+			if sub.streamID != es.GlobalStreamID() && sub.streamID != d.StreamID {
+				continue
+			}
+
+			select {
+			case sub.eventChannel <- d:
+			case <-sub.closed:
+				es.removeSubscription(sub.closed)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
 }
 
 func (es *InMemoryEventStore) ReadFromStream(ctx context.Context, streamID StreamID, opts ...ReadFromStreamOption) (StreamSlice, error) {
@@ -141,15 +249,11 @@ func (es *InMemoryEventStore) ReadFromStream(ctx context.Context, streamID Strea
 	options := BuildReadFromStreamOptions(opts)
 	isGlobalStream := streamID == es.GlobalStreamID()
 
-	if !isGlobalStream {
-		streamExists, err := es.StreamExists(ctx, streamID)
-		if err != nil {
-			return StreamSlice{}, err
-		}
+	es.mu.RLock()
+	defer es.mu.RUnlock()
 
-		if !streamExists {
-			return StreamSlice{}, NewStreamNotFoundError(streamID)
-		}
+	if !isGlobalStream && !es.streamExists(streamID) {
+		return StreamSlice{}, NewStreamNotFoundError(streamID)
 	}
 
 	eventsOfStream := es.events
@@ -160,6 +264,15 @@ func (es *InMemoryEventStore) ReadFromStream(ctx context.Context, streamID Strea
 		}.Select(func(descriptor RecordedEventDescriptor) bool {
 			return streamID == descriptor.StreamID
 		})
+
+		// The global stream is in sequence number order by construction, the events of a stream are ordered by version,
+		// with the sequence number as the final tie-breaker so that the order is deterministic.
+		sort.SliceStable(eventsOfStream, func(i, j int) bool {
+			if eventsOfStream[i].Version != eventsOfStream[j].Version {
+				return eventsOfStream[i].Version < eventsOfStream[j].Version
+			}
+			return eventsOfStream[i].SequenceNumber < eventsOfStream[j].SequenceNumber
+		})
 	}
 
 	streamSlice := StreamSlice{
@@ -178,9 +291,9 @@ func (es *InMemoryEventStore) ReadFromStream(ctx context.Context, streamID Strea
 		Descriptors: streamSlice.Select(func(descriptor RecordedEventDescriptor) bool {
 			var eventPosition Position
 			if streamID == es.GlobalStreamID() {
-				eventPosition = Position(descriptor.SequenceNumber)
+				eventPosition = PositionFromSequenceNumber(descriptor.SequenceNumber)
 			} else {
-				eventPosition = Position(descriptor.Version)
+				eventPosition = PositionFromVersion(descriptor.Version)
 			}
 
 			if options.Direction == Backward {
@@ -196,38 +309,54 @@ func (es *InMemoryEventStore) ReadFromStream(ctx context.Context, streamID Strea
 		streamSlice = StreamSlice{
 			StreamID: streamID,
 			Descriptors: streamSlice.Select(func(descriptor RecordedEventDescriptor) bool {
-				matchesFilter := false
-				for _, tn := range options.EventTypeNameFilter.EventTypeNames {
-					if tn == descriptor.TypeName {
-						matchesFilter = true
-						break
-					}
-				}
-
-				if options.EventTypeNameFilter.Mode == Exclude {
-					return !matchesFilter
-				}
+				return options.EventTypeNameFilter.Matches(descriptor.TypeName)
+			}),
+		}
+	}
 
-				return matchesFilter
+	// Recorded after
+	if options.RecordedAfter != nil {
+		streamSlice = StreamSlice{
+			StreamID: streamID,
+			Descriptors: streamSlice.Select(func(descriptor RecordedEventDescriptor) bool {
+				return descriptor.RecordedAt.After(*options.RecordedAfter)
 			}),
 		}
 	}
 
+	// Max count, applied once filtered like a LIMIT clause would be.
+	if options.MaxCount > 0 && len(streamSlice.Descriptors) > options.MaxCount {
+		streamSlice.Descriptors = streamSlice.Descriptors[:options.MaxCount]
+	}
+
+	// Payload projection, the recorded payloads are left untouched.
+	if options.WithoutPayloads || len(options.PayloadProjection) != 0 {
+		projected := make([]RecordedEventDescriptor, 0, len(streamSlice.Descriptors))
+		for _, descriptor := range streamSlice.Descriptors {
+			if options.WithoutPayloads {
+				descriptor.Payload = nil
+			} else {
+				descriptor.Payload = descriptor.Payload.Project(options.PayloadProjection...)
+			}
+			projected = append(projected, descriptor)
+		}
+		streamSlice.Descriptors = projected
+	}
+
 	return streamSlice, nil
 }
 
 func (es *InMemoryEventStore) TruncateStream(ctx context.Context, streamID StreamID, opts ...TruncateStreamOption) error {
 	options := BuildTruncateFromStreamOptions(opts)
 
-	streamExists, err := es.StreamExists(ctx, streamID)
-	if err != nil {
-		return err
-	}
+	es.mu.Lock()
+	defer es.mu.Unlock()
 
-	if !streamExists {
+	if !es.streamExists(streamID) {
 		return NewStreamNotFoundError(streamID)
 	}
 
+	cutoff := options.CutoffPosition(es.streamVersionByID[streamID])
 	es.events = StreamSlice{
 		StreamID:    es.GlobalStreamID(),
 		Descriptors: es.events,
@@ -235,19 +364,38 @@ func (es *InMemoryEventStore) TruncateStream(ctx context.Context, streamID Strea
 		if descriptor.StreamID != streamID {
 			return true
 		}
-		return Position(descriptor.Version) >= options.BeforePosition
+		return PositionFromVersion(descriptor.Version) >= cutoff
 	})
 
 	return nil
 }
 
-func (es *InMemoryEventStore) DeleteStream(ctx context.Context, id StreamID) error {
-	exists, err := es.StreamExists(ctx, id)
-	if err != nil {
-		return err
+func (es *InMemoryEventStore) RenameEventType(ctx context.Context, streamID StreamID, from, to event.PayloadTypeName) (int, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	isGlobalStream := streamID == es.GlobalStreamID()
+	if !isGlobalStream && !es.streamExists(streamID) {
+		return 0, NewStreamNotFoundError(streamID)
+	}
+
+	renamed := 0
+	for i, descriptor := range es.events {
+		if descriptor.TypeName != from || (!isGlobalStream && descriptor.StreamID != streamID) {
+			continue
+		}
+		es.events[i].TypeName = to
+		renamed++
 	}
 
-	if !exists {
+	return renamed, nil
+}
+
+func (es *InMemoryEventStore) DeleteStream(ctx context.Context, id StreamID) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	if !es.streamExists(id) {
 		return nil
 	}
 
@@ -264,6 +412,9 @@ func (es *InMemoryEventStore) DeleteStream(ctx context.Context, id StreamID) err
 }
 
 func (es *InMemoryEventStore) Clear(ctx context.Context) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
 	es.events = []RecordedEventDescriptor{}
 	es.eventIds = map[EventID]struct{}{}
 	es.streamVersionByID = map[StreamID]StreamVersion{}
@@ -272,11 +423,39 @@ func (es *InMemoryEventStore) Clear(ctx context.Context) error {
 }
 
 func (es *InMemoryEventStore) StreamExists(ctx context.Context, id StreamID) (bool, error) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	return es.streamExists(id), nil
+}
+
+// streamExists indicates if a stream exists, the caller must hold the lock of the store.
+func (es *InMemoryEventStore) streamExists(id StreamID) bool {
 	_, found := es.streamVersionByID[id]
-	return found, nil
+	return found
+}
+
+func (es *InMemoryEventStore) CountEvents(ctx context.Context, streamID StreamID) (int64, error) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	if streamID == es.GlobalStreamID() {
+		return int64(len(es.events)), nil
+	}
+
+	var count int64
+	for _, d := range es.events {
+		if d.StreamID == streamID {
+			count++
+		}
+	}
+
+	return count, nil
 }
 
 func (es *InMemoryEventStore) GetStream(ctx context.Context, id StreamID) (Stream, error) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
 
 	min := StreamVersion(Start)
 	max := min
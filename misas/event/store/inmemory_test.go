@@ -16,12 +16,15 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/clock"
 	"github.com/morebec/misas-go/misas/event"
 	"github.com/stretchr/testify/assert"
+	"sync"
 	"testing"
+	"time"
 )
 
 const InMemoryUnitTestPassedEventTypeName event.PayloadTypeName = "unit_test.passed"
@@ -63,6 +66,49 @@ func TestInMemoryEventStore_AppendToStream(t *testing.T) {
 	assert.Equal(t, misas.Metadata{"hello": "world"}, events.First().Metadata)
 }
 
+func TestInMemoryEventStore_AppendToStream_ConcurrencyError(t *testing.T) {
+	store := NewInMemoryEventStore(clock.UTCClock{})
+
+	streamID := StreamID("unit_test")
+	err := store.AppendToStream(context.Background(), streamID, []EventDescriptor{
+		{ID: "event#1", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{}},
+	}, WithExpectedVersion(InitialVersion))
+	assert.NoError(t, err)
+
+	err = store.AppendToStream(context.Background(), streamID, []EventDescriptor{
+		{ID: "event#2", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{}},
+	}, WithExpectedVersion(InitialVersion))
+	assert.True(t, IsConcurrencyError(err))
+	assert.Equal(t, NewConcurrencyError(streamID, InitialVersion, 0), err)
+
+	// The stream should not have been modified.
+	events, err := store.ReadFromStream(context.Background(), streamID, FromStart())
+	assert.NoError(t, err)
+	assert.Len(t, events.Descriptors, 1)
+}
+
+func TestInMemoryEventStore_AppendToStream_DuplicateEventID(t *testing.T) {
+	store := NewInMemoryEventStore(clock.UTCClock{})
+
+	streamID := StreamID("unit_test")
+	err := store.AppendToStream(context.Background(), streamID, []EventDescriptor{
+		{ID: "event#1", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{}},
+		{ID: "event#2", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{}},
+		{ID: "event#1", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{}},
+	})
+	assert.True(t, IsDuplicateEventIDError(err))
+	assert.Equal(t, NewDuplicateEventIDError(streamID, "event#1"), err)
+
+	// Nothing should have been written.
+	exists, err := store.StreamExists(context.Background(), streamID)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	events, err := store.ReadFromStream(context.Background(), store.GlobalStreamID(), FromStart())
+	assert.NoError(t, err)
+	assert.Empty(t, events.Descriptors)
+}
+
 func TestInMemoryEventStore_ReadFromStream(t *testing.T) {
 	store := NewInMemoryEventStore(clock.UTCClock{})
 
@@ -314,3 +360,391 @@ func TestInMemoryEventStore_TruncateStream(t *testing.T) {
 	events, err := store.ReadFromStream(context.Background(), streamID, FromStart(), InForwardDirection())
 	assert.Len(t, events.Descriptors, 2)
 }
+
+func TestInMemoryEventStore_AppendToStream_WithBlockingNotify(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{}, WithBlockingNotify())
+
+	subscription, err := es.SubscribeToStream(context.Background(), es.GlobalStreamID())
+	assert.NoError(t, err)
+
+	appended := make(chan error, 1)
+	go func() {
+		appended <- es.AppendToStream(context.Background(), "unit-test", []EventDescriptor{
+			{ID: "event-1", TypeName: InMemoryUnitTestPassedEventTypeName},
+		})
+	}()
+
+	// The append should block as long as the subscriber does not receive the event.
+	select {
+	case <-appended:
+		t.Fatal("append should block until the subscriber receives the event")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	d := <-subscription.EventChannel()
+	assert.Equal(t, EventID("event-1"), d.ID)
+
+	select {
+	case err := <-appended:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("append should proceed once the subscriber received the event")
+	}
+}
+
+func TestInMemoryEventStore_AppendToStream_WithBlockingNotifyCancelled(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{}, WithBlockingNotify())
+
+	_, err := es.SubscribeToStream(context.Background(), es.GlobalStreamID())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = es.AppendToStream(ctx, "unit-test", []EventDescriptor{
+		{ID: "event-1", TypeName: InMemoryUnitTestPassedEventTypeName},
+	})
+	// The event was appended, only its notification was dropped.
+	assert.NoError(t, err)
+
+	stream, err := es.ReadFromStream(context.Background(), "unit-test", FromStart())
+	assert.NoError(t, err)
+	if assert.Len(t, stream.Descriptors, 1) {
+		assert.Equal(t, EventID("event-1"), stream.Descriptors[0].ID)
+	}
+}
+
+func TestInMemoryEventStore_AppendToStream_WithBlockingNotifyClosedSubscription(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{}, WithBlockingNotify())
+
+	subscription, err := es.SubscribeToStream(context.Background(), es.GlobalStreamID())
+	assert.NoError(t, err)
+	assert.NoError(t, subscription.Close())
+
+	err = es.AppendToStream(context.Background(), "unit-test", []EventDescriptor{
+		{ID: "event-1", TypeName: InMemoryUnitTestPassedEventTypeName},
+	})
+	assert.NoError(t, err)
+}
+
+func TestInMemoryEventStore_SubscribeToStream_WithSubscriptionBufferSize(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{}, WithBlockingNotify())
+
+	subscription, err := es.SubscribeToStream(context.Background(), es.GlobalStreamID(), WithSubscriptionBufferSize(3))
+	assert.NoError(t, err)
+
+	// Appends should not block while the buffer of the subscription has room, even if the consumer does not read.
+	for i := 1; i <= 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := es.AppendToStream(ctx, "unit-test", []EventDescriptor{
+			{ID: EventID(fmt.Sprintf("event-%d", i)), TypeName: InMemoryUnitTestPassedEventTypeName},
+		})
+		cancel()
+		assert.NoError(t, err)
+	}
+
+	// Once the buffer is full, the append should block rather than drop the event.
+	appended := make(chan error, 1)
+	go func() {
+		appended <- es.AppendToStream(context.Background(), "unit-test", []EventDescriptor{
+			{ID: "event-4", TypeName: InMemoryUnitTestPassedEventTypeName},
+		})
+	}()
+
+	select {
+	case <-appended:
+		t.Fatal("append should block until the buffer of the subscriber has room")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// No event should have been lost.
+	for i := 1; i <= 4; i++ {
+		select {
+		case d := <-subscription.EventChannel():
+			assert.Equal(t, EventID(fmt.Sprintf("event-%d", i)), d.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("event-%d was not received", i)
+		}
+	}
+
+	select {
+	case err := <-appended:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("append should proceed once the subscriber received the buffered events")
+	}
+}
+
+func TestWithSubscriptionBufferSize(t *testing.T) {
+	assert.Equal(t, 0, BuildSubscribeToStreamOptions(nil).BufferSize)
+	assert.Equal(t, 10, BuildSubscribeToStreamOptions([]SubscribeToStreamOption{WithSubscriptionBufferSize(10)}).BufferSize)
+	assert.Equal(t, 0, BuildSubscribeToStreamOptions([]SubscribeToStreamOption{WithSubscriptionBufferSize(-1)}).BufferSize)
+}
+
+func TestWithSubscriptionFilter(t *testing.T) {
+	assert.Nil(t, BuildSubscribeToStreamOptions([]SubscribeToStreamOption{WithSubscriptionFilter()}).EventTypeNameFilter)
+
+	options := BuildSubscribeToStreamOptions([]SubscribeToStreamOption{
+		WithSubscriptionFilter(SelectEventTypeNames(InMemoryUnitTestPassedEventTypeName)),
+	})
+	assert.Equal(t, &TypeNameFilter{
+		Mode:           Select,
+		EventTypeNames: []event.PayloadTypeName{InMemoryUnitTestPassedEventTypeName},
+	}, options.EventTypeNameFilter)
+}
+
+func TestInMemoryEventStore_ReadFromStream_FromEnd(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{})
+
+	streamID := StreamID("unit_test")
+	err := es.AppendToStream(context.Background(), streamID, []EventDescriptor{
+		{ID: "event#1", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#2", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#3", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	for _, id := range []StreamID{streamID, es.GlobalStreamID()} {
+		// Reading forward from the end should yield nothing.
+		events, err := es.ReadFromStream(context.Background(), id, FromEnd(), InForwardDirection())
+		assert.NoError(t, err)
+		assert.Empty(t, events.Descriptors, "stream %s", id)
+
+		// Reading backward from the end should yield all the events, latest first.
+		events, err = es.ReadFromStream(context.Background(), id, FromEnd(), InBackwardDirection())
+		assert.NoError(t, err)
+		if assert.Len(t, events.Descriptors, 3, "stream %s", id) {
+			assert.Equal(t, EventID("event#3"), events.First().ID)
+			assert.Equal(t, EventID("event#1"), events.Last().ID)
+		}
+
+		// Reading the last event should only yield the latest event.
+		events, err = es.ReadFromStream(context.Background(), id, LastEvent())
+		assert.NoError(t, err)
+		if assert.Len(t, events.Descriptors, 1, "stream %s", id) {
+			assert.Equal(t, EventID("event#3"), events.First().ID)
+		}
+	}
+}
+
+func TestInMemoryEventStore_ReadFromStream_RecordedAfter(t *testing.T) {
+	before := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	after := before.Add(time.Hour)
+	c := clock.NewFixedClock(before)
+	es := NewInMemoryEventStore(c)
+
+	streamID := StreamID("unit_test")
+	err := es.AppendToStream(context.Background(), streamID, []EventDescriptor{
+		{ID: "event#1", TypeName: InMemoryUnitTestPassedEventTypeName},
+	})
+	assert.NoError(t, err)
+
+	c.Set(after)
+	err = es.AppendToStream(context.Background(), streamID, []EventDescriptor{
+		{ID: "event#2", TypeName: InMemoryUnitTestPassedEventTypeName},
+		{ID: "event#3", TypeName: InMemoryUnitTestPassedEventTypeName},
+	})
+	assert.NoError(t, err)
+
+	events, err := es.ReadFromStream(context.Background(), streamID, FromStart(), RecordedAfter(before))
+	assert.NoError(t, err)
+	assert.Equal(t, []EventID{"event#2", "event#3"}, eventIDs(events))
+
+	// Should compose with the position and direction.
+	events, err = es.ReadFromStream(context.Background(), es.GlobalStreamID(), FromEnd(), InBackwardDirection(), RecordedAfter(before))
+	assert.NoError(t, err)
+	assert.Equal(t, []EventID{"event#3", "event#2"}, eventIDs(events))
+
+	events, err = es.ReadFromStream(context.Background(), streamID, From(1), RecordedAfter(before))
+	assert.NoError(t, err)
+	assert.Equal(t, []EventID{"event#3"}, eventIDs(events))
+
+	events, err = es.ReadFromStream(context.Background(), streamID, FromStart(), RecordedAfter(after))
+	assert.NoError(t, err)
+	assert.Empty(t, events.Descriptors)
+}
+
+// eventIDs returns the ids of the events of a StreamSlice.
+func eventIDs(s StreamSlice) []EventID {
+	var ids []EventID
+	for _, d := range s.Descriptors {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+func TestInMemoryEventStore_CountEvents(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{})
+	ctx := context.Background()
+
+	count, err := es.CountEvents(ctx, "empty")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	assert.NoError(t, es.AppendToStream(ctx, "single", []EventDescriptor{
+		{ID: NewEventID(), TypeName: InMemoryUnitTestPassedEventTypeName},
+	}))
+	count, err = es.CountEvents(ctx, "single")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, es.AppendToStream(ctx, "multi", []EventDescriptor{
+		{ID: NewEventID(), TypeName: InMemoryUnitTestPassedEventTypeName},
+		{ID: NewEventID(), TypeName: InMemoryUnitTestPassedEventTypeName},
+		{ID: NewEventID(), TypeName: InMemoryUnitTestPassedEventTypeName},
+	}))
+	count, err = es.CountEvents(ctx, "multi")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	count, err = es.CountEvents(ctx, es.GlobalStreamID())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestInMemoryEventStore_ReadFromStream_SameRecordedAtOrdering(t *testing.T) {
+	es := NewInMemoryEventStore(clock.NewFixedClock(time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)))
+	ctx := context.Background()
+
+	for _, batch := range []struct {
+		streamID StreamID
+		ids      []EventID
+	}{
+		{streamID: "account-1", ids: []EventID{"event#1", "event#2"}},
+		{streamID: "account-2", ids: []EventID{"event#3"}},
+		{streamID: "account-1", ids: []EventID{"event#4", "event#5"}},
+	} {
+		var descriptors []EventDescriptor
+		for _, id := range batch.ids {
+			descriptors = append(descriptors, EventDescriptor{ID: id, TypeName: InMemoryUnitTestPassedEventTypeName})
+		}
+		assert.NoError(t, es.AppendToStream(ctx, batch.streamID, descriptors))
+	}
+
+	slice, err := es.ReadFromStream(ctx, "account-1", FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, []EventID{"event#1", "event#2", "event#4", "event#5"}, eventIDs(slice))
+	assert.Equal(t, slice.First().RecordedAt, slice.Last().RecordedAt)
+
+	slice, err = es.ReadFromStream(ctx, "account-1", FromEnd(), InBackwardDirection())
+	assert.NoError(t, err)
+	assert.Equal(t, []EventID{"event#5", "event#4", "event#2", "event#1"}, eventIDs(slice))
+
+	slice, err = es.ReadFromStream(ctx, es.GlobalStreamID(), FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, []EventID{"event#1", "event#2", "event#3", "event#4", "event#5"}, eventIDs(slice))
+}
+
+func TestInMemoryEventStore_AppendToStream_Concurrently(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{})
+	ctx := context.Background()
+	streamID := StreamID("unit_test")
+
+	const nbGoroutines = 50
+	const nbEventsPerAppend = 2
+
+	var wg sync.WaitGroup
+	for i := 0; i < nbGoroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var descriptors []EventDescriptor
+			for j := 0; j < nbEventsPerAppend; j++ {
+				descriptors = append(descriptors, EventDescriptor{ID: NewEventID(), TypeName: InMemoryUnitTestPassedEventTypeName})
+			}
+			assert.NoError(t, es.AppendToStream(ctx, streamID, descriptors))
+			_, err := es.ReadFromStream(ctx, streamID, FromStart())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	slice, err := es.ReadFromStream(ctx, streamID, FromStart())
+	assert.NoError(t, err)
+	if assert.Len(t, slice.Descriptors, nbGoroutines*nbEventsPerAppend) {
+		for i, d := range slice.Descriptors {
+			assert.Equal(t, StreamVersion(i), d.Version)
+			assert.Equal(t, SequenceNumber(i), d.SequenceNumber)
+		}
+	}
+}
+
+func TestInMemoryEventStore_ReadFromStream_WithPayloadProjection(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{})
+
+	streamID := StreamID("unit_test")
+	err := es.AppendToStream(context.Background(), streamID, []EventDescriptor{
+		{
+			ID:       "event#1",
+			TypeName: InMemoryUnitTestPassedEventTypeName,
+			Payload:  DescriptorPayload{"testName": "TestInMemoryEventStore_ReadFromStream_WithPayloadProjection", "duration": 12, "output": "ok"},
+		},
+	})
+	assert.NoError(t, err)
+
+	events, err := es.ReadFromStream(context.Background(), streamID, FromStart(), WithPayloadProjection("testName", "duration", "unknown"))
+	assert.NoError(t, err)
+	assert.Len(t, events.Descriptors, 1)
+	assert.Equal(t, DescriptorPayload{"testName": "TestInMemoryEventStore_ReadFromStream_WithPayloadProjection", "duration": 12}, events.Descriptors[0].Payload)
+
+	// The recorded payloads should be left untouched.
+	events, err = es.ReadFromStream(context.Background(), streamID, FromStart())
+	assert.NoError(t, err)
+	assert.Len(t, events.Descriptors[0].Payload, 3)
+}
+
+func TestInMemoryEventStore_ReadFromStream_WithoutPayloads(t *testing.T) {
+	es := NewInMemoryEventStore(clock.UTCClock{})
+
+	streamID := StreamID("unit_test")
+	err := es.AppendToStream(context.Background(), streamID, []EventDescriptor{
+		{ID: "event#1", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{"testName": "WithoutPayloads"}},
+		{ID: "event#2", TypeName: InMemoryUnitTestPassedEventTypeName, Payload: DescriptorPayload{"testName": "WithoutPayloads"}},
+	})
+	assert.NoError(t, err)
+
+	events, err := es.ReadFromStream(context.Background(), streamID, FromStart(), WithoutPayloads(), WithPayloadProjection("testName"))
+	assert.NoError(t, err)
+	if assert.Len(t, events.Descriptors, 2) {
+		for i, d := range events.Descriptors {
+			assert.Nil(t, d.Payload)
+			assert.Equal(t, EventID(fmt.Sprintf("event#%d", i+1)), d.ID)
+			assert.Equal(t, InMemoryUnitTestPassedEventTypeName, d.TypeName)
+			assert.Equal(t, StreamVersion(i), d.Version)
+		}
+	}
+
+	// The recorded payloads should be left untouched.
+	events, err = es.ReadFromStream(context.Background(), streamID, FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, DescriptorPayload{"testName": "WithoutPayloads"}, events.Descriptors[0].Payload)
+}
+
+func TestInMemoryEventStore_TruncateStream_KeepLast(t *testing.T) {
+	store := NewInMemoryEventStore(clock.UTCClock{})
+
+	streamID := StreamID("unit_test")
+	var descriptors []EventDescriptor
+	for i := 0; i < 10; i++ {
+		descriptors = append(descriptors, EventDescriptor{ID: NewEventID(), TypeName: InMemoryUnitTestPassedEventTypeName})
+	}
+	assert.NoError(t, store.AppendToStream(context.Background(), streamID, descriptors))
+
+	err := store.TruncateStream(context.Background(), streamID, KeepLast(3))
+	assert.NoError(t, err)
+
+	events, err := store.ReadFromStream(context.Background(), streamID, FromStart(), InForwardDirection())
+	assert.NoError(t, err)
+	var versions []StreamVersion
+	for _, d := range events.Descriptors {
+		versions = append(versions, d.Version)
+	}
+	assert.Equal(t, []StreamVersion{7, 8, 9}, versions)
+
+	// Keeping more events than the stream contains should not truncate anything.
+	err = store.TruncateStream(context.Background(), streamID, KeepLast(5))
+	assert.NoError(t, err)
+	events, err = store.ReadFromStream(context.Background(), streamID, FromStart(), InForwardDirection())
+	assert.NoError(t, err)
+	assert.Len(t, events.Descriptors, 3)
+}
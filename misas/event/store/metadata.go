@@ -0,0 +1,105 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/morebec/misas-go/misas/event"
+)
+
+// MissingRequiredMetadataError error representing the fact that an event was missing a required metadata key.
+type MissingRequiredMetadataError struct {
+	EventID EventID
+	Key     string
+}
+
+func (m MissingRequiredMetadataError) Error() string {
+	return fmt.Sprintf("event \"%s\" is missing required metadata key \"%s\"", m.EventID, m.Key)
+}
+
+func NewMissingRequiredMetadataError(eventID EventID, key string) error {
+	return MissingRequiredMetadataError{EventID: eventID, Key: key}
+}
+
+// IsMissingRequiredMetadataError Indicates if a given error is a MissingRequiredMetadataError or not.
+func IsMissingRequiredMetadataError(err error) bool {
+	_, ok := err.(MissingRequiredMetadataError)
+	return ok
+}
+
+// RequiredMetadataEventStoreDecorator decorator around an event store that rejects appends where any event
+// is missing one of a set of required metadata keys.
+type RequiredMetadataEventStoreDecorator struct {
+	requiredKeys []string
+	inner        EventStore
+}
+
+// NewRequiredMetadataDecorator returns a new required metadata event store decorator.
+func NewRequiredMetadataDecorator(inner EventStore, requiredKeys ...string) *RequiredMetadataEventStoreDecorator {
+	return &RequiredMetadataEventStoreDecorator{inner: inner, requiredKeys: requiredKeys}
+}
+
+func (r RequiredMetadataEventStoreDecorator) GlobalStreamID() StreamID {
+	return r.inner.GlobalStreamID()
+}
+
+func (r RequiredMetadataEventStoreDecorator) AppendToStream(ctx context.Context, streamID StreamID, events []EventDescriptor, opts ...AppendToStreamOption) error {
+	for _, d := range events {
+		for _, k := range r.requiredKeys {
+			if !d.Metadata.Has(k) {
+				return NewMissingRequiredMetadataError(d.ID, k)
+			}
+		}
+	}
+
+	return r.inner.AppendToStream(ctx, streamID, events, opts...)
+}
+
+func (r RequiredMetadataEventStoreDecorator) ReadFromStream(ctx context.Context, streamID StreamID, opts ...ReadFromStreamOption) (StreamSlice, error) {
+	return r.inner.ReadFromStream(ctx, streamID, opts...)
+}
+
+func (r RequiredMetadataEventStoreDecorator) TruncateStream(ctx context.Context, streamID StreamID, opts ...TruncateStreamOption) error {
+	return r.inner.TruncateStream(ctx, streamID, opts...)
+}
+
+func (r RequiredMetadataEventStoreDecorator) DeleteStream(ctx context.Context, id StreamID) error {
+	return r.inner.DeleteStream(ctx, id)
+}
+
+func (r RequiredMetadataEventStoreDecorator) SubscribeToStream(ctx context.Context, streamID StreamID, opts ...SubscribeToStreamOption) (Subscription, error) {
+	return r.inner.SubscribeToStream(ctx, streamID, opts...)
+}
+
+func (r RequiredMetadataEventStoreDecorator) StreamExists(ctx context.Context, id StreamID) (bool, error) {
+	return r.inner.StreamExists(ctx, id)
+}
+
+func (r RequiredMetadataEventStoreDecorator) RenameEventType(ctx context.Context, streamID StreamID, from, to event.PayloadTypeName) (int, error) {
+	return RenameEventType(ctx, r.inner, streamID, from, to)
+}
+
+func (r RequiredMetadataEventStoreDecorator) GetStream(ctx context.Context, id StreamID) (Stream, error) {
+	return r.inner.GetStream(ctx, id)
+}
+
+func (r RequiredMetadataEventStoreDecorator) CountEvents(ctx context.Context, streamID StreamID) (int64, error) {
+	return r.inner.CountEvents(ctx, streamID)
+}
+
+func (r RequiredMetadataEventStoreDecorator) Clear(ctx context.Context) error {
+	return r.inner.Clear(ctx)
+}
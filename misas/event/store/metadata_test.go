@@ -0,0 +1,74 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRequiredMetadataEventStoreDecorator_AppendToStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		descriptors []EventDescriptor
+		wantErr     bool
+	}{
+		{
+			name: "events containing the required keys should be appended",
+			descriptors: []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.ran", Metadata: misas.Metadata{"tenantId": "t-1", "correlationId": "c-1"}},
+				{ID: "evt-2", TypeName: "unit_test.ran", Metadata: misas.Metadata{"tenantId": "t-1", "correlationId": "c-1", "other": true}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "an event missing a required key should reject the whole append",
+			descriptors: []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.ran", Metadata: misas.Metadata{"tenantId": "t-1", "correlationId": "c-1"}},
+				{ID: "evt-2", TypeName: "unit_test.ran", Metadata: misas.Metadata{"tenantId": "t-1"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "an event without metadata should be rejected",
+			descriptors: []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.ran"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			inner := NewInMemoryEventStore(clock.UTCClock{})
+			es := NewRequiredMetadataDecorator(inner, "tenantId", "correlationId")
+
+			err := es.AppendToStream(ctx, "unit-test", tt.descriptors)
+
+			exists, existsErr := inner.StreamExists(ctx, "unit-test")
+			assert.NoError(t, existsErr)
+			if tt.wantErr {
+				assert.True(t, IsMissingRequiredMetadataError(err))
+				assert.False(t, exists)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, exists)
+			}
+		})
+	}
+}
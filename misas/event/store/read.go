@@ -17,6 +17,7 @@ package store
 import (
 	"github.com/morebec/misas-go/misas/event"
 	"math"
+	"time"
 )
 
 // Position (See ReadFromStreamOptions) Represents a position in the event store depending on where it is used.
@@ -29,6 +30,48 @@ const (
 	End   = Position(math.MaxInt)
 )
 
+// PositionFromSequenceNumber returns the Position of an event in the global stream from its SequenceNumber.
+// Sequence numbers out of the range of positions are clamped to Start and End.
+func PositionFromSequenceNumber(n SequenceNumber) Position {
+	return positionFromInt64(int64(n))
+}
+
+// PositionFromVersion returns the Position of an event in its stream from its StreamVersion.
+// Versions out of the range of positions are clamped to Start and End.
+func PositionFromVersion(v StreamVersion) Position {
+	return positionFromInt64(int64(v))
+}
+
+func positionFromInt64(n int64) Position {
+	if n <= int64(Start) {
+		return Start
+	}
+	if uint64(n) >= uint64(End) {
+		return End
+	}
+	return Position(n)
+}
+
+// Next returns the position following this one. The position following End is End.
+func (p Position) Next() Position {
+	if p >= End {
+		return End
+	}
+	return p + 1
+}
+
+// Previous returns the position preceding this one, which is useful to read from a position while including the event at
+// this position. The position preceding Start is Start, and End has no preceding position as it is always after the last event.
+func (p Position) Previous() Position {
+	if p <= Start {
+		return Start
+	}
+	if p == End {
+		return End
+	}
+	return p - 1
+}
+
 // Direction in which the reading should be performed.
 type Direction string
 
@@ -50,12 +93,39 @@ type TypeNameFilter struct {
 	EventTypeNames []event.PayloadTypeName
 }
 
+// Matches indicates if an event type name passes this filter.
+func (f TypeNameFilter) Matches(name event.PayloadTypeName) bool {
+	matchesFilter := false
+	for _, tn := range f.EventTypeNames {
+		if tn == name {
+			matchesFilter = true
+			break
+		}
+	}
+
+	if f.Mode == Exclude {
+		return !matchesFilter
+	}
+
+	return matchesFilter
+}
+
 // ReadFromStreamOptions UpcastableEventPayload structure representing the options that can be used to read from a stream.
 type ReadFromStreamOptions struct {
 	Position            Position
 	MaxCount            int
 	Direction           Direction
 	EventTypeNameFilter *TypeNameFilter
+
+	// RecordedAfter when not nil, indicates that only the events recorded strictly after this time should be read.
+	RecordedAfter *time.Time
+
+	// PayloadProjection when not empty, indicates that only these top level fields of the payloads should be returned.
+	PayloadProjection []string
+
+	// WithoutPayloads indicates that the payloads of events should not be read, only their headers. It takes precedence
+	// over PayloadProjection.
+	WithoutPayloads bool
 }
 
 type ReadFromStreamOption func(ro *ReadFromStreamOptions)
@@ -100,6 +170,31 @@ func WithMaxCount(maxCount int) ReadFromStreamOption {
 		ro.MaxCount = maxCount
 	}
 }
+
+// RecordedAfter Allows specifying that only the events recorded strictly after a given time should be read.
+// It can be combined with a position and a direction.
+func RecordedAfter(t time.Time) ReadFromStreamOption {
+	return func(ro *ReadFromStreamOptions) {
+		ro.RecordedAfter = &t
+	}
+}
+
+// WithPayloadProjection Allows specifying that only some top level fields of the payloads of events should be returned,
+// for lightweight projections not requiring full payloads. Fields that are not part of a payload are absent from it.
+func WithPayloadProjection(fields ...string) ReadFromStreamOption {
+	return func(ro *ReadFromStreamOptions) {
+		ro.PayloadProjection = fields
+	}
+}
+
+// WithoutPayloads Allows specifying that only the headers of events, such as their IDs, type names and positions, should
+// be read, for fast scans not requiring payloads. The descriptors returned have nil payloads.
+func WithoutPayloads() ReadFromStreamOption {
+	return func(ro *ReadFromStreamOptions) {
+		ro.WithoutPayloads = true
+	}
+}
+
 func LastEvent() ReadFromStreamOption {
 	return func(ro *ReadFromStreamOptions) {
 		ro.Direction = Backward
@@ -0,0 +1,67 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestPosition_Next(t *testing.T) {
+	assert.Equal(t, Position(0), Start.Next())
+	assert.Equal(t, Position(6), Position(5).Next())
+	assert.Equal(t, End, (End - 1).Next())
+	assert.Equal(t, End, End.Next())
+}
+
+func TestPosition_Previous(t *testing.T) {
+	assert.Equal(t, Start, Start.Previous())
+	assert.Equal(t, Start, Position(0).Previous())
+	assert.Equal(t, Start, Position(-5).Previous())
+	assert.Equal(t, Position(4), Position(5).Previous())
+	assert.Equal(t, End, End.Previous())
+	assert.Equal(t, End-2, (End - 1).Previous())
+}
+
+func TestPositionFromSequenceNumber(t *testing.T) {
+	assert.Equal(t, Start, PositionFromSequenceNumber(-1))
+	assert.Equal(t, Start, PositionFromSequenceNumber(math.MinInt64))
+	assert.Equal(t, Position(0), PositionFromSequenceNumber(0))
+	assert.Equal(t, Position(42), PositionFromSequenceNumber(42))
+	assert.Equal(t, End, PositionFromSequenceNumber(math.MaxInt64))
+
+	// Reading from the previous position includes the event of the sequence number.
+	assert.Equal(t, Position(41), PositionFromSequenceNumber(42).Previous())
+	assert.Equal(t, Start, PositionFromSequenceNumber(0).Previous())
+}
+
+func TestPositionFromVersion(t *testing.T) {
+	assert.Equal(t, Start, PositionFromVersion(InitialVersion))
+	assert.Equal(t, Position(0), PositionFromVersion(0))
+	assert.Equal(t, Position(3), PositionFromVersion(3))
+	assert.Equal(t, End, PositionFromVersion(math.MaxInt64))
+}
+
+func TestTypeNameFilter_Matches(t *testing.T) {
+	selecting := TypeNameFilter{Mode: Select, EventTypeNames: []event.PayloadTypeName{"user.registered"}}
+	assert.True(t, selecting.Matches("user.registered"))
+	assert.False(t, selecting.Matches("user.deleted"))
+
+	excluding := TypeNameFilter{Mode: Exclude, EventTypeNames: []event.PayloadTypeName{"user.registered"}}
+	assert.False(t, excluding.Matches("user.registered"))
+	assert.True(t, excluding.Matches("user.deleted"))
+}
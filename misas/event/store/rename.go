@@ -0,0 +1,42 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/pkg/errors"
+)
+
+// EventTypeRenamer is implemented by the event stores able to rewrite the type names of recorded events in place.
+type EventTypeRenamer interface {
+	// RenameEventType renames the type of the events of a stream, or of all streams when it is the global stream, and
+	// returns the number of events renamed.
+	RenameEventType(ctx context.Context, streamID StreamID, from, to event.PayloadTypeName) (int, error)
+}
+
+// RenameEventType permanently renames the type of the events of a stream from one type name to another, and returns the
+// number of events renamed. Renaming the type of the events of the global stream renames them in all streams.
+// Unlike upcasting, the recorded events are rewritten, as a result, the event store must implement EventTypeRenamer.
+// The decorators of this package implement it by renaming the event types of the event store they decorate.
+func RenameEventType(ctx context.Context, es EventStore, streamID StreamID, from, to event.PayloadTypeName) (int, error) {
+	renamer, ok := es.(EventTypeRenamer)
+	if !ok {
+		return 0, errors.Errorf("failed renaming event type \"%s\" to \"%s\" in stream \"%s\", event store %T does not support renaming event types", from, to, streamID, es)
+	}
+
+	// The error is not wrapped, since decorators rename the event types of the event store they decorate using this function.
+	return renamer.RenameEventType(ctx, streamID, from, to)
+}
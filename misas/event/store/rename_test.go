@@ -0,0 +1,133 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRenameEventType(t *testing.T) {
+	tests := []struct {
+		name        string
+		streamID    StreamID
+		wantRenamed int
+		wantTypes   map[StreamID][]event.PayloadTypeName
+		wantErr     assert.ErrorAssertionFunc
+	}{
+		{
+			name:        "rename in a stream",
+			streamID:    "unit_test",
+			wantRenamed: 2,
+			wantTypes: map[StreamID][]event.PayloadTypeName{
+				"unit_test":       {"unit_test.started", "unit_test.succeeded", "unit_test.succeeded"},
+				"other_unit_test": {"unit_test.passed"},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:        "rename in all streams",
+			streamID:    "$all",
+			wantRenamed: 3,
+			wantTypes: map[StreamID][]event.PayloadTypeName{
+				"unit_test":       {"unit_test.started", "unit_test.succeeded", "unit_test.succeeded"},
+				"other_unit_test": {"unit_test.succeeded"},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:        "rename in a stream that does not exist",
+			streamID:    "not_found",
+			wantRenamed: 0,
+			wantTypes: map[StreamID][]event.PayloadTypeName{
+				"unit_test":       {"unit_test.started", "unit_test.passed", "unit_test.passed"},
+				"other_unit_test": {"unit_test.passed"},
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.True(t, IsStreamNotFoundError(errors.Cause(err)))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			es := NewInMemoryEventStore(clock.UTCClock{})
+			err := es.AppendToStream(ctx, "unit_test", []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.started"},
+				{ID: "evt-2", TypeName: "unit_test.passed"},
+				{ID: "evt-3", TypeName: "unit_test.passed"},
+			})
+			assert.NoError(t, err)
+			err = es.AppendToStream(ctx, "other_unit_test", []EventDescriptor{
+				{ID: "evt-4", TypeName: "unit_test.passed"},
+			})
+			assert.NoError(t, err)
+
+			renamed, err := RenameEventType(ctx, es, tt.streamID, "unit_test.passed", "unit_test.succeeded")
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.wantRenamed, renamed)
+
+			for streamID, wantTypes := range tt.wantTypes {
+				slice, err := es.ReadFromStream(ctx, streamID, FromStart())
+				assert.NoError(t, err)
+				var types []event.PayloadTypeName
+				for _, d := range slice.Descriptors {
+					types = append(types, d.TypeName)
+				}
+				assert.Equal(t, wantTypes, types)
+			}
+		})
+	}
+}
+
+func TestRenameEventType_Decorated(t *testing.T) {
+	ctx := context.Background()
+	primary := NewInMemoryEventStore(clock.UTCClock{})
+	secondary := NewInMemoryEventStore(clock.UTCClock{})
+	es := NewUpcastingEventStoreDecorator(
+		NewRequiredMetadataDecorator(
+			NewEnrichingDecorator(NewTeeDecorator(primary, secondary, nil)),
+		),
+		NewUpcasterChain(),
+	)
+	err := es.AppendToStream(ctx, "unit_test", []EventDescriptor{
+		{ID: "evt-1", TypeName: "unit_test.started"},
+		{ID: "evt-2", TypeName: "unit_test.passed"},
+	})
+	assert.NoError(t, err)
+
+	renamed, err := RenameEventType(ctx, es, "unit_test", "unit_test.passed", "unit_test.succeeded")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renamed)
+
+	for _, s := range []EventStore{primary, secondary} {
+		slice, err := s.ReadFromStream(ctx, "unit_test", FromStart())
+		assert.NoError(t, err)
+		assert.Equal(t, event.PayloadTypeName("unit_test.succeeded"), slice.Descriptors[1].TypeName)
+	}
+}
+
+func TestRenameEventType_Unsupported(t *testing.T) {
+	// Embedding the EventStore interface hides the RenameEventType method of the in memory event store.
+	es := struct{ EventStore }{NewInMemoryEventStore(clock.UTCClock{})}
+
+	renamed, err := RenameEventType(context.Background(), es, "unit_test", "unit_test.passed", "unit_test.succeeded")
+	assert.Error(t, err)
+	assert.Equal(t, 0, renamed)
+}
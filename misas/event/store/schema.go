@@ -0,0 +1,83 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/event"
+)
+
+// SchemaVersionMetadataKey is the metadata key under which the schema version of the payload of an event is stamped.
+const SchemaVersionMetadataKey = "schemaVersion"
+
+// SchemaVersion represents the version of the schema of the payload of an event type.
+type SchemaVersion int
+
+// InitialSchemaVersion is the schema version of event types that were never versioned, and of events appended without one.
+const InitialSchemaVersion SchemaVersion = 1
+
+// SchemaRegistry keeps track of the current schema version of event types, so that events can be stamped with the version
+// of the schema they were appended with and upcasters can tell which schema a recorded event follows.
+type SchemaRegistry struct {
+	versions map[event.PayloadTypeName]SchemaVersion
+}
+
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{versions: map[event.PayloadTypeName]SchemaVersion{}}
+}
+
+// Register registers the current schema version of an event type, replacing any previously registered version.
+func (r *SchemaRegistry) Register(name event.PayloadTypeName, version SchemaVersion) *SchemaRegistry {
+	r.versions[name] = version
+	return r
+}
+
+// CurrentVersion returns the current schema version of an event type, or InitialSchemaVersion if none was registered.
+func (r *SchemaRegistry) CurrentVersion(name event.PayloadTypeName) SchemaVersion {
+	if v, found := r.versions[name]; found {
+		return v
+	}
+	return InitialSchemaVersion
+}
+
+// SchemaVersionEnricher returns an EventDescriptorEnricher stamping the current schema version of the type of the
+// descriptors, according to a SchemaRegistry, on their metadata under SchemaVersionMetadataKey.
+// Versions already present in the metadata of a descriptor are left untouched.
+func SchemaVersionEnricher(registry *SchemaRegistry) EventDescriptorEnricher {
+	return func(ctx context.Context, d EventDescriptor) EventDescriptor {
+		if !d.Metadata.Has(SchemaVersionMetadataKey) {
+			d.Metadata = d.Metadata.Set(SchemaVersionMetadataKey, int(registry.CurrentVersion(d.TypeName)))
+		}
+		return d
+	}
+}
+
+// SchemaVersionFromMetadata returns the schema version stamped on the metadata of an event, or InitialSchemaVersion if
+// the event was appended without one. Versions decoded from JSON as floating point numbers are supported.
+func SchemaVersionFromMetadata(m misas.Metadata) SchemaVersion {
+	switch v := m.Get(SchemaVersionMetadataKey, nil).(type) {
+	case SchemaVersion:
+		return v
+	case int:
+		return SchemaVersion(v)
+	case int64:
+		return SchemaVersion(v)
+	case float64:
+		return SchemaVersion(v)
+	default:
+		return InitialSchemaVersion
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSchemaVersionEnricher(t *testing.T) {
+	registry := NewSchemaRegistry().
+		Register("user.registered", 3).
+		Register("user.renamed", 2)
+
+	inner := NewInMemoryEventStore(clock.UTCClock{})
+	es := NewEnrichingDecorator(inner, SchemaVersionEnricher(registry))
+
+	err := es.AppendToStream(context.Background(), "user-123", []EventDescriptor{
+		{ID: "event#1", TypeName: "user.registered", Metadata: misas.Metadata{}},
+		{ID: "event#2", TypeName: "user.renamed"},
+		{ID: "event#3", TypeName: "user.deleted"},
+		{ID: "event#4", TypeName: "user.registered", Metadata: misas.Metadata{SchemaVersionMetadataKey: 2}},
+	})
+	assert.NoError(t, err)
+
+	slice, err := inner.ReadFromStream(context.Background(), "user-123", FromStart())
+	assert.NoError(t, err)
+
+	var versions []SchemaVersion
+	for _, d := range slice.Descriptors {
+		versions = append(versions, SchemaVersionFromMetadata(d.Metadata))
+	}
+	// Unversioned event types are at their initial version, explicit versions are kept.
+	assert.Equal(t, []SchemaVersion{3, 2, InitialSchemaVersion, 2}, versions)
+	assert.Equal(t, 3, slice.First().Metadata.Get(SchemaVersionMetadataKey, nil))
+}
+
+func TestSchemaVersionFromMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata misas.Metadata
+		want     SchemaVersion
+	}{
+		{name: "missing", metadata: misas.Metadata{}, want: InitialSchemaVersion},
+		{name: "nil metadata", metadata: nil, want: InitialSchemaVersion},
+		{name: "int", metadata: misas.Metadata{SchemaVersionMetadataKey: 4}, want: 4},
+		{name: "decoded from JSON", metadata: misas.Metadata{SchemaVersionMetadataKey: float64(5)}, want: 5},
+		{name: "invalid", metadata: misas.Metadata{SchemaVersionMetadataKey: "v2"}, want: InitialSchemaVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SchemaVersionFromMetadata(tt.metadata))
+		})
+	}
+}
@@ -19,15 +19,24 @@ import "github.com/pkg/errors"
 // SubscribeToStreamOptions Represents the options
 type SubscribeToStreamOptions struct {
 	EventTypeNameFilter *TypeNameFilter
+
+	// BufferSize is the number of events the channel of the subscription can hold before its consumer receives them.
+	BufferSize int
 }
 
 type SubscribeToStreamOption func(options *SubscribeToStreamOptions)
 
+// WithSubscriptionFilter allows specifying that a subscription should only receive the events matching a filter on their
+// type names. Without options, the subscription receives all the events of its stream.
 func WithSubscriptionFilter(opts ...TypeNameFilterOption) SubscribeToStreamOption {
 	return func(o *SubscribeToStreamOptions) {
 		if len(opts) == 0 {
 			o.EventTypeNameFilter = nil
 		} else {
+			o.EventTypeNameFilter = &TypeNameFilter{
+				Mode:           Select,
+				EventTypeNames: nil,
+			}
 			for _, opt := range opts {
 				opt(o.EventTypeNameFilter)
 			}
@@ -35,6 +44,20 @@ func WithSubscriptionFilter(opts ...TypeNameFilterOption) SubscribeToStreamOptio
 	}
 }
 
+// WithSubscriptionBufferSize allows specifying the number of events that can be buffered in the channel of a subscription,
+// so that a consumer slower than the producer of events does not block it until the buffer is full.
+// Events are never dropped: once the buffer is full, the emission of events blocks until the consumer receives them,
+// which for stores notifying subscribers synchronously means blocking the appends or the notification listener.
+// By default, subscriptions are unbuffered. Negative sizes are treated as unbuffered.
+func WithSubscriptionBufferSize(n int) SubscribeToStreamOption {
+	return func(o *SubscribeToStreamOptions) {
+		if n < 0 {
+			n = 0
+		}
+		o.BufferSize = n
+	}
+}
+
 // Subscription allows listening to a specific Stream in order to receive notifications about new events being appended to some given streams.
 type Subscription struct {
 	eventChannel chan RecordedEventDescriptor
@@ -99,6 +122,7 @@ func (s Subscription) Close() error {
 func BuildSubscribeToStreamOptions(opts []SubscribeToStreamOption) SubscribeToStreamOptions {
 	options := &SubscribeToStreamOptions{
 		EventTypeNameFilter: nil,
+		BufferSize:          0,
 	}
 	for _, opt := range opts {
 		opt(options)
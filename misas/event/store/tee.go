@@ -0,0 +1,118 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/event"
+)
+
+// TeeEventStoreDecorator decorator around a primary event store that also writes to a secondary event store, for
+// instance to migrate from one backend to another while live. Appends, truncations, deletions, clears and renamings of
+// event types are performed on the primary store, then on the secondary one, failures of the secondary store being reported to a callback rather
+// than failing the operation. Reads and subscriptions are served by the primary store.
+type TeeEventStoreDecorator struct {
+	primary          EventStore
+	secondary        EventStore
+	onSecondaryError func(error)
+}
+
+// NewTeeDecorator returns a new tee event store decorator, onSecondaryError being called with the errors of the secondary store.
+func NewTeeDecorator(primary, secondary EventStore, onSecondaryError func(error)) *TeeEventStoreDecorator {
+	return &TeeEventStoreDecorator{primary: primary, secondary: secondary, onSecondaryError: onSecondaryError}
+}
+
+func (t TeeEventStoreDecorator) GlobalStreamID() StreamID {
+	return t.primary.GlobalStreamID()
+}
+
+func (t TeeEventStoreDecorator) AppendToStream(ctx context.Context, streamID StreamID, events []EventDescriptor, opts ...AppendToStreamOption) error {
+	if err := t.primary.AppendToStream(ctx, streamID, events, opts...); err != nil {
+		return err
+	}
+
+	t.reportSecondaryError(t.secondary.AppendToStream(ctx, streamID, events, opts...))
+
+	return nil
+}
+
+func (t TeeEventStoreDecorator) ReadFromStream(ctx context.Context, streamID StreamID, opts ...ReadFromStreamOption) (StreamSlice, error) {
+	return t.primary.ReadFromStream(ctx, streamID, opts...)
+}
+
+func (t TeeEventStoreDecorator) TruncateStream(ctx context.Context, streamID StreamID, opts ...TruncateStreamOption) error {
+	if err := t.primary.TruncateStream(ctx, streamID, opts...); err != nil {
+		return err
+	}
+
+	t.reportSecondaryError(t.secondary.TruncateStream(ctx, streamID, opts...))
+
+	return nil
+}
+
+func (t TeeEventStoreDecorator) DeleteStream(ctx context.Context, id StreamID) error {
+	if err := t.primary.DeleteStream(ctx, id); err != nil {
+		return err
+	}
+
+	t.reportSecondaryError(t.secondary.DeleteStream(ctx, id))
+
+	return nil
+}
+
+func (t TeeEventStoreDecorator) SubscribeToStream(ctx context.Context, streamID StreamID, opts ...SubscribeToStreamOption) (Subscription, error) {
+	return t.primary.SubscribeToStream(ctx, streamID, opts...)
+}
+
+func (t TeeEventStoreDecorator) StreamExists(ctx context.Context, id StreamID) (bool, error) {
+	return t.primary.StreamExists(ctx, id)
+}
+
+func (t TeeEventStoreDecorator) RenameEventType(ctx context.Context, streamID StreamID, from, to event.PayloadTypeName) (int, error) {
+	renamed, err := RenameEventType(ctx, t.primary, streamID, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = RenameEventType(ctx, t.secondary, streamID, from, to)
+	t.reportSecondaryError(err)
+
+	return renamed, nil
+}
+
+func (t TeeEventStoreDecorator) GetStream(ctx context.Context, id StreamID) (Stream, error) {
+	return t.primary.GetStream(ctx, id)
+}
+
+func (t TeeEventStoreDecorator) CountEvents(ctx context.Context, streamID StreamID) (int64, error) {
+	return t.primary.CountEvents(ctx, streamID)
+}
+
+func (t TeeEventStoreDecorator) Clear(ctx context.Context) error {
+	if err := t.primary.Clear(ctx); err != nil {
+		return err
+	}
+
+	t.reportSecondaryError(t.secondary.Clear(ctx))
+
+	return nil
+}
+
+// reportSecondaryError reports an error of the secondary store to the callback, if any.
+func (t TeeEventStoreDecorator) reportSecondaryError(err error) {
+	if err != nil && t.onSecondaryError != nil {
+		t.onSecondaryError(err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTeeEventStoreDecorator_AppendToStream(t *testing.T) {
+	ctx := context.Background()
+	primary := NewInMemoryEventStore(clock.UTCClock{})
+	secondary := NewInMemoryEventStore(clock.UTCClock{})
+	var secondaryErrors []error
+	es := NewTeeDecorator(primary, secondary, func(err error) {
+		secondaryErrors = append(secondaryErrors, err)
+	})
+
+	err := es.AppendToStream(ctx, "unit-test", []EventDescriptor{
+		{ID: "evt-1", TypeName: "unit_test.ran"},
+		{ID: "evt-2", TypeName: "unit_test.ran"},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, secondaryErrors)
+
+	for _, s := range []EventStore{primary, secondary} {
+		slice, err := s.ReadFromStream(ctx, "unit-test", FromStart())
+		assert.NoError(t, err)
+		assert.Equal(t, []EventID{"evt-1", "evt-2"}, eventIDs(slice))
+	}
+
+	slice, err := es.ReadFromStream(ctx, "unit-test", FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, []EventID{"evt-1", "evt-2"}, eventIDs(slice))
+}
+
+func TestTeeEventStoreDecorator_AppendToStream_SecondaryFailure(t *testing.T) {
+	ctx := context.Background()
+	primary := NewInMemoryEventStore(clock.UTCClock{})
+	secondary := NewInMemoryEventStore(clock.UTCClock{})
+	var secondaryErrors []error
+	es := NewTeeDecorator(primary, NewRequiredMetadataDecorator(secondary, "tenantId"), func(err error) {
+		secondaryErrors = append(secondaryErrors, err)
+	})
+
+	err := es.AppendToStream(ctx, "unit-test", []EventDescriptor{
+		{ID: "evt-1", TypeName: "unit_test.ran", Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, secondaryErrors, 1) {
+		assert.True(t, IsMissingRequiredMetadataError(secondaryErrors[0]))
+	}
+
+	exists, err := primary.StreamExists(ctx, "unit-test")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = secondary.StreamExists(ctx, "unit-test")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestTeeEventStoreDecorator_AppendToStream_PrimaryFailure(t *testing.T) {
+	ctx := context.Background()
+	secondary := NewInMemoryEventStore(clock.UTCClock{})
+	es := NewTeeDecorator(NewRequiredMetadataDecorator(NewInMemoryEventStore(clock.UTCClock{}), "tenantId"), secondary, nil)
+
+	err := es.AppendToStream(ctx, "unit-test", []EventDescriptor{
+		{ID: "evt-1", TypeName: "unit_test.ran"},
+	})
+	assert.True(t, IsMissingRequiredMetadataError(err))
+
+	exists, err := secondary.StreamExists(ctx, "unit-test")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -23,6 +23,10 @@ import (
 type TruncateStreamOptions struct {
 	BeforePosition Position
 	Reason         *string
+
+	// KeepLast when not nil, indicates that only this number of the most recent events of the stream should be kept,
+	// the position before which events are truncated being computed from the current version of the stream.
+	KeepLast *int
 }
 
 type TruncateStreamOption func(options *TruncateStreamOptions)
@@ -30,9 +34,26 @@ type TruncateStreamOption func(options *TruncateStreamOptions)
 func BeforePosition(p Position) TruncateStreamOption {
 	return func(options *TruncateStreamOptions) {
 		options.BeforePosition = p
+		options.KeepLast = nil
+	}
+}
+
+// KeepLast Allows specifying that only the n most recent events of the stream should be kept.
+func KeepLast(n int) TruncateStreamOption {
+	return func(options *TruncateStreamOptions) {
+		options.KeepLast = &n
 	}
 }
 
+// CutoffPosition returns the position before which the events of a stream at a given version should be truncated.
+func (o TruncateStreamOptions) CutoffPosition(streamVersion StreamVersion) Position {
+	if o.KeepLast == nil {
+		return o.BeforePosition
+	}
+
+	return PositionFromVersion(streamVersion - StreamVersion(*o.KeepLast) + 1)
+}
+
 const StreamTruncatedEventTypeName event.PayloadTypeName = "es.stream.truncated"
 
 type StreamTruncatedEvent struct {
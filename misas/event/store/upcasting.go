@@ -338,10 +338,18 @@ func (u UpcastingEventStoreDecorator) StreamExists(ctx context.Context, id Strea
 	return u.inner.StreamExists(ctx, id)
 }
 
+func (u UpcastingEventStoreDecorator) RenameEventType(ctx context.Context, streamID StreamID, from, to event.PayloadTypeName) (int, error) {
+	return RenameEventType(ctx, u.inner, streamID, from, to)
+}
+
 func (u UpcastingEventStoreDecorator) GetStream(ctx context.Context, id StreamID) (Stream, error) {
 	return u.inner.GetStream(ctx, id)
 }
 
+func (u UpcastingEventStoreDecorator) CountEvents(ctx context.Context, streamID StreamID) (int64, error) {
+	return u.inner.CountEvents(ctx, streamID)
+}
+
 func (u UpcastingEventStoreDecorator) Clear(ctx context.Context) error {
 	return u.inner.Clear(ctx)
 }
@@ -0,0 +1,146 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"reflect"
+)
+
+// DifferenceKind represents the kind of Difference found between the versions of a stream in two event stores.
+type DifferenceKind string
+
+const (
+	// MissingEventDifference indicates that an event exists in only one of the stores.
+	MissingEventDifference DifferenceKind = "missing_event"
+
+	// EventIDDifference indicates that the events at a given version have different IDs.
+	EventIDDifference DifferenceKind = "event_id"
+
+	// TypeNameDifference indicates that the events at a given version have different type names.
+	TypeNameDifference DifferenceKind = "type_name"
+
+	// PayloadDifference indicates that the events at a given version have different payloads.
+	PayloadDifference DifferenceKind = "payload"
+)
+
+// Difference represents a difference between the events at a given version of a stream in two event stores.
+// A and B are the differing values in the first and second store respectively, nil if the event is missing from a store.
+type Difference struct {
+	Version StreamVersion
+	Kind    DifferenceKind
+	A       any
+	B       any
+}
+
+// VerifyStoresConsistent compares the events of a stream in two event stores, for instance to validate a migration from one
+// store to another before cutting over. The events are compared version by version on their IDs, type names and payloads,
+// payloads being compared on their JSON representation so that stores encoding values differently can be compared.
+// A stream that does not exist in a store is considered empty. Returns true if no differences were found.
+func VerifyStoresConsistent(ctx context.Context, a, b EventStore, streamID StreamID) (bool, []Difference, error) {
+	operationFailed := func(err error) (bool, []Difference, error) {
+		return false, nil, errors.Wrapf(err, "failed verifying consistency of stream \"%s\"", streamID)
+	}
+
+	descriptorsOfA, err := readStreamForVerification(ctx, a, streamID)
+	if err != nil {
+		return operationFailed(err)
+	}
+
+	descriptorsOfB, err := readStreamForVerification(ctx, b, streamID)
+	if err != nil {
+		return operationFailed(err)
+	}
+
+	var differences []Difference
+	for i := 0; i < len(descriptorsOfA) || i < len(descriptorsOfB); i++ {
+		if i >= len(descriptorsOfB) {
+			differences = append(differences, Difference{Version: descriptorsOfA[i].Version, Kind: MissingEventDifference, A: descriptorsOfA[i].ID})
+			continue
+		}
+		if i >= len(descriptorsOfA) {
+			differences = append(differences, Difference{Version: descriptorsOfB[i].Version, Kind: MissingEventDifference, B: descriptorsOfB[i].ID})
+			continue
+		}
+
+		diffs, err := compareDescriptors(descriptorsOfA[i], descriptorsOfB[i])
+		if err != nil {
+			return operationFailed(err)
+		}
+		differences = append(differences, diffs...)
+	}
+
+	return len(differences) == 0, differences, nil
+}
+
+// readStreamForVerification reads all the events of a stream, a stream that does not exist being read as empty.
+func readStreamForVerification(ctx context.Context, es EventStore, streamID StreamID) ([]RecordedEventDescriptor, error) {
+	slice, err := es.ReadFromStream(ctx, streamID, FromStart(), InForwardDirection())
+	if err != nil {
+		if errors.Is(err, NewStreamNotFoundError(streamID)) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return slice.Descriptors, nil
+}
+
+// compareDescriptors returns the differences between two descriptors found at the same position of a stream.
+func compareDescriptors(a, b RecordedEventDescriptor) ([]Difference, error) {
+	var differences []Difference
+	if a.ID != b.ID {
+		differences = append(differences, Difference{Version: a.Version, Kind: EventIDDifference, A: a.ID, B: b.ID})
+	}
+
+	if a.TypeName != b.TypeName {
+		differences = append(differences, Difference{Version: a.Version, Kind: TypeNameDifference, A: a.TypeName, B: b.TypeName})
+	}
+
+	payloadOfA, err := normalizePayload(a.Payload)
+	if err != nil {
+		return nil, err
+	}
+	payloadOfB, err := normalizePayload(b.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(payloadOfA, payloadOfB) {
+		differences = append(differences, Difference{Version: a.Version, Kind: PayloadDifference, A: a.Payload, B: b.Payload})
+	}
+
+	return differences, nil
+}
+
+// normalizePayload returns the JSON representation of a payload decoded back to generic values, a nil payload being empty.
+func normalizePayload(p DescriptorPayload) (any, error) {
+	if p == nil {
+		p = DescriptorPayload{}
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
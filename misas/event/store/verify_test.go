@@ -0,0 +1,98 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVerifyStoresConsistent(t *testing.T) {
+	tests := []struct {
+		name            string
+		eventsOfA       []EventDescriptor
+		eventsOfB       []EventDescriptor
+		wantConsistent  bool
+		wantDifferences []Difference
+	}{
+		{
+			name: "identical streams",
+			eventsOfA: []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.started", Payload: DescriptorPayload{"name": "unit", "attempt": 1}},
+				{ID: "evt-2", TypeName: "unit_test.passed"},
+			},
+			eventsOfB: []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.started", Payload: DescriptorPayload{"name": "unit", "attempt": float64(1)}},
+				{ID: "evt-2", TypeName: "unit_test.passed", Payload: DescriptorPayload{}},
+			},
+			wantConsistent: true,
+		},
+		{
+			name:           "stream missing from both stores",
+			wantConsistent: true,
+		},
+		{
+			name: "divergent streams",
+			eventsOfA: []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.started", Payload: DescriptorPayload{"name": "unit"}},
+				{ID: "evt-2", TypeName: "unit_test.passed"},
+				{ID: "evt-3", TypeName: "unit_test.passed"},
+			},
+			eventsOfB: []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.started", Payload: DescriptorPayload{"name": "integration"}},
+				{ID: "evt-4", TypeName: "unit_test.failed"},
+			},
+			wantConsistent: false,
+			wantDifferences: []Difference{
+				{Version: 0, Kind: PayloadDifference, A: DescriptorPayload{"name": "unit"}, B: DescriptorPayload{"name": "integration"}},
+				{Version: 1, Kind: EventIDDifference, A: EventID("evt-2"), B: EventID("evt-4")},
+				{Version: 1, Kind: TypeNameDifference, A: event.PayloadTypeName("unit_test.passed"), B: event.PayloadTypeName("unit_test.failed")},
+				{Version: 2, Kind: MissingEventDifference, A: EventID("evt-3")},
+			},
+		},
+		{
+			name: "stream missing from a store",
+			eventsOfB: []EventDescriptor{
+				{ID: "evt-1", TypeName: "unit_test.started"},
+			},
+			wantConsistent: false,
+			wantDifferences: []Difference{
+				{Version: 0, Kind: MissingEventDifference, B: EventID("evt-1")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			a := NewInMemoryEventStore(clock.UTCClock{})
+			b := NewInMemoryEventStore(clock.UTCClock{})
+			if len(tt.eventsOfA) != 0 {
+				assert.NoError(t, a.AppendToStream(ctx, "unit-test", tt.eventsOfA))
+			}
+			if len(tt.eventsOfB) != 0 {
+				assert.NoError(t, b.AppendToStream(ctx, "unit-test", tt.eventsOfB))
+			}
+
+			consistent, differences, err := VerifyStoresConsistent(ctx, a, b, "unit-test")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantConsistent, consistent)
+			assert.Equal(t, tt.wantDifferences, differences)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// LogSafe is implemented by types that can provide a representation of themselves which can be logged
+// without exposing personal data, using secret.RedactedValue in place of this data. It is implemented by the types generated by the spectool.
+type LogSafe interface {
+	LogSafe() map[string]any
+}
+
+// LogSafeValue returns a representation of a value that can safely be logged.
+// Values implementing LogSafe are converted using their LogSafe method, slices and maps are converted element by element,
+// and primitive values are returned as is. Other values are never logged raw, only their type is returned.
+func LogSafeValue(v any) any {
+	if v == nil {
+		return nil
+	}
+
+	if ls, ok := v.(LogSafe); ok {
+		fields := ls.LogSafe()
+		safeFields := make(map[string]any, len(fields))
+		for k, f := range fields {
+			safeFields[k] = LogSafeValue(f)
+		}
+		return safeFields
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return LogSafeValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		values := make([]any, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values[i] = LogSafeValue(rv.Index(i).Interface())
+		}
+		return values
+	case reflect.Map:
+		values := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			values[fmt.Sprint(iter.Key().Interface())] = LogSafeValue(iter.Value().Interface())
+		}
+		return values
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return v
+	}
+
+	return fmt.Sprintf("<%T>", v)
+}
+
+// Logger is a hook used by generated endpoints and clients to log requests and responses.
+// The fields passed to a Logger by generated code are always made safe using LogSafeValue.
+type Logger func(ctx context.Context, message string, fields map[string]any)
+
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of a context carrying a Logger.
+func ContextWithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFromContext returns the Logger of a context or nil if it has none.
+func LoggerFromContext(ctx context.Context) Logger {
+	logger, _ := ctx.Value(loggerContextKey{}).(Logger)
+	return logger
+}
+
+// Log logs a message using the Logger of a context. If the context has no Logger, this is a no-op.
+func Log(ctx context.Context, message string, fields map[string]any) {
+	if logger := LoggerFromContext(ctx); logger != nil {
+		logger(ctx, message, fields)
+	}
+}
+
+// WithLogger allows specifying a Logger made available to endpoints through the context of their requests.
+func WithLogger(logger Logger) ServerOption {
+	return WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ContextWithLogger(r.Context(), logger)))
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/secret"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type logSafeUser struct {
+	ID           string
+	EmailAddress string
+}
+
+func (u logSafeUser) LogSafe() map[string]any {
+	return map[string]any{"id": u.ID, "emailAddress": secret.RedactedValue}
+}
+
+type logSafeTeam struct {
+	Name    string
+	Members []logSafeUser
+}
+
+func (t logSafeTeam) LogSafe() map[string]any {
+	return map[string]any{"name": t.Name, "members": t.Members}
+}
+
+type unsafeValue struct {
+	Secret string
+}
+
+func TestLogSafeValue(t *testing.T) {
+	user := logSafeUser{ID: "123", EmailAddress: "jane@example.com"}
+
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "nil", value: nil, want: nil},
+		{name: "primitive", value: "value", want: "value"},
+		{name: "log safe value", value: user, want: map[string]any{"id": "123", "emailAddress": secret.RedactedValue}},
+		{name: "pointer to log safe value", value: &user, want: map[string]any{"id": "123", "emailAddress": secret.RedactedValue}},
+		{
+			name:  "nested log safe values",
+			value: logSafeTeam{Name: "team", Members: []logSafeUser{user}},
+			want: map[string]any{
+				"name":    "team",
+				"members": []any{map[string]any{"id": "123", "emailAddress": secret.RedactedValue}},
+			},
+		},
+		{name: "unsafe value", value: unsafeValue{Secret: "secret"}, want: "<httpapi.unsafeValue>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, LogSafeValue(tt.value))
+		})
+	}
+}
+
+func TestLog(t *testing.T) {
+	// Without a logger, this should be a no-op.
+	assert.NotPanics(t, func() {
+		Log(context.Background(), "message", nil)
+	})
+
+	var logged map[string]any
+	ctx := ContextWithLogger(context.Background(), func(ctx context.Context, message string, fields map[string]any) {
+		logged = fields
+	})
+	Log(ctx, "message", map[string]any{"request": LogSafeValue(logSafeUser{ID: "123", EmailAddress: "jane@example.com"})})
+
+	assert.Equal(t, map[string]any{"request": map[string]any{"id": "123", "emailAddress": secret.RedactedValue}}, logged)
+}
@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/morebec/go-errors/errors"
+	"net/url"
+)
+
+// MarshalQuery encodes the JSON representation of a struct as URL query parameters, so that it can be sent by requests
+// without a body such as GET requests. Each field becomes a parameter named after its JSON name: strings are sent
+// verbatim, other values as their JSON text and null values are omitted. UnmarshalQuery performs the reverse operation.
+func MarshalQuery(v any) (url.Values, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	for name, value := range fields {
+		if string(value) == "null" {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			query.Set(name, s)
+			continue
+		}
+		query.Set(name, string(value))
+	}
+
+	return query, nil
+}
+
+// UnmarshalQuery decodes URL query parameters encoded by MarshalQuery into a struct. Since a query parameter does not
+// tell whether it holds a string or the JSON text of another value, stringFields are the JSON names of the fields of
+// the struct represented as JSON strings, whose parameters are taken verbatim.
+// Errors returned by this function can be directly passed to the NewErrorResponse without wrapping.
+func UnmarshalQuery(query url.Values, stringFields []string, v any) error {
+	isString := map[string]bool{}
+	for _, name := range stringFields {
+		isString[name] = true
+	}
+
+	fields := map[string]json.RawMessage{}
+	for name := range query {
+		value := query.Get(name)
+		if !isString[name] {
+			fields[name] = json.RawMessage(value)
+			continue
+		}
+		// Marshalling a string cannot fail.
+		fields[name], _ = json.Marshal(value)
+	}
+
+	for name, value := range fields {
+		if !json.Valid(value) {
+			return errors.NewWithMessage(BadRequestErrorCode, fmt.Sprintf("query parameter %s is badly-formed", name))
+		}
+	}
+
+	// The fields cannot fail being marshalled since they are all valid JSON.
+	data, _ := json.Marshal(fields)
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.WrapWithMessage(err, BadRequestErrorCode, fmt.Sprintf("query parameters are invalid: %s", err.Error()))
+	}
+
+	return nil
+}
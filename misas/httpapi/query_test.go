@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"github.com/morebec/go-errors/errors"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type queryTestRequest struct {
+	UserID   string     `json:"userId"`
+	Limit    int64      `json:"limit"`
+	Enabled  bool       `json:"enabled"`
+	Since    *time.Time `json:"since"`
+	Tags     []string   `json:"tags"`
+	Nickname *string    `json:"nickname"`
+}
+
+func TestMarshalQuery(t *testing.T) {
+	nickname := "12"
+	query, err := MarshalQuery(queryTestRequest{
+		UserID:   "user 1",
+		Limit:    10,
+		Enabled:  true,
+		Tags:     []string{"a", "b"},
+		Nickname: &nickname,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"userId":   {"user 1"},
+		"limit":    {"10"},
+		"enabled":  {"true"},
+		"tags":     {`["a","b"]`},
+		"nickname": {"12"},
+	}, query)
+}
+
+func TestUnmarshalQuery(t *testing.T) {
+	since := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	nickname := "12"
+	expected := queryTestRequest{
+		UserID:   "user 1",
+		Limit:    10,
+		Enabled:  true,
+		Since:    &since,
+		Tags:     []string{"a", "b"},
+		Nickname: &nickname,
+	}
+	stringFields := []string{"userId", "since", "nickname"}
+
+	query, err := MarshalQuery(expected)
+	assert.NoError(t, err)
+
+	var actual queryTestRequest
+	assert.NoError(t, UnmarshalQuery(query, stringFields, &actual))
+	assert.Equal(t, expected, actual)
+
+	var empty queryTestRequest
+	assert.NoError(t, UnmarshalQuery(url.Values{}, stringFields, &empty))
+	assert.Equal(t, queryTestRequest{}, empty)
+
+	err = UnmarshalQuery(url.Values{"limit": {"ten"}}, stringFields, &actual)
+	assert.True(t, errors.HasCode(err, BadRequestErrorCode))
+
+	err = UnmarshalQuery(url.Values{"limit": {`"10"`}}, stringFields, &actual)
+	assert.True(t, errors.HasCode(err, BadRequestErrorCode))
+}
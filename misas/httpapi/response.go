@@ -75,12 +75,13 @@ func NewFailureResponse(errorType string, message string, data any) EndpointResp
 }
 
 // NewErrorResponse returns a new response based on an error, it tries to find if an error code can be inferred from the error, if not
-// wil return an InternalErrorResponse.
+// wil return an InternalErrorResponse. The error code is also looked up in the errors wrapped by err.
 func NewErrorResponse(err error) EndpointResponse {
-	if errWithCode, ok := err.(interface {
+	var errWithCode interface {
 		Code() string
 		Error() string
-	}); ok {
+	}
+	if errors.As(err, &errWithCode) {
 		errorCode := errWithCode.Code()
 		r := NewFailureResponse(errorCode, errWithCode.Error(), nil)
 		if errorCode == errors.NotFoundCode {
@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"fmt"
 	"github.com/morebec/go-errors/errors"
 	"net/http"
 	"reflect"
@@ -137,6 +138,22 @@ func TestNewErrorResponse(t *testing.T) {
 				},
 			},
 		},
+		{
+			testName: "test new error response from wrapped error",
+			given: GivenArgs{
+				err: fmt.Errorf("failed handling request: %w", errors.NewWithMessage(errors.NotFoundCode, "resource xyz not found")),
+			},
+			expect: EndpointResponse{
+				StatusCode: http.StatusNotFound,
+				Status:     Failure,
+				Data:       nil,
+				Error: &Error{
+					Type:    errors.NotFoundCode,
+					Message: "resource xyz not found",
+					Data:    nil,
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
@@ -240,6 +240,27 @@ func (o *OpenTelemetryEventStoreDecorator) StreamExists(ctx context.Context, id
 	return exists, nil
 }
 
+func (o *OpenTelemetryEventStoreDecorator) RenameEventType(ctx context.Context, streamID store.StreamID, from, to event.PayloadTypeName) (int, error) {
+	ctx, span := o.Tracer.Start(ctx, "eventStore.RenameEventType")
+	defer span.End()
+
+	span.SetAttributes(semconv.DBSystemKey.String("eventstore"))
+	span.SetAttributes(semconv.DBStatementKey.String(string("RenameEventType " + streamID)))
+	span.SetAttributes(semconv.DBOperationKey.String("RenameEventType"))
+	span.SetAttributes(attribute.String("db.eventstore.streamId", string(streamID)))
+	span.SetAttributes(attribute.String("db.statement.options.from", string(from)))
+	span.SetAttributes(attribute.String("db.statement.options.to", string(to)))
+
+	renamed, err := store.RenameEventType(ctx, o.EventStore, streamID, from, to)
+	if err != nil {
+		span.RecordError(err, trace.WithStackTrace(true))
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	return renamed, nil
+}
+
 func (o *OpenTelemetryEventStoreDecorator) GetStream(ctx context.Context, id store.StreamID) (store.Stream, error) {
 	ctx, span := o.Tracer.Start(ctx, "eventStore.GetStream")
 	defer span.End()
@@ -259,6 +280,25 @@ func (o *OpenTelemetryEventStoreDecorator) GetStream(ctx context.Context, id sto
 	return stream, nil
 }
 
+func (o *OpenTelemetryEventStoreDecorator) CountEvents(ctx context.Context, streamID store.StreamID) (int64, error) {
+	ctx, span := o.Tracer.Start(ctx, "eventStore.CountEvents")
+	defer span.End()
+
+	span.SetAttributes(semconv.DBSystemKey.String("eventstore"))
+	span.SetAttributes(semconv.DBStatementKey.String(string("CountEvents " + streamID)))
+	span.SetAttributes(semconv.DBOperationKey.String("CountEvents"))
+	span.SetAttributes(attribute.String("db.eventstore.streamId", string(streamID)))
+
+	count, err := o.EventStore.CountEvents(ctx, streamID)
+	if err != nil {
+		span.RecordError(err, trace.WithStackTrace(true))
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	return count, nil
+}
+
 func (o *OpenTelemetryEventStoreDecorator) Clear(ctx context.Context) error {
 	ctx, span := o.Tracer.Start(ctx, "eventStore.Clear")
 	defer span.End()
@@ -0,0 +1,168 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrumentation
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/event/store"
+	"time"
+)
+
+// Logger is a structured logger used by the logging decorators of this package.
+type Logger interface {
+	// Debug logs a message with some fields at the debug level.
+	Debug(ctx context.Context, message string, fields map[string]any)
+
+	// Error logs a message with some fields at the error level.
+	Error(ctx context.Context, message string, fields map[string]any)
+}
+
+// LoggingEventStoreDecorator is a decorator logging the operations performed on a store.EventStore.
+// Each operation is logged once with its stream ID, number of events and duration, at the debug level
+// when it succeeds and at the error level along with its error when it fails.
+type LoggingEventStoreDecorator struct {
+	store.EventStore
+	Logger Logger
+}
+
+func NewLoggingEventStoreDecorator(eventStore store.EventStore, logger Logger) *LoggingEventStoreDecorator {
+	return &LoggingEventStoreDecorator{EventStore: eventStore, Logger: logger}
+}
+
+func (d *LoggingEventStoreDecorator) GlobalStreamID() store.StreamID {
+	return d.EventStore.GlobalStreamID()
+}
+
+func (d *LoggingEventStoreDecorator) AppendToStream(ctx context.Context, streamID store.StreamID, events []store.EventDescriptor, opts ...store.AppendToStreamOption) error {
+	start := time.Now()
+	err := d.EventStore.AppendToStream(ctx, streamID, events, opts...)
+	d.log(ctx, "eventStore.AppendToStream", start, err, map[string]any{
+		"streamId":   string(streamID),
+		"eventCount": len(events),
+	})
+
+	return err
+}
+
+func (d *LoggingEventStoreDecorator) ReadFromStream(ctx context.Context, streamID store.StreamID, opts ...store.ReadFromStreamOption) (store.StreamSlice, error) {
+	start := time.Now()
+	slice, err := d.EventStore.ReadFromStream(ctx, streamID, opts...)
+	d.log(ctx, "eventStore.ReadFromStream", start, err, map[string]any{
+		"streamId":   string(streamID),
+		"eventCount": len(slice.Descriptors),
+	})
+
+	return slice, err
+}
+
+func (d *LoggingEventStoreDecorator) TruncateStream(ctx context.Context, streamID store.StreamID, opts ...store.TruncateStreamOption) error {
+	start := time.Now()
+	err := d.EventStore.TruncateStream(ctx, streamID, opts...)
+	d.log(ctx, "eventStore.TruncateStream", start, err, map[string]any{
+		"streamId":       string(streamID),
+		"beforePosition": int64(store.BuildTruncateFromStreamOptions(opts).BeforePosition),
+	})
+
+	return err
+}
+
+func (d *LoggingEventStoreDecorator) DeleteStream(ctx context.Context, id store.StreamID) error {
+	start := time.Now()
+	err := d.EventStore.DeleteStream(ctx, id)
+	d.log(ctx, "eventStore.DeleteStream", start, err, map[string]any{
+		"streamId": string(id),
+	})
+
+	return err
+}
+
+func (d *LoggingEventStoreDecorator) SubscribeToStream(ctx context.Context, streamID store.StreamID, opts ...store.SubscribeToStreamOption) (store.Subscription, error) {
+	start := time.Now()
+	subscription, err := d.EventStore.SubscribeToStream(ctx, streamID, opts...)
+	d.log(ctx, "eventStore.SubscribeToStream", start, err, map[string]any{
+		"streamId": string(streamID),
+	})
+
+	return subscription, err
+}
+
+func (d *LoggingEventStoreDecorator) StreamExists(ctx context.Context, id store.StreamID) (bool, error) {
+	start := time.Now()
+	exists, err := d.EventStore.StreamExists(ctx, id)
+	d.log(ctx, "eventStore.StreamExists", start, err, map[string]any{
+		"streamId": string(id),
+		"exists":   exists,
+	})
+
+	return exists, err
+}
+
+func (d *LoggingEventStoreDecorator) RenameEventType(ctx context.Context, streamID store.StreamID, from, to event.PayloadTypeName) (int, error) {
+	start := time.Now()
+	renamed, err := store.RenameEventType(ctx, d.EventStore, streamID, from, to)
+	d.log(ctx, "eventStore.RenameEventType", start, err, map[string]any{
+		"streamId": string(streamID),
+		"from":     string(from),
+		"to":       string(to),
+		"renamed":  renamed,
+	})
+
+	return renamed, err
+}
+
+func (d *LoggingEventStoreDecorator) GetStream(ctx context.Context, id store.StreamID) (store.Stream, error) {
+	start := time.Now()
+	stream, err := d.EventStore.GetStream(ctx, id)
+	d.log(ctx, "eventStore.GetStream", start, err, map[string]any{
+		"streamId": string(id),
+	})
+
+	return stream, err
+}
+
+func (d *LoggingEventStoreDecorator) CountEvents(ctx context.Context, streamID store.StreamID) (int64, error) {
+	start := time.Now()
+	count, err := d.EventStore.CountEvents(ctx, streamID)
+	d.log(ctx, "eventStore.CountEvents", start, err, map[string]any{
+		"streamId":   string(streamID),
+		"eventCount": count,
+	})
+
+	return count, err
+}
+
+func (d *LoggingEventStoreDecorator) Clear(ctx context.Context) error {
+	start := time.Now()
+	err := d.EventStore.Clear(ctx)
+	d.log(ctx, "eventStore.Clear", start, err, map[string]any{})
+
+	return err
+}
+
+func (d *LoggingEventStoreDecorator) Decorated() store.EventStore {
+	return d.EventStore
+}
+
+// log logs an operation at the debug level, or at the error level if it failed.
+func (d *LoggingEventStoreDecorator) log(ctx context.Context, operation string, start time.Time, err error, fields map[string]any) {
+	fields["duration"] = time.Since(start)
+	if err != nil {
+		fields["error"] = err.Error()
+		d.Logger.Error(ctx, operation, fields)
+		return
+	}
+	d.Logger.Debug(ctx, operation, fields)
+}
@@ -0,0 +1,100 @@
+package instrumentation
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type logEntry struct {
+	level   string
+	message string
+	fields  map[string]any
+}
+
+// capturingLogger is a Logger capturing the entries logged.
+type capturingLogger struct {
+	entries []logEntry
+}
+
+func (l *capturingLogger) Debug(_ context.Context, message string, fields map[string]any) {
+	l.entries = append(l.entries, logEntry{level: "debug", message: message, fields: fields})
+}
+
+func (l *capturingLogger) Error(_ context.Context, message string, fields map[string]any) {
+	l.entries = append(l.entries, logEntry{level: "error", message: message, fields: fields})
+}
+
+func TestLoggingEventStoreDecorator(t *testing.T) {
+	logger := &capturingLogger{}
+	es := NewLoggingEventStoreDecorator(store.NewInMemoryEventStore(clock.NewUTCClock()), logger)
+	ctx := context.Background()
+
+	assert.NoError(t, es.AppendToStream(ctx, "unit-test", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: "unit_test.passed"},
+		{ID: store.NewEventID(), TypeName: "unit_test.passed"},
+	}))
+	_, err := es.ReadFromStream(ctx, "unit-test", store.FromStart())
+	assert.NoError(t, err)
+	_, err = es.StreamExists(ctx, "unit-test")
+	assert.NoError(t, err)
+	_, err = es.GetStream(ctx, "unit-test")
+	assert.NoError(t, err)
+	_, err = es.ReadFromStream(ctx, "not-found", store.FromStart())
+	assert.Error(t, err)
+	assert.NoError(t, es.DeleteStream(ctx, "unit-test"))
+	assert.NoError(t, es.Clear(ctx))
+
+	var messages []string
+	for _, entry := range logger.entries {
+		messages = append(messages, entry.level+" "+entry.message)
+		assert.IsType(t, time.Duration(0), entry.fields["duration"])
+	}
+	assert.Equal(t, []string{
+		"debug eventStore.AppendToStream",
+		"debug eventStore.ReadFromStream",
+		"debug eventStore.StreamExists",
+		"debug eventStore.GetStream",
+		"error eventStore.ReadFromStream",
+		"debug eventStore.DeleteStream",
+		"debug eventStore.Clear",
+	}, messages)
+
+	assert.Equal(t, "unit-test", logger.entries[0].fields["streamId"])
+	assert.Equal(t, 2, logger.entries[0].fields["eventCount"])
+	assert.Equal(t, 2, logger.entries[1].fields["eventCount"])
+	assert.Equal(t, "not-found", logger.entries[4].fields["streamId"])
+	assert.NotEmpty(t, logger.entries[4].fields["error"])
+}
+
+func TestEventStoreDecorators_RenameEventType(t *testing.T) {
+	ctx := context.Background()
+	inner := store.NewInMemoryEventStore(clock.NewUTCClock())
+	assert.NoError(t, inner.AppendToStream(ctx, "unit-test", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: "unit_test.passed"},
+		{ID: store.NewEventID(), TypeName: "unit_test.started"},
+	}))
+
+	metrics, err := NewOpenTelemetryEventStoreMetricsDecorator(inner, nil)
+	assert.NoError(t, err)
+	tracing := &OpenTelemetryEventStoreDecorator{EventStore: metrics, Tracer: NewSystemTracer()}
+	logger := &capturingLogger{}
+	es := NewLoggingEventStoreDecorator(tracing, logger)
+
+	renamed, err := store.RenameEventType(ctx, es, "unit-test", "unit_test.passed", "unit_test.succeeded")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renamed)
+
+	slice, err := inner.ReadFromStream(ctx, "unit-test", store.FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, event.PayloadTypeName("unit_test.succeeded"), slice.Descriptors[0].TypeName)
+
+	if assert.Len(t, logger.entries, 1) {
+		assert.Equal(t, "eventStore.RenameEventType", logger.entries[0].message)
+		assert.Equal(t, 1, logger.entries[0].fields["renamed"])
+	}
+}
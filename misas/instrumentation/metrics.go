@@ -0,0 +1,143 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrumentation
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"time"
+)
+
+// EventStoreAppendBatchSizeMetricName is the name of the histogram of the number of events appended per call to AppendToStream.
+const EventStoreAppendBatchSizeMetricName = "eventstore.append.batchSize"
+
+// EventStoreAppendedEventsMetricName is the name of the counter of the events appended to the event store.
+const EventStoreAppendedEventsMetricName = "eventstore.append.events"
+
+// EventStoreReadsMetricName is the name of the counter of the read operations performed on the event store.
+const EventStoreReadsMetricName = "eventstore.read.operations"
+
+// EventStoreOperationDurationMetricName is the name of the histogram of the duration of AppendToStream and ReadFromStream in seconds.
+const EventStoreOperationDurationMetricName = "eventstore.operation.duration"
+
+// OpenTelemetryEventStoreMetricsDecorator is a decorator allowing to record metrics about the usage of a store.EventStore.
+// Metrics are recorded with the ID of the stream and the name of the operation as attributes.
+type OpenTelemetryEventStoreMetricsDecorator struct {
+	store.EventStore
+	appendBatchSize   metric.Int64Histogram
+	appendedEvents    metric.Int64Counter
+	reads             metric.Int64Counter
+	operationDuration metric.Float64Histogram
+}
+
+// NewOpenTelemetryEventStoreMetricsDecorator returns a decorator recording the metrics of an event store using a meter.
+// If meter is nil, the meter of the global meter provider is used.
+func NewOpenTelemetryEventStoreMetricsDecorator(eventStore store.EventStore, meter metric.Meter) (*OpenTelemetryEventStoreMetricsDecorator, error) {
+	if meter == nil {
+		meter = otel.Meter("")
+	}
+
+	appendBatchSize, err := meter.Int64Histogram(
+		EventStoreAppendBatchSizeMetricName,
+		metric.WithDescription("Number of events appended per call to AppendToStream."),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating metric %s", EventStoreAppendBatchSizeMetricName)
+	}
+
+	appendedEvents, err := meter.Int64Counter(
+		EventStoreAppendedEventsMetricName,
+		metric.WithDescription("Number of events appended to the event store."),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating metric %s", EventStoreAppendedEventsMetricName)
+	}
+
+	reads, err := meter.Int64Counter(
+		EventStoreReadsMetricName,
+		metric.WithDescription("Number of read operations performed on the event store."),
+		metric.WithUnit("{operation}"),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating metric %s", EventStoreReadsMetricName)
+	}
+
+	operationDuration, err := meter.Float64Histogram(
+		EventStoreOperationDurationMetricName,
+		metric.WithDescription("Duration of the AppendToStream and ReadFromStream operations."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating metric %s", EventStoreOperationDurationMetricName)
+	}
+
+	return &OpenTelemetryEventStoreMetricsDecorator{
+		EventStore:        eventStore,
+		appendBatchSize:   appendBatchSize,
+		appendedEvents:    appendedEvents,
+		reads:             reads,
+		operationDuration: operationDuration,
+	}, nil
+}
+
+func (o *OpenTelemetryEventStoreMetricsDecorator) AppendToStream(ctx context.Context, streamID store.StreamID, events []store.EventDescriptor, opts ...store.AppendToStreamOption) error {
+	attributes := eventStoreMetricAttributes(streamID, "AppendToStream")
+
+	start := time.Now()
+	err := o.EventStore.AppendToStream(ctx, streamID, events, opts...)
+	o.operationDuration.Record(ctx, time.Since(start).Seconds(), attributes)
+	if err != nil {
+		return err
+	}
+
+	// Only the appends that succeeded are counted, since the others did not write anything.
+	o.appendBatchSize.Record(ctx, int64(len(events)), attributes)
+	o.appendedEvents.Add(ctx, int64(len(events)), attributes)
+
+	return nil
+}
+
+func (o *OpenTelemetryEventStoreMetricsDecorator) ReadFromStream(ctx context.Context, streamID store.StreamID, opts ...store.ReadFromStreamOption) (store.StreamSlice, error) {
+	attributes := eventStoreMetricAttributes(streamID, "ReadFromStream")
+
+	start := time.Now()
+	slice, err := o.EventStore.ReadFromStream(ctx, streamID, opts...)
+	o.operationDuration.Record(ctx, time.Since(start).Seconds(), attributes)
+	o.reads.Add(ctx, 1, attributes)
+	if err != nil {
+		return store.StreamSlice{}, err
+	}
+
+	return slice, nil
+}
+
+func (o *OpenTelemetryEventStoreMetricsDecorator) RenameEventType(ctx context.Context, streamID store.StreamID, from, to event.PayloadTypeName) (int, error) {
+	return store.RenameEventType(ctx, o.EventStore, streamID, from, to)
+}
+
+// eventStoreMetricAttributes returns the attributes with which the metrics of an operation on a stream are recorded.
+func eventStoreMetricAttributes(streamID store.StreamID, operation string) metric.MeasurementOption {
+	return metric.WithAttributes(
+		attribute.String("db.eventstore.streamId", string(streamID)),
+		attribute.String("db.eventstore.operation", operation),
+	)
+}
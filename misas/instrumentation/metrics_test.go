@@ -0,0 +1,121 @@
+package instrumentation
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"testing"
+)
+
+func TestOpenTelemetryEventStoreMetricsDecorator_AppendToStream(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	es, err := NewOpenTelemetryEventStoreMetricsDecorator(store.NewInMemoryEventStore(clock.NewUTCClock()), provider.Meter("unit-test"))
+	assert.NoError(t, err)
+
+	for _, batchSize := range []int{1, 3, 2} {
+		var events []store.EventDescriptor
+		for i := 0; i < batchSize; i++ {
+			events = append(events, store.EventDescriptor{ID: store.NewEventID(), TypeName: "unit_test.passed"})
+		}
+		assert.NoError(t, es.AppendToStream(context.Background(), "unit-test", events))
+	}
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	m, found := findMetric(rm, EventStoreAppendBatchSizeMetricName)
+	if !assert.True(t, found) {
+		return
+	}
+
+	histogram, ok := m.Data.(metricdata.Histogram[int64])
+	if !assert.True(t, ok) || !assert.Len(t, histogram.DataPoints, 1) {
+		return
+	}
+	dataPoint := histogram.DataPoints[0]
+	assert.Equal(t, uint64(3), dataPoint.Count)
+	assert.Equal(t, int64(6), dataPoint.Sum)
+	min, _ := dataPoint.Min.Value()
+	assert.Equal(t, int64(1), min)
+	max, _ := dataPoint.Max.Value()
+	assert.Equal(t, int64(3), max)
+}
+
+func TestOpenTelemetryEventStoreMetricsDecorator_CountersAndDurations(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	es, err := NewOpenTelemetryEventStoreMetricsDecorator(store.NewInMemoryEventStore(clock.NewUTCClock()), provider.Meter("unit-test"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, es.AppendToStream(context.Background(), "unit-test", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: "unit_test.passed"},
+		{ID: store.NewEventID(), TypeName: "unit_test.passed"},
+	}))
+	assert.NoError(t, es.AppendToStream(context.Background(), "unit-test", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: "unit_test.passed"},
+	}))
+	_, err = es.ReadFromStream(context.Background(), "unit-test", store.FromStart())
+	assert.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	appendAttributes := attribute.NewSet(
+		attribute.String("db.eventstore.streamId", "unit-test"),
+		attribute.String("db.eventstore.operation", "AppendToStream"),
+	)
+	readAttributes := attribute.NewSet(
+		attribute.String("db.eventstore.streamId", "unit-test"),
+		attribute.String("db.eventstore.operation", "ReadFromStream"),
+	)
+
+	m, found := findMetric(rm, EventStoreAppendedEventsMetricName)
+	if assert.True(t, found) {
+		sum, ok := m.Data.(metricdata.Sum[int64])
+		if assert.True(t, ok) && assert.Len(t, sum.DataPoints, 1) {
+			assert.Equal(t, int64(3), sum.DataPoints[0].Value)
+			assert.Equal(t, appendAttributes, sum.DataPoints[0].Attributes)
+		}
+	}
+
+	m, found = findMetric(rm, EventStoreReadsMetricName)
+	if assert.True(t, found) {
+		sum, ok := m.Data.(metricdata.Sum[int64])
+		if assert.True(t, ok) && assert.Len(t, sum.DataPoints, 1) {
+			assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+			assert.Equal(t, readAttributes, sum.DataPoints[0].Attributes)
+		}
+	}
+
+	m, found = findMetric(rm, EventStoreOperationDurationMetricName)
+	if assert.True(t, found) {
+		histogram, ok := m.Data.(metricdata.Histogram[float64])
+		if assert.True(t, ok) && assert.Len(t, histogram.DataPoints, 2) {
+			counts := map[attribute.Set]uint64{}
+			for _, dataPoint := range histogram.DataPoints {
+				counts[dataPoint.Attributes] = dataPoint.Count
+				assert.GreaterOrEqual(t, dataPoint.Sum, float64(0))
+			}
+			assert.Equal(t, map[attribute.Set]uint64{appendAttributes: 2, readAttributes: 1}, counts)
+		}
+	}
+}
+
+// findMetric returns the metric with a given name from collected metrics.
+func findMetric(rm metricdata.ResourceMetrics, name string) (metricdata.Metrics, bool) {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m, true
+			}
+		}
+	}
+	return metricdata.Metrics{}, false
+}
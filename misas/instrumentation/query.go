@@ -35,6 +35,8 @@ func (b *OpenTelemetryQueryBusDecorator) RegisterHandler(t query.PayloadTypeName
 			ctx, span := b.Tracer.Start(ctx, fmt.Sprintf("%s.handle", t))
 			defer span.End()
 
+			span.SetAttributes(attribute.String("query.typeName", string(t)))
+
 			data, err := h.Handle(ctx, q)
 			if err != nil {
 				span.RecordError(err, trace.WithStackTrace(true))
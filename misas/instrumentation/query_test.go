@@ -0,0 +1,84 @@
+package instrumentation
+
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/query"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+type findUnitTestQuery struct {
+}
+
+func (f findUnitTestQuery) TypeName() query.PayloadTypeName {
+	return "unit_test.find"
+}
+
+// recordSpans registers an in memory span recorder as the global tracer provider for the duration of a test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+	return recorder
+}
+
+func TestOpenTelemetryQueryBusDecorator_Send(t *testing.T) {
+	recorder := recordSpans(t)
+
+	bus := &OpenTelemetryQueryBusDecorator{Bus: query.NewInMemoryBus(), Tracer: NewSystemTracer()}
+	bus.RegisterHandler(findUnitTestQuery{}.TypeName(), query.HandlerFunc(func(ctx context.Context, q query.Query) (any, error) {
+		return "result", nil
+	}))
+
+	result, err := bus.Send(context.Background(), query.New(findUnitTestQuery{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "result", result)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+
+	// The handler span ends first, as a child of the send span.
+	handleSpan, sendSpan := spans[0], spans[1]
+	assert.Equal(t, "queryBus.Send", sendSpan.Name())
+	assert.Contains(t, sendSpan.Attributes(), attribute.String("query.typeName", "unit_test.find"))
+	assert.Equal(t, codes.Unset, sendSpan.Status().Code)
+
+	assert.Equal(t, "unit_test.find.handle", handleSpan.Name())
+	assert.Contains(t, handleSpan.Attributes(), attribute.String("query.typeName", "unit_test.find"))
+	assert.Equal(t, sendSpan.SpanContext().SpanID(), handleSpan.Parent().SpanID())
+}
+
+func TestOpenTelemetryQueryBusDecorator_Send_Error(t *testing.T) {
+	recorder := recordSpans(t)
+
+	bus := &OpenTelemetryQueryBusDecorator{Bus: query.NewInMemoryBus(), Tracer: NewSystemTracer()}
+	bus.RegisterHandler(findUnitTestQuery{}.TypeName(), query.HandlerFunc(func(ctx context.Context, q query.Query) (any, error) {
+		return nil, errors.New("query failed")
+	}))
+
+	_, err := bus.Send(context.Background(), query.New(findUnitTestQuery{}))
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+
+	for _, span := range spans {
+		assert.Equal(t, codes.Error, span.Status().Code, span.Name())
+		if assert.NotEmpty(t, span.Events(), span.Name()) {
+			assert.Equal(t, "exception", span.Events()[0].Name)
+		}
+	}
+}
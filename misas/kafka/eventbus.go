@@ -0,0 +1,190 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
+)
+
+// DefaultConsumerGroupID is the consumer group used by a KafkaEventBus when none is specified.
+const DefaultConsumerGroupID = "misas"
+
+// Message represents a record published to or consumed from a Kafka topic.
+type Message struct {
+	Topic string
+	Key   []byte
+	Value []byte
+}
+
+// MessageHandler handles a message consumed from a topic.
+type MessageHandler func(ctx context.Context, m Message) error
+
+// Producer is an abstraction over a Kafka client publishing messages to topics.
+type Producer interface {
+	// Produce publishes a message to its topic.
+	Produce(ctx context.Context, m Message) error
+}
+
+// Consumer is an abstraction over a Kafka client consuming messages from topics as part of a consumer group.
+// The lifecycle of the consumption (connecting, polling and committing offsets) is the responsibility of the implementation.
+type Consumer interface {
+	// Subscribe registers a handler for the messages of a topic consumed as part of a consumer group.
+	Subscribe(topic string, groupID string, h MessageHandler)
+}
+
+// TopicResolver resolves the topic to which the events of a given type are published.
+type TopicResolver func(t event.PayloadTypeName) string
+
+// DefaultTopicResolver publishes the events to a topic named after their type name.
+func DefaultTopicResolver(t event.PayloadTypeName) string {
+	return string(t)
+}
+
+// messageValue is the serialized representation of an event in a Message.
+type messageValue struct {
+	ID       store.EventID           `json:"id"`
+	TypeName event.PayloadTypeName   `json:"typeName"`
+	Payload  store.DescriptorPayload `json:"payload"`
+	Metadata misas.Metadata          `json:"metadata"`
+}
+
+// KafkaEventBus is an implementation of an event.Bus distributing the events across service instances through Kafka topics.
+// Events are serialized using a store.EventConverter and published to a topic resolved from their type name, while
+// handlers consume from the same topic as part of a consumer group.
+type KafkaEventBus struct {
+	producer       Producer
+	consumer       Consumer
+	eventConverter *store.EventConverter
+	groupID        string
+	topicResolver  TopicResolver
+}
+
+type KafkaEventBusOption func(b *KafkaEventBus)
+
+// WithConsumerGroupID allows specifying the consumer group from which the handlers consume.
+func WithConsumerGroupID(groupID string) KafkaEventBusOption {
+	return func(b *KafkaEventBus) {
+		b.groupID = groupID
+	}
+}
+
+// WithTopicResolver allows specifying how the topic of an event type is resolved.
+func WithTopicResolver(r TopicResolver) KafkaEventBusOption {
+	return func(b *KafkaEventBus) {
+		b.topicResolver = r
+	}
+}
+
+func NewKafkaEventBus(producer Producer, consumer Consumer, eventConverter *store.EventConverter, opts ...KafkaEventBusOption) *KafkaEventBus {
+	b := &KafkaEventBus{
+		producer:       producer,
+		consumer:       consumer,
+		eventConverter: eventConverter,
+		groupID:        DefaultConsumerGroupID,
+		topicResolver:  DefaultTopicResolver,
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Send publishes the serialized event to the topic of its type, keyed by the ID of its stream.
+func (b *KafkaEventBus) Send(ctx context.Context, e event.Event) error {
+	descriptor, err := b.eventConverter.ConvertEventToDescriptor(e)
+	if err != nil {
+		return errors.Wrapf(err, "failed sending event \"%s\"", e.Payload.TypeName())
+	}
+
+	value, err := json.Marshal(messageValue{
+		ID:       descriptor.ID,
+		TypeName: descriptor.TypeName,
+		Payload:  descriptor.Payload,
+		// Descriptors do not carry the metadata of events, which is left to the store recording them.
+		Metadata: e.Metadata,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed sending event \"%s\"", e.Payload.TypeName())
+	}
+
+	if err := b.producer.Produce(ctx, Message{
+		Topic: b.topicResolver(descriptor.TypeName),
+		Key:   messageKey(e, descriptor),
+		Value: value,
+	}); err != nil {
+		return errors.Wrapf(err, "failed sending event \"%s\"", e.Payload.TypeName())
+	}
+
+	return nil
+}
+
+// messageKey returns the key of the message of an event. Kafka only preserves the order of messages sharing the same
+// key, so events are keyed by the ID of their stream, as set in their metadata when they are loaded from a
+// store.EventStore. Events sent without a stream fall back to their own ID.
+func messageKey(e event.Event, descriptor store.EventDescriptor) []byte {
+	if streamID, ok := e.Metadata.Get("streamId", nil).(string); ok && streamID != "" {
+		return []byte(streamID)
+	}
+	return []byte(descriptor.ID)
+}
+
+// RegisterHandler subscribes a handler to the topic of an event type as part of the consumer group of this bus.
+func (b *KafkaEventBus) RegisterHandler(t event.PayloadTypeName, h event.Handler) {
+	b.consumer.Subscribe(b.topicResolver(t), b.groupID, func(ctx context.Context, m Message) error {
+		e, err := b.deserialize(m)
+		if err != nil {
+			return err
+		}
+
+		// Topics can be shared by multiple event types depending on the TopicResolver.
+		if e.Payload.TypeName() != t {
+			return nil
+		}
+
+		if err := h.Handle(ctx, e); err != nil {
+			return errors.Wrapf(err, "failed handling event \"%s\"", t)
+		}
+
+		return nil
+	})
+}
+
+// deserialize converts a message back to an event.Event.
+func (b *KafkaEventBus) deserialize(m Message) (event.Event, error) {
+	var value messageValue
+	if err := json.Unmarshal(m.Value, &value); err != nil {
+		return event.Event{}, errors.Wrapf(err, "failed deserializing message from topic \"%s\"", m.Topic)
+	}
+
+	payload, err := b.eventConverter.ConvertDescriptorPayloadToEventPayload(value.Payload, value.TypeName)
+	if err != nil {
+		return event.Event{}, errors.Wrapf(err, "failed deserializing message from topic \"%s\"", m.Topic)
+	}
+
+	metadata := value.Metadata
+	if metadata == nil {
+		metadata = misas.Metadata{}
+	}
+	metadata.Set("id", string(value.ID))
+
+	return event.NewWithMetadata(payload, metadata), nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type unitTestPassedEvent struct {
+	TestName string `json:"testName"`
+}
+
+func (u unitTestPassedEvent) TypeName() event.PayloadTypeName {
+	return "unit_test.passed"
+}
+
+// mockBroker is an in memory Producer and Consumer recording the produced messages and delivering them to the subscribers.
+type mockBroker struct {
+	produced      []Message
+	subscriptions map[string]map[string]MessageHandler
+	err           error
+}
+
+func newMockBroker() *mockBroker {
+	return &mockBroker{subscriptions: map[string]map[string]MessageHandler{}}
+}
+
+func (b *mockBroker) Produce(_ context.Context, m Message) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.produced = append(b.produced, m)
+	return nil
+}
+
+func (b *mockBroker) Subscribe(topic string, groupID string, h MessageHandler) {
+	if _, found := b.subscriptions[topic]; !found {
+		b.subscriptions[topic] = map[string]MessageHandler{}
+	}
+	b.subscriptions[topic][groupID] = h
+}
+
+// deliver delivers the produced messages to the subscribers of their topic.
+func (b *mockBroker) deliver(ctx context.Context) error {
+	for _, m := range b.produced {
+		for _, h := range b.subscriptions[m.Topic] {
+			if err := h(ctx, m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestKafkaEventBus_Send(t *testing.T) {
+	broker := newMockBroker()
+	bus := NewKafkaEventBus(broker, broker, store.NewEventConverter())
+
+	err := bus.Send(context.Background(), event.NewWithMetadata(
+		unitTestPassedEvent{TestName: "TestKafkaEventBus_Send"},
+		misas.Metadata{"correlationId": "correlation-1"},
+	))
+	assert.NoError(t, err)
+
+	if assert.Len(t, broker.produced, 1) {
+		m := broker.produced[0]
+		assert.Equal(t, "unit_test.passed", m.Topic)
+		assert.NotEmpty(t, m.Key)
+
+		var value map[string]any
+		assert.NoError(t, json.Unmarshal(m.Value, &value))
+		assert.Equal(t, string(m.Key), value["id"])
+		assert.Equal(t, "unit_test.passed", value["typeName"])
+		assert.Equal(t, map[string]any{"testName": "TestKafkaEventBus_Send"}, value["payload"])
+		assert.Equal(t, map[string]any{"correlationId": "correlation-1"}, value["metadata"])
+	}
+
+	// Producer errors should be returned.
+	broker.err = errors.New("broker unavailable")
+	err = bus.Send(context.Background(), event.New(unitTestPassedEvent{}))
+	assert.Error(t, err)
+}
+
+func TestKafkaEventBus_Send_Key(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata misas.Metadata
+		wantKey  func(value map[string]any) string
+	}{
+		{
+			name:     "event of a stream",
+			metadata: misas.Metadata{"id": "event-1", "streamId": "account-1"},
+			wantKey:  func(map[string]any) string { return "account-1" },
+		},
+		{
+			name:     "event without a stream",
+			metadata: misas.Metadata{"id": "event-1"},
+			wantKey:  func(value map[string]any) string { return value["id"].(string) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := newMockBroker()
+			bus := NewKafkaEventBus(broker, broker, store.NewEventConverter())
+
+			assert.NoError(t, bus.Send(context.Background(), event.NewWithMetadata(unitTestPassedEvent{}, tt.metadata)))
+			if assert.Len(t, broker.produced, 1) {
+				var value map[string]any
+				assert.NoError(t, json.Unmarshal(broker.produced[0].Value, &value))
+				assert.Equal(t, tt.wantKey(value), string(broker.produced[0].Key))
+			}
+		})
+	}
+}
+
+func TestKafkaEventBus_Send_WithTopicResolver(t *testing.T) {
+	broker := newMockBroker()
+	bus := NewKafkaEventBus(broker, broker, store.NewEventConverter(), WithTopicResolver(func(t event.PayloadTypeName) string {
+		return "events." + string(t)
+	}))
+
+	assert.NoError(t, bus.Send(context.Background(), event.New(unitTestPassedEvent{})))
+	if assert.Len(t, broker.produced, 1) {
+		assert.Equal(t, "events.unit_test.passed", broker.produced[0].Topic)
+	}
+}
+
+func TestKafkaEventBus_RegisterHandler(t *testing.T) {
+	broker := newMockBroker()
+	converter := store.NewEventConverter()
+	converter.RegisterEventPayload(unitTestPassedEvent{})
+	bus := NewKafkaEventBus(broker, broker, converter, WithConsumerGroupID("unit_tests"))
+
+	var received []event.Event
+	bus.RegisterHandler(unitTestPassedEvent{}.TypeName(), event.HandlerFunc(func(ctx context.Context, e event.Event) error {
+		received = append(received, e)
+		return nil
+	}))
+	assert.Contains(t, broker.subscriptions["unit_test.passed"], "unit_tests")
+
+	assert.NoError(t, bus.Send(context.Background(), event.NewWithMetadata(
+		unitTestPassedEvent{TestName: "TestKafkaEventBus_RegisterHandler"},
+		misas.Metadata{"correlationId": "correlation-1"},
+	)))
+	assert.NoError(t, broker.deliver(context.Background()))
+
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, unitTestPassedEvent{TestName: "TestKafkaEventBus_RegisterHandler"}, received[0].Payload)
+		assert.Equal(t, "correlation-1", received[0].Metadata.Get("correlationId", nil))
+		assert.Equal(t, string(broker.produced[0].Key), received[0].Metadata.Get("id", nil))
+	}
+}
+
+func TestKafkaEventBus_RegisterHandler_HandlerError(t *testing.T) {
+	broker := newMockBroker()
+	converter := store.NewEventConverter()
+	converter.RegisterEventPayload(unitTestPassedEvent{})
+	bus := NewKafkaEventBus(broker, broker, converter)
+
+	bus.RegisterHandler(unitTestPassedEvent{}.TypeName(), event.HandlerFunc(func(ctx context.Context, e event.Event) error {
+		return errors.New("handler failed")
+	}))
+
+	assert.NoError(t, bus.Send(context.Background(), event.New(unitTestPassedEvent{})))
+	assert.Error(t, broker.deliver(context.Background()))
+}
@@ -17,7 +17,9 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"github.com/morebec/misas-go/misas/event/processing"
+	"github.com/morebec/misas-go/misas/event/store"
 	"github.com/pkg/errors"
 )
 
@@ -37,8 +39,11 @@ CREATE TABLE IF NOT EXISTS checkpoints
 (
     id        VARCHAR(255) NOT NULL PRIMARY KEY,
     stream_id VARCHAR(255) NOT NULL,
-    position  INTEGER NOT NULL
-);`
+    position  INTEGER NOT NULL,
+    positions JSONB NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE checkpoints ADD COLUMN IF NOT EXISTS positions JSONB NOT NULL DEFAULT '{}';`
 
 	_, err := cs.conn.ExecContext(ctx, createTableCheckpointSql)
 	if err != nil {
@@ -72,13 +77,22 @@ func (cs *CheckpointStore) Close() error {
 func (cs *CheckpointStore) Save(ctx context.Context, checkpoint processing.Checkpoint) error {
 
 	insertSql := `
-INSERT INTO checkpoints (id, stream_id, position) 
-VALUES($1, $2, $3)
-ON CONFLICT (id) DO UPDATE SET position = excluded.position
+INSERT INTO checkpoints (id, stream_id, position, positions) 
+VALUES($1, $2, $3, $4)
+ON CONFLICT (id) DO UPDATE SET position = excluded.position, positions = excluded.positions
 ;
 `
 
-	_, err := cs.conn.ExecContext(ctx, insertSql, checkpoint.ID, checkpoint.StreamID, checkpoint.Position)
+	positions := checkpoint.Positions
+	if positions == nil {
+		positions = map[store.StreamID]store.Position{}
+	}
+	positionsJson, err := json.Marshal(positions)
+	if err != nil {
+		return errors.Wrapf(err, "failed storing checkpoint \"%s\" for stream \"%s\"", checkpoint.ID, checkpoint.StreamID)
+	}
+
+	_, err = cs.execer(ctx).ExecContext(ctx, insertSql, checkpoint.ID, checkpoint.StreamID, checkpoint.Position, positionsJson)
 	if err != nil {
 		return errors.Wrapf(err,
 			"failed storing checkpoint \"%s\" for stream \"%s\"",
@@ -90,9 +104,22 @@ ON CONFLICT (id) DO UPDATE SET position = excluded.position
 	return nil
 }
 
+// execer returns the *sql.Tx carried by ctx when a processing.Processor saves its checkpoint in a batch transaction,
+// otherwise the connection of this store.
+func (cs *CheckpointStore) execer(ctx context.Context) interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+} {
+	if tx, ok := processing.TxFromContext(ctx); ok {
+		if sqlTx, ok := tx.(*sql.Tx); ok {
+			return sqlTx
+		}
+	}
+	return cs.conn
+}
+
 func (cs *CheckpointStore) FindById(ctx context.Context, id processing.CheckpointID) (*processing.Checkpoint, error) {
 	selecSql := `
-SELECT id, stream_id, position FROM checkpoints
+SELECT id, stream_id, position, positions FROM checkpoints
 WHERE id = $1;
 `
 	row := cs.conn.QueryRowContext(ctx, selecSql, id)
@@ -101,14 +128,23 @@ WHERE id = $1;
 	}
 
 	checkpoint := &processing.Checkpoint{}
+	var positionsJson []byte
 	if err := row.Scan(
 		&checkpoint.ID,
 		&checkpoint.StreamID,
 		&checkpoint.Position,
+		&positionsJson,
 	); err != nil {
 		return nil, errors.Wrapf(row.Err(), "failed retrieving checkpoint \"%s\"", id)
 	}
 
+	if err := json.Unmarshal(positionsJson, &checkpoint.Positions); err != nil {
+		return nil, errors.Wrapf(err, "failed retrieving checkpoint \"%s\"", id)
+	}
+	if len(checkpoint.Positions) == 0 {
+		checkpoint.Positions = nil
+	}
+
 	return checkpoint, nil
 
 }
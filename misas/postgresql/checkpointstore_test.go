@@ -17,6 +17,7 @@ package postgresql
 import (
 	"context"
 	"github.com/morebec/misas-go/misas/event/processing"
+	"github.com/morebec/misas-go/misas/event/store"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -78,3 +79,22 @@ func TestCheckpointStore_Store(t *testing.T) {
 	err = store.Save(context.Background(), checkpoint)
 	assert.NoError(t, err)
 }
+
+func TestCheckpointStore_SaveMultiStreamPositions(t *testing.T) {
+	checkpointStore := buildCheckpointStore()
+
+	checkpoint := processing.Checkpoint{
+		ID:       "multi",
+		StreamID: "$all",
+		Positions: map[store.StreamID]store.Position{
+			"users":    3,
+			"accounts": 7,
+		},
+	}
+	err := checkpointStore.Save(context.Background(), checkpoint)
+	assert.NoError(t, err)
+
+	found, err := checkpointStore.FindById(context.Background(), "multi")
+	assert.NoError(t, err)
+	assert.Equal(t, &checkpoint, found)
+}
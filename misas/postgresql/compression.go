@@ -0,0 +1,55 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// compressPayload gzip-compresses the JSON representation of a payload, so that it can be stored in the compressed_data
+// column of events.
+func compressPayload(payloadJSON []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(payloadJSON); err != nil {
+		return nil, errors.Wrap(err, "failed compressing payload")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed compressing payload")
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// decompressPayload returns the JSON representation of a payload compressed with compressPayload.
+func decompressPayload(compressed []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decompressing payload")
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	payloadJSON, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decompressing payload")
+	}
+
+	return payloadJSON, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestCompressPayload(t *testing.T) {
+	payloadJSON := []byte(`{"TestName":"` + strings.Repeat("TestCompressPayload", 1000) + `"}`)
+
+	compressed, err := compressPayload(payloadJSON)
+	assert.NoError(t, err)
+	assert.Less(t, len(compressed), len(payloadJSON))
+
+	decompressed, err := decompressPayload(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, payloadJSON, decompressed)
+}
+
+func TestDecompressPayload_Uncompressed(t *testing.T) {
+	_, err := decompressPayload([]byte(`{"TestName":"TestDecompressPayload_Uncompressed"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package postgresql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// documentQueryOperator represents the comparison performed by a predicate of a DocumentQuery.
+type documentQueryOperator string
+
+const (
+	documentQueryEq   documentQueryOperator = "="
+	documentQueryNeq  documentQueryOperator = "<>"
+	documentQueryGt   documentQueryOperator = ">"
+	documentQueryLt   documentQueryOperator = "<"
+	documentQueryIn   documentQueryOperator = "IN"
+	documentQueryLike documentQueryOperator = "LIKE"
+)
+
+// documentQueryPredicate represents the comparison of a field of the documents with some values.
+type documentQueryPredicate struct {
+	path     []string
+	operator documentQueryOperator
+	values   []any
+}
+
+// DocumentQuery is a builder of the conditions that documents must satisfy to be returned by DocumentStore.FindBy,
+// DocumentStore.FindOneBy or DocumentStore.DeleteBy, which avoids writing raw SQL fragments. It is started with Where:
+//
+//	query, args := Where("status").Eq("active").And("customer.age").Gt(30).Build()
+//	docs, err := ds.FindBy(ctx, "orders", query, args...)
+//
+// Fields are the path of a value in the documents, where nested fields are separated by dots. Values are compared as
+// JSON values, so that numbers are compared numerically and strings lexically. A document without the field never
+// matches a predicate on it. A DocumentQuery is immutable, each call returning a new one.
+type DocumentQuery struct {
+	predicates []documentQueryPredicate
+}
+
+// DocumentQueryField represents a field of a DocumentQuery awaiting the comparison it must satisfy.
+type DocumentQueryField struct {
+	query DocumentQuery
+	path  []string
+}
+
+// Where starts a DocumentQuery with a condition on a given field.
+func Where(field string) DocumentQueryField {
+	return DocumentQuery{}.And(field)
+}
+
+// And adds a condition on a given field to this query.
+func (q DocumentQuery) And(field string) DocumentQueryField {
+	return DocumentQueryField{query: q, path: strings.Split(field, ".")}
+}
+
+// Eq requires the field to be equal to a given value.
+func (f DocumentQueryField) Eq(v any) DocumentQuery {
+	return f.compare(documentQueryEq, v)
+}
+
+// Neq requires the field to be different from a given value.
+func (f DocumentQueryField) Neq(v any) DocumentQuery {
+	return f.compare(documentQueryNeq, v)
+}
+
+// Gt requires the field to be greater than a given value.
+func (f DocumentQueryField) Gt(v any) DocumentQuery {
+	return f.compare(documentQueryGt, v)
+}
+
+// Lt requires the field to be lower than a given value.
+func (f DocumentQueryField) Lt(v any) DocumentQuery {
+	return f.compare(documentQueryLt, v)
+}
+
+// In requires the field to be equal to one of some given values. No document matches an empty list of values.
+func (f DocumentQueryField) In(values ...any) DocumentQuery {
+	return f.compare(documentQueryIn, values...)
+}
+
+// Like requires the text of the field to match a given SQL LIKE pattern, where % matches any sequence of characters
+// and _ any single character.
+func (f DocumentQueryField) Like(pattern string) DocumentQuery {
+	return f.compare(documentQueryLike, pattern)
+}
+
+func (f DocumentQueryField) compare(operator documentQueryOperator, values ...any) DocumentQuery {
+	// The predicates are copied so that queries sharing a common prefix do not share their predicates.
+	predicates := make([]documentQueryPredicate, len(f.query.predicates), len(f.query.predicates)+1)
+	copy(predicates, f.query.predicates)
+
+	return DocumentQuery{predicates: append(predicates, documentQueryPredicate{
+		path:     f.path,
+		operator: operator,
+		values:   values,
+	})}
+}
+
+// Build returns the SQL condition of this query and its positional arguments, numbered from $1.
+// A query without predicates matches every document.
+func (q DocumentQuery) Build() (string, []any) {
+	if len(q.predicates) == 0 {
+		return "TRUE", nil
+	}
+
+	var clauses []string
+	var args []any
+	param := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, p := range q.predicates {
+		// PostgreSQL cannot determine the type of unused parameters, so the path is only bound when it is compared.
+		if p.operator == documentQueryIn && len(p.values) == 0 {
+			clauses = append(clauses, "FALSE")
+			continue
+		}
+		path := param(pq.Array(p.path))
+
+		switch p.operator {
+		case documentQueryLike:
+			clauses = append(clauses, fmt.Sprintf("data #>> %s LIKE %s", path, param(p.values[0])))
+		case documentQueryIn:
+			var params []string
+			for _, v := range p.values {
+				params = append(params, param(documentQueryValue{v})+"::jsonb")
+			}
+			clauses = append(clauses, fmt.Sprintf("data #> %s IN (%s)", path, strings.Join(params, ", ")))
+		default:
+			clauses = append(clauses, fmt.Sprintf("data #> %s %s %s::jsonb", path, p.operator, param(documentQueryValue{p.values[0]})))
+		}
+	}
+
+	return strings.Join(clauses, " AND "), args
+}
+
+// documentQueryValue is a value of a DocumentQuery sent to PostgreSQL as JSON.
+type documentQueryValue struct {
+	v any
+}
+
+func (v documentQueryValue) Value() (driver.Value, error) {
+	data, err := json.Marshal(v.v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed encoding query value %v", v.v)
+	}
+	return string(data), nil
+}
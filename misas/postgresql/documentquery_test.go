@@ -0,0 +1,225 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestDocumentQuery_Build(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        DocumentQuery
+		expectedSql  string
+		expectedArgs []any
+	}{
+		{
+			name:         "no predicates",
+			query:        DocumentQuery{},
+			expectedSql:  "TRUE",
+			expectedArgs: nil,
+		},
+		{
+			name:         "eq",
+			query:        Where("status").Eq("active"),
+			expectedSql:  "data #> $1 = $2::jsonb",
+			expectedArgs: []any{pq.Array([]string{"status"}), documentQueryValue{"active"}},
+		},
+		{
+			name:         "neq",
+			query:        Where("status").Neq("active"),
+			expectedSql:  "data #> $1 <> $2::jsonb",
+			expectedArgs: []any{pq.Array([]string{"status"}), documentQueryValue{"active"}},
+		},
+		{
+			name:         "gt",
+			query:        Where("age").Gt(30),
+			expectedSql:  "data #> $1 > $2::jsonb",
+			expectedArgs: []any{pq.Array([]string{"age"}), documentQueryValue{30}},
+		},
+		{
+			name:         "lt",
+			query:        Where("age").Lt(30),
+			expectedSql:  "data #> $1 < $2::jsonb",
+			expectedArgs: []any{pq.Array([]string{"age"}), documentQueryValue{30}},
+		},
+		{
+			name:         "in",
+			query:        Where("status").In("active", "pending"),
+			expectedSql:  "data #> $1 IN ($2::jsonb, $3::jsonb)",
+			expectedArgs: []any{pq.Array([]string{"status"}), documentQueryValue{"active"}, documentQueryValue{"pending"}},
+		},
+		{
+			name:         "in without values",
+			query:        Where("status").In(),
+			expectedSql:  "FALSE",
+			expectedArgs: nil,
+		},
+		{
+			name:         "like",
+			query:        Where("username").Like("user_%"),
+			expectedSql:  "data #>> $1 LIKE $2",
+			expectedArgs: []any{pq.Array([]string{"username"}), "user_%"},
+		},
+		{
+			name:         "in without values followed by another predicate",
+			query:        Where("status").In().And("age").Gt(30),
+			expectedSql:  "FALSE AND data #> $1 > $2::jsonb",
+			expectedArgs: []any{pq.Array([]string{"age"}), documentQueryValue{30}},
+		},
+		{
+			name:        "and with nested field",
+			query:       Where("status").Eq("active").And("customer.age").Gt(30),
+			expectedSql: "data #> $1 = $2::jsonb AND data #> $3 > $4::jsonb",
+			expectedArgs: []any{
+				pq.Array([]string{"status"}),
+				documentQueryValue{"active"},
+				pq.Array([]string{"customer", "age"}),
+				documentQueryValue{30},
+			},
+		},
+		{
+			name:         "field with quote",
+			query:        Where("it's").Eq("x' OR '1'='1"),
+			expectedSql:  "data #> $1 = $2::jsonb",
+			expectedArgs: []any{pq.Array([]string{"it's"}), documentQueryValue{"x' OR '1'='1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.query.Build()
+			assert.Equal(t, tt.expectedSql, sql)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestDocumentQuery_IsImmutable(t *testing.T) {
+	base := Where("status").Eq("active")
+	byAge := base.And("age").Gt(30)
+	byName := base.And("username").Like("user_%")
+
+	sql, _ := base.Build()
+	assert.Equal(t, "data #> $1 = $2::jsonb", sql)
+
+	sql, _ = byAge.Build()
+	assert.Equal(t, "data #> $1 = $2::jsonb AND data #> $3 > $4::jsonb", sql)
+
+	sql, _ = byName.Build()
+	assert.Equal(t, "data #> $1 = $2::jsonb AND data #>> $3 LIKE $4", sql)
+}
+
+func TestDocumentQueryValue_Value(t *testing.T) {
+	v, err := documentQueryValue{map[string]any{"age": 30}}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"age":30}`, v)
+
+	_, err = documentQueryValue{make(chan int)}.Value()
+	assert.Error(t, err)
+}
+
+func TestDocumentStore_FindBy_DocumentQuery(t *testing.T) {
+	type customer struct {
+		Age int `json:"age"`
+	}
+	type user struct {
+		Id       string   `json:"id"`
+		Username string   `json:"username"`
+		Status   string   `json:"status"`
+		Customer customer `json:"customer"`
+	}
+
+	ctx := context.Background()
+	ds := buildDocumentStore()
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, ctx, "unit_test")
+
+	seeds := []user{
+		{Id: "0", Username: "alice", Status: "active", Customer: customer{Age: 9}},
+		{Id: "1", Username: "bob", Status: "active", Customer: customer{Age: 30}},
+		{Id: "2", Username: "bobby", Status: "active", Customer: customer{Age: 42}},
+		{Id: "3", Username: "carol", Status: "pending", Customer: customer{Age: 100}},
+		{Id: "4", Username: "dave", Status: "disabled", Customer: customer{Age: 55}},
+	}
+	var docs []Document
+	for i, u := range seeds {
+		doc, err := NewDocument(strconv.Itoa(i), u)
+		if err != nil {
+			panic(err)
+		}
+		docs = append(docs, doc)
+	}
+	assert.NoError(t, ds.InsertMany(ctx, "unit_test", docs))
+
+	tests := []struct {
+		name        string
+		query       DocumentQuery
+		expectedIDs []string
+	}{
+		{name: "eq", query: Where("status").Eq("active"), expectedIDs: []string{"0", "1", "2"}},
+		{name: "neq", query: Where("status").Neq("active"), expectedIDs: []string{"3", "4"}},
+		{name: "gt compares numbers numerically", query: Where("customer.age").Gt(30), expectedIDs: []string{"2", "3", "4"}},
+		{name: "lt compares numbers numerically", query: Where("customer.age").Lt(30), expectedIDs: []string{"0"}},
+		{name: "in", query: Where("status").In("pending", "disabled"), expectedIDs: []string{"3", "4"}},
+		{name: "in without values", query: Where("status").In(), expectedIDs: nil},
+		{name: "like", query: Where("username").Like("bob%"), expectedIDs: []string{"1", "2"}},
+		{name: "and", query: Where("status").Eq("active").And("customer.age").Gt(10), expectedIDs: []string{"1", "2"}},
+		{name: "missing field", query: Where("unknown").Neq("active"), expectedIDs: nil},
+		{name: "injection attempt", query: Where("status").Eq("active' OR '1'='1"), expectedIDs: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := tt.query.Build()
+			found, err := ds.Collection("unit_test").FindBy(ctx, query+" ORDER BY id", args...)
+			assert.NoError(t, err)
+
+			var ids []string
+			for _, d := range found {
+				ids = append(ids, d.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestCollection_DocumentQuery(t *testing.T) {
+	type user struct {
+		Username string `json:"username"`
+		Enabled  bool   `json:"enabled"`
+	}
+
+	ctx := context.Background()
+	ds := buildDocumentStore()
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, ctx, "unit_test")
+
+	collection := ds.Collection("unit_test")
+	for i, u := range []user{{Username: "alice", Enabled: true}, {Username: "bob", Enabled: true}, {Username: "carol", Enabled: false}} {
+		doc, err := NewDocument(strconv.Itoa(i), u)
+		if err != nil {
+			panic(err)
+		}
+		assert.NoError(t, collection.InsertOne(ctx, doc))
+	}
+
+	query, args := Where("username").Eq("bob").Build()
+	doc, err := collection.FindOneBy(ctx, query, args...)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", doc.ID)
+
+	query, args = Where("enabled").Eq(true).Build()
+	docs, err := collection.FindBy(ctx, query, args...)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+
+	assert.NoError(t, collection.DeleteBy(ctx, query, args...))
+	docs, err = collection.FindBy(ctx, "TRUE")
+	assert.NoError(t, err)
+	if assert.Len(t, docs, 1) {
+		assert.Equal(t, "2", docs[0].ID)
+	}
+}
@@ -2,10 +2,16 @@ package postgresql
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"strings"
+	"unicode/utf8"
 )
 
 // Document represents a document to be stored in the DocumentStore.
@@ -44,6 +50,11 @@ type RecordedDocument struct {
 	data json.RawMessage
 }
 
+// MarshalJSON returns the data of the document.
+func (d RecordedDocument) MarshalJSON() ([]byte, error) {
+	return d.data, nil
+}
+
 // Unmarshall the document to a value.
 func (d RecordedDocument) Unmarshall(v any) error {
 	if err := json.Unmarshal(d.data, v); err != nil {
@@ -58,10 +69,57 @@ func (d RecordedDocument) Unmarshall(v any) error {
 type DocumentStore struct {
 	connectionString string
 	conn             *sql.DB
+	options          DocumentStoreOptions
+
+	// openDB opens the database using a connection string, an optional dialer and an optional logger, it can be
+	// replaced in tests.
+	openDB func(connectionString string, dialer pq.Dialer, logger *zap.Logger) (*sql.DB, error)
+}
+
+// DocumentStoreOptions represents the options of a DocumentStore.
+type DocumentStoreOptions struct {
+	// ConnectionPool are the settings of the pool of connections to the database.
+	ConnectionPool ConnectionPoolOptions
+
+	// Logger is the logger used to log the queries executed against the database at the debug level, when specified.
+	Logger *zap.Logger
+}
+
+type DocumentStoreOption func(options *DocumentStoreOptions)
+
+// WithDocumentStoreConnectionPool configures the pool of connections the DocumentStore uses to query the database.
+func WithDocumentStoreConnectionPool(pool ConnectionPoolOptions) DocumentStoreOption {
+	return func(options *DocumentStoreOptions) {
+		options.ConnectionPool = pool
+	}
+}
+
+// WithDocumentStoreLogger makes the DocumentStore log each query it executes at the debug level, along with its
+// duration and its arguments, textual and binary arguments being redacted.
+func WithDocumentStoreLogger(logger *zap.Logger) DocumentStoreOption {
+	return func(options *DocumentStoreOptions) {
+		options.Logger = logger
+	}
 }
 
 func NewDocumentStore(connectionString string) *DocumentStore {
-	return &DocumentStore{connectionString: connectionString}
+	return NewDocumentStoreWithOptions(connectionString)
+}
+
+// NewDocumentStoreWithOptions creates a new DocumentStore using a given set of options.
+func NewDocumentStoreWithOptions(connectionString string, opts ...DocumentStoreOption) *DocumentStore {
+	options := DocumentStoreOptions{
+		ConnectionPool: DefaultConnectionPoolOptions(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &DocumentStore{
+		connectionString: connectionString,
+		options:          options,
+		openDB:           openDB,
+	}
 }
 
 // Open a connection to the DocumentStore.
@@ -69,10 +127,11 @@ func (ds *DocumentStore) Open(ctx context.Context) error {
 	operationFailed := func(err error) error {
 		return errors.Wrap(err, "failed opening connection to document store")
 	}
-	conn, err := sql.Open("postgres", ds.connectionString)
+	conn, err := ds.openDB(ds.connectionString, nil, ds.options.Logger)
 	if err != nil {
 		return operationFailed(err)
 	}
+	ds.options.ConnectionPool.applyTo(conn)
 	ds.conn = conn
 
 	if err = ds.conn.PingContext(ctx); err != nil {
@@ -86,6 +145,20 @@ func (ds *DocumentStore) Open(ctx context.Context) error {
 	return nil
 }
 
+// Ping checks that the database of the DocumentStore can be reached, for instance to serve readiness probes.
+// It returns a StoreNotOpenError if the DocumentStore was not opened.
+func (ds *DocumentStore) Ping(ctx context.Context) error {
+	if ds.conn == nil {
+		return NewStoreNotOpenError("document store")
+	}
+
+	if err := ds.conn.PingContext(ctx); err != nil {
+		return errors.Wrap(err, "failed pinging document store")
+	}
+
+	return nil
+}
+
 // Close the connection to the DocumentStore.
 func (ds *DocumentStore) Close() error {
 	if err := ds.conn.Close(); err != nil {
@@ -104,6 +177,36 @@ func (ds *DocumentStore) BeginTransaction(ctx context.Context) (*sql.Tx, error)
 	return ds.conn.BeginTx(ctx, nil)
 }
 
+// WithTransaction runs a function in a transaction, which is committed if the function returns nil and rolled back
+// otherwise, including when it panics. The operations performed through the CollectionTx passed to the function are
+// part of the transaction.
+func (ds *DocumentStore) WithTransaction(ctx context.Context, fn func(tx *CollectionTx) error) (err error) {
+	tx, err := ds.BeginTransaction(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed beginning document store transaction")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&CollectionTx{tx: tx}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Wrapf(err, "failed rolling back document store transaction: %s", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed committing document store transaction")
+	}
+
+	return nil
+}
+
 // Collection returns a Collection object that acts as a scoped proxy dor the DocumentStore where operations apply to the collection.
 func (ds *DocumentStore) Collection(name string) Collection {
 	return Collection{
@@ -124,39 +227,98 @@ func (ds *DocumentStore) CreateCollection(ctx context.Context, collectionName st
 		return errors.Wrapf(err, "failed creating collection %s", collectionName)
 	}
 
+	if err := createCollection(ctx, tx, collectionName); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return errors.Wrapf(err, "failed creating collection %s", collectionName)
+		}
+		return errors.Wrapf(err, "failed creating collection %s", collectionName)
+	}
+
+	// commit transaction
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "failed creating collection %s", collectionName)
+	}
+
+	return nil
+}
+
+// createCollection creates the table of a collection, if it does not exist, and adds it to the list of collections using a transaction.
+func createCollection(ctx context.Context, tx *sql.Tx, collectionName string) error {
 	// Create Collection Table
 	createCollectionTableSql := fmt.Sprintf(`
 CREATE TABLE IF NOT EXISTS "%s" (
     id   VARCHAR(255) NOT NULL PRIMARY KEY,
 	data JSONB
 );`, collectionName)
-	if _, err := ds.conn.ExecContext(ctx, createCollectionTableSql); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return errors.Wrapf(err, "failed creating collection %s", collectionName)
-		}
-		return errors.Wrapf(err, "failed creating collection %s", collectionName)
+	if _, err := tx.ExecContext(ctx, createCollectionTableSql); err != nil {
+		return err
 	}
 
 	// Add to list of collections.
-	if _, err := ds.conn.ExecContext(
+	_, err := tx.ExecContext(
 		ctx,
 		"INSERT INTO document_store_collections (collection_name) VALUES ($1) ON CONFLICT DO NOTHING",
 		collectionName,
-	); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return errors.Wrapf(err, "failed creating collection %s", collectionName)
-		}
-		return errors.Wrapf(err, "failed creating collection %s", collectionName)
+	)
+
+	return err
+}
+
+// CreateCollectionWithIndexes creates a new collection in the document store along with an expression index
+// on each of the provided top level fields of its documents.
+func (ds *DocumentStore) CreateCollectionWithIndexes(ctx context.Context, collectionName string, indexedFields ...string) error {
+	if err := ds.CreateCollection(ctx, collectionName); err != nil {
+		return err
 	}
 
-	// commit transaction
-	if err := tx.Commit(); err != nil {
-		return errors.Wrapf(err, "failed creating collection %s", collectionName)
+	for _, field := range indexedFields {
+		if _, err := ds.conn.ExecContext(ctx, collectionIndexSql(collectionName, field)); err != nil {
+			return errors.Wrapf(err, "failed creating index on field %s of collection %s", field, collectionName)
+		}
 	}
 
 	return nil
 }
 
+// collectionIndexSql returns the SQL statement creating an expression index on a top level field of the documents of a collection.
+func collectionIndexSql(collectionName string, field string) string {
+	return fmt.Sprintf(
+		`CREATE INDEX IF NOT EXISTS %s ON %s ((data->>'%s'));`,
+		pq.QuoteIdentifier(collectionIndexName(collectionName, field)),
+		pq.QuoteIdentifier(collectionName),
+		strings.ReplaceAll(field, "'", "''"),
+	)
+}
+
+// maxIdentifierLength is the maximum length in bytes of an identifier in PostgreSQL, longer identifiers are truncated.
+const maxIdentifierLength = 63
+
+// collectionIndexName returns the name of the index of a field of a collection.
+func collectionIndexName(collectionName string, field string) string {
+	return shortenedIdentifier(fmt.Sprintf("%s_%s", collectionName, field), "_idx")
+}
+
+// shortenedIdentifier returns an identifier made of a name and a suffix.
+// Since PostgreSQL silently truncates identifiers longer than maxIdentifierLength, which could make distinct identifiers
+// collide, long names are shortened and suffixed with a hash of the full name so that they remain unique and deterministic.
+func shortenedIdentifier(name string, suffix string) string {
+	name = name + suffix
+	if len(name) <= maxIdentifierLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	suffix = "_" + hex.EncodeToString(sum[:])[:8] + suffix
+
+	prefix := name[:maxIdentifierLength-len(suffix)]
+	// Avoid cutting a multibyte character in half.
+	for len(prefix) > 0 && !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+
+	return prefix + suffix
+}
+
 // DeleteCollection deletes a collection from the document store.
 func (ds *DocumentStore) DeleteCollection(ctx context.Context, collectionName string) error {
 	if collectionName == "" {
@@ -198,6 +360,125 @@ func (ds *DocumentStore) DeleteCollection(ctx context.Context, collectionName st
 	return nil
 }
 
+// ReplaceCollection atomically replaces all the documents of a collection, for instance when rebuilding a projection.
+// The documents are inserted in a replacement table that is renamed over the collection within a transaction, so that
+// readers either see the previous documents or the new ones, but never a partial collection. If the collection does not
+// exist, it will be created. The indexes of the previous collection, such as the ones created by
+// CreateCollectionWithIndexes, are recreated on the replacement once its documents are inserted.
+func (ds *DocumentStore) ReplaceCollection(ctx context.Context, collectionName string, docs []Document) error {
+	if collectionName == "" {
+		return errors.New("cannot replace a collection named \"\"")
+	}
+
+	operationFailed := func(err error) error {
+		return errors.Wrapf(err, "failed replacing collection %s", collectionName)
+	}
+
+	tx, err := ds.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return operationFailed(err)
+	}
+
+	if err := ds.replaceCollection(ctx, tx, collectionName, docs); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return operationFailed(rollbackErr)
+		}
+		return operationFailed(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return operationFailed(err)
+	}
+
+	return nil
+}
+
+// replaceCollection fills a replacement table with documents and renames it over a collection using a transaction.
+func (ds *DocumentStore) replaceCollection(ctx context.Context, tx *sql.Tx, collectionName string, docs []Document) error {
+	replacementName := shortenedIdentifier(collectionName, "_replacement")
+	replacementPrimaryKeyName := shortenedIdentifier(replacementName, "_pkey")
+
+	createReplacementTableSql := fmt.Sprintf(`
+CREATE TABLE %s (
+    id   VARCHAR(255) NOT NULL,
+	data JSONB,
+	CONSTRAINT %s PRIMARY KEY (id)
+);`, pq.QuoteIdentifier(replacementName), pq.QuoteIdentifier(replacementPrimaryKeyName))
+	if _, err := tx.ExecContext(ctx, createReplacementTableSql); err != nil {
+		return err
+	}
+
+	insertQuery := fmt.Sprintf(`INSERT INTO %s (id, data) VALUES ($1, $2)`, pq.QuoteIdentifier(replacementName))
+	for _, d := range docs {
+		if _, err := tx.ExecContext(ctx, insertQuery, d.id, d.data); err != nil {
+			return err
+		}
+	}
+
+	indexDefinitions, err := collectionIndexDefinitions(ctx, tx, collectionName)
+	if err != nil {
+		return err
+	}
+
+	swapSql := fmt.Sprintf(`
+DROP TABLE IF EXISTS %[1]s;
+ALTER TABLE %[2]s RENAME TO %[1]s;
+ALTER TABLE %[1]s RENAME CONSTRAINT %[3]s TO %[4]s;
+`,
+		pq.QuoteIdentifier(collectionName),
+		pq.QuoteIdentifier(replacementName),
+		pq.QuoteIdentifier(replacementPrimaryKeyName),
+		pq.QuoteIdentifier(shortenedIdentifier(collectionName, "_pkey")),
+	)
+	if _, err := tx.ExecContext(ctx, swapSql); err != nil {
+		return err
+	}
+
+	// The indexes were dropped along with the previous collection, which freed their names. Since their definitions
+	// refer to the collection by name, they now apply to the replacement.
+	for _, definition := range indexDefinitions {
+		if _, err := tx.ExecContext(ctx, definition); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO document_store_collections (collection_name) VALUES ($1) ON CONFLICT DO NOTHING",
+		collectionName,
+	)
+
+	return err
+}
+
+// collectionIndexDefinitions returns the statements creating the indexes of a collection, other than its primary key,
+// using a transaction. A collection that does not exist has no indexes.
+func collectionIndexDefinitions(ctx context.Context, tx *sql.Tx, collectionName string) (definitions []string, err error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		"SELECT pg_get_indexdef(indexrelid) FROM pg_index WHERE indrelid = to_regclass($1) AND NOT indisprimary ORDER BY indexrelid",
+		pq.QuoteIdentifier(collectionName),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var definition string
+		if err := rows.Scan(&definition); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, rows.Err()
+}
+
 // InsertOne document into a collection.
 // If the collection does not exist, it will be created. if a document with the provided documentId already exists, will return an error.
 func (ds *DocumentStore) InsertOne(ctx context.Context, collectionName string, d Document) error {
@@ -213,15 +494,56 @@ func (ds *DocumentStore) InsertOne(ctx context.Context, collectionName string, d
 	return nil
 }
 
+// InsertDocuments inserts documents in a collection, so that the DocumentStore can be used to seed the read models
+// of test scenarios. The documents must be Document values.
+func (ds *DocumentStore) InsertDocuments(ctx context.Context, collectionName string, docs ...any) error {
+	var documents []Document
+	for _, doc := range docs {
+		d, ok := doc.(Document)
+		if !ok {
+			return errors.Errorf("failed inserting documents into collection %s, expected a postgresql.Document, got %T", collectionName, doc)
+		}
+		documents = append(documents, d)
+	}
+
+	return ds.InsertMany(ctx, collectionName, documents)
+}
+
+// FindDocument returns the RecordedDocument of a collection having a given ID and indicates if it was found, so that
+// the DocumentStore can be used by the expectations of test scenarios. A collection that does not exist contains no
+// documents.
+func (ds *DocumentStore) FindDocument(ctx context.Context, collectionName string, documentID string) (any, bool, error) {
+	docs, err := ds.FindBy(ctx, collectionName, "id = $1", documentID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "42P01" {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	if len(docs) == 0 {
+		return nil, false, nil
+	}
+
+	return docs[0], true, nil
+}
+
 // InsertMany documents in a collection.
+// If the collection does not exist, it will be created once for the whole batch.
 func (ds *DocumentStore) InsertMany(ctx context.Context, collectionName string, docs []Document) error {
+	if err := ds.CreateCollection(ctx, collectionName); err != nil {
+		return errors.Wrapf(err, "failed inserting documents")
+	}
+
 	tx, err := ds.BeginTransaction(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "failed inserting documents")
 	}
 
+	insertQuery := fmt.Sprintf(`INSERT INTO "%s" (id, data) VALUES ($1, $2)`, collectionName)
 	for _, d := range docs {
-		if err := ds.InsertOne(ctx, collectionName, d); err != nil {
+		if _, err := tx.ExecContext(ctx, insertQuery, d.id, d.data); err != nil {
 			if err := tx.Rollback(); err != nil {
 				return errors.Wrapf(err, "failed inserting documents")
 			}
@@ -355,7 +677,7 @@ func (ds *DocumentStore) FindOneBy(ctx context.Context, collectionName string, q
 	return docs[0], nil
 }
 
-// FindBy returns documents matching a certain query.
+// FindBy returns documents matching a certain query, which can be built with a DocumentQuery.
 func (ds *DocumentStore) FindBy(ctx context.Context, collectionName string, query string, args ...any) (documents []RecordedDocument, err error) {
 	rows, err := ds.conn.QueryContext(ctx, fmt.Sprintf(`SELECT id, data FROM "%s" WHERE %s`, collectionName, query), args...)
 	defer func(rows *sql.Rows) {
@@ -394,6 +716,54 @@ func (ds *DocumentStore) DeleteBy(ctx context.Context, collectionName string, qu
 	return nil
 }
 
+// GroupByCount returns the number of documents of a collection grouped by the value of a field.
+// The jsonPath is the path of the field in the documents, where nested fields are separated by dots (e.g. "customer.status").
+// Documents without the field are counted under the empty string. Returns an empty map if the collection does not exist.
+func (ds *DocumentStore) GroupByCount(ctx context.Context, collectionName string, jsonPath string) (counts map[string]int64, err error) {
+	counts = map[string]int64{}
+
+	var exists bool
+	if err := ds.conn.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM document_store_collections WHERE collection_name = $1)",
+		collectionName,
+	).Scan(&exists); err != nil {
+		return nil, errors.Wrapf(err, "failed counting documents of collection %s by %s", collectionName, jsonPath)
+	}
+	if !exists {
+		return counts, nil
+	}
+
+	rows, err := ds.conn.QueryContext(
+		ctx,
+		fmt.Sprintf(`SELECT data #>> $1, COUNT(*) FROM %s GROUP BY 1`, pq.QuoteIdentifier(collectionName)),
+		pq.Array(strings.Split(jsonPath, ".")),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed counting documents of collection %s by %s", collectionName, jsonPath)
+	}
+	defer func(rows *sql.Rows) {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = errors.Wrapf(closeErr, "failed counting documents of collection %s by %s", collectionName, jsonPath)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var value sql.NullString
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, errors.Wrapf(err, "failed counting documents of collection %s by %s", collectionName, jsonPath)
+		}
+		counts[value.String] += count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed counting documents of collection %s by %s", collectionName, jsonPath)
+	}
+
+	return counts, nil
+}
+
 func (ds *DocumentStore) processRows(rows *sql.Rows) ([]RecordedDocument, error) {
 	var docs []RecordedDocument
 	for rows.Next() {
@@ -443,6 +813,10 @@ func (c Collection) Create(ctx context.Context) error {
 	return c.ds.CreateCollection(ctx, c.name)
 }
 
+func (c Collection) CreateWithIndexes(ctx context.Context, indexedFields ...string) error {
+	return c.ds.CreateCollectionWithIndexes(ctx, c.name, indexedFields...)
+}
+
 func (c Collection) Delete(ctx context.Context) error {
 	return c.ds.DeleteCollection(ctx, c.name)
 }
@@ -455,6 +829,10 @@ func (c Collection) InsertMany(ctx context.Context, docs []Document) error {
 	return c.ds.InsertMany(ctx, c.name, docs)
 }
 
+func (c Collection) Replace(ctx context.Context, docs []Document) error {
+	return c.ds.ReplaceCollection(ctx, c.name, docs)
+}
+
 func (c Collection) UpsertOne(ctx context.Context, d Document) error {
 	return c.ds.UpsertOne(ctx, c.name, d)
 }
@@ -476,11 +854,11 @@ func (c Collection) FindOneByID(ctx context.Context, documentID string) (doc Rec
 }
 
 func (c Collection) FindOneBy(ctx context.Context, query string, args ...any) (doc RecordedDocument, err error) {
-	return c.ds.FindOneBy(ctx, c.name, query, args)
+	return c.ds.FindOneBy(ctx, c.name, query, args...)
 }
 
 func (c Collection) FindBy(ctx context.Context, query string, args ...any) (documents []RecordedDocument, err error) {
-	return c.ds.FindBy(ctx, c.name, query, args)
+	return c.ds.FindBy(ctx, c.name, query, args...)
 }
 
 func (c Collection) DeleteOneByID(ctx context.Context, documentID string) error {
@@ -488,5 +866,76 @@ func (c Collection) DeleteOneByID(ctx context.Context, documentID string) error
 }
 
 func (c Collection) DeleteBy(ctx context.Context, query string, args ...any) error {
-	return c.ds.DeleteBy(ctx, c.name, query, args)
+	return c.ds.DeleteBy(ctx, c.name, query, args...)
+}
+
+func (c Collection) GroupByCount(ctx context.Context, jsonPath string) (map[string]int64, error) {
+	return c.ds.GroupByCount(ctx, c.name, jsonPath)
+}
+
+// CollectionTx allows performing operations on the collections of a DocumentStore within a transaction (see DocumentStore.WithTransaction).
+type CollectionTx struct {
+	tx *sql.Tx
+}
+
+// Tx returns the underlying transaction, to perform operations not supported by the CollectionTx.
+func (ct *CollectionTx) Tx() *sql.Tx {
+	return ct.tx
+}
+
+// InsertOne document into a collection within the transaction.
+// If the collection does not exist, it will be created as part of the transaction. If a document with the provided id
+// already exists, will return an error.
+func (ct *CollectionTx) InsertOne(ctx context.Context, collectionName string, d Document) error {
+	if collectionName == "" {
+		return errors.New("cannot insert into a collection named \"\"")
+	}
+
+	if err := createCollection(ctx, ct.tx, collectionName); err != nil {
+		return errors.Wrapf(err, "failed inserting document into collection %s", collectionName)
+	}
+
+	insertQuery := fmt.Sprintf(`INSERT INTO "%s" (id, data) VALUES ($1, $2)`, collectionName)
+	if _, err := ct.tx.ExecContext(ctx, insertQuery, d.id, d.data); err != nil {
+		return errors.Wrapf(err, "failed inserting document into collection %s", collectionName)
+	}
+
+	return nil
+}
+
+// UpsertOne a document into a collection within the transaction.
+// If the collection does not exist, it will be created as part of the transaction.
+func (ct *CollectionTx) UpsertOne(ctx context.Context, collectionName string, d Document) error {
+	if collectionName == "" {
+		return errors.New("cannot upsert into a collection named \"\"")
+	}
+
+	if err := createCollection(ctx, ct.tx, collectionName); err != nil {
+		return errors.Wrapf(err, "failed upserting document into collection %s", collectionName)
+	}
+
+	upsertQuery := fmt.Sprintf(`
+INSERT INTO "%s" (id, data) 
+VALUES ($1, $2) 
+ON CONFLICT (id) DO UPDATE
+SET data = $2
+`, collectionName)
+	if _, err := ct.tx.ExecContext(ctx, upsertQuery, d.id, d.data); err != nil {
+		return errors.Wrapf(err, "failed upserting document into collection %s", collectionName)
+	}
+
+	return nil
+}
+
+// DeleteBy deletes documents from a collection by a certain query within the transaction.
+func (ct *CollectionTx) DeleteBy(ctx context.Context, collectionName string, query string, args ...any) error {
+	if collectionName == "" {
+		return errors.New("cannot delete from a collection named \"\"")
+	}
+
+	if _, err := ct.tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM "%s" WHERE %s`, collectionName, query), args...); err != nil {
+		return errors.Wrapf(err, "failed deleting document from collection %s", collectionName)
+	}
+
+	return nil
 }
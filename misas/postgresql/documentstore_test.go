@@ -2,10 +2,18 @@ package postgresql
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 	"strconv"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func buildDocumentStore() *DocumentStore {
@@ -16,6 +24,17 @@ func buildDocumentStore() *DocumentStore {
 	return ds
 }
 
+func TestDocumentStore_Ping(t *testing.T) {
+	ctx := context.Background()
+
+	ds := NewDocumentStore("postgres://postgres@localhost:5432/postgres?sslmode=disable")
+	err := ds.Ping(ctx)
+	assert.True(t, IsStoreNotOpenError(err))
+
+	assert.NoError(t, ds.Open(ctx))
+	assert.NoError(t, ds.Ping(ctx))
+}
+
 func TestDocumentStore_CreateCollection(t *testing.T) {
 	ds := buildDocumentStore()
 	if err := ds.CreateCollection(context.Background(), "test"); err != nil {
@@ -29,6 +48,85 @@ func TestDocumentStore_CreateCollection(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDocumentStore_CreateCollection_RollsBackOnFailure(t *testing.T) {
+	ds := buildDocumentStore()
+	ctx := context.Background()
+
+	// PostgreSQL truncates the name of the table, while the name being too long for the list of collections makes the insert fail.
+	collectionName := strings.Repeat("a", 256)
+	err := ds.CreateCollection(ctx, collectionName)
+	assert.Error(t, err)
+
+	var tableName *string
+	err = ds.Connection().QueryRowContext(ctx, "SELECT to_regclass($1)::text", pq.QuoteIdentifier(collectionName)).Scan(&tableName)
+	assert.NoError(t, err)
+	assert.Nil(t, tableName)
+}
+
+func TestDocumentStore_CreateCollectionWithIndexes(t *testing.T) {
+	ds := buildDocumentStore()
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, context.Background(), "unit_test")
+
+	err := ds.CreateCollectionWithIndexes(context.Background(), "unit_test", "username", "emailAddress")
+	assert.NoError(t, err)
+
+	rows, err := ds.Connection().QueryContext(context.Background(), `SELECT indexname FROM pg_indexes WHERE tablename = 'unit_test' ORDER BY indexname`)
+	assert.NoError(t, err)
+	defer rows.Close()
+
+	var indexNames []string
+	for rows.Next() {
+		var name string
+		assert.NoError(t, rows.Scan(&name))
+		indexNames = append(indexNames, name)
+	}
+	assert.Contains(t, indexNames, "unit_test_username_idx")
+	assert.Contains(t, indexNames, "unit_test_emailAddress_idx")
+
+	// Creating the collection again should not fail.
+	err = ds.CreateCollectionWithIndexes(context.Background(), "unit_test", "username")
+	assert.NoError(t, err)
+}
+
+func Test_collectionIndexSql(t *testing.T) {
+	assert.Equal(
+		t,
+		`CREATE INDEX IF NOT EXISTS "users_username_idx" ON "users" ((data->>'username'));`,
+		collectionIndexSql("users", "username"),
+	)
+	assert.Equal(
+		t,
+		`CREATE INDEX IF NOT EXISTS "users_it's_idx" ON "users" ((data->>'it''s'));`,
+		collectionIndexSql("users", "it's"),
+	)
+	assert.Equal(
+		t,
+		`CREATE INDEX IF NOT EXISTS "my""users_name_idx" ON "my""users" ((data->>'name'));`,
+		collectionIndexSql(`my"users`, "name"),
+	)
+}
+
+func Test_collectionIndexName(t *testing.T) {
+	assert.Equal(t, "users_username_idx", collectionIndexName("users", "username"))
+
+	longCollection := strings.Repeat("c", 40)
+	name := collectionIndexName(longCollection, strings.Repeat("f", 40))
+	assert.Len(t, name, maxIdentifierLength)
+	assert.True(t, strings.HasPrefix(name, longCollection))
+	assert.True(t, strings.HasSuffix(name, "_idx"))
+
+	// Shortened names should be deterministic and distinct for fields sharing a long prefix.
+	assert.Equal(t, name, collectionIndexName(longCollection, strings.Repeat("f", 40)))
+	assert.NotEqual(t, name, collectionIndexName(longCollection, strings.Repeat("f", 39)+"g"))
+
+	// Multibyte characters should not be cut in half.
+	name = collectionIndexName(strings.Repeat("é", 40), "field")
+	assert.True(t, utf8.ValidString(name))
+	assert.LessOrEqual(t, len(name), maxIdentifierLength)
+}
+
 func TestDocumentStore_DeleteCollection(t *testing.T) {
 	ds := buildDocumentStore()
 	if err := ds.CreateCollection(context.Background(), "test"); err != nil {
@@ -101,6 +199,171 @@ func TestDocumentStore_InsertMany(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestDocumentStore_InsertMany_CreatesCollectionOnce(t *testing.T) {
+	ctx := context.Background()
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	ds := NewDocumentStoreWithOptions("postgres://postgres@localhost:5432/postgres?sslmode=disable", WithDocumentStoreLogger(zap.New(core)))
+	if err := ds.Open(ctx); err != nil {
+		panic(err)
+	}
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, ctx, "unit_test")
+
+	docs := buildInsertManyDocuments(100)
+	logs.TakeAll()
+
+	err := ds.InsertMany(ctx, "unit_test", docs)
+	assert.NoError(t, err)
+
+	nbCreateTables := 0
+	nbInserts := 0
+	for _, entry := range logs.FilterMessage("postgresql.query").AllUntimed() {
+		query := entry.ContextMap()["query"].(string)
+		if strings.Contains(query, `CREATE TABLE IF NOT EXISTS "unit_test"`) {
+			nbCreateTables++
+		}
+		if strings.Contains(query, `INSERT INTO "unit_test"`) {
+			nbInserts++
+		}
+	}
+	assert.Equal(t, 1, nbCreateTables)
+	assert.Equal(t, 100, nbInserts)
+
+	var count int
+	err = ds.Connection().QueryRowContext(ctx, `SELECT COUNT(*) FROM "unit_test"`).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, count)
+}
+
+func BenchmarkDocumentStore_InsertMany(b *testing.B) {
+	ds := buildDocumentStore()
+	ctx := context.Background()
+	docs := buildInsertManyDocuments(100)
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if err := ds.DeleteCollection(ctx, "unit_test"); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := ds.InsertMany(ctx, "unit_test", docs); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.StopTimer()
+	_ = ds.DeleteCollection(ctx, "unit_test")
+}
+
+// buildInsertManyDocuments builds a given number of user documents to insert.
+func buildInsertManyDocuments(n int) []Document {
+	var docs []Document
+	for i := 0; i < n; i++ {
+		doc, err := NewDocument(strconv.Itoa(i), map[string]any{
+			"id":       strconv.Itoa(i),
+			"username": fmt.Sprintf("user_%d", i),
+		})
+		if err != nil {
+			panic(err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func TestDocumentStore_ReplaceCollection(t *testing.T) {
+	ds := buildDocumentStore()
+	ctx := context.Background()
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, ctx, "unit_test")
+
+	err := ds.InsertMany(ctx, "unit_test", buildInsertManyDocuments(3))
+	assert.NoError(t, err)
+
+	// Readers should either see the previous documents or the new ones.
+	done := make(chan struct{})
+	readerResult := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-done:
+				readerResult <- nil
+				return
+			default:
+			}
+
+			documents, err := ds.FindBy(ctx, "unit_test", "TRUE")
+			if err != nil {
+				readerResult <- err
+				return
+			}
+			if len(documents) != 3 && len(documents) != 50 {
+				readerResult <- fmt.Errorf("reader saw %d documents", len(documents))
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		err = ds.ReplaceCollection(ctx, "unit_test", buildInsertManyDocuments(50))
+		assert.NoError(t, err)
+	}
+	close(done)
+	assert.NoError(t, <-readerResult)
+
+	documents, err := ds.FindBy(ctx, "unit_test", "TRUE")
+	assert.NoError(t, err)
+	assert.Len(t, documents, 50)
+
+	// Replacing a collection that does not exist creates it.
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, ctx, "unit_test_replaced")
+	err = ds.ReplaceCollection(ctx, "unit_test_replaced", buildInsertManyDocuments(2))
+	assert.NoError(t, err)
+
+	documents, err = ds.Collection("unit_test_replaced").FindBy(ctx, "TRUE")
+	assert.NoError(t, err)
+	assert.Len(t, documents, 2)
+}
+
+func TestDocumentStore_ReplaceCollection_KeepsIndexes(t *testing.T) {
+	ds := buildDocumentStore()
+	ctx := context.Background()
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, ctx, "unit_test")
+
+	assert.NoError(t, ds.CreateCollectionWithIndexes(ctx, "unit_test", "username", "emailAddress"))
+
+	indexDefinitions := func() map[string]string {
+		rows, err := ds.Connection().QueryContext(ctx, `SELECT indexname, indexdef FROM pg_indexes WHERE tablename = 'unit_test'`)
+		assert.NoError(t, err)
+		defer rows.Close()
+
+		definitions := map[string]string{}
+		for rows.Next() {
+			var name, definition string
+			assert.NoError(t, rows.Scan(&name, &definition))
+			definitions[name] = definition
+		}
+		return definitions
+	}
+	before := indexDefinitions()
+	assert.Contains(t, before, "unit_test_username_idx")
+	assert.Contains(t, before, "unit_test_emailAddress_idx")
+
+	// Rebuilding more than once should not accumulate or lose indexes.
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, ds.ReplaceCollection(ctx, "unit_test", buildInsertManyDocuments(3)))
+	}
+	assert.Equal(t, before, indexDefinitions())
+}
+
 func TestDocumentStore_UpsertOne(t *testing.T) {
 	type user struct {
 		Id       string `json:"id"`
@@ -456,3 +719,172 @@ func TestDocumentStore_DeleteBy(t *testing.T) {
 
 	assert.Len(t, docs, 0)
 }
+
+func TestDocumentStore_GroupByCount(t *testing.T) {
+	type customer struct {
+		Country string `json:"country"`
+	}
+	type order struct {
+		ID       string   `json:"id"`
+		Status   string   `json:"status"`
+		Customer customer `json:"customer"`
+	}
+
+	ds := buildDocumentStore()
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, context.Background(), "unit_test")
+
+	statuses := []string{"pending", "shipped", "pending", "cancelled", "pending", "shipped"}
+	var orders []Document
+	for i, status := range statuses {
+		doc, err := NewDocument(strconv.Itoa(i), order{
+			ID:       strconv.Itoa(i),
+			Status:   status,
+			Customer: customer{Country: "CA"},
+		})
+		if err != nil {
+			panic(err)
+		}
+		orders = append(orders, doc)
+	}
+
+	err := ds.InsertMany(context.Background(), "unit_test", orders)
+	assert.NoError(t, err)
+
+	counts, err := ds.GroupByCount(context.Background(), "unit_test", "status")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"pending": 3, "shipped": 2, "cancelled": 1}, counts)
+
+	// nested fields
+	counts, err = ds.Collection("unit_test").GroupByCount(context.Background(), "customer.country")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"CA": 6}, counts)
+
+	// nonexistent collection
+	counts, err = ds.GroupByCount(context.Background(), "does_not_exist", "status")
+	assert.NoError(t, err)
+	assert.Empty(t, counts)
+	assert.NotNil(t, counts)
+}
+
+func TestDocumentStore_WithTransaction(t *testing.T) {
+	type user struct {
+		Id       string `json:"id"`
+		Username string `json:"username"`
+	}
+
+	newUserDocument := func(id string, username string) Document {
+		doc, err := NewDocument(id, user{Id: id, Username: username})
+		if err != nil {
+			panic(err)
+		}
+		return doc
+	}
+
+	ctx := context.Background()
+	ds := buildDocumentStore()
+
+	documentIDs := func() []string {
+		docs, err := ds.FindBy(ctx, "unit_test", "TRUE ORDER BY id")
+		assert.NoError(t, err)
+		var ids []string
+		for _, d := range docs {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name    string
+		failure error
+		want    []string
+	}{
+		{
+			name:    "committing callback",
+			failure: nil,
+			want:    []string{"001", "002"},
+		},
+		{
+			name:    "rolling back callback",
+			failure: errors.New("callback failed"),
+			want:    []string{"000", "001"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				_ = ds.DeleteCollection(ctx, "unit_test")
+			}()
+			assert.NoError(t, ds.InsertMany(ctx, "unit_test", []Document{
+				newUserDocument("000", "user_0"),
+				newUserDocument("001", "user_1"),
+			}))
+
+			err := ds.WithTransaction(ctx, func(tx *CollectionTx) error {
+				if err := tx.InsertOne(ctx, "unit_test", newUserDocument("002", "user_2")); err != nil {
+					return err
+				}
+				if err := tx.UpsertOne(ctx, "unit_test", newUserDocument("001", "user_1_renamed")); err != nil {
+					return err
+				}
+				if err := tx.DeleteBy(ctx, "unit_test", "id = $1", "000"); err != nil {
+					return err
+				}
+				return tt.failure
+			})
+			if tt.failure != nil {
+				assert.ErrorIs(t, err, tt.failure)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, documentIDs())
+
+			doc, err := ds.FindOneByID(ctx, "unit_test", "001")
+			assert.NoError(t, err)
+			var u user
+			assert.NoError(t, doc.Unmarshall(&u))
+			if tt.failure != nil {
+				assert.Equal(t, "user_1", u.Username)
+			} else {
+				assert.Equal(t, "user_1_renamed", u.Username)
+			}
+		})
+	}
+}
+
+func TestDocumentStore_InsertDocuments(t *testing.T) {
+	ctx := context.Background()
+
+	err := NewDocumentStore("").InsertDocuments(ctx, "unit_test", struct{ ID string }{ID: "0"})
+	assert.Error(t, err)
+
+	ds := buildDocumentStore()
+	defer func(ds *DocumentStore, ctx context.Context, collectionName string) {
+		_ = ds.DeleteCollection(ctx, collectionName)
+	}(ds, ctx, "unit_test")
+
+	doc, err := NewDocument("0", map[string]any{"username": "alice"})
+	assert.NoError(t, err)
+	assert.NoError(t, ds.InsertDocuments(ctx, "unit_test", doc))
+
+	found, ok, err := ds.FindDocument(ctx, "unit_test", "0")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "0", found.(RecordedDocument).ID)
+
+	_, ok, err = ds.FindDocument(ctx, "unit_test", "1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = ds.FindDocument(ctx, "unknown_collection", "0")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRecordedDocument_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(RecordedDocument{ID: "0", data: json.RawMessage(`{"username":"alice"}`)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"username":"alice"}`, string(data))
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"fmt"
+	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
+)
+
+// StoreNotOpenError is returned when an operation is performed on a store whose connection was not opened.
+type StoreNotOpenError struct {
+	StoreName string
+}
+
+func (e StoreNotOpenError) Error() string {
+	return fmt.Sprintf("%s is not open", e.StoreName)
+}
+
+func NewStoreNotOpenError(storeName string) error {
+	return StoreNotOpenError{StoreName: storeName}
+}
+
+// IsStoreNotOpenError Indicates if a given error is a StoreNotOpenError or wraps one.
+func IsStoreNotOpenError(err error) bool {
+	var notOpenError StoreNotOpenError
+	return errors.As(err, &notOpenError)
+}
+
+// MalformedPayloadError is returned when the payload of a stored event cannot be decoded.
+type MalformedPayloadError struct {
+	EventID store.EventID
+	Err     error
+}
+
+func (e MalformedPayloadError) Error() string {
+	return fmt.Sprintf("payload of event \"%s\" is malformed: %s", e.EventID, e.Err)
+}
+
+func (e MalformedPayloadError) Unwrap() error {
+	return e.Err
+}
+
+func NewMalformedPayloadError(eventID store.EventID, err error) error {
+	return MalformedPayloadError{EventID: eventID, Err: err}
+}
+
+// IsMalformedPayloadError Indicates if a given error is a MalformedPayloadError or wraps one.
+func IsMalformedPayloadError(err error) bool {
+	var malformedPayloadError MalformedPayloadError
+	return errors.As(err, &malformedPayloadError)
+}
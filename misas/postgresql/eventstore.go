@@ -16,6 +16,7 @@ package postgresql
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -23,8 +24,10 @@ import (
 	"github.com/lib/pq"
 	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/clock"
+	"github.com/morebec/misas-go/misas/event"
 	"github.com/morebec/misas-go/misas/event/store"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	"strings"
 	"sync"
 	"time"
@@ -39,22 +42,142 @@ type EventStore struct {
 	database         *sql.DB
 	clock            clock.Clock
 	upcasterChain    store.UpcasterChain
+	options          EventStoreOptions
+
+	// openDB opens the database using a connection string, an optional dialer and an optional logger, it can be
+	// replaced in tests.
+	openDB func(connectionString string, dialer pq.Dialer, logger *zap.Logger) (*sql.DB, error)
+	dialer pq.Dialer
 
 	// Postgres channel listener, to be notified of new incoming events.
-	notifyListener    *pq.Listener
-	subscriptions     []*store.Subscription
-	subscriptionsLock sync.Mutex
+	notifyListener      *pq.Listener
+	listenerReconnected chan struct{}
+	subscriptions       []*notifiedSubscription
+	subscriptionsLock   sync.Mutex
+}
+
+// EventStoreOptions represents the options of an EventStore.
+type EventStoreOptions struct {
+	// TLSConfig is the configuration used to establish TLS sessions with the database, when specified.
+	TLSConfig *tls.Config
+
+	// ConnectionPool are the settings of the pool of connections to the database.
+	ConnectionPool ConnectionPoolOptions
+
+	// Logger is the logger used to log the queries executed against the database at the debug level, when specified.
+	Logger *zap.Logger
+
+	// PayloadCompression indicates if the payloads of events are gzip-compressed when appended.
+	PayloadCompression bool
+
+	// MalformedPayloadPolicy indicates how events whose payload cannot be decoded are read.
+	MalformedPayloadPolicy MalformedPayloadPolicy
+}
+
+// MalformedPayloadPolicy represents how an EventStore reads the events whose stored payload cannot be decoded, for
+// instance because it was written by an external writer or was corrupted.
+type MalformedPayloadPolicy string
+
+const (
+	// FailOnMalformedPayload fails the whole read with a MalformedPayloadError identifying the offending event.
+	FailOnMalformedPayload MalformedPayloadPolicy = "fail"
+
+	// SkipMalformedPayload leaves the offending events out of the slice read.
+	SkipMalformedPayload MalformedPayloadPolicy = "skip"
+
+	// RawMalformedPayload returns the offending events with a payload only containing their stored data as a string
+	// under RawPayloadKey.
+	RawMalformedPayload MalformedPayloadPolicy = "raw"
+)
+
+// RawPayloadKey is the key under which the stored data of an event is returned by the RawMalformedPayload policy.
+const RawPayloadKey = "$raw"
+
+type EventStoreOption func(options *EventStoreOptions)
+
+// WithTLSConfig makes the EventStore connect to the database using a custom TLS configuration, for instance to trust the
+// certificate authority of a managed database. The TLS session is established by the EventStore, as a result, the
+// sslmode of the connection string is ignored.
+func WithTLSConfig(config *tls.Config) EventStoreOption {
+	return func(options *EventStoreOptions) {
+		options.TLSConfig = config
+	}
+}
+
+// WithConnectionPool configures the pool of connections the EventStore uses to query the database.
+func WithConnectionPool(pool ConnectionPoolOptions) EventStoreOption {
+	return func(options *EventStoreOptions) {
+		options.ConnectionPool = pool
+	}
+}
+
+// WithLogger makes the EventStore log each query it executes at the debug level, along with its duration and its
+// arguments, textual and binary arguments being redacted.
+func WithLogger(logger *zap.Logger) EventStoreOption {
+	return func(options *EventStoreOptions) {
+		options.Logger = logger
+	}
+}
+
+// WithPayloadCompression makes the EventStore gzip-compress the payloads of the events it appends. Compressed payloads
+// are stored as binary data in the compressed_data column instead of the data column, so that payloads appended without
+// compression remain readable. Metadata is never compressed to remain queryable.
+func WithPayloadCompression() EventStoreOption {
+	return func(options *EventStoreOptions) {
+		options.PayloadCompression = true
+	}
+}
+
+// WithMalformedPayloadPolicy specifies how the EventStore reads the events whose payload cannot be decoded, so that a
+// single malformed event does not necessarily prevent reading a whole stream. By default, reads fail.
+func WithMalformedPayloadPolicy(policy MalformedPayloadPolicy) EventStoreOption {
+	return func(options *EventStoreOptions) {
+		options.MalformedPayloadPolicy = policy
+	}
 }
 
 func NewEventStore(
 	connectionString string,
 	clock clock.Clock,
 ) *EventStore {
+	return NewEventStoreWithOptions(connectionString, clock)
+}
+
+// NewEventStoreWithOptions creates a new EventStore using a given set of options.
+func NewEventStoreWithOptions(connectionString string, clk clock.Clock, opts ...EventStoreOption) *EventStore {
+	options := EventStoreOptions{
+		ConnectionPool:         DefaultConnectionPoolOptions(),
+		MalformedPayloadPolicy: FailOnMalformedPayload,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return &EventStore{
 		connectionString: connectionString,
 		database:         nil,
-		clock:            clock,
+		clock:            clk,
+		options:          options,
+		openDB:           openDB,
+	}
+}
+
+// openDB opens a database using the connection string and, if specified, a dialer and a logger of the queries.
+func openDB(connectionString string, dialer pq.Dialer, logger *zap.Logger) (*sql.DB, error) {
+	connector, err := pq.NewConnector(connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	if dialer != nil {
+		connector.Dialer(dialer)
+	}
+
+	if logger != nil {
+		return sql.OpenDB(newQueryLoggingConnector(connector, logger)), nil
 	}
+
+	return sql.OpenDB(connector), nil
 }
 
 func (es *EventStore) setupSchemas(ctx context.Context) error {
@@ -66,11 +189,16 @@ CREATE TABLE IF NOT EXISTS events
     stream_version  INTEGER      NOT NULL,
     type            VARCHAR(255) NOT NULL,
     metadata        JSONB        NOT NULL,
-    data            JSONB        NOT NULL,
+    data            JSONB,
+    compressed_data BYTEA,
     recorded_at     TIMESTAMP(0) NOT NULL,
     sequence_number SERIAL
 );
 
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS compressed_data BYTEA,
+    ALTER COLUMN data DROP NOT NULL;
+
 CREATE INDEX IF NOT EXISTS idx_id
     ON events (id);
 
@@ -128,10 +256,20 @@ FOR EACH ROW EXECUTE PROCEDURE notify_events();
 }
 
 func (es *EventStore) Open(ctx context.Context) error {
-	db, err := sql.Open("postgres", es.connectionString)
+	connectionString := es.connectionString
+	if es.options.TLSConfig != nil {
+		var err error
+		if connectionString, err = withDriverSSLDisabled(connectionString); err != nil {
+			return errors.Wrap(err, "failed opening connection to event store")
+		}
+		es.dialer = newTLSDialer(es.options.TLSConfig)
+	}
+
+	db, err := es.openDB(connectionString, es.dialer, es.options.Logger)
 	if err != nil {
 		return errors.Wrap(err, "failed opening connection to event store")
 	}
+	es.options.ConnectionPool.applyTo(db)
 	es.database = db
 
 	if err := es.database.PingContext(ctx); err != nil {
@@ -142,9 +280,23 @@ func (es *EventStore) Open(ctx context.Context) error {
 		return errors.Wrap(err, "failed opening connection to event store")
 	}
 
-	if err := es.setupNotifyListener(ctx); err != nil {
+	if err := es.setupNotifyListener(ctx, connectionString); err != nil {
+
+	}
+	return nil
+}
+
+// Ping checks that the database of the EventStore can be reached, for instance to serve readiness probes.
+// It returns a StoreNotOpenError if the EventStore was not opened.
+func (es *EventStore) Ping(ctx context.Context) error {
+	if es.database == nil {
+		return NewStoreNotOpenError("event store")
+	}
 
+	if err := es.database.PingContext(ctx); err != nil {
+		return errors.Wrap(err, "failed pinging event store")
 	}
+
 	return nil
 }
 
@@ -180,6 +332,10 @@ func (es *EventStore) AppendToStream(ctx context.Context, streamID store.StreamI
 		return nil
 	}
 
+	if err := store.CheckEventIDsUnique(streamID, events); err != nil {
+		return err
+	}
+
 	stream, err := es.GetStream(ctx, streamID)
 	streamFound := true
 	if err != nil {
@@ -211,20 +367,30 @@ func (es *EventStore) AppendToStream(ctx context.Context, streamID store.StreamI
 		streamVersion++
 
 		insertEventSql := `
-INSERT INTO events (id, stream_id, stream_version, type, metadata, data, recorded_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO events (id, stream_id, stream_version, type, metadata, data, compressed_data, recorded_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 `
 		eventAsJson, err := json.Marshal(d.Payload)
 		if err != nil {
 			return errors.Wrap(err, "failed appending events to the event store")
 		}
 
+		// Exactly one of the data and compressed_data columns is set.
+		var data, compressedData any = eventAsJson, nil
+		if es.options.PayloadCompression {
+			compressed, err := compressPayload(eventAsJson)
+			if err != nil {
+				return errors.Wrap(err, "failed appending events to the event store")
+			}
+			data, compressedData = nil, compressed
+		}
+
 		metadataAsJson, err := json.Marshal(d.Metadata)
 		if err != nil {
 			return errors.Wrap(err, "failed appending events to the event store")
 		}
 
-		if _, err = tx.ExecContext(ctx, insertEventSql, d.ID, streamID, streamVersion, d.TypeName, metadataAsJson, eventAsJson, es.clock.Now()); err != nil {
+		if _, err = tx.ExecContext(ctx, insertEventSql, d.ID, streamID, streamVersion, d.TypeName, metadataAsJson, data, compressedData, es.clock.Now()); err != nil {
 			if rollbackErr := tx.Rollback(); rollbackErr != nil {
 				return errors.Wrap(rollbackErr, "failed rolling back transaction when appending event to the event store")
 			}
@@ -246,6 +412,93 @@ VALUES ($1, $2, $3, $4, $5, $6, $7)
 	return nil
 }
 
+// decodePayload decodes the stored data of an event into its payload, from its compressed data when it has some.
+// Payloads are decompressed regardless of the options of the store, as they might have been appended by another one.
+func decodePayload(data []byte, compressedData []byte) (store.DescriptorPayload, error) {
+	if compressedData != nil {
+		decompressed, err := decompressPayload(compressedData)
+		if err != nil {
+			return nil, err
+		}
+		data = decompressed
+	}
+
+	var payload store.DescriptorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errors.Wrap(err, "failed decoding payload")
+	}
+
+	return payload, nil
+}
+
+// selectEventsSql returns the SELECT clause reading events according to some options, along with its parameters
+// numbered from 1.
+func selectEventsSql(options *store.ReadFromStreamOptions) (string, []any) {
+	var stmtParams []any
+
+	// Payloads are projected by the database, except compressed ones which are projected once decompressed.
+	// When payloads are not read, the data column is not selected at all so that the database does not load it.
+	dataColumns := "data, compressed_data"
+	if options.WithoutPayloads {
+		dataColumns = "NULL::jsonb, NULL::bytea"
+	} else if len(options.PayloadProjection) != 0 {
+		dataColumns = "CASE WHEN compressed_data IS NULL THEN (SELECT COALESCE(jsonb_object_agg(key, value), '{}'::jsonb) FROM jsonb_each(data) WHERE key = ANY($1)) END, compressed_data"
+		stmtParams = append(stmtParams, pq.Array(options.PayloadProjection))
+	}
+
+	return fmt.Sprintf("SELECT id, type, stream_id, stream_version, %s, metadata, sequence_number, recorded_at FROM events", dataColumns), stmtParams
+}
+
+// typeNameFilterCondition returns the condition selecting the events passing a type name filter, along with its
+// parameters numbered from paramIndex. Filtering in the database ensures that the maximum number of events read only
+// counts the events passing the filter. An empty condition selects all the events.
+func typeNameFilterCondition(filter *store.TypeNameFilter, paramIndex int) (string, []any) {
+	if filter == nil {
+		return "", nil
+	}
+
+	typeNames := make([]string, 0, len(filter.EventTypeNames))
+	for _, tn := range filter.EventTypeNames {
+		typeNames = append(typeNames, string(tn))
+	}
+
+	condition := fmt.Sprintf("type = ANY($%d)", paramIndex)
+	if filter.Mode == store.Exclude {
+		condition = fmt.Sprintf("NOT (%s)", condition)
+	}
+
+	return condition, []any{pq.Array(typeNames)}
+}
+
+// positionCondition returns the condition selecting the events following the position of some options in their
+// direction, along with its parameters numbered from paramIndex. An empty condition selects all the events.
+// The End position being after every event, no event follows it forward and all of them precede it backward, which is
+// expressed without comparing positions, so that reading backward from End always starts at the true last event.
+func positionCondition(isGlobalStream bool, options *store.ReadFromStreamOptions, paramIndex int) (string, []any) {
+	if options.Position < store.Position(store.InitialVersion) {
+		return "", nil
+	}
+
+	if options.Position == store.End {
+		if options.Direction == store.Forward {
+			return "FALSE", nil
+		}
+		return "", nil
+	}
+
+	positionColumn := "stream_version"
+	if isGlobalStream {
+		positionColumn = "sequence_number"
+	}
+
+	positionSign := "<"
+	if options.Direction == store.Forward {
+		positionSign = ">"
+	}
+
+	return fmt.Sprintf("%s %s $%d", positionColumn, positionSign, paramIndex), []any{fmt.Sprintf("%d", options.Position)}
+}
+
 func (es *EventStore) ReadFromStream(ctx context.Context, streamID store.StreamID, opts ...store.ReadFromStreamOption) (store.StreamSlice, error) {
 	options := store.BuildReadFromStreamOptions(opts)
 	isGlobalStream := streamID == es.GlobalStreamID()
@@ -260,48 +513,32 @@ func (es *EventStore) ReadFromStream(ctx context.Context, streamID store.StreamI
 		}
 	}
 
-	var stmtParams []any
-	stmtParamCounter := 1
+	selectSql, stmtParams := selectEventsSql(options)
+	stmtParamCounter := len(stmtParams) + 1
 	var whereClauses []string
 
-	selectSql := "SELECT id, type, stream_id, stream_version, data, metadata, sequence_number, recorded_at FROM events"
-
 	if !isGlobalStream {
 		whereClauses = append(whereClauses, fmt.Sprintf("stream_id = $%d", stmtParamCounter))
 		stmtParamCounter++
 		stmtParams = append(stmtParams, streamID)
 	}
 
-	if options.Position >= store.Position(store.InitialVersion) {
-		var positionColumn string
-		if isGlobalStream {
-			positionColumn = "sequence_number"
-		} else {
-			positionColumn = "stream_version"
-		}
-		var positionValue string
-		if options.Position == store.End {
-			if isGlobalStream {
-				positionValue = fmt.Sprintf("(SELECT MAX(%s) + 1 FROM events)", positionColumn)
-			} else {
-				positionValue = fmt.Sprintf("(SELECT MAX(%s) + 1 FROM events WHERE stream_id = $%d)", positionColumn, stmtParamCounter)
-				stmtParams = append(stmtParams, fmt.Sprintf("%s", streamID))
-				stmtParamCounter++
-			}
-		} else {
-			positionValue = fmt.Sprintf("$%d", stmtParamCounter)
-			stmtParams = append(stmtParams, fmt.Sprintf("%d", options.Position))
-			stmtParamCounter++
-		}
+	if condition, params := positionCondition(isGlobalStream, options, stmtParamCounter); condition != "" {
+		whereClauses = append(whereClauses, condition)
+		stmtParams = append(stmtParams, params...)
+		stmtParamCounter += len(params)
+	}
 
-		var positionSign string
-		if options.Direction == store.Forward {
-			positionSign = ">"
-		} else {
-			positionSign = "<"
-		}
+	if condition, params := typeNameFilterCondition(options.EventTypeNameFilter, stmtParamCounter); condition != "" {
+		whereClauses = append(whereClauses, condition)
+		stmtParams = append(stmtParams, params...)
+		stmtParamCounter += len(params)
+	}
 
-		whereClauses = append(whereClauses, fmt.Sprintf("%s %s %s", positionColumn, positionSign, positionValue))
+	if options.RecordedAfter != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("recorded_at > $%d", stmtParamCounter))
+		stmtParams = append(stmtParams, *options.RecordedAfter)
+		stmtParamCounter++
 	}
 
 	var direction string
@@ -310,7 +547,12 @@ func (es *EventStore) ReadFromStream(ctx context.Context, streamID store.StreamI
 	} else {
 		direction = "DESC"
 	}
+	// The sequence number is always the final sort key, so that the order is deterministic even for events sharing a
+	// version or recorded at the same time.
 	orderBySql := fmt.Sprintf("ORDER BY sequence_number %s", direction)
+	if !isGlobalStream {
+		orderBySql = fmt.Sprintf("ORDER BY stream_version %s, sequence_number %s", direction, direction)
+	}
 
 	var limitSql string
 	if options.MaxCount > 0 {
@@ -319,12 +561,17 @@ func (es *EventStore) ReadFromStream(ctx context.Context, streamID store.StreamI
 		limitSql = ""
 	}
 
+	var whereSql string
+	if len(whereClauses) != 0 {
+		whereSql = fmt.Sprintf("WHERE %s", strings.Join(whereClauses, " AND "))
+	}
+
 	querySql := fmt.Sprintf(`
 %s
-WHERE %s
 %s
 %s
-`, selectSql, strings.Join(whereClauses, " AND "), orderBySql, limitSql)
+%s
+`, selectSql, whereSql, orderBySql, limitSql)
 
 	rows, err := es.database.QueryContext(ctx, querySql, stmtParams...)
 	defer func(rows *sql.Rows) {
@@ -342,6 +589,7 @@ WHERE %s
 	for rows.Next() {
 		var descriptor store.RecordedEventDescriptor
 		var jsonEventData []byte
+		var compressedEventData []byte
 		var jsonMetadata []byte
 
 		if err := rows.Scan(
@@ -350,6 +598,7 @@ WHERE %s
 			&descriptor.StreamID,
 			&descriptor.Version,
 			&jsonEventData,
+			&compressedEventData,
 			&jsonMetadata,
 			&descriptor.SequenceNumber,
 			&descriptor.RecordedAt,
@@ -357,28 +606,29 @@ WHERE %s
 			return store.StreamSlice{}, errors.Wrapf(err, "failed reading from stream \"%s\"", streamID)
 		}
 
-		if options.EventTypeNameFilter != nil {
-			if options.EventTypeNameFilter.Mode == store.Exclude {
-				for _, tn := range options.EventTypeNameFilter.EventTypeNames {
-					if descriptor.TypeName == tn {
-						continue
+		if !options.WithoutPayloads {
+			payload, err := decodePayload(jsonEventData, compressedEventData)
+			if err != nil {
+				switch es.options.MalformedPayloadPolicy {
+				case SkipMalformedPayload:
+					continue
+				case RawMalformedPayload:
+					rawEventData := jsonEventData
+					if compressedEventData != nil {
+						rawEventData = compressedEventData
 					}
+					descriptor.Payload = store.DescriptorPayload{RawPayloadKey: string(rawEventData)}
+				default:
+					return store.StreamSlice{}, errors.Wrapf(NewMalformedPayloadError(descriptor.ID, err), "failed reading from stream \"%s\"", streamID)
 				}
-			}
-
-			if options.EventTypeNameFilter.Mode == store.Select {
-				for _, tn := range options.EventTypeNameFilter.EventTypeNames {
-					if descriptor.TypeName != tn {
-						continue
-					}
+			} else {
+				descriptor.Payload = payload
+				if compressedEventData != nil && len(options.PayloadProjection) != 0 {
+					descriptor.Payload = descriptor.Payload.Project(options.PayloadProjection...)
 				}
 			}
 		}
 
-		if err := json.Unmarshal(jsonEventData, &descriptor.Payload); err != nil {
-			return store.StreamSlice{}, errors.Wrapf(err, "failed reading from stream \"%s\"", streamID)
-		}
-
 		if err := json.Unmarshal(jsonMetadata, &descriptor.Metadata); err != nil {
 			return store.StreamSlice{}, errors.Wrapf(err, "failed reading from stream \"%s\"", streamID)
 		}
@@ -404,11 +654,20 @@ WHERE %s
 func (es *EventStore) TruncateStream(ctx context.Context, id store.StreamID, opts ...store.TruncateStreamOption) error {
 	options := store.BuildTruncateFromStreamOptions(opts)
 
+	cutoff := options.BeforePosition
+	if options.KeepLast != nil {
+		stream, err := es.GetStream(ctx, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed truncating from stream \"%s\"", id)
+		}
+		cutoff = options.CutoffPosition(stream.Version)
+	}
+
 	tx, err := es.database.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.Wrapf(err, "failed truncating from stream \"%s\"", id)
 	}
-	_, err = tx.ExecContext(ctx, "DELETE FROM events WHERE stream_id = $1 AND stream_version < $2", id, options.BeforePosition)
+	_, err = tx.ExecContext(ctx, "DELETE FROM events WHERE stream_id = $1 AND stream_version < $2", id, cutoff)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return errors.Wrapf(err, "failed rolling back transaction when truncating stream \"%s\"", id)
@@ -421,7 +680,7 @@ func (es *EventStore) TruncateStream(ctx context.Context, id store.StreamID, opt
 			ID:       store.EventID(uuid.New().String()),
 			TypeName: store.StreamTruncatedEventTypeName,
 			Payload: store.DescriptorPayload{
-				"streamId":    "",
+				"streamId":    string(id),
 				"reason":      options.Reason,
 				"truncatedAt": es.clock.Now(),
 			},
@@ -445,6 +704,35 @@ func (es *EventStore) TruncateStream(ctx context.Context, id store.StreamID, opt
 	return nil
 }
 
+func (es *EventStore) RenameEventType(ctx context.Context, streamID store.StreamID, from, to event.PayloadTypeName) (int, error) {
+	updateSql := "UPDATE events SET type = $1 WHERE type = $2"
+	params := []any{to, from}
+	if streamID != es.GlobalStreamID() {
+		exists, err := es.StreamExists(ctx, streamID)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed renaming event type \"%s\" in stream \"%s\"", from, streamID)
+		}
+		if !exists {
+			return 0, store.NewStreamNotFoundError(streamID)
+		}
+
+		updateSql += " AND stream_id = $3"
+		params = append(params, streamID)
+	}
+
+	result, err := es.database.ExecContext(ctx, updateSql, params...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed renaming event type \"%s\" in stream \"%s\"", from, streamID)
+	}
+
+	renamed, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed renaming event type \"%s\" in stream \"%s\"", from, streamID)
+	}
+
+	return int(renamed), nil
+}
+
 func (es *EventStore) DeleteStream(ctx context.Context, id store.StreamID) error {
 	tx, err := es.database.BeginTx(ctx, nil)
 	if err != nil {
@@ -496,15 +784,125 @@ func (es *EventStore) DeleteStream(ctx context.Context, id store.StreamID) error
 	return nil
 }
 
-// setupNotifyListener sets up a listen/notify connection with the database to listen to new incoming events in realtime.
-func (es *EventStore) setupNotifyListener(ctx context.Context) error {
-	es.notifyListener = pq.NewListener(es.connectionString, 5*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
-		if err != nil {
-			for _, s := range es.subscriptions {
-				s.EmitError(err)
-			}
+// abandonedSequenceGapTimeout is the time after which a gap in the sequence numbers notified to a subscription is
+// considered to have been left by a transaction that was rolled back, rather than by one that has yet to commit.
+const abandonedSequenceGapTimeout = time.Minute
+
+// subscriptionSeedSize is the number of most recent events inspected when subscribing, so that the events of
+// transactions still in progress at that time are notified to the subscription once committed.
+const subscriptionSeedSize = 100
+
+// notifiedSubscription is a store.Subscription along with the sequence numbers of the events it was notified of,
+// whether they were delivered to it or filtered out, so that each event is notified exactly once, including the events
+// missed while the notification listener was disconnected, which are delivered once it reconnects.
+// Sequence numbers being assigned when events are inserted rather than when their transaction commits, events can be
+// committed out of order. As a result, the notified sequence numbers are tracked as a checkpoint up to which all events
+// were notified, followed by the sequence numbers notified after a gap left by events that have yet to be committed.
+type notifiedSubscription struct {
+	*store.Subscription
+	checkpoint store.SequenceNumber
+	notified   map[store.SequenceNumber]bool
+
+	// gapSince is the time at which the checkpoint was last blocked by a gap.
+	gapSince time.Time
+}
+
+// newNotifiedSubscription returns a notifiedSubscription that was notified of some events preceding it, ordered by
+// sequence number, so that only the events committed after it are notified to it. The sequence numbers missing between
+// these events might be committed later on, therefore they are not considered as notified.
+func newNotifiedSubscription(subscription *store.Subscription, preceding []store.RecordedEventDescriptor, now time.Time) *notifiedSubscription {
+	s := &notifiedSubscription{
+		Subscription: subscription,
+		checkpoint:   store.SequenceNumber(store.Start),
+		notified:     map[store.SequenceNumber]bool{},
+	}
+	if len(preceding) == 0 {
+		return s
+	}
+
+	s.checkpoint = preceding[0].SequenceNumber
+	for _, d := range preceding[1:] {
+		s.markNotified(d.SequenceNumber, now)
+	}
+
+	return s
+}
+
+// wasNotified indicates if the event with a given sequence number was notified to this subscription.
+func (s *notifiedSubscription) wasNotified(n store.SequenceNumber) bool {
+	return n <= s.checkpoint || s.notified[n]
+}
+
+// markNotified records that the event with a given sequence number was notified to this subscription, and advances its
+// checkpoint over the sequence numbers notified without gaps. Gaps remaining for longer than abandonedSequenceGapTimeout
+// are skipped, so that the sequence numbers consumed by rolled back transactions do not accumulate forever.
+func (s *notifiedSubscription) markNotified(n store.SequenceNumber, now time.Time) {
+	if s.wasNotified(n) {
+		return
+	}
+	s.notified[n] = true
+
+	previousCheckpoint := s.checkpoint
+	s.advanceCheckpoint()
+
+	if len(s.notified) == 0 {
+		s.gapSince = time.Time{}
+		return
+	}
+	if s.gapSince.IsZero() || s.checkpoint != previousCheckpoint {
+		s.gapSince = now
+		return
+	}
+	if now.Sub(s.gapSince) < abandonedSequenceGapTimeout {
+		return
+	}
+
+	// The gap is abandoned, the checkpoint moves to the first sequence number notified after it.
+	s.checkpoint = s.firstNotifiedAfterCheckpoint() - 1
+	s.advanceCheckpoint()
+	s.gapSince = now
+	if len(s.notified) == 0 {
+		s.gapSince = time.Time{}
+	}
+}
+
+// advanceCheckpoint moves the checkpoint over the sequence numbers notified right after it.
+func (s *notifiedSubscription) advanceCheckpoint() {
+	for s.notified[s.checkpoint+1] {
+		delete(s.notified, s.checkpoint+1)
+		s.checkpoint++
+	}
+}
+
+// firstNotifiedAfterCheckpoint returns the lowest sequence number notified after the checkpoint.
+func (s *notifiedSubscription) firstNotifiedAfterCheckpoint() store.SequenceNumber {
+	first := store.SequenceNumber(store.End)
+	for n := range s.notified {
+		if n < first {
+			first = n
 		}
-	})
+	}
+	return first
+}
+
+// receives indicates if an event should be emitted to this subscription, according to its stream and type name filter.
+func (s *notifiedSubscription) receives(globalStreamID store.StreamID, d store.RecordedEventDescriptor) bool {
+	if s.StreamID() != globalStreamID && s.StreamID() != d.StreamID {
+		return false
+	}
+
+	filter := s.Options().EventTypeNameFilter
+	return filter == nil || filter.Matches(d.TypeName)
+}
+
+// setupNotifyListener sets up a listen/notify connection with the database to listen to new incoming events in realtime.
+func (es *EventStore) setupNotifyListener(ctx context.Context, connectionString string) error {
+	es.listenerReconnected = make(chan struct{}, 1)
+	if es.dialer != nil {
+		es.notifyListener = pq.NewDialListener(es.dialer, connectionString, 5*time.Second, time.Minute, es.handleListenerEvent)
+	} else {
+		es.notifyListener = pq.NewListener(connectionString, 5*time.Second, time.Minute, es.handleListenerEvent)
+	}
 
 	if err := es.notifyListener.Listen("events"); err != nil {
 		return err
@@ -522,7 +920,7 @@ func (es *EventStore) setupNotifyListener(ctx context.Context) error {
 				descriptorData := map[string]any{}
 				err := json.Unmarshal([]byte(n.Extra), &descriptorData)
 				if err != nil {
-					for _, s := range es.subscriptions {
+					for _, s := range es.subscriptionsSnapshot() {
 						s.EmitError(err)
 					}
 					break
@@ -532,24 +930,21 @@ func (es *EventStore) setupNotifyListener(ctx context.Context) error {
 				stream, err := es.ReadFromStream(
 					ctx,
 					es.GlobalStreamID(),
-					store.From(store.Position(descriptorData["sequence_number"].(float64))-1),
+					store.From(store.PositionFromSequenceNumber(store.SequenceNumber(descriptorData["sequence_number"].(float64))).Previous()),
 					store.InForwardDirection(),
 					store.WithMaxCount(1),
 				)
 				if err != nil {
-					for _, s := range es.subscriptions {
-						if stream.StreamID == s.StreamID() {
-							s.EmitError(err)
-						}
+					for _, s := range es.subscriptionsSnapshot() {
+						s.EmitError(err)
 					}
+					break
 				}
 
-				e := stream.First()
-				for _, s := range es.subscriptions {
-					if stream.StreamID == s.StreamID() {
-						s.EmitEvent(e)
-					}
-				}
+				es.notifySubscriptions(es.subscriptionsSnapshot(), stream.Descriptors)
+
+			case <-es.listenerReconnected:
+				es.resumeSubscriptions(ctx)
 			}
 		}
 	}()
@@ -557,15 +952,103 @@ func (es *EventStore) setupNotifyListener(ctx context.Context) error {
 	return nil
 }
 
+// handleListenerEvent handles the events of the connection of the notification listener. The notifications sent while
+// the listener was disconnected being lost, its reconnection triggers the resumption of the subscriptions.
+func (es *EventStore) handleListenerEvent(event pq.ListenerEventType, err error) {
+	if err != nil {
+		for _, s := range es.subscriptionsSnapshot() {
+			s.EmitError(err)
+		}
+	}
+
+	if event == pq.ListenerEventReconnected {
+		select {
+		case es.listenerReconnected <- struct{}{}:
+		default:
+			// A resumption is already pending, it will deliver all the missed events.
+		}
+	}
+}
+
+// resumeSubscriptions delivers to the subscriptions the events that were appended after their checkpoint and that they
+// were not notified of, from the global stream.
+func (es *EventStore) resumeSubscriptions(ctx context.Context) {
+	subscriptions := es.subscriptionsSnapshot()
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	from := subscriptions[0].checkpoint
+	for _, s := range subscriptions[1:] {
+		if s.checkpoint < from {
+			from = s.checkpoint
+		}
+	}
+
+	stream, err := es.ReadFromStream(ctx, es.GlobalStreamID(), store.From(store.PositionFromSequenceNumber(from)), store.InForwardDirection())
+	if err != nil {
+		for _, s := range subscriptions {
+			s.EmitError(errors.Wrap(err, "failed resuming subscription"))
+		}
+		return
+	}
+
+	es.notifySubscriptions(subscriptions, stream.Descriptors)
+}
+
+// notifySubscriptions emits events to the subscriptions of their stream. Events a subscription was already notified of
+// are skipped, so that events read both when resuming and from a notification are only delivered once.
+// It must only be called from the goroutine of the notification listener.
+func (es *EventStore) notifySubscriptions(subscriptions []*notifiedSubscription, descriptors []store.RecordedEventDescriptor) {
+	now := es.clock.Now()
+	for _, s := range subscriptions {
+		for _, d := range descriptors {
+			if s.wasNotified(d.SequenceNumber) {
+				continue
+			}
+			if s.receives(es.GlobalStreamID(), d) {
+				s.EmitEvent(d)
+			}
+			s.markNotified(d.SequenceNumber, now)
+		}
+	}
+}
+
+// subscriptionsSnapshot returns a copy of the current subscriptions, so they can be notified without holding their lock.
+func (es *EventStore) subscriptionsSnapshot() []*notifiedSubscription {
+	es.subscriptionsLock.Lock()
+	defer es.subscriptionsLock.Unlock()
+
+	return append([]*notifiedSubscription(nil), es.subscriptions...)
+}
+
 func (es *EventStore) SubscribeToStream(ctx context.Context, streamID store.StreamID, opts ...store.SubscribeToStreamOption) (store.Subscription, error) {
 
+	// Only the events committed after the subscription are notified to it.
+	recent, err := es.ReadFromStream(
+		ctx,
+		es.GlobalStreamID(),
+		store.FromEnd(),
+		store.InBackwardDirection(),
+		store.WithMaxCount(subscriptionSeedSize),
+		store.WithoutPayloads(),
+	)
+	if err != nil {
+		return store.Subscription{}, errors.Wrapf(err, "failed subscribing to stream \"%s\"", streamID)
+	}
+
+	options := store.BuildSubscribeToStreamOptions(opts)
 	closeChan := make(chan bool, 1)
-	subscription := store.NewSubscription(
-		make(chan store.RecordedEventDescriptor),
-		make(chan error),
-		closeChan,
-		streamID,
-		store.BuildSubscribeToStreamOptions(opts),
+	subscription := newNotifiedSubscription(
+		store.NewSubscription(
+			make(chan store.RecordedEventDescriptor, options.BufferSize),
+			make(chan error),
+			closeChan,
+			streamID,
+			options,
+		),
+		recent.Reversed().Descriptors,
+		es.clock.Now(),
 	)
 
 	es.subscriptionsLock.Lock()
@@ -577,7 +1060,7 @@ func (es *EventStore) SubscribeToStream(ctx context.Context, streamID store.Stre
 		es.subscriptionsLock.Lock()
 		defer es.subscriptionsLock.Unlock()
 		// Remove sub when it is closed.
-		var subs []*store.Subscription
+		var subs []*notifiedSubscription
 		for _, s := range es.subscriptions {
 			if s != subscription {
 				subs = append(subs, s)
@@ -586,7 +1069,7 @@ func (es *EventStore) SubscribeToStream(ctx context.Context, streamID store.Stre
 		es.subscriptions = subs
 	}()
 
-	return *subscription, nil
+	return *subscription.Subscription, nil
 }
 
 func (es *EventStore) StreamExists(ctx context.Context, id store.StreamID) (bool, error) {
@@ -620,6 +1103,22 @@ func (es *EventStore) GetStream(ctx context.Context, id store.StreamID) (store.S
 	}, nil
 }
 
+func (es *EventStore) CountEvents(ctx context.Context, streamID store.StreamID) (int64, error) {
+	query := "SELECT COUNT(*) FROM events"
+	var args []any
+	if streamID != es.GlobalStreamID() {
+		query += " WHERE stream_id = $1"
+		args = append(args, streamID)
+	}
+
+	var count int64
+	if err := es.database.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, errors.Wrapf(err, "failed counting events of stream \"%s\"", streamID)
+	}
+
+	return count, nil
+}
+
 func (es *EventStore) Clear(ctx context.Context) error {
 	tx, err := es.database.BeginTx(ctx, nil)
 	if err != nil {
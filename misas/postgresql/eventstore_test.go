@@ -16,12 +16,22 @@ package postgresql
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/clock"
 	"github.com/morebec/misas-go/misas/event"
 	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -50,12 +60,53 @@ func buildEventStore() *EventStore {
 	return s
 }
 
+func TestEventStore_WithLogger(t *testing.T) {
+	ctx := context.Background()
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	st := NewEventStoreWithOptions("postgres://postgres@localhost:5432/postgres?sslmode=disable", clock.UTCClock{}, WithLogger(zap.New(core)))
+	assert.NoError(t, st.Open(ctx))
+	assert.NoError(t, st.Clear(ctx))
+
+	err := st.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{
+			ID:       store.NewEventID(),
+			TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(),
+			Payload:  store.DescriptorPayload{"TestName": "TestEventStore_WithLogger"},
+		},
+	})
+	assert.NoError(t, err)
+
+	var insertEntries []observer.LoggedEntry
+	for _, entry := range logs.FilterMessage("postgresql.query").AllUntimed() {
+		if strings.Contains(entry.ContextMap()["query"].(string), "INSERT INTO events") {
+			insertEntries = append(insertEntries, entry)
+		}
+	}
+	if assert.Len(t, insertEntries, 1) {
+		assert.Equal(t, zapcore.DebugLevel, insertEntries[0].Level)
+		assert.NotContains(t, insertEntries[0].ContextMap()["args"], "TestEventStore_WithLogger")
+		assert.Contains(t, insertEntries[0].ContextMap(), "duration")
+	}
+}
+
 func TestEventStore_OpenConnection(t *testing.T) {
 	assert.NotPanics(t, func() {
 		_ = buildEventStore()
 	})
 }
 
+func TestEventStore_Ping(t *testing.T) {
+	ctx := context.Background()
+
+	st := NewEventStore("postgres://postgres@localhost:5432/postgres?sslmode=disable", clock.UTCClock{})
+	err := st.Ping(ctx)
+	assert.True(t, IsStoreNotOpenError(err))
+
+	assert.NoError(t, st.Open(ctx))
+	assert.NoError(t, st.Ping(ctx))
+}
+
 func TestEventStore_CloseConnection(t *testing.T) {
 	st := buildEventStore()
 	err := st.Close()
@@ -314,6 +365,214 @@ func TestEventStore_SubscribeToStream(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestEventStore_SubscribeToStream_ResumesAfterListenerReconnection(t *testing.T) {
+	st := buildEventStore()
+	streamID := store.UniqueStreamID("unit_test")
+
+	subscription, err := st.SubscribeToStream(context.Background(), streamID)
+	assert.NoError(t, err)
+	defer subscription.Close()
+
+	appendEvent := func(id store.EventID) {
+		err := st.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+			{ID: id, TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		}, store.WithOptimisticConcurrencyCheckDisabled())
+		assert.NoError(t, err)
+	}
+
+	receive := func() store.EventID {
+		select {
+		case e := <-subscription.EventChannel():
+			return e.ID
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event was received")
+			return ""
+		}
+	}
+
+	appendEvent("event#1")
+	assert.Equal(t, store.EventID("event#1"), receive())
+
+	// Simulate a dropped connection: the events appended while the listener is not listening are never notified.
+	assert.NoError(t, st.notifyListener.Unlisten("events"))
+	appendEvent("event#2")
+	appendEvent("event#3")
+	assert.NoError(t, st.notifyListener.Listen("events"))
+	st.handleListenerEvent(pq.ListenerEventReconnected, nil)
+
+	assert.Equal(t, store.EventID("event#2"), receive())
+	assert.Equal(t, store.EventID("event#3"), receive())
+
+	// Live notifications should resume without redelivering the events of the gap.
+	appendEvent("event#4")
+	assert.Equal(t, store.EventID("event#4"), receive())
+
+	select {
+	case e := <-subscription.EventChannel():
+		t.Fatalf("unexpected event %s was received", e.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEventStore_SubscribeToStream_EventsCommittedOutOfOrder(t *testing.T) {
+	ctx := context.Background()
+
+	// beginAppend inserts an event in a transaction that is left open, the event obtaining its sequence number
+	// from the insertion, but only being notified once the transaction is committed.
+	beginAppend := func(st *EventStore, id store.EventID) *sql.Tx {
+		tx, err := st.database.BeginTx(ctx, nil)
+		assert.NoError(t, err)
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO events (id, stream_id, stream_version, type, metadata, data, recorded_at) VALUES ($1, $2, 0, $3, '{}', '{}', $4)`,
+			id, store.UniqueStreamID("unit_test"), postgreSQLUnitTestPassedEvent{}.TypeName(), time.Now(),
+		)
+		assert.NoError(t, err)
+		return tx
+	}
+
+	receive := func(subscription store.Subscription) store.EventID {
+		select {
+		case e := <-subscription.EventChannel():
+			return e.ID
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event was received")
+			return ""
+		}
+	}
+
+	t.Run("live", func(t *testing.T) {
+		st := buildEventStore()
+		subscription, err := st.SubscribeToStream(ctx, st.GlobalStreamID())
+		assert.NoError(t, err)
+		defer subscription.Close()
+
+		first := beginAppend(st, "event#1")
+		second := beginAppend(st, "event#2")
+
+		assert.NoError(t, second.Commit())
+		assert.Equal(t, store.EventID("event#2"), receive(subscription))
+
+		assert.NoError(t, first.Commit())
+		assert.Equal(t, store.EventID("event#1"), receive(subscription))
+	})
+
+	t.Run("after listener reconnection", func(t *testing.T) {
+		st := buildEventStore()
+		subscription, err := st.SubscribeToStream(ctx, st.GlobalStreamID())
+		assert.NoError(t, err)
+		defer subscription.Close()
+
+		first := beginAppend(st, "event#1")
+		second := beginAppend(st, "event#2")
+
+		assert.NoError(t, second.Commit())
+		assert.Equal(t, store.EventID("event#2"), receive(subscription))
+
+		assert.NoError(t, st.notifyListener.Unlisten("events"))
+		assert.NoError(t, first.Commit())
+		assert.NoError(t, st.notifyListener.Listen("events"))
+		st.handleListenerEvent(pq.ListenerEventReconnected, nil)
+
+		assert.Equal(t, store.EventID("event#1"), receive(subscription))
+	})
+
+	t.Run("in progress when subscribing", func(t *testing.T) {
+		st := buildEventStore()
+		first := beginAppend(st, "event#1")
+		second := beginAppend(st, "event#2")
+		assert.NoError(t, second.Commit())
+
+		subscription, err := st.SubscribeToStream(ctx, st.GlobalStreamID())
+		assert.NoError(t, err)
+		defer subscription.Close()
+
+		assert.NoError(t, first.Commit())
+		assert.Equal(t, store.EventID("event#1"), receive(subscription))
+	})
+}
+
+func TestNotifiedSubscription_markNotified(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("events notified in order", func(t *testing.T) {
+		s := newNotifiedSubscription(nil, nil, now)
+		s.markNotified(0, now)
+		s.markNotified(1, now)
+		assert.Equal(t, store.SequenceNumber(1), s.checkpoint)
+		assert.Empty(t, s.notified)
+	})
+
+	t.Run("events notified out of order", func(t *testing.T) {
+		s := newNotifiedSubscription(nil, []store.RecordedEventDescriptor{{SequenceNumber: 1}}, now)
+		s.markNotified(3, now)
+		assert.True(t, s.wasNotified(3))
+		assert.False(t, s.wasNotified(2))
+		assert.Equal(t, store.SequenceNumber(1), s.checkpoint)
+
+		s.markNotified(2, now)
+		assert.True(t, s.wasNotified(2))
+		assert.Equal(t, store.SequenceNumber(3), s.checkpoint)
+		assert.Empty(t, s.notified)
+	})
+
+	t.Run("gaps between preceding events", func(t *testing.T) {
+		s := newNotifiedSubscription(nil, []store.RecordedEventDescriptor{{SequenceNumber: 1}, {SequenceNumber: 3}}, now)
+		assert.True(t, s.wasNotified(3))
+		assert.False(t, s.wasNotified(2))
+	})
+
+	t.Run("abandoned gap", func(t *testing.T) {
+		s := newNotifiedSubscription(nil, []store.RecordedEventDescriptor{{SequenceNumber: 1}}, now)
+		s.markNotified(3, now)
+		s.markNotified(4, now.Add(abandonedSequenceGapTimeout/2))
+		assert.False(t, s.wasNotified(2))
+
+		s.markNotified(6, now.Add(abandonedSequenceGapTimeout))
+		assert.True(t, s.wasNotified(2))
+		assert.Equal(t, store.SequenceNumber(4), s.checkpoint)
+
+		// The gap following the abandoned one has its own timeout.
+		assert.False(t, s.wasNotified(5))
+		s.markNotified(7, now.Add(abandonedSequenceGapTimeout+time.Second))
+		assert.False(t, s.wasNotified(5))
+	})
+}
+
+func TestEventStore_SubscribeToStream_WithSubscriptionFilter(t *testing.T) {
+	st := buildEventStore()
+	streamID := store.UniqueStreamID("unit_test")
+
+	subscription, err := st.SubscribeToStream(
+		context.Background(),
+		st.GlobalStreamID(),
+		store.WithSubscriptionFilter(store.SelectEventTypeNames(postgreSQLUnitTestPassedEvent{}.TypeName())),
+	)
+	assert.NoError(t, err)
+	defer subscription.Close()
+
+	err = st.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+		{ID: "event#1", TypeName: "unit_test.failed", Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#2", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#3", TypeName: "unit_test.failed", Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	select {
+	case e := <-subscription.EventChannel():
+		assert.Equal(t, store.EventID("event#2"), e.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the matching event was not received")
+	}
+
+	// Non-matching events should not be delivered.
+	select {
+	case e := <-subscription.EventChannel():
+		t.Fatalf("unexpected event %s was received", e.ID)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
 func TestEventStore_TruncateStream(t *testing.T) {
 	st := buildEventStore()
 
@@ -346,3 +605,711 @@ func TestEventStore_TruncateStream(t *testing.T) {
 	events, err := st.ReadFromStream(context.Background(), streamID, store.FromStart(), store.InForwardDirection())
 	assert.Len(t, events.Descriptors, 2)
 }
+
+func TestEventStore_ReadFromStream_FromEnd(t *testing.T) {
+	st := buildEventStore()
+
+	streamID := store.StreamID("unit_test")
+	err := st.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+		{ID: "event#1", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#2", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#3", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	for _, id := range []store.StreamID{streamID, st.GlobalStreamID()} {
+		// Reading forward from the end should yield nothing.
+		events, err := st.ReadFromStream(context.Background(), id, store.FromEnd(), store.InForwardDirection())
+		assert.NoError(t, err)
+		assert.Empty(t, events.Descriptors, "stream %s", id)
+
+		// Reading backward from the end should yield all the events, latest first.
+		events, err = st.ReadFromStream(context.Background(), id, store.FromEnd(), store.InBackwardDirection())
+		assert.NoError(t, err)
+		if assert.Len(t, events.Descriptors, 3, "stream %s", id) {
+			assert.Equal(t, store.EventID("event#3"), events.First().ID)
+			assert.Equal(t, store.EventID("event#1"), events.Last().ID)
+		}
+
+		// Reading the last event should only yield the latest event.
+		events, err = st.ReadFromStream(context.Background(), id, store.LastEvent())
+		assert.NoError(t, err)
+		if assert.Len(t, events.Descriptors, 1, "stream %s", id) {
+			assert.Equal(t, store.EventID("event#3"), events.First().ID)
+		}
+	}
+}
+
+func TestEventStore_ReadFromStream_BackwardFromEnd(t *testing.T) {
+	st := buildEventStore()
+	ctx := context.Background()
+
+	appendEvent := func(streamID store.StreamID, id store.EventID) {
+		err := st.AppendToStream(ctx, streamID, []store.EventDescriptor{
+			{ID: id, TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		}, store.WithOptimisticConcurrencyCheckDisabled())
+		assert.NoError(t, err)
+	}
+
+	// The streams are interleaved, so that the last event of a stream is not the last event of the store.
+	appendEvent("users", "user#1")
+	appendEvent("accounts", "account#1")
+	appendEvent("users", "user#2")
+	appendEvent("accounts", "account#2")
+	appendEvent("accounts", "account#3")
+
+	tests := []struct {
+		name     string
+		streamID store.StreamID
+		maxCount int
+		want     []store.EventID
+	}{
+		{name: "stream", streamID: "users", want: []store.EventID{"user#2", "user#1"}},
+		{name: "stream with max count", streamID: "users", maxCount: 1, want: []store.EventID{"user#2"}},
+		{name: "global stream", streamID: GlobalStreamID, want: []store.EventID{"account#3", "account#2", "user#2", "account#1", "user#1"}},
+		{name: "global stream with max count", streamID: GlobalStreamID, maxCount: 2, want: []store.EventID{"account#3", "account#2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slice, err := st.ReadFromStream(ctx, tt.streamID, store.FromEnd(), store.InBackwardDirection(), store.WithMaxCount(tt.maxCount))
+			assert.NoError(t, err)
+
+			var got []store.EventID
+			for _, d := range slice.Descriptors {
+				got = append(got, d.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPositionCondition(t *testing.T) {
+	tests := []struct {
+		name           string
+		isGlobalStream bool
+		opts           []store.ReadFromStreamOption
+		wantCondition  string
+		wantParams     []any
+	}{
+		{
+			name:          "forward from position in stream",
+			opts:          []store.ReadFromStreamOption{store.From(2), store.InForwardDirection()},
+			wantCondition: "stream_version > $3",
+			wantParams:    []any{"2"},
+		},
+		{
+			name:           "backward from position in global stream",
+			isGlobalStream: true,
+			opts:           []store.ReadFromStreamOption{store.From(2), store.InBackwardDirection()},
+			wantCondition:  "sequence_number < $3",
+			wantParams:     []any{"2"},
+		},
+		{
+			name:          "forward from end",
+			opts:          []store.ReadFromStreamOption{store.FromEnd(), store.InForwardDirection()},
+			wantCondition: "FALSE",
+		},
+		{
+			name:           "backward from end",
+			isGlobalStream: true,
+			opts:           []store.ReadFromStreamOption{store.FromEnd(), store.InBackwardDirection()},
+			wantCondition:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, params := positionCondition(tt.isGlobalStream, store.BuildReadFromStreamOptions(tt.opts), 3)
+			assert.Equal(t, tt.wantCondition, condition)
+			assert.Equal(t, tt.wantParams, params)
+		})
+	}
+}
+
+func TestEventStore_ReadFromStream_RecordedAfter(t *testing.T) {
+	before := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	after := before.Add(time.Hour)
+	c := clock.NewFixedClock(before)
+	st := buildEventStore()
+	st.clock = c
+
+	streamID := store.StreamID("unit_test")
+	err := st.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+		{ID: "event#1", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	c.Set(after)
+	err = st.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+		{ID: "event#2", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#3", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	events, err := st.ReadFromStream(context.Background(), streamID, store.FromStart(), store.RecordedAfter(before))
+	assert.NoError(t, err)
+	if assert.Len(t, events.Descriptors, 2) {
+		assert.Equal(t, store.EventID("event#2"), events.First().ID)
+		assert.Equal(t, store.EventID("event#3"), events.Last().ID)
+	}
+
+	// Should compose with the position and direction.
+	events, err = st.ReadFromStream(context.Background(), st.GlobalStreamID(), store.FromEnd(), store.InBackwardDirection(), store.RecordedAfter(before))
+	assert.NoError(t, err)
+	if assert.Len(t, events.Descriptors, 2) {
+		assert.Equal(t, store.EventID("event#3"), events.First().ID)
+		assert.Equal(t, store.EventID("event#2"), events.Last().ID)
+	}
+
+	events, err = st.ReadFromStream(context.Background(), streamID, store.FromStart(), store.RecordedAfter(after))
+	assert.NoError(t, err)
+	assert.Empty(t, events.Descriptors)
+}
+
+func TestEventStore_AppendToStream_ConcurrencyErrorParity(t *testing.T) {
+	appendTwiceWithInitialVersion := func(es store.EventStore) error {
+		streamID := store.StreamID("unit_test")
+		if err := es.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+			{ID: store.EventID(uuid.NewString()), TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		}, store.WithExpectedVersion(store.InitialVersion)); err != nil {
+			return err
+		}
+
+		return es.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+			{ID: store.EventID(uuid.NewString()), TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		}, store.WithExpectedVersion(store.InitialVersion))
+	}
+
+	postgresErr := appendTwiceWithInitialVersion(buildEventStore())
+	inMemoryErr := appendTwiceWithInitialVersion(store.NewInMemoryEventStore(clock.UTCClock{}))
+
+	assert.True(t, store.IsConcurrencyError(postgresErr))
+	assert.True(t, store.IsConcurrencyError(inMemoryErr))
+	assert.IsType(t, inMemoryErr, postgresErr)
+	assert.Equal(t, inMemoryErr, postgresErr)
+}
+
+func TestEventStore_AppendToStream_DuplicateEventID(t *testing.T) {
+	st := buildEventStore()
+
+	streamID := store.StreamID("unit_test")
+	err := st.AppendToStream(context.Background(), streamID, []store.EventDescriptor{
+		{ID: "event#1", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#1", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.True(t, store.IsDuplicateEventIDError(err))
+	assert.Equal(t, store.NewDuplicateEventIDError(streamID, "event#1"), err)
+
+	exists, err := st.StreamExists(context.Background(), streamID)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestEventStore_CountEvents(t *testing.T) {
+	st := buildEventStore()
+	ctx := context.Background()
+
+	count, err := st.CountEvents(ctx, "empty")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	err = st.AppendToStream(ctx, "single", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+	count, err = st.CountEvents(ctx, "single")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	err = st.AppendToStream(ctx, "multi", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: store.NewEventID(), TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: store.NewEventID(), TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+	count, err = st.CountEvents(ctx, "multi")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	count, err = st.CountEvents(ctx, st.GlobalStreamID())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestNewEventStoreWithOptions_WithTLSConfig(t *testing.T) {
+	tlsConfig := &tls.Config{ServerName: "events.example.com"}
+	st := NewEventStoreWithOptions("postgres://postgres@localhost:5432/postgres?sslmode=verify-full", clock.UTCClock{}, WithTLSConfig(tlsConfig))
+
+	var openedConnectionString string
+	var openedDialer pq.Dialer
+	st.openDB = func(connectionString string, dialer pq.Dialer, logger *zap.Logger) (*sql.DB, error) {
+		openedConnectionString = connectionString
+		openedDialer = dialer
+		return nil, errors.New("not connecting in unit tests")
+	}
+
+	assert.Error(t, st.Open(context.Background()))
+	assert.Equal(t, newTLSDialer(tlsConfig), openedDialer)
+	assert.Contains(t, openedConnectionString, "host='localhost'")
+	assert.True(t, strings.HasSuffix(openedConnectionString, " sslmode=disable"))
+}
+
+func TestNewEventStore_WithoutTLSConfig(t *testing.T) {
+	connectionString := "postgres://postgres@localhost:5432/postgres?sslmode=disable"
+	st := NewEventStore(connectionString, clock.UTCClock{})
+
+	var openedConnectionString string
+	var openedDialer pq.Dialer
+	st.openDB = func(connectionString string, dialer pq.Dialer, logger *zap.Logger) (*sql.DB, error) {
+		openedConnectionString = connectionString
+		openedDialer = dialer
+		return nil, errors.New("not connecting in unit tests")
+	}
+
+	assert.Error(t, st.Open(context.Background()))
+	assert.Nil(t, openedDialer)
+	assert.Equal(t, connectionString, openedConnectionString)
+}
+
+func TestEventStore_ReadFromStream_SameRecordedAtOrdering(t *testing.T) {
+	st := buildEventStore()
+	st.clock = clock.NewFixedClock(time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	for _, batch := range []struct {
+		streamID store.StreamID
+		ids      []store.EventID
+	}{
+		{streamID: "account-1", ids: []store.EventID{"event#1", "event#2"}},
+		{streamID: "account-2", ids: []store.EventID{"event#3"}},
+		{streamID: "account-1", ids: []store.EventID{"event#4", "event#5"}},
+	} {
+		var descriptors []store.EventDescriptor
+		for _, id := range batch.ids {
+			descriptors = append(descriptors, store.EventDescriptor{ID: id, TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}})
+		}
+		assert.NoError(t, st.AppendToStream(ctx, batch.streamID, descriptors))
+	}
+
+	ids := func(s store.StreamSlice) []store.EventID {
+		var ids []store.EventID
+		for _, d := range s.Descriptors {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+
+	slice, err := st.ReadFromStream(ctx, "account-1", store.FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, []store.EventID{"event#1", "event#2", "event#4", "event#5"}, ids(slice))
+
+	slice, err = st.ReadFromStream(ctx, "account-1", store.FromEnd(), store.InBackwardDirection())
+	assert.NoError(t, err)
+	assert.Equal(t, []store.EventID{"event#5", "event#4", "event#2", "event#1"}, ids(slice))
+
+	slice, err = st.ReadFromStream(ctx, st.GlobalStreamID(), store.FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, []store.EventID{"event#1", "event#2", "event#3", "event#4", "event#5"}, ids(slice))
+}
+
+func TestEventStore_WithPayloadCompression(t *testing.T) {
+	ctx := context.Background()
+	st := NewEventStoreWithOptions("postgres://postgres@localhost:5432/postgres?sslmode=disable", clock.UTCClock{}, WithPayloadCompression())
+	assert.NoError(t, st.Open(ctx))
+	assert.NoError(t, st.Clear(ctx))
+
+	testName := strings.Repeat("TestEventStore_WithPayloadCompression", 10000)
+	err := st.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{
+			ID:       "large",
+			TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(),
+			Payload:  store.DescriptorPayload{"TestName": testName},
+			Metadata: misas.Metadata{}.Set("test", "compression"),
+		},
+	})
+	assert.NoError(t, err)
+
+	var data []byte
+	var compressedDataSize int
+	var metadataTest string
+	row := st.database.QueryRowContext(ctx, "SELECT data, octet_length(compressed_data), metadata->>'test' FROM events WHERE id = 'large'")
+	assert.NoError(t, row.Scan(&data, &compressedDataSize, &metadataTest))
+	assert.Nil(t, data)
+	assert.Less(t, compressedDataSize, len(testName))
+	assert.Equal(t, "compression", metadataTest)
+
+	slice, err := st.ReadFromStream(ctx, "unit_test", store.FromStart())
+	assert.NoError(t, err)
+	assert.Len(t, slice.Descriptors, 1)
+	assert.Equal(t, testName, slice.Descriptors[0].Payload["TestName"])
+}
+
+func TestEventStore_WithPayloadCompression_LegacyUncompressedPayload(t *testing.T) {
+	ctx := context.Background()
+	legacy := buildEventStore()
+	err := legacy.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{
+			ID:       "legacy",
+			TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(),
+			Payload:  store.DescriptorPayload{"TestName": "TestEventStore_WithPayloadCompression_LegacyUncompressedPayload"},
+		},
+	})
+	assert.NoError(t, err)
+
+	st := NewEventStoreWithOptions("postgres://postgres@localhost:5432/postgres?sslmode=disable", clock.UTCClock{}, WithPayloadCompression())
+	assert.NoError(t, st.Open(ctx))
+
+	slice, err := st.ReadFromStream(ctx, "unit_test", store.FromStart())
+	assert.NoError(t, err)
+	assert.Len(t, slice.Descriptors, 1)
+	assert.Equal(t, "TestEventStore_WithPayloadCompression_LegacyUncompressedPayload", slice.Descriptors[0].Payload["TestName"])
+}
+
+func TestEventStore_ReadFromStream_WithPayloadProjection(t *testing.T) {
+	ctx := context.Background()
+	payload := store.DescriptorPayload{"TestName": "TestEventStore_ReadFromStream_WithPayloadProjection", "Duration": float64(12), "Output": "ok"}
+	want := store.DescriptorPayload{"TestName": "TestEventStore_ReadFromStream_WithPayloadProjection", "Duration": float64(12)}
+
+	st := buildEventStore()
+	err := st.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{ID: "uncompressed", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: payload},
+	})
+	assert.NoError(t, err)
+
+	compressing := NewEventStoreWithOptions("postgres://postgres@localhost:5432/postgres?sslmode=disable", clock.UTCClock{}, WithPayloadCompression())
+	assert.NoError(t, compressing.Open(ctx))
+	err = compressing.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{ID: "compressed", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: payload},
+	})
+	assert.NoError(t, err)
+
+	slice, err := st.ReadFromStream(ctx, "unit_test", store.FromStart(), store.WithPayloadProjection("TestName", "Duration", "Unknown"))
+	assert.NoError(t, err)
+	assert.Len(t, slice.Descriptors, 2)
+	for _, d := range slice.Descriptors {
+		assert.Equal(t, want, d.Payload, "payload of event %s", d.ID)
+	}
+
+	// Projection should compose with other parameterized options.
+	slice, err = st.ReadFromStream(ctx, "unit_test", store.From(0), store.WithPayloadProjection("Output"))
+	assert.NoError(t, err)
+	assert.Len(t, slice.Descriptors, 1)
+	assert.Equal(t, store.DescriptorPayload{"Output": "ok"}, slice.Descriptors[0].Payload)
+
+	slice, err = st.ReadFromStream(ctx, "unit_test", store.FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, payload, slice.Descriptors[0].Payload)
+}
+
+func TestEventStore_ReadFromStream_WithoutPayloads(t *testing.T) {
+	ctx := context.Background()
+	payload := store.DescriptorPayload{"TestName": "TestEventStore_ReadFromStream_WithoutPayloads"}
+
+	st := buildEventStore()
+	err := st.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{ID: "event#1", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: payload, Metadata: misas.Metadata{"userId": "user-123"}},
+		{ID: "event#2", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: payload, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	slice, err := st.ReadFromStream(ctx, "unit_test", store.FromStart(), store.WithoutPayloads())
+	assert.NoError(t, err)
+	if assert.Len(t, slice.Descriptors, 2) {
+		for i, d := range slice.Descriptors {
+			assert.Nil(t, d.Payload)
+			assert.Equal(t, store.EventID(fmt.Sprintf("event#%d", i+1)), d.ID)
+			assert.Equal(t, postgreSQLUnitTestPassedEvent{}.TypeName(), d.TypeName)
+			assert.Equal(t, store.StreamVersion(i), d.Version)
+		}
+		assert.Equal(t, misas.Metadata{"userId": "user-123"}, slice.First().Metadata)
+	}
+}
+
+func TestEventStore_ReadFromStream_WithReadingFilter(t *testing.T) {
+	ctx := context.Background()
+	st := buildEventStore()
+	err := st.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{ID: "event#1", TypeName: "unit_test.started", Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#2", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#3", TypeName: "unit_test.failed", Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "event#4", TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		streamID store.StreamID
+		opts     []store.ReadFromStreamOption
+		wantIDs  []store.EventID
+	}{
+		{
+			name:     "select",
+			streamID: "unit_test",
+			opts:     []store.ReadFromStreamOption{store.WithReadingFilter(store.SelectEventTypeNames(postgreSQLUnitTestPassedEvent{}.TypeName()))},
+			wantIDs:  []store.EventID{"event#2", "event#4"},
+		},
+		{
+			name:     "exclude",
+			streamID: "unit_test",
+			opts:     []store.ReadFromStreamOption{store.WithReadingFilter(store.ExcludeEventTypeNames(postgreSQLUnitTestPassedEvent{}.TypeName()))},
+			wantIDs:  []store.EventID{"event#1", "event#3"},
+		},
+		{
+			name:     "max count only counts matching events",
+			streamID: "unit_test",
+			opts: []store.ReadFromStreamOption{
+				store.WithReadingFilter(store.SelectEventTypeNames(postgreSQLUnitTestPassedEvent{}.TypeName(), "unit_test.failed")),
+				store.WithMaxCount(2),
+			},
+			wantIDs: []store.EventID{"event#2", "event#3"},
+		},
+		{
+			name:     "global stream",
+			streamID: st.GlobalStreamID(),
+			opts:     []store.ReadFromStreamOption{store.WithReadingFilter(store.SelectEventTypeNames("unit_test.started"))},
+			wantIDs:  []store.EventID{"event#1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slice, err := st.ReadFromStream(ctx, tt.streamID, append([]store.ReadFromStreamOption{store.FromStart()}, tt.opts...)...)
+			assert.NoError(t, err)
+
+			var ids []store.EventID
+			for _, d := range slice.Descriptors {
+				ids = append(ids, d.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestTypeNameFilterCondition(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        *store.TypeNameFilter
+		wantCondition string
+		wantParams    []any
+	}{
+		{
+			name:          "no filter",
+			filter:        nil,
+			wantCondition: "",
+		},
+		{
+			name:          "select",
+			filter:        &store.TypeNameFilter{Mode: store.Select, EventTypeNames: []event.PayloadTypeName{"unit_test.passed"}},
+			wantCondition: "type = ANY($3)",
+			wantParams:    []any{pq.Array([]string{"unit_test.passed"})},
+		},
+		{
+			name:          "exclude",
+			filter:        &store.TypeNameFilter{Mode: store.Exclude, EventTypeNames: []event.PayloadTypeName{"unit_test.passed", "unit_test.failed"}},
+			wantCondition: "NOT (type = ANY($3))",
+			wantParams:    []any{pq.Array([]string{"unit_test.passed", "unit_test.failed"})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, params := typeNameFilterCondition(tt.filter, 3)
+			assert.Equal(t, tt.wantCondition, condition)
+			assert.Equal(t, tt.wantParams, params)
+		})
+	}
+}
+
+func TestSelectEventsSql(t *testing.T) {
+	dataColumn := regexp.MustCompile(`\bdata\b`)
+
+	selectSql, params := selectEventsSql(store.BuildReadFromStreamOptions(nil))
+	assert.Regexp(t, dataColumn, selectSql)
+	assert.Empty(t, params)
+
+	selectSql, params = selectEventsSql(store.BuildReadFromStreamOptions([]store.ReadFromStreamOption{store.WithPayloadProjection("TestName")}))
+	assert.Regexp(t, dataColumn, selectSql)
+	assert.Len(t, params, 1)
+
+	// The data column should not be selected at all, even along with a projection.
+	selectSql, params = selectEventsSql(store.BuildReadFromStreamOptions([]store.ReadFromStreamOption{
+		store.WithoutPayloads(),
+		store.WithPayloadProjection("TestName"),
+	}))
+	assert.NotRegexp(t, dataColumn, selectSql)
+	assert.Empty(t, params)
+}
+
+func TestEventStore_ReadFromStream_MalformedPayload(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		policy  MalformedPayloadPolicy
+		wantErr bool
+		want    []store.DescriptorPayload
+	}{
+		{
+			name:    "fail",
+			policy:  FailOnMalformedPayload,
+			wantErr: true,
+		},
+		{
+			name:   "skip",
+			policy: SkipMalformedPayload,
+			want:   []store.DescriptorPayload{{"TestName": "event#1"}, {"TestName": "event#3"}},
+		},
+		{
+			name:   "raw",
+			policy: RawMalformedPayload,
+			want:   []store.DescriptorPayload{{"TestName": "event#1"}, {RawPayloadKey: "[1, 2]"}, {"TestName": "event#3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := NewEventStoreWithOptions("postgres://postgres@localhost:5432/postgres?sslmode=disable", clock.UTCClock{}, WithMalformedPayloadPolicy(tt.policy))
+			assert.NoError(t, st.Open(ctx))
+			assert.NoError(t, st.Clear(ctx))
+
+			appendEvent := func(id store.EventID) {
+				err := st.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+					{ID: id, TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{"TestName": string(id)}, Metadata: misas.Metadata{}},
+				}, store.WithOptimisticConcurrencyCheckDisabled())
+				assert.NoError(t, err)
+			}
+
+			appendEvent("event#1")
+			// A payload that is valid JSON, but not an object, as an external writer could have stored.
+			_, err := st.database.ExecContext(ctx, `
+INSERT INTO events (id, stream_id, stream_version, type, metadata, data, recorded_at)
+VALUES ('malformed', 'unit_test', 1, 'unit_test.passed', '{}', '[1, 2]', NOW());
+UPDATE streams SET version = 1 WHERE id = 'unit_test';`)
+			assert.NoError(t, err)
+			appendEvent("event#3")
+
+			slice, err := st.ReadFromStream(ctx, "unit_test", store.FromStart())
+			if tt.wantErr {
+				assert.True(t, IsMalformedPayloadError(err))
+				assert.Contains(t, err.Error(), "malformed")
+				var malformedPayloadError MalformedPayloadError
+				if assert.ErrorAs(t, err, &malformedPayloadError) {
+					assert.Equal(t, store.EventID("malformed"), malformedPayloadError.EventID)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			var got []store.DescriptorPayload
+			for _, d := range slice.Descriptors {
+				got = append(got, d.Payload)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	compressed, err := compressPayload([]byte(`{"TestName": "DecodePayload"}`))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		data           []byte
+		compressedData []byte
+		want           store.DescriptorPayload
+		wantErr        bool
+	}{
+		{name: "object", data: []byte(`{"TestName": "DecodePayload"}`), want: store.DescriptorPayload{"TestName": "DecodePayload"}},
+		{name: "compressed object", compressedData: compressed, want: store.DescriptorPayload{"TestName": "DecodePayload"}},
+		{name: "not an object", data: []byte(`[1, 2]`), wantErr: true},
+		{name: "invalid JSON", data: []byte(`{"TestName": `), wantErr: true},
+		{name: "corrupted compressed data", compressedData: []byte("not gzip"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePayload(tt.data, tt.compressedData)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEventStore_TruncateStream_KeepLast(t *testing.T) {
+	ctx := context.Background()
+	st := buildEventStore()
+
+	var descriptors []store.EventDescriptor
+	for i := 0; i < 10; i++ {
+		descriptors = append(descriptors, store.EventDescriptor{
+			ID:       store.NewEventID(),
+			TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(),
+			Payload:  store.DescriptorPayload{"TestName": "TestEventStore_TruncateStream_KeepLast"},
+			Metadata: misas.Metadata{},
+		})
+	}
+	assert.NoError(t, st.AppendToStream(ctx, "unit_test", descriptors))
+
+	err := st.TruncateStream(ctx, "unit_test", store.KeepLast(3))
+	assert.NoError(t, err)
+
+	slice, err := st.ReadFromStream(ctx, "unit_test", store.FromStart())
+	assert.NoError(t, err)
+	var versions []store.StreamVersion
+	for _, d := range slice.Descriptors {
+		versions = append(versions, d.Version)
+	}
+	assert.Equal(t, []store.StreamVersion{7, 8, 9}, versions)
+}
+
+func TestEventStore_RenameEventType(t *testing.T) {
+	ctx := context.Background()
+	st := buildEventStore()
+
+	err := st.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{ID: "evt-1", TypeName: "unit_test.started", Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "evt-2", TypeName: "unit_test.passed", Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: "evt-3", TypeName: "unit_test.passed", Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+	err = st.AppendToStream(ctx, "other_unit_test", []store.EventDescriptor{
+		{ID: "evt-4", TypeName: "unit_test.passed", Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	renamed, err := store.RenameEventType(ctx, st, "unit_test", "unit_test.passed", "unit_test.succeeded")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, renamed)
+
+	slice, err := st.ReadFromStream(ctx, "unit_test", store.FromStart())
+	assert.NoError(t, err)
+	var types []event.PayloadTypeName
+	for _, d := range slice.Descriptors {
+		types = append(types, d.TypeName)
+	}
+	assert.Equal(t, []event.PayloadTypeName{"unit_test.started", "unit_test.succeeded", "unit_test.succeeded"}, types)
+
+	other, err := st.ReadFromStream(ctx, "other_unit_test", store.FromStart())
+	assert.NoError(t, err)
+	assert.Equal(t, event.PayloadTypeName("unit_test.passed"), other.Descriptors[0].TypeName)
+}
+
+func TestEventStore_TruncateStream_RecordsTruncatedStreamID(t *testing.T) {
+	ctx := context.Background()
+	st := buildEventStore()
+
+	err := st.AppendToStream(ctx, "unit_test", []store.EventDescriptor{
+		{ID: store.NewEventID(), TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+		{ID: store.NewEventID(), TypeName: postgreSQLUnitTestPassedEvent{}.TypeName(), Payload: store.DescriptorPayload{}, Metadata: misas.Metadata{}},
+	})
+	assert.NoError(t, err)
+
+	err = st.TruncateStream(ctx, "unit_test", store.BeforePosition(1))
+	assert.NoError(t, err)
+
+	slice, err := st.ReadFromStream(ctx, InternalStreamID, store.FromStart())
+	assert.NoError(t, err)
+	assert.Len(t, slice.Descriptors, 1)
+	assert.Equal(t, store.StreamTruncatedEventTypeName, slice.Descriptors[0].TypeName)
+	assert.Equal(t, "unit_test", slice.Descriptors[0].Payload["streamId"])
+}
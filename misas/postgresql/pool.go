@@ -0,0 +1,54 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"time"
+)
+
+// ConnectionPoolOptions represents the settings of the pool of connections to the database used by a store.
+type ConnectionPoolOptions struct {
+	// MaxOpenConns is the maximum number of open connections to the database, zero meaning unlimited.
+	MaxOpenConns int
+
+	// MaxIdleConns is the maximum number of connections kept in the idle pool, zero or less meaning none are kept.
+	MaxIdleConns int
+
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused, zero meaning forever.
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultConnectionPoolOptions returns the settings of the connection pool used by default, which are the ones of database/sql.
+func DefaultConnectionPoolOptions() ConnectionPoolOptions {
+	return ConnectionPoolOptions{
+		MaxOpenConns:    0,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: 0,
+	}
+}
+
+// connectionPool represents a pool of connections that can be tuned, such as *sql.DB.
+type connectionPool interface {
+	SetMaxOpenConns(n int)
+	SetMaxIdleConns(n int)
+	SetConnMaxLifetime(d time.Duration)
+}
+
+// applyTo configures a connection pool according to these options.
+func (o ConnectionPoolOptions) applyTo(pool connectionPool) {
+	pool.SetMaxOpenConns(o.MaxOpenConns)
+	pool.SetMaxIdleConns(o.MaxIdleConns)
+	pool.SetConnMaxLifetime(o.ConnMaxLifetime)
+}
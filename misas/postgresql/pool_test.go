@@ -0,0 +1,108 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/morebec/misas-go/misas/clock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// unreachableConnectionString is a connection string to a database that cannot be reached, so that opening a store fails.
+const unreachableConnectionString = "host=127.0.0.1 port=1 user=postgres sslmode=disable connect_timeout=1"
+
+type recordingConnectionPool struct {
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+}
+
+func (p *recordingConnectionPool) SetMaxOpenConns(n int) {
+	p.maxOpenConns = n
+}
+
+func (p *recordingConnectionPool) SetMaxIdleConns(n int) {
+	p.maxIdleConns = n
+}
+
+func (p *recordingConnectionPool) SetConnMaxLifetime(d time.Duration) {
+	p.connMaxLifetime = d
+}
+
+func TestConnectionPoolOptions_applyTo(t *testing.T) {
+	pool := &recordingConnectionPool{}
+
+	ConnectionPoolOptions{
+		MaxOpenConns:    20,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Minute,
+	}.applyTo(pool)
+
+	assert.Equal(t, &recordingConnectionPool{maxOpenConns: 20, maxIdleConns: 5, connMaxLifetime: time.Minute}, pool)
+}
+
+func TestDefaultConnectionPoolOptions(t *testing.T) {
+	pool := &recordingConnectionPool{}
+	DefaultConnectionPoolOptions().applyTo(pool)
+
+	assert.Equal(t, &recordingConnectionPool{maxOpenConns: 0, maxIdleConns: 2, connMaxLifetime: 0}, pool)
+}
+
+func TestNewEventStoreWithOptions_WithConnectionPool(t *testing.T) {
+	st := NewEventStoreWithOptions(unreachableConnectionString, clock.UTCClock{}, WithConnectionPool(ConnectionPoolOptions{
+		MaxOpenConns:    20,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Minute,
+	}))
+
+	var opened *sql.DB
+	st.openDB = func(connectionString string, dialer pq.Dialer, logger *zap.Logger) (*sql.DB, error) {
+		db, err := openDB(connectionString, dialer, logger)
+		opened = db
+		return db, err
+	}
+
+	assert.Error(t, st.Open(context.Background()))
+	if assert.NotNil(t, opened) {
+		defer opened.Close()
+		assert.Equal(t, 20, opened.Stats().MaxOpenConnections)
+	}
+}
+
+func TestNewDocumentStoreWithOptions_WithDocumentStoreConnectionPool(t *testing.T) {
+	ds := NewDocumentStoreWithOptions(unreachableConnectionString, WithDocumentStoreConnectionPool(ConnectionPoolOptions{
+		MaxOpenConns:    20,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Minute,
+	}))
+
+	var opened *sql.DB
+	ds.openDB = func(connectionString string, dialer pq.Dialer, logger *zap.Logger) (*sql.DB, error) {
+		db, err := openDB(connectionString, dialer, logger)
+		opened = db
+		return db, err
+	}
+
+	assert.Error(t, ds.Open(context.Background()))
+	if assert.NotNil(t, opened) {
+		defer opened.Close()
+		assert.Equal(t, 20, opened.Stats().MaxOpenConnections)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql/driver"
+	"go.uber.org/zap"
+	"time"
+)
+
+// redactedQueryArg replaces the values of query arguments that could contain sensitive data in logs.
+const redactedQueryArg = "[REDACTED]"
+
+// redactQueryArgs returns the arguments of a query as they should appear in logs. Textual and binary values, such as
+// identifiers, payloads and documents, are redacted as they could contain sensitive data.
+func redactQueryArgs(args []driver.NamedValue) []any {
+	redacted := make([]any, 0, len(args))
+	for _, arg := range args {
+		switch arg.Value.(type) {
+		case string, []byte:
+			redacted = append(redacted, redactedQueryArg)
+		default:
+			redacted = append(redacted, arg.Value)
+		}
+	}
+	return redacted
+}
+
+// queryLoggingConnector is a driver.Connector whose connections log the queries they execute.
+type queryLoggingConnector struct {
+	driver.Connector
+	logger *zap.Logger
+}
+
+func newQueryLoggingConnector(connector driver.Connector, logger *zap.Logger) *queryLoggingConnector {
+	return &queryLoggingConnector{Connector: connector, logger: logger}
+}
+
+func (c *queryLoggingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connections not supporting the interfaces that can be logged are left as is.
+	loggable, ok := conn.(loggableConn)
+	if !ok {
+		return conn, nil
+	}
+
+	return &queryLoggingConn{loggableConn: loggable, logger: c.logger}, nil
+}
+
+// loggableConn represents the interfaces of a driver.Conn that must be supported for its queries to be logged.
+type loggableConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// queryLoggingConn is a connection logging the queries it executes at the debug level.
+type queryLoggingConn struct {
+	loggableConn
+	logger *zap.Logger
+}
+
+func (c *queryLoggingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	result, err := c.loggableConn.ExecContext(ctx, query, args)
+	c.log(query, args, start, err)
+
+	return result, err
+}
+
+func (c *queryLoggingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.loggableConn.QueryContext(ctx, query, args)
+	c.log(query, args, start, err)
+
+	return rows, err
+}
+
+// log logs a query along with its redacted arguments and its duration.
+func (c *queryLoggingConn) log(query string, args []driver.NamedValue, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		// The query will be executed by database/sql through another path.
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("query", query),
+		zap.Any("args", redactQueryArgs(args)),
+		zap.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	c.logger.Debug("postgresql.query", fields...)
+}
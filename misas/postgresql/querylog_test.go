@@ -0,0 +1,80 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+	"time"
+)
+
+// execOnlyConn is a loggableConn only supporting ExecContext, returning a given error.
+type execOnlyConn struct {
+	loggableConn
+	err error
+}
+
+func (c execOnlyConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), c.err
+}
+
+func Test_redactQueryArgs(t *testing.T) {
+	now := time.Now()
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: "secret"},
+		{Ordinal: 2, Value: []byte(`{"password": "secret"}`)},
+		{Ordinal: 3, Value: int64(5)},
+		{Ordinal: 4, Value: true},
+		{Ordinal: 5, Value: now},
+		{Ordinal: 6, Value: nil},
+	}
+
+	assert.Equal(t, []any{redactedQueryArg, redactedQueryArg, int64(5), true, now, nil}, redactQueryArgs(args))
+}
+
+func TestQueryLoggingConn_ExecContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	conn := &queryLoggingConn{loggableConn: execOnlyConn{}, logger: zap.New(core)}
+
+	_, err := conn.ExecContext(context.Background(), "DELETE FROM events WHERE stream_id = $1", []driver.NamedValue{{Ordinal: 1, Value: "stream"}})
+	assert.NoError(t, err)
+
+	conn.loggableConn = execOnlyConn{err: driver.ErrSkip}
+	_, err = conn.ExecContext(context.Background(), "SELECT 1", nil)
+	assert.ErrorIs(t, err, driver.ErrSkip)
+
+	conn.loggableConn = execOnlyConn{err: errors.New("query failed")}
+	_, err = conn.ExecContext(context.Background(), "SELECT 2", nil)
+	assert.Error(t, err)
+
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+		assert.Equal(t, "postgresql.query", entries[0].Message)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "DELETE FROM events WHERE stream_id = $1", fields["query"])
+		assert.Equal(t, []any{redactedQueryArg}, fields["args"])
+		assert.Contains(t, fields, "duration")
+
+		assert.Equal(t, "SELECT 2", entries[1].ContextMap()["query"])
+		assert.Equal(t, "query failed", entries[1].ContextMap()["error"])
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// sslRequestCode is the code of the message sent by a client to request a TLS session to a PostgreSQL server.
+const sslRequestCode = 80877103
+
+// tlsDialer is a pq.Dialer negotiating a TLS session with a PostgreSQL server using a custom tls.Config.
+// The TLS session being established by the dialer, the driver must be configured with "sslmode=disable".
+type tlsDialer struct {
+	config *tls.Config
+	dialer net.Dialer
+}
+
+func newTLSDialer(config *tls.Config) *tlsDialer {
+	return &tlsDialer{config: config}
+}
+
+func (d *tlsDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d *tlsDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, address)
+}
+
+func (d *tlsDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn, err := d.negotiateTLS(ctx, conn, address)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "failed establishing TLS session with \"%s\"", address)
+	}
+
+	return tlsConn, nil
+}
+
+// negotiateTLS sends an SSLRequest message to the server and performs the TLS handshake if the server accepts it.
+func (d *tlsDialer) negotiateTLS(ctx context.Context, conn net.Conn, address string) (net.Conn, error) {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], sslRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, 1)
+	if _, err := conn.Read(response); err != nil {
+		return nil, err
+	}
+	if response[0] != 'S' {
+		return nil, errors.New("server does not support TLS")
+	}
+
+	config := d.config.Clone()
+	if config.ServerName == "" && !config.InsecureSkipVerify {
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			config.ServerName = host
+		}
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// withDriverSSLDisabled returns a connection string in the key/value format, with the SSL negotiation of the driver disabled.
+func withDriverSSLDisabled(connectionString string) (string, error) {
+	if strings.HasPrefix(connectionString, "postgres://") || strings.HasPrefix(connectionString, "postgresql://") {
+		converted, err := pq.ParseURL(connectionString)
+		if err != nil {
+			return "", err
+		}
+		connectionString = converted
+	}
+
+	// The last occurrence of a key takes precedence over the previous ones.
+	return connectionString + " sslmode=disable", nil
+}
@@ -1,8 +1,10 @@
 package spectool
 
 import (
+	"fmt"
 	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/specter"
+	"strings"
 )
 
 type CommandField struct {
@@ -68,3 +70,36 @@ func (c *Command) Dependencies() []specter.SpecificationName {
 	}
 	return deps
 }
+
+// DefaultPastTenseSuffixes are the suffixes used by CommandsShouldUseImperativeNaming when none are provided.
+var DefaultPastTenseSuffixes = []string{"ed", "en"}
+
+// CommandsShouldUseImperativeNaming returns a linter reporting a warning for every command whose last name segment
+// ends with one of the given past tense suffixes, e.g. "user.registered" instead of "user.register".
+// If no suffixes are provided, DefaultPastTenseSuffixes are used.
+// Since matching suffixes also reports some imperative verbs such as "open" or "seed", this linter is not enabled
+// by default and can be enabled using WithLinters.
+func CommandsShouldUseImperativeNaming(pastTenseSuffixes ...string) specter.SpecificationLinterFunc {
+	if len(pastTenseSuffixes) == 0 {
+		pastTenseSuffixes = DefaultPastTenseSuffixes
+	}
+
+	return func(specs specter.SpecificationGroup) specter.LinterResultSet {
+		var result specter.LinterResultSet
+		for _, c := range specs.SelectType(specter.SpecificationType("command")) {
+			name := string(c.Name())
+			verb := name[strings.LastIndex(name, ".")+1:]
+			for _, suffix := range pastTenseSuffixes {
+				if strings.HasSuffix(verb, suffix) {
+					result = append(result, specter.LinterResult{
+						Severity: specter.WarningSeverity,
+						Message:  fmt.Sprintf("command \"%s\" should be named using an imperative verb at \"%s\"", c.Name(), c.Source().Location),
+					})
+					break
+				}
+			}
+		}
+
+		return result
+	}
+}
@@ -0,0 +1,38 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCommandsShouldUseImperativeNaming(t *testing.T) {
+	tests := []struct {
+		name         string
+		commandName  string
+		suffixes     []string
+		wantWarnings int
+	}{
+		{name: "imperative command", commandName: "user.register", wantWarnings: 0},
+		{name: "past tense command", commandName: "user.registered", wantWarnings: 1},
+		{name: "past participle command", commandName: "user.forgotten", wantWarnings: 1},
+		{name: "custom suffixes", commandName: "user.registered", suffixes: []string{"ing"}, wantWarnings: 0},
+		{name: "matching custom suffixes", commandName: "user.registering", suffixes: []string{"ing"}, wantWarnings: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs := specter.SpecificationGroup{
+				&Command{Nam: tt.commandName, Desc: "A command.", Src: testSource},
+				&Event{Nam: "user.registered", Desc: "An event.", Src: testSource},
+			}
+
+			results := CommandsShouldUseImperativeNaming(tt.suffixes...)(specs)
+
+			assert.Len(t, results, tt.wantWarnings)
+			for _, r := range results {
+				assert.Equal(t, specter.WarningSeverity, r.Severity)
+				assert.Contains(t, r.Message, tt.commandName)
+			}
+		})
+	}
+}
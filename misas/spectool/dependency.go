@@ -0,0 +1,80 @@
+package spectool
+
+import (
+	"fmt"
+	"github.com/morebec/specter"
+	"strings"
+)
+
+// DependencyCycleError is returned when specifications depend on each other in a cycle.
+type DependencyCycleError struct {
+	// Names of the specifications forming the cycle, in dependency order.
+	Names []specter.SpecificationName
+}
+
+func (e DependencyCycleError) Error() string {
+	names := make([]string, 0, len(e.Names)+1)
+	for _, n := range e.Names {
+		names = append(names, string(n))
+	}
+	// Close the loop to make the cycle explicit, e.g. a -> b -> a.
+	names = append(names, string(e.Names[0]))
+
+	return fmt.Sprintf("circular dependency found between specifications: %s", strings.Join(names, " -> "))
+}
+
+// CheckDependencyCycles performs a topological sort of the dependencies between specifications and returns a
+// DependencyCycleError listing the names forming a cycle if one is found. Dependencies on specifications
+// that are not part of the given list are ignored.
+func CheckDependencyCycles(specs []specter.Specification) error {
+	specsByName := map[specter.SpecificationName]specter.Specification{}
+	for _, s := range specs {
+		specsByName[s.Name()] = s
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[specter.SpecificationName]int{}
+	var path []specter.SpecificationName
+
+	var visit func(name specter.SpecificationName) []specter.SpecificationName
+	visit = func(name specter.SpecificationName) []specter.SpecificationName {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			// The cycle starts where the name was first encountered in the current path.
+			for i, n := range path {
+				if n == name {
+					return append([]specter.SpecificationName{}, path[i:]...)
+				}
+			}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range specsByName[name].Dependencies() {
+			if _, found := specsByName[dep]; !found {
+				continue
+			}
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, s := range specs {
+		if cycle := visit(s.Name()); cycle != nil {
+			return DependencyCycleError{Names: cycle}
+		}
+	}
+
+	return nil
+}
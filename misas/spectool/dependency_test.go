@@ -0,0 +1,51 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckDependencyCycles(t *testing.T) {
+	tests := []struct {
+		name      string
+		specs     []specter.Specification
+		wantCycle []specter.SpecificationName
+	}{
+		{
+			name: "acyclic graph",
+			specs: []specter.Specification{
+				&Struct{Nam: "user.address", Fields: []StructField{{Name: "city", Type: String}}},
+				&Struct{Nam: "user.profile", Fields: []StructField{{Name: "address", Type: "user.address"}}},
+				&Command{Nam: "user.register", Fields: []CommandField{{Name: "profile", Type: "user.profile"}}},
+			},
+		},
+		{
+			name: "two node cycle",
+			specs: []specter.Specification{
+				&Struct{Nam: "user.address", Fields: []StructField{{Name: "city", Type: String}}},
+				&Struct{Nam: "user.profile", Fields: []StructField{{Name: "account", Type: "user.account"}}},
+				&Struct{Nam: "user.account", Fields: []StructField{{Name: "profile", Type: "user.profile"}}},
+			},
+			wantCycle: []specter.SpecificationName{"user.profile", "user.account"},
+		},
+		{
+			name: "dependencies outside of the graph are ignored",
+			specs: []specter.Specification{
+				&Struct{Nam: "user.profile", Fields: []StructField{{Name: "address", Type: "user.address"}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDependencyCycles(tt.specs)
+			if tt.wantCycle == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Equal(t, DependencyCycleError{Names: tt.wantCycle}, err)
+			assert.EqualError(t, err, "circular dependency found between specifications: user.profile -> user.account -> user.profile")
+		})
+	}
+}
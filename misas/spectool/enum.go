@@ -1,6 +1,9 @@
 package spectool
 
-import "github.com/morebec/specter"
+import (
+	"fmt"
+	"github.com/morebec/specter"
+)
 
 type EnumValue struct {
 	Name  string `hcl:"name,label"`
@@ -48,3 +51,38 @@ func (e *Enum) SetSource(s specter.Source) {
 func (e *Enum) Dependencies() []specter.SpecificationName {
 	return []specter.SpecificationName{specter.SpecificationName(e.BaseType)}
 }
+
+// EnumValuesMustNotBeRemoved returns a linter reporting an error for every value of an enum of a previous version of the
+// specifications that is no longer part of the same enum, as removing a value is a breaking change that can orphan stored data.
+// Adding values to an enum is allowed. Enums that do not exist anymore are not reported by this linter.
+func EnumValuesMustNotBeRemoved(previous specter.SpecificationGroup) specter.SpecificationLinterFunc {
+	return func(specs specter.SpecificationGroup) specter.LinterResultSet {
+		var result specter.LinterResultSet
+		for _, s := range specs.SelectType(specter.SpecificationType("enum")) {
+			previousEnum, ok := previous.SelectName(s.Name()).(*Enum)
+			if !ok {
+				continue
+			}
+
+			values := map[string]struct{}{}
+			for _, v := range s.(*Enum).Values {
+				values[v.Name] = struct{}{}
+			}
+
+			for _, v := range previousEnum.Values {
+				if _, found := values[v.Name]; found {
+					continue
+				}
+				result = append(result, specter.LinterResult{
+					Severity: specter.ErrorSeverity,
+					Message: fmt.Sprintf(
+						"enum \"%s\" has its value \"%s\" removed, which is a breaking change at \"%s\"",
+						s.Name(), v.Name, s.Source().Location,
+					),
+				})
+			}
+		}
+
+		return result
+	}
+}
@@ -0,0 +1,65 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEnumValuesMustNotBeRemoved(t *testing.T) {
+	previous := specter.SpecificationGroup{
+		&Enum{
+			Nam:      "user.status",
+			Desc:     "Status of a user.",
+			BaseType: String,
+			Values: []EnumValue{
+				{Name: "ACTIVE", Value: "active"},
+				{Name: "DISABLED", Value: "disabled"},
+			},
+			Src: specter.Source{Location: "/unit/user.spec.hcl"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		values       []EnumValue
+		wantMessages []string
+	}{
+		{
+			name: "added value",
+			values: []EnumValue{
+				{Name: "ACTIVE", Value: "active"},
+				{Name: "DISABLED", Value: "disabled"},
+				{Name: "BANNED", Value: "banned"},
+			},
+		},
+		{
+			name: "removed value",
+			values: []EnumValue{
+				{Name: "ACTIVE", Value: "active"},
+			},
+			wantMessages: []string{`enum "user.status" has its value "DISABLED" removed, which is a breaking change at "/unit/user.spec.hcl"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs := specter.SpecificationGroup{
+				&Enum{
+					Nam:      "user.status",
+					Desc:     "Status of a user.",
+					BaseType: String,
+					Values:   tt.values,
+					Src:      specter.Source{Location: "/unit/user.spec.hcl"},
+				},
+			}
+
+			results := EnumValuesMustNotBeRemoved(previous)(specs)
+
+			assert.Len(t, results, len(tt.wantMessages))
+			for i, r := range results {
+				assert.Equal(t, specter.ErrorSeverity, r.Severity)
+				assert.Equal(t, tt.wantMessages[i], r.Message)
+			}
+		})
+	}
+}
@@ -90,3 +90,30 @@ func EventsMustHaveDateTimeField() specter.SpecificationLinterFunc {
 		return result
 	}
 }
+
+// EventsShouldBeReferenced returns a linter reporting a warning for every event that is not a dependency of any other specification.
+// Such orphan events are neither produced nor consumed by any part of the system.
+func EventsShouldBeReferenced() specter.SpecificationLinterFunc {
+	return func(specs specter.SpecificationGroup) specter.LinterResultSet {
+		referenced := map[specter.SpecificationName]struct{}{}
+		for _, s := range specs {
+			for _, dep := range s.Dependencies() {
+				if dep != s.Name() {
+					referenced[dep] = struct{}{}
+				}
+			}
+		}
+
+		var result specter.LinterResultSet
+		for _, e := range specs.SelectType(specter.SpecificationType("event")) {
+			if _, found := referenced[e.Name()]; !found {
+				result = append(result, specter.LinterResult{
+					Severity: specter.WarningSeverity,
+					Message:  fmt.Sprintf("event \"%s\" is not referenced by any specification at \"%s\"", e.Name(), e.Source().Location),
+				})
+			}
+		}
+
+		return result
+	}
+}
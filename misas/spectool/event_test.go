@@ -0,0 +1,39 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEventsShouldBeReferenced(t *testing.T) {
+	specs := specter.SpecificationGroup{
+		&Event{
+			Nam:  "user.registered",
+			Desc: "Indicates that a user was registered.",
+			Src:  specter.Source{Location: "/unit/user.spec.hcl"},
+		},
+		&Event{
+			Nam:  "user.forgotten",
+			Desc: "Indicates that a user was forgotten.",
+			Src:  specter.Source{Location: "/unit/orphan.spec.hcl"},
+		},
+		&HTTPEndpoint{
+			Nam:     "user.register",
+			Method:  "POST",
+			Path:    "/users",
+			Desc:    "Registers a user.",
+			Request: String,
+			Responses: HTTPEndpointResponses{
+				Success: HTTPEndpointSuccessResponse{Type: "user.registered"},
+			},
+		},
+	}
+
+	results := EventsShouldBeReferenced()(specs)
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, specter.WarningSeverity, results[0].Severity)
+	assert.Contains(t, results[0].Message, "user.forgotten")
+	assert.Contains(t, results[0].Message, "/unit/orphan.spec.hcl")
+}
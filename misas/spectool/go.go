@@ -8,10 +8,14 @@ import (
 	"github.com/morebec/specter"
 	"github.com/pkg/errors"
 	"go/format"
+	"go/token"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -66,7 +70,7 @@ func (p *GoPackage) SearchTreePreorderTraversal(selectFunc func(node *GoPackage)
 // ImportPath Returns the import path of this Package.
 func (p *GoPackage) ImportPath() string {
 	if p.Parent == nil {
-		return p.ModFile.Name + "/" + p.Name
+		return p.ModFile.Name
 	}
 
 	return p.Parent.ImportPath() + "/" + p.Name
@@ -239,6 +243,16 @@ type GeneratedGoFile struct {
 	Package  *GoPackage
 	Path     string
 	Snippets []GoSnippet
+
+	// Specs lists the names of the specifications that generated code in this file.
+	Specs []specter.SpecificationName
+
+	// importAliases maps the import paths of this file to the name under which they are referenced.
+	importAliases map[string]string
+
+	// importsReferencedByBaseName lists the import paths referenced by hardcoded qualifiers (e.g. "time.Time")
+	// in the code of the snippets, which therefore cannot be aliased.
+	importsReferencedByBaseName map[string]struct{}
 }
 
 // AddSnippet adds a snippet to this file.
@@ -246,6 +260,16 @@ func (f *GeneratedGoFile) AddSnippet(snippet GoSnippet) {
 	f.Snippets = append(f.Snippets, snippet)
 }
 
+// addSpec records a specification as an input of this file.
+func (f *GeneratedGoFile) addSpec(name specter.SpecificationName) {
+	for _, s := range f.Specs {
+		if s == name {
+			return
+		}
+	}
+	f.Specs = append(f.Specs, name)
+}
+
 // GeneratedTypes Returns the types generated in this file.
 func (f *GeneratedGoFile) GeneratedTypes() []GoType {
 	var types []GoType
@@ -277,7 +301,7 @@ func (f *GeneratedGoFile) Imports() []string {
 
 	// import type used
 	for _, ut := range f.TypesUsed() {
-		if ut.ImportPath != "" {
+		if ut.ImportPath != "" && ut.ImportPath != f.Package.ImportPath() {
 			imports[ut.ImportPath] = struct{}{}
 		}
 	}
@@ -296,6 +320,46 @@ func (f *GeneratedGoFile) Imports() []string {
 	return values
 }
 
+// ImportAlias returns the name under which an import path is referenced in this file.
+// The base name of the import path is used, unless it is already used by another import path of this file,
+// in which case a unique alias is generated by suffixing it with a number.
+func (f *GeneratedGoFile) ImportAlias(importPath string) string {
+	if f.importAliases == nil {
+		f.importAliases = map[string]string{}
+	}
+
+	if alias, found := f.importAliases[importPath]; found {
+		return alias
+	}
+
+	baseName := path.Base(importPath)
+	alias := baseName
+	for i := 2; f.isImportAliasUsed(alias); i++ {
+		alias = fmt.Sprintf("%s%d", baseName, i)
+	}
+	f.importAliases[importPath] = alias
+
+	return alias
+}
+
+func (f *GeneratedGoFile) isImportAliasUsed(alias string) bool {
+	for _, a := range f.importAliases {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// referenceImportByBaseName indicates that an import path is referenced by its base name in the code of this file.
+func (f *GeneratedGoFile) referenceImportByBaseName(importPath string) {
+	if f.importsReferencedByBaseName == nil {
+		f.importsReferencedByBaseName = map[string]struct{}{}
+	}
+	f.importsReferencedByBaseName[importPath] = struct{}{}
+	f.ImportAlias(importPath)
+}
+
 // GoSnippet represents a piece of generated code.
 type GoSnippet struct {
 	Code string
@@ -310,6 +374,9 @@ type GoSnippet struct {
 type GoSnippetGenerationContext struct {
 	ParentContext *GoProcessingContext
 
+	// File in which the snippet is generated. It is used to qualify the types of other packages with their import alias.
+	File *GeneratedGoFile
+
 	// Name of the template for debugging purposes.
 	TemplateName string
 
@@ -324,6 +391,9 @@ type GoSnippetGenerationContext struct {
 	TypesUsed []GoType
 
 	StaticImports []string
+
+	// First error encountered while resolving a type during the execution of the template.
+	resolutionErr error
 }
 
 func NewGoSnippetGenerationContext(
@@ -351,6 +421,9 @@ func (ctx *GoSnippetGenerationContext) PackageTree() *GoPackage {
 type GoProcessingContext struct {
 	ParentContext specter.ProcessingContext
 	PackageTree   *GoPackage
+
+	// Specification currently being processed, recorded as an input of the files it generates code in.
+	currentSpec MisasSpecification
 }
 
 func (ctx *GoProcessingContext) Specs() specter.SpecificationGroup {
@@ -373,6 +446,17 @@ func GenerateCodeForSpec(ctx *GoSnippetGenerationContext, s MisasSpecification)
 	} else if commandAggregateName := extractAggregateName(s.Name()); commandAggregateName != "" {
 		fileName = commandAggregateName + "_" + fileName
 	}
+
+	if err := GenerateCodeInFile(ctx, pkg, fileName); err != nil {
+		return errors.Wrapf(err, "failed generating code for %s %s", s.Type(), s.Name())
+	}
+
+	return nil
+}
+
+// GenerateCodeInFile generates some go code using a template and some data, and adds the resulting snippet
+// to the file with a given name in a package.
+func GenerateCodeInFile(ctx *GoSnippetGenerationContext, pkg *GoPackage, fileName string) error {
 	filePath := pkg.FilePath + "/" + fileName
 
 	file := pkg.FindGeneratedFileAtPath(filePath)
@@ -385,7 +469,24 @@ func GenerateCodeForSpec(ctx *GoSnippetGenerationContext, s MisasSpecification)
 		pkg.AddFile(file)
 	}
 
+	// Generated types are imported from the package of the file.
+	for i, gt := range ctx.GeneratedTypes {
+		if gt.ImportPath == "" {
+			ctx.GeneratedTypes[i].ImportPath = pkg.ImportPath()
+		}
+	}
+
+	// Static imports are referenced by their base name in templates, so they get the priority for their alias.
+	for _, i := range ctx.StaticImports {
+		file.referenceImportByBaseName(i)
+	}
+
+	if ctx.ParentContext != nil && ctx.ParentContext.currentSpec != nil {
+		file.addSpec(ctx.ParentContext.currentSpec.Name())
+	}
+
 	// Generate snippet
+	ctx.File = file
 	snippet, err := GenerateSnippet(ctx)
 	if err != nil {
 		return err
@@ -399,31 +500,14 @@ func GenerateCodeForSpec(ctx *GoSnippetGenerationContext, s MisasSpecification)
 // GenerateSnippet generates a GoSnippet from a GoSnippetGenerationContext.
 func GenerateSnippet(ctx *GoSnippetGenerationContext) (GoSnippet, error) {
 
-	acronyms := map[string]struct{}{
-		"URL":  {},
-		"ID":   {},
-		"HTTP": {},
-	}
-
 	t := template.New("template " + ctx.TemplateName).Funcs(map[string]any{
 
 		// converts a string so that it adheres to the exported type naming scheme of go.
 		// This can be useful for type names, struct field names, and constants.
-		"AsExportedGoName": func(value string) string {
-			upper := strcase.ToCamel(value)
-			re := regexp.MustCompile(`[A-Z][^A-Z]*`)
-			matches := re.FindAllString(upper, -1)
-			final := ""
-			for _, element := range matches {
-				upperElem := strings.ToUpper(element)
-				if _, found := acronyms[upperElem]; found {
-					final += upperElem
-				} else {
-					final += element
-				}
-			}
-			return final
-		},
+		"AsExportedGoName": ExportedGoName,
+
+		// Returns the name of the Go struct field generated for a field, taking into account the GoFieldNameAnnotation.
+		"AsGoFieldName": GoFieldName,
 
 		// converts a string so that it adheres to the non exported type naming scheme of go.
 		// This can be useful for type names, struct field names, and constants.
@@ -435,21 +519,36 @@ func GenerateSnippet(ctx *GoSnippetGenerationContext) (GoSnippet, error) {
 		},
 
 		// Converts a DataType to a GoType.
+		// Resolution errors are recorded in the context and returned once the template is executed.
 		"AsResolvedGoType": func(t DataType) string {
 			rgt, err := ResolveGoType(ctx, t)
 			if err != nil {
-				panic(err)
+				if ctx.resolutionErr == nil {
+					ctx.resolutionErr = err
+				}
+				return string(t)
 			}
 			return rgt.TypeName
 		},
+
+		// Converts a description so that it can be rendered as a comment block.
+		"AsGoComment": FormatGoCommentText,
+
 		"AsJsonAnnotation": func(fieldName string) string {
+			return fmt.Sprintf("`json:\"%s\"`", jsonFieldName(fieldName))
+		},
 
-			if fieldName != "id" {
-				fieldName = strcase.ToLowerCamel(fieldName)
+		// Returns the JSON annotation of a field of a request, that is a command or a query, taking into account the
+		// GoReadonlyAnnotation.
+		"AsRequestJsonAnnotation": func(fieldName string, annotations Annotations) string {
+			if annotations.Has(GoReadonlyAnnotation) {
+				return "`json:\"-\"`"
 			}
-
-			return fmt.Sprintf("`json:\"%s\"`", fieldName)
+			return fmt.Sprintf("`json:\"%s\"`", jsonFieldName(fieldName))
 		},
+
+		// Converts a field name to the name of its JSON representation.
+		"AsJsonFieldName": jsonFieldName,
 	})
 
 	t, err := t.Parse(ctx.TemplateCode)
@@ -462,6 +561,10 @@ func GenerateSnippet(ctx *GoSnippetGenerationContext) (GoSnippet, error) {
 		return GoSnippet{}, errors.Wrap(err, "failed generating go code")
 	}
 
+	if ctx.resolutionErr != nil {
+		return GoSnippet{}, errors.Wrap(ctx.resolutionErr, "failed generating go code")
+	}
+
 	return GoSnippet{
 		Code:           b.String(),
 		GeneratedTypes: ctx.GeneratedTypes,
@@ -490,12 +593,15 @@ func ResolveGoType(ctx *GoSnippetGenerationContext, t DataType) (GoType, error)
 			return NewGoType("int64", Int, ""), nil
 		case Float:
 			return NewGoType("float64", Float, ""), nil
-		case Date:
-			return NewGoType("time.Time", Date, "time"), nil
-		case DateTime:
-			return NewGoType("time.Time", DateTime, "time"), nil
-		case Duration:
-			return NewGoType("time.Duration", Duration, "time"), nil
+		case Date, DateTime, Duration:
+			// Types of the time package are qualified by its base name.
+			if ctx.File != nil {
+				ctx.File.referenceImportByBaseName("time")
+			}
+			if t == Duration {
+				return NewGoType("time.Duration", Duration, "time"), nil
+			}
+			return NewGoType("time.Time", t, "time"), nil
 		}
 
 		if t.IsContainer() {
@@ -520,6 +626,9 @@ func ResolveGoType(ctx *GoSnippetGenerationContext, t DataType) (GoType, error)
 		// User defined type.
 		for _, gt := range ctx.PackageTree().GeneratedTypesRecursive() {
 			if gt.InternalTypeName == t {
+				if ctx.File != nil && gt.ImportPath != "" && gt.ImportPath != ctx.File.Package.ImportPath() {
+					gt.TypeName = ctx.File.ImportAlias(gt.ImportPath) + "." + gt.TypeName
+				}
 				return gt, nil
 			}
 		}
@@ -538,13 +647,25 @@ func ResolveGoType(ctx *GoSnippetGenerationContext, t DataType) (GoType, error)
 // RenderGeneratedFile Renders and Formats a Generated File as a string
 func RenderGeneratedFile(f GeneratedGoFile) (string, error) {
 	// Resolve imports
-	imports := f.Imports()
+	var imports []string
+	for _, i := range f.Imports() {
+		alias := f.ImportAlias(i)
+		if alias == path.Base(i) {
+			imports = append(imports, fmt.Sprintf("\"%s\"", i))
+			continue
+		}
+
+		if _, found := f.importsReferencedByBaseName[i]; found {
+			return "", errors.Errorf("failed rendering file \"%s\": import \"%s\" conflicts with the import of another package with the same name", f.Path, i)
+		}
+		imports = append(imports, fmt.Sprintf("%s \"%s\"", alias, i))
+	}
 
 	// Generate Header of file.
 	header := fmt.Sprintf("// IMPORTANT: This file was auto-generated by the morebec/spectool program. Do not edit manually. \n\n")
 	header += fmt.Sprintf("package %s\n\n", f.Package.Name)
 	if imports != nil {
-		header += fmt.Sprintf("import (\n\"%s\"\n)\n", strings.Join(imports, "\"\n\""))
+		header += fmt.Sprintf("import (\n%s\n)\n", strings.Join(imports, "\n"))
 	}
 
 	code := "\n"
@@ -571,6 +692,87 @@ func FormatGoSource(content []byte) ([]byte, error) {
 	return formattedContent, nil
 }
 
+// goAcronyms lists the acronyms that should be kept uppercase in exported go names.
+var goAcronyms = map[string]struct{}{
+	"URL":  {},
+	"ID":   {},
+	"HTTP": {},
+}
+
+// ExportedGoName converts a string so that it adheres to the exported naming scheme of go.
+func ExportedGoName(value string) string {
+	upper := strcase.ToCamel(value)
+	re := regexp.MustCompile(`[A-Z][^A-Z]*`)
+	matches := re.FindAllString(upper, -1)
+	final := ""
+	for _, element := range matches {
+		upperElem := strings.ToUpper(element)
+		if _, found := goAcronyms[upperElem]; found {
+			final += upperElem
+		} else {
+			final += element
+		}
+	}
+	return final
+}
+
+// GoFieldNameAnnotation allows overriding the name of the Go struct field generated for a field, e.g. "gen:go:fieldName=LegacyID".
+// The JSON name of the field remains derived from the name of the field in the specification.
+const GoFieldNameAnnotation = "gen:go:fieldName"
+
+// GoFieldName returns the name of the Go struct field generated for a field, that is its exported Go name unless
+// overridden with the GoFieldNameAnnotation.
+func GoFieldName(name string, annotations Annotations) string {
+	if override, found := annotations.Value(GoFieldNameAnnotation); found {
+		return override
+	}
+	return ExportedGoName(name)
+}
+
+// GoReadonlyAnnotation marks a field of a command or a query as computed or derived by the server, e.g. "gen:go:readonly".
+// Such a field is excluded from the JSON decoding of requests so that it cannot be bound from their body, while it
+// remains part of the JSON representation of structs and events used as responses. Since it is never part of the JSON
+// representation of commands and queries, it is also omitted from their TypeScript and OpenAPI definitions.
+const GoReadonlyAnnotation = "gen:go:readonly"
+
+// IsReadonlyRequestField indicates if a field of a specification is a field of a command or a query annotated with
+// GoReadonlyAnnotation, which is excluded from their JSON representation.
+func IsReadonlyRequestField(s MisasSpecification, f SpecificationField) bool {
+	if s.Type() != (&Command{}).Type() && s.Type() != (&Query{}).Type() {
+		return false
+	}
+	return f.Annotations.Has(GoReadonlyAnnotation)
+}
+
+// jsonFieldName returns the name of a field as it should appear in JSON.
+func jsonFieldName(fieldName string) string {
+	if fieldName != "id" {
+		fieldName = strcase.ToLowerCamel(fieldName)
+	}
+	return fieldName
+}
+
+// FormatGoCommentText formats a description so that it can be rendered as the text of a Go comment.
+// Multiline descriptions are split into lines each prefixed with "//" so that they remain a valid comment block.
+func FormatGoCommentText(description string) string {
+	description = strings.TrimSpace(strings.ReplaceAll(description, "\r\n", "\n"))
+	lines := strings.Split(description, "\n")
+
+	text := ""
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if i != 0 {
+			text += "\n//"
+			if line != "" {
+				text += " "
+			}
+		}
+		text += line
+	}
+
+	return text
+}
+
 // extractAggregateName extracts the name of an aggregate for a SpecificationTypeName of a Command/Query/Payload.
 // E.g. website.add -> website.
 func extractAggregateName(name specter.SpecificationName) string {
@@ -587,6 +789,17 @@ func extractAggregateName(name specter.SpecificationName) string {
 
 // GoCodeGenerator is a specification processor responsible for generating go code from misas specifications.
 type GoCodeGenerator struct {
+	// DryRun indicates if the generated files should only be compared against the files on disk instead of being output.
+	// In this mode, an error listing the files that are not up-to-date is returned, which allows verifying in CI that
+	// the generated code was committed.
+	DryRun bool
+
+	// Incremental indicates if only the files whose input specifications changed since the last run should be rendered and output.
+	// The hashes of the inputs are persisted in a manifest file named GoGenerationManifestFileName next to the go.mod file.
+	// Since unchanged files are not output, this mode must not be used with an output processor deleting
+	// the files of its previous run, such as the specter.WriteFileOutputsProcessor using a registry.
+	// The spectool takes care of this when using WithIncrementalGeneration.
+	Incremental bool
 }
 
 func (c GoCodeGenerator) Name() string {
@@ -594,7 +807,6 @@ func (c GoCodeGenerator) Name() string {
 }
 
 func (c GoCodeGenerator) Process(ctx specter.ProcessingContext) ([]specter.ProcessingOutput, error) {
-
 	// System specification
 	candidates := specter.SpecificationGroup(ctx.DependencyGraph).SelectType((&System{}).Type())
 	if len(candidates) == 0 {
@@ -603,21 +815,11 @@ func (c GoCodeGenerator) Process(ctx specter.ProcessingContext) ([]specter.Proce
 
 	systemSpec := candidates[0].(*System)
 
-	goMod, err := FindGoMod(systemSpec)
-	if err != nil {
-		return nil, err
-	}
-
-	tree, err := BuildGoPackageTree(goMod)
+	gCtx, err := NewGoProcessingContext(ctx, systemSpec)
 	if err != nil {
 		return nil, err
 	}
 
-	gCtx := &GoProcessingContext{
-		ParentContext: ctx,
-		PackageTree:   tree,
-	}
-
 	processingHandlers := map[specter.SpecificationType]func(ctx *GoProcessingContext, s MisasSpecification) error{
 		(&Command{}).Type():      generateCommand,
 		(&Query{}).Type():        generateQuery,
@@ -625,25 +827,94 @@ func (c GoCodeGenerator) Process(ctx specter.ProcessingContext) ([]specter.Proce
 		(&Struct{}).Type():       generateStruct,
 		(&Enum{}).Type():         generateEnum,
 		(&HTTPEndpoint{}).Type(): generateHTTPEndpoint,
+		(&Mapping{}).Type():      generateMapping,
+		(&System{}).Type():       generateSystemVersion,
 	}
 
-	for _, dep := range ctx.DependencyGraph {
-		if fun, found := processingHandlers[dep.Type()]; found {
+	if err := generateGoCodeForSpecs(gCtx, processingHandlers); err != nil {
+		return nil, err
+	}
+
+	if err := generateGoAggregates(gCtx); err != nil {
+		return nil, err
+	}
+
+	// Convert go files to OutputFiles
+	ctx.Logger.Info("Generating Go code ...")
+	var outputFiles []specter.ProcessingOutput
+	if c.Incremental && !c.DryRun {
+		manifestPath := filepath.Join(filepath.Dir(gCtx.PackageTree.ModFile.Path), GoGenerationManifestFileName)
+		specHashes := SpecificationInputHashes(ctx.DependencyGraph)
+		outputFiles, err = renderIncrementalGoOutputFiles(manifestPath, gCtx.PackageTree.GeneratedFilesRecursive(), specHashes)
+	} else {
+		outputFiles, err = renderGoOutputFiles(gCtx.PackageTree.GeneratedFilesRecursive())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.DryRun {
+		if err := CheckOutputFilesUpToDate(outputFiles); err != nil {
+			return nil, errors.Wrap(err, "failed generating go code")
+		}
+		ctx.Logger.Success("Go code is up-to-date.")
+		return nil, nil
+	}
+	ctx.Logger.Info("Go code generated successfully.")
+
+	return outputFiles, nil
+}
+
+// NewGoProcessingContext creates a GoProcessingContext with the package tree of the go module of a system specification.
+func NewGoProcessingContext(ctx specter.ProcessingContext, systemSpec *System) (*GoProcessingContext, error) {
+	goMod, err := FindGoMod(systemSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := BuildGoPackageTree(goMod)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GoProcessingContext{
+		ParentContext: ctx,
+		PackageTree:   tree,
+	}, nil
+}
+
+// generateGoCodeForSpecs calls the handler of the type of every specification of the dependency graph in order.
+func generateGoCodeForSpecs(ctx *GoProcessingContext, handlers map[specter.SpecificationType]func(ctx *GoProcessingContext, s MisasSpecification) error) error {
+	for _, dep := range ctx.ParentContext.DependencyGraph {
+		if fun, found := handlers[dep.Type()]; found {
 			misasDep, ok := dep.(MisasSpecification)
 			if !ok {
 				continue
 			}
 
-			if err := fun(gCtx, misasDep); err != nil {
-				return nil, err
+			if err := runGoSpecHandler(ctx, fun, misasDep); err != nil {
+				return err
 			}
 		}
 	}
 
-	// Convert go files to OutputFiles
+	return nil
+}
+
+// runGoSpecHandler runs the handler of a specification, converting any panic into an error identifying the specification.
+func runGoSpecHandler(ctx *GoProcessingContext, handler func(ctx *GoProcessingContext, s MisasSpecification) error, s MisasSpecification) (err error) {
+	defer recoverAsError(&err, "failed generating go code for %s %s", s.Type(), s.Name())
+
+	ctx.currentSpec = s
+	defer func() { ctx.currentSpec = nil }()
+
+	return handler(ctx, s)
+}
+
+// renderGoOutputFiles renders generated go files as processing outputs.
+func renderGoOutputFiles(files []*GeneratedGoFile) ([]specter.ProcessingOutput, error) {
 	var outputFiles []specter.ProcessingOutput
-	ctx.Logger.Info("Generating Go code ...")
-	for _, gf := range gCtx.PackageTree.GeneratedFilesRecursive() {
+	for _, gf := range files {
 		code, err := RenderGeneratedFile(*gf)
 		if err != nil {
 			return nil, err
@@ -657,44 +928,162 @@ func (c GoCodeGenerator) Process(ctx specter.ProcessingContext) ([]specter.Proce
 			},
 		})
 	}
-	ctx.Logger.Info("Go code generated successfully.")
 
 	return outputFiles, nil
 }
 
+// CheckOutputFilesUpToDate compares file outputs against the files on disk and returns an error listing
+// the files that are missing or whose content differ, along with the first line that differs.
+func CheckOutputFilesUpToDate(outputs []specter.ProcessingOutput) error {
+	var staleFiles []string
+	for _, o := range outputs {
+		file, ok := o.Value.(specter.FileOutput)
+		if !ok {
+			continue
+		}
+
+		existing, err := os.ReadFile(file.Path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return errors.Wrapf(err, "failed reading file \"%s\"", file.Path)
+			}
+			staleFiles = append(staleFiles, fmt.Sprintf("%s: file does not exist", file.Path))
+			continue
+		}
+
+		if line, differs := firstDifferingLine(string(existing), string(file.Data)); differs {
+			staleFiles = append(staleFiles, fmt.Sprintf("%s: differs at line %d", file.Path, line))
+		}
+	}
+
+	if len(staleFiles) != 0 {
+		return errors.Errorf("generated files are not up-to-date:\n%s", strings.Join(staleFiles, "\n"))
+	}
+
+	return nil
+}
+
+// firstDifferingLine returns the number of the first line that differs between two texts.
+func firstDifferingLine(actual string, expected string) (int, bool) {
+	if actual == expected {
+		return 0, false
+	}
+
+	actualLines := strings.Split(actual, "\n")
+	expectedLines := strings.Split(expected, "\n")
+	for i := 0; i < len(actualLines) && i < len(expectedLines); i++ {
+		if actualLines[i] != expectedLines[i] {
+			return i + 1, true
+		}
+	}
+
+	if len(actualLines) < len(expectedLines) {
+		return len(actualLines) + 1, true
+	}
+	return len(expectedLines) + 1, true
+}
+
 func generateStruct(ctx *GoProcessingContext, s MisasSpecification) error {
 	strct := s.(*Struct)
 	templateCode := `
-const {{ .StructName }}TypeName string = "{{ .TypeName }}"
+{{ if .TypeNameConst }}const {{ .TypeNameConst }} string = "{{ .TypeName }}"{{ end }}
 // {{ .StructName }} {{ .Description }}
 type {{ .StructName }} struct {
 	{{ range $field := .Fields }}
-		// {{ $field.Description }} {{ if $field.Annotations.Has "personal_data" }}
+		// {{ $field.Description | AsGoComment }} {{ if $field.Annotations.Has "personal_data" }}
 		// NOTE: This field contains personal data{{ end }}
-		{{ $field.Name | AsExportedGoName }} {{ if $field.Nullable }}*{{ end }}{{ $field.Type | AsResolvedGoType }} {{ $field.Name | AsJsonAnnotation }}
+		{{ AsGoFieldName $field.Name $field.Annotations }} {{ if $field.Nullable }}*{{ end }}{{ $field.Type | AsResolvedGoType }} {{ $field.Name | AsJsonAnnotation }}
 	{{ end }}
 }
-func (c {{ .StructName }}) PayloadTypeName() string {
-	return {{ .StructName }}TypeName
+func ({{ .Receiver }} {{ .StructName }}) PayloadTypeName() string {
+	return {{ if .TypeNameConst }}{{ .TypeNameConst }}{{ else }}"{{ .TypeName }}"{{ end }}
 }
-`
+{{ if .IndexedFields }}
+// {{ .StructName }}IndexedFields lists the fields of {{ .StructName }} annotated as indexable.
+// They can be passed to postgresql.DocumentStore.CreateCollectionWithIndexes when creating its collection.
+var {{ .StructName }}IndexedFields = []string{ {{ range $field := .IndexedFields }}"{{ $field }}", {{ end }} }
+{{ end }}
+{{ if .DefaultFields }}
+// New{{ .StructName }} returns a {{ .StructName }} with its fields initialized to their default values.
+func New{{ .StructName }}() {{ .StructName }} {
+	return {{ .StructName }}{
+		{{ range $field := .DefaultFields }}{{ AsGoFieldName $field.Name $field.Annotations }}: {{ $field.Type | AsResolvedGoType }}{},
+		{{ end }}
+	}
+}
+{{ end }}
+// Equal indicates if {{ .StructName }} is equal to another {{ .StructName }}, comparing slices, maps and pointers by value.
+func ({{ .Receiver }} {{ .StructName }}) Equal(other {{ .StructName }}) bool {
+	{{ range $check := .EqualityChecks }}{{ $check }}
+	{{ end }}
+	return true
+}` + goValidateMethodTemplate + goLogSafeMethodTemplate
 
 	type TemplateData struct {
-		Package     string
-		Imports     []string
-		StructName  string
-		TypeName    string
-		FilePath    string
-		Fields      []StructField
-		Description string
+		Package       string
+		Imports       []string
+		StructName    string
+		Receiver      string
+		TypeName      string
+		TypeNameConst string
+		FilePath      string
+		Fields        []StructField
+		Description   string
+
+		ValidationChecks       []string
+		ValidationDeclarations []string
+		IndexedFields          []string
+		DefaultFields          []StructField
+		EqualityChecks         []string
 	}
 
+	var validatedFields []goValidatedField
+	for _, f := range strct.Fields {
+		validatedFields = append(validatedFields, goValidatedField{Name: f.Name, Type: f.Type, Nullable: f.Nullable, Annotations: f.Annotations})
+	}
+	structName := strct.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(strct.Name()))).AsString()
+	typeNameConst, err := goTypeNameConst(strct, structName)
+	if err != nil {
+		return err
+	}
+	receiver, err := goReceiverName(strct, structName)
+	if err != nil {
+		return err
+	}
+
+	validationChecks, validationDeclarations, validationImports, err := generateGoValidationChecks(structName, receiver, validatedFields)
+	if err != nil {
+		return errors.Wrapf(err, "failed generating validation for %s %s", strct.Type(), strct.Name())
+	}
+	validationImports = append(validationImports, goLogSafeImports(goValidatedFieldsAnnotations(validatedFields))...)
+	equalityChecks, equalityImports := generateGoEqualityChecks(receiver, strct.Fields, ctx.Specs())
+	validationImports = append(validationImports, equalityImports...)
+
 	// Generate Go Code Snippet
 	templateData := TemplateData{
-		StructName:  strct.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(strct.Name()))).AsString(),
-		Description: strings.ReplaceAll(strings.TrimSuffix(strct.Description(), "\n"), "\n", "\n// "),
+		StructName:  structName,
+		Receiver:    receiver,
+		Description: FormatGoCommentText(strct.Description()),
 		TypeName:    string(strct.Name()),
-		Fields:      strct.Fields,
+
+		TypeNameConst: typeNameConst,
+		Fields:        strct.Fields,
+
+		ValidationChecks:       validationChecks,
+		ValidationDeclarations: validationDeclarations,
+		EqualityChecks:         equalityChecks,
+	}
+	for _, f := range strct.Fields {
+		if f.Annotations.Has(IndexableAnnotation) {
+			templateData.IndexedFields = append(templateData.IndexedFields, jsonFieldName(f.Name))
+		}
+
+		if f.Meta.HasKey("gen:go:default") {
+			if err := checkGoDefaultField(f); err != nil {
+				return errors.Wrapf(err, "failed generating code for %s %s", strct.Type(), strct.Name())
+			}
+			templateData.DefaultFields = append(templateData.DefaultFields, f)
+		}
 	}
 
 	//goland:noinspection GoRedundantConversion
@@ -710,12 +1099,83 @@ func (c {{ .StructName }}) PayloadTypeName() string {
 				ImportPath:       "",
 			},
 		},
-		[]string{},
+		validationImports,
 	)
 
 	return GenerateCodeForSpec(tem, s)
 }
 
+// GoEmptyDefault is the only value currently supported by the "gen:go:default" metadata of struct fields.
+// It initializes container fields to an empty slice or map instead of nil.
+const GoEmptyDefault = "empty"
+
+// checkGoDefaultField checks that the "gen:go:default" metadata of a struct field is supported.
+func checkGoDefaultField(f StructField) error {
+	defaultValue := f.Meta.GetOrDefault("gen:go:default", "").AsString()
+	if defaultValue != GoEmptyDefault {
+		return errors.Errorf("unsupported default value \"%s\" for field \"%s\", only \"%s\" is supported", defaultValue, f.Name, GoEmptyDefault)
+	}
+
+	if !f.Type.IsContainer() || f.Nullable {
+		return errors.Errorf("default value of field \"%s\" can only be declared on non nullable container types", f.Name)
+	}
+
+	return nil
+}
+
+// generateGoEqualityChecks returns the Go statements of the Equal method of a struct returning false as soon as one of its
+// fields differs from the one of other, as well as the imports they require. Fields that are not comparable with ==, such
+// as containers, are compared deeply, nested structs are compared with their own Equal method and nullable fields are
+// compared by the value they point to.
+func generateGoEqualityChecks(receiver string, fields []StructField, specs specter.SpecificationGroup) ([]string, []string) {
+	var checks []string
+	imports := map[string]struct{}{}
+
+	for _, f := range fields {
+		goName := GoFieldName(f.Name, f.Annotations)
+		a, b := receiver+"."+goName, "other."+goName
+
+		// Returns the condition under which the values of a field differ, given the expressions of both values.
+		var differ func(a, b string) string
+		switch {
+		case f.Type.IsContainer() || f.Type == Any:
+			imports["reflect"] = struct{}{}
+			// reflect.DeepEqual already follows pointers.
+			checks = append(checks, fmt.Sprintf("if !reflect.DeepEqual(%s, %s) {\nreturn false\n}", a, b))
+			continue
+		case f.Type == Date || f.Type == DateTime:
+			differ = func(a, b string) string { return fmt.Sprintf("!%s.Equal(%s)", a, b) }
+		case f.Type.IsUserDefined():
+			switch specs.SelectName(specter.SpecificationName(f.Type)).(type) {
+			case *Struct:
+				differ = func(a, b string) string { return fmt.Sprintf("!%s.Equal(%s)", a, b) }
+			case *Enum:
+				differ = func(a, b string) string { return fmt.Sprintf("%s != %s", a, b) }
+			default:
+				// The type is unknown, and might not be comparable with ==.
+				imports["reflect"] = struct{}{}
+				checks = append(checks, fmt.Sprintf("if !reflect.DeepEqual(%s, %s) {\nreturn false\n}", a, b))
+				continue
+			}
+		default:
+			differ = func(a, b string) string { return fmt.Sprintf("%s != %s", a, b) }
+		}
+
+		condition := differ(a, b)
+		if f.Nullable {
+			condition = fmt.Sprintf("(%s == nil) != (%s == nil) || (%s != nil && %s)", a, b, a, differ("(*"+a+")", "*"+b))
+		}
+		checks = append(checks, fmt.Sprintf("if %s {\nreturn false\n}", condition))
+	}
+
+	var importList []string
+	for i := range imports {
+		importList = append(importList, i)
+	}
+
+	return checks, importList
+}
+
 func generateEnum(ctx *GoProcessingContext, s MisasSpecification) error {
 	enum := s.(*Enum)
 
@@ -723,7 +1183,7 @@ func generateEnum(ctx *GoProcessingContext, s MisasSpecification) error {
 // {{ .EnumName }} {{ .Description }}
 type {{ .EnumName }} {{ .EnumBaseType | AsResolvedGoType }}
 const ({{ range $value := .Values }}
-	// {{ .Name | AsExportedGoName }} {{ $value.Description }}
+	// {{ .Name | AsExportedGoName }} {{ $value.Description | AsGoComment }}
 	{{ .Name | AsExportedGoName }} {{ $.EnumName }} =
 	{{ if eq $.EnumBaseType "string" }} "{{ .Value }}" {{ else }} {{ .Value }} {{ end }}
 {{ end }})
@@ -756,7 +1216,7 @@ func IsValid{{.EnumName}}(v {{ .EnumName }}) bool {
 	//goland:noinspection GoRedundantConversion
 	templateData := TemplateData{
 		EnumName:     enum.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(enum.Name()))).AsString(),
-		Description:  strings.ReplaceAll(strings.TrimSuffix(enum.Description(), "\n"), "\n", "\n// "),
+		Description:  FormatGoCommentText(enum.Description()),
 		TypeName:     string(enum.Name()),
 		EnumBaseType: DataType(enum.BaseType),
 		Values:       enum.Values,
@@ -781,40 +1241,116 @@ func IsValid{{.EnumName}}(v {{ .EnumName }}) bool {
 	return GenerateCodeForSpec(tem, s)
 }
 
+// goTypeNameConst returns the name of the constant holding the type name of a generated type, which can be customized
+// using the "gen:go:typeNameConst" metadata. Defaults to the type name suffixed with "TypeName".
+// Setting the metadata to "-" suppresses the constant, the TypeName method then returning the type name literal.
+func goTypeNameConst(s MisasSpecification, typeName string) (string, error) {
+	name := s.Metadata().GetOrDefault("gen:go:typeNameConst", typeName+"TypeName").AsString()
+	if name == "-" {
+		return "", nil
+	}
+
+	if !token.IsIdentifier(name) {
+		return "", errors.Errorf("failed generating code for %s %s, \"%s\" is not a valid constant name", s.Type(), s.Name(), name)
+	}
+
+	return name, nil
+}
+
+// goReceiverName returns the receiver identifier of the methods generated for a type, which can be customized
+// using the "gen:go:receiver" metadata. Defaults to the lower cased first letter of the type name.
+func goReceiverName(s MisasSpecification, typeName string) (string, error) {
+	defaultName := "c"
+	if typeName != "" {
+		defaultName = strings.ToLower(typeName[:1])
+	}
+
+	name := s.Metadata().GetOrDefault("gen:go:receiver", defaultName).AsString()
+	// The generated methods refer to their receiver, which therefore cannot be blank.
+	if !token.IsIdentifier(name) || name == "_" {
+		return "", errors.Errorf("failed generating code for %s %s, \"%s\" is not a valid receiver name", s.Type(), s.Name(), name)
+	}
+
+	return name, nil
+}
+
 // generates the Go Code for a command.Command.
 func generateCommand(ctx *GoProcessingContext, s MisasSpecification) error {
 	cmd := s.(*Command)
 	templateCode := `
-const {{ .StructName }}TypeName command.PayloadTypeName = "{{ .TypeName }}"
+{{ if .TypeNameConst }}const {{ .TypeNameConst }} command.PayloadTypeName = "{{ .TypeName }}"{{ end }}
 // {{ .StructName }} {{ .Description }}
 type {{ .StructName }} struct {
 	{{ range $field := .Fields }}
-		// {{ $field.Description }} {{ if $field.Annotations.Has "personal_data" }}
+		// {{ $field.Description | AsGoComment }} {{ if $field.Annotations.Has "personal_data" }}
 		// NOTE: This field contains personal data{{ end }}
-		{{ $field.Name | AsExportedGoName }} {{ if $field.Nullable }}*{{ end }}{{ $field.Type | AsResolvedGoType }} {{ $field.Name | AsJsonAnnotation }}
+		{{ AsGoFieldName $field.Name $field.Annotations }} {{ if $field.Nullable }}*{{ end }}{{ $field.Type | AsResolvedGoType }} {{ AsRequestJsonAnnotation $field.Name $field.Annotations }}
 	{{ end }}
 }
-func (c {{ .StructName }}) TypeName() command.PayloadTypeName {
-	return {{ .StructName }}TypeName
+func ({{ .Receiver }} {{ .StructName }}) TypeName() command.PayloadTypeName {
+	return {{ if .TypeNameConst }}{{ .TypeNameConst }}{{ else }}"{{ .TypeName }}"{{ end }}
 }
-`
+// Ensures {{ .StructName }} satisfies the command.Payload interface at compile time.
+var _ command.Payload = (*{{ .StructName }})(nil)
+` + goValidateMethodTemplate + goLogSafeMethodTemplate + goIdempotentHandlerTemplate
 
 	type TemplateData struct {
-		Package     string
-		Imports     []string
-		StructName  string
-		TypeName    string
-		FilePath    string
-		Fields      []CommandField
-		Description string
+		Package       string
+		Imports       []string
+		StructName    string
+		Receiver      string
+		TypeName      string
+		TypeNameConst string
+		FilePath      string
+		Fields        []CommandField
+		Description   string
+
+		ValidationChecks       []string
+		ValidationDeclarations []string
+
+		IdempotentHandler *goIdempotentHandler
+	}
+
+	var validatedFields []goValidatedField
+	for _, f := range cmd.Fields {
+		validatedFields = append(validatedFields, goValidatedField{Name: f.Name, Type: f.Type, Nullable: f.Nullable, Annotations: f.Annotations})
+	}
+	structName := cmd.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(cmd.Name()))+"Command").AsString()
+	typeNameConst, err := goTypeNameConst(cmd, structName)
+	if err != nil {
+		return err
+	}
+	receiver, err := goReceiverName(cmd, structName)
+	if err != nil {
+		return err
+	}
+
+	validationChecks, validationDeclarations, validationImports, err := generateGoValidationChecks(structName, receiver, validatedFields)
+	if err != nil {
+		return errors.Wrapf(err, "failed generating validation for %s %s", cmd.Type(), cmd.Name())
+	}
+	validationImports = append(validationImports, goLogSafeImports(goValidatedFieldsAnnotations(validatedFields))...)
+
+	idempotentHandler, idempotentHandlerImports, err := goCommandIdempotentHandler(cmd, structName)
+	if err != nil {
+		return err
 	}
+	validationImports = append(validationImports, idempotentHandlerImports...)
 
 	// Generate Go Code Snippet
 	templateData := TemplateData{
-		StructName:  cmd.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(cmd.Name()))+"Command").AsString(),
-		Description: strings.ReplaceAll(strings.TrimSuffix(cmd.Description(), "\n"), "\n", "\n// "),
+		StructName:  structName,
+		Receiver:    receiver,
+		Description: FormatGoCommentText(cmd.Description()),
 		TypeName:    string(cmd.Name()),
-		Fields:      cmd.Fields,
+
+		TypeNameConst: typeNameConst,
+		Fields:        cmd.Fields,
+
+		ValidationChecks:       validationChecks,
+		ValidationDeclarations: validationDeclarations,
+
+		IdempotentHandler: idempotentHandler,
 	}
 
 	//goland:noinspection GoRedundantConversion
@@ -830,9 +1366,9 @@ func (c {{ .StructName }}) TypeName() command.PayloadTypeName {
 				ImportPath:       "",
 			},
 		},
-		[]string{
+		append([]string{
 			"github.com/morebec/misas-go/misas/command",
-		},
+		}, validationImports...),
 	)
 
 	return GenerateCodeForSpec(tem, s)
@@ -842,36 +1378,69 @@ func (c {{ .StructName }}) TypeName() command.PayloadTypeName {
 func generateQuery(ctx *GoProcessingContext, s MisasSpecification) error {
 	query := s.(*Query)
 	templateCode := `
-const {{ .StructName }}TypeName query.PayloadTypeName = "{{ .TypeName }}"
+{{ if .TypeNameConst }}const {{ .TypeNameConst }} query.PayloadTypeName = "{{ .TypeName }}"{{ end }}
 // {{ .StructName }} {{ .Description }}
 type {{ .StructName }} struct {
 	{{ range $field := .Fields }}
-		// {{ $field.Description }} {{ if $field.Annotations.Has "personal_data" }}
+		// {{ $field.Description | AsGoComment }} {{ if $field.Annotations.Has "personal_data" }}
 		// NOTE: This field contains personal data{{ end }}
-		{{ $field.Name | AsExportedGoName }} {{ if $field.Nullable }}*{{ end }}{{ $field.Type | AsResolvedGoType }} {{ $field.Name | AsJsonAnnotation }}
+		{{ AsGoFieldName $field.Name $field.Annotations }} {{ if $field.Nullable }}*{{ end }}{{ $field.Type | AsResolvedGoType }} {{ AsRequestJsonAnnotation $field.Name $field.Annotations }}
 	{{ end }}
 }
-func (c {{ .StructName }}) TypeName() query.PayloadTypeName {
-	return {{ .StructName }}TypeName
+func ({{ .Receiver }} {{ .StructName }}) TypeName() query.PayloadTypeName {
+	return {{ if .TypeNameConst }}{{ .TypeNameConst }}{{ else }}"{{ .TypeName }}"{{ end }}
 }
-`
+// Ensures {{ .StructName }} satisfies the query.Payload interface at compile time.
+var _ query.Payload = (*{{ .StructName }})(nil)
+` + goValidateMethodTemplate + goLogSafeMethodTemplate
 
 	type TemplateData struct {
-		Package     string
-		Imports     []string
-		StructName  string
-		TypeName    string
-		FilePath    string
-		Fields      []QueryField
-		Description string
+		Package       string
+		Imports       []string
+		StructName    string
+		Receiver      string
+		TypeName      string
+		TypeNameConst string
+		FilePath      string
+		Fields        []QueryField
+		Description   string
+
+		ValidationChecks       []string
+		ValidationDeclarations []string
+	}
+
+	var validatedFields []goValidatedField
+	for _, f := range query.Fields {
+		validatedFields = append(validatedFields, goValidatedField{Name: f.Name, Type: f.Type, Nullable: f.Nullable, Annotations: f.Annotations})
+	}
+	structName := query.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(query.Name()))+"Query").AsString()
+	typeNameConst, err := goTypeNameConst(query, structName)
+	if err != nil {
+		return err
+	}
+	receiver, err := goReceiverName(query, structName)
+	if err != nil {
+		return err
+	}
+
+	validationChecks, validationDeclarations, validationImports, err := generateGoValidationChecks(structName, receiver, validatedFields)
+	if err != nil {
+		return errors.Wrapf(err, "failed generating validation for %s %s", query.Type(), query.Name())
 	}
+	validationImports = append(validationImports, goLogSafeImports(goValidatedFieldsAnnotations(validatedFields))...)
 
 	// Generate Go Code Snippet
 	templateData := TemplateData{
-		StructName:  query.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(query.Name()))+"Query").AsString(),
-		Description: strings.ReplaceAll(strings.TrimSuffix(query.Description(), "\n"), "\n", "\n// "),
+		StructName:  structName,
+		Receiver:    receiver,
+		Description: FormatGoCommentText(query.Description()),
 		TypeName:    string(query.Name()),
-		Fields:      query.Fields,
+
+		TypeNameConst: typeNameConst,
+		Fields:        query.Fields,
+
+		ValidationChecks:       validationChecks,
+		ValidationDeclarations: validationDeclarations,
 	}
 
 	//goland:noinspection GoRedundantConversion
@@ -887,9 +1456,9 @@ func (c {{ .StructName }}) TypeName() query.PayloadTypeName {
 				ImportPath:       "",
 			},
 		},
-		[]string{
+		append([]string{
 			"github.com/morebec/misas-go/misas/query",
-		},
+		}, validationImports...),
 	)
 
 	return GenerateCodeForSpec(tem, s)
@@ -899,36 +1468,57 @@ func (c {{ .StructName }}) TypeName() query.PayloadTypeName {
 func generateEvent(ctx *GoProcessingContext, s MisasSpecification) error {
 	evt := s.(*Event)
 	templateCode := `
-const {{ .StructName }}TypeName event.PayloadTypeName = "{{ .TypeName }}"
+{{ if .TypeNameConst }}const {{ .TypeNameConst }} event.PayloadTypeName = "{{ .TypeName }}"{{ end }}
 // {{ .StructName }} {{ .Description }}
 type {{ .StructName }} struct {
 	{{ range $field := .Fields }}
-		// {{ $field.Description }} {{ if $field.Annotations.Has "personal_data" }}
+		// {{ $field.Description | AsGoComment }} {{ if $field.Annotations.Has "personal_data" }}
 		// NOTE: This field contains personal data{{ end }}
-		{{ $field.Name | AsExportedGoName }} {{ if $field.Nullable }}*{{ end }}{{ $field.Type | AsResolvedGoType }} {{ $field.Name | AsJsonAnnotation }}
+		{{ AsGoFieldName $field.Name $field.Annotations }} {{ if $field.Nullable }}*{{ end }}{{ $field.Type | AsResolvedGoType }} {{ $field.Name | AsJsonAnnotation }}
 	{{ end }}
 }
-func (c {{ .StructName }}) TypeName() event.PayloadTypeName {
-	return {{ .StructName }}TypeName
+func ({{ .Receiver }} {{ .StructName }}) TypeName() event.PayloadTypeName {
+	return {{ if .TypeNameConst }}{{ .TypeNameConst }}{{ else }}"{{ .TypeName }}"{{ end }}
 }
-`
+// Ensures {{ .StructName }} satisfies the event.Payload interface at compile time.
+var _ event.Payload = (*{{ .StructName }})(nil)
+` + goLogSafeMethodTemplate
 
 	type TemplateData struct {
-		Package     string
-		Imports     []string
-		StructName  string
-		TypeName    string
-		FilePath    string
-		Fields      []EventField
-		Description string
+		Package       string
+		Imports       []string
+		StructName    string
+		Receiver      string
+		TypeName      string
+		TypeNameConst string
+		FilePath      string
+		Fields        []EventField
+		Description   string
+	}
+
+	var fieldAnnotations []Annotations
+	for _, f := range evt.Fields {
+		fieldAnnotations = append(fieldAnnotations, f.Annotations)
+	}
+	structName := goEventStructName(evt)
+	typeNameConst, err := goTypeNameConst(evt, structName)
+	if err != nil {
+		return err
+	}
+	receiver, err := goReceiverName(evt, structName)
+	if err != nil {
+		return err
 	}
 
 	// Generate Go Code Snippet
 	templateData := TemplateData{
-		StructName:  evt.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(evt.Name()))+"Event").AsString(),
-		Description: strings.ReplaceAll(strings.TrimSuffix(evt.Description(), "\n"), "\n", "\n// "),
+		StructName:  structName,
+		Receiver:    receiver,
+		Description: FormatGoCommentText(evt.Description()),
 		TypeName:    string(evt.Name()),
-		Fields:      evt.Fields,
+
+		TypeNameConst: typeNameConst,
+		Fields:        evt.Fields,
 	}
 
 	//goland:noinspection GoRedundantConversion
@@ -944,79 +1534,245 @@ func (c {{ .StructName }}) TypeName() event.PayloadTypeName {
 				ImportPath:       "",
 			},
 		},
-		[]string{
+		append([]string{
 			"github.com/morebec/misas-go/misas/event",
-		},
+		}, goLogSafeImports(fieldAnnotations)...),
 	)
 
 	return GenerateCodeForSpec(tem, s)
 }
 
+// goEventStructName returns the name of the Go struct generated for an event.
+func goEventStructName(evt *Event) string {
+	return evt.Metadata().GetOrDefault("gen:go:name", strcase.ToCamel(string(evt.Name()))+"Event").AsString()
+}
+
+// generates the Go Code exposing the version of a system so that runtime artifacts can be tied to the version of the specifications.
+func generateSystemVersion(ctx *GoProcessingContext, s MisasSpecification) error {
+	system := s.(*System)
+	if system.Version == "" {
+		return nil
+	}
+
+	templateCode := `
+// ModuleVersion is the version of the specifications of the {{ .SystemName }} system.
+const ModuleVersion = "{{ .Version }}"
+
+// ModuleVersionMetadataKey is the key of the event metadata containing the ModuleVersion.
+const ModuleVersionMetadataKey = "moduleVersion"
+
+// ModuleVersionHeader is the HTTP header containing the ModuleVersion.
+const ModuleVersionHeader = "X-Module-Version"
+
+// EnrichWithModuleVersion is a store.EventDescriptorEnricher adding the ModuleVersion to the metadata of events.
+func EnrichWithModuleVersion(_ context.Context, d store.EventDescriptor) store.EventDescriptor {
+	d.Metadata = d.Metadata.Set(ModuleVersionMetadataKey, ModuleVersion)
+	return d
+}
+
+// ModuleVersionMiddleware is an HTTP middleware adding the ModuleVersion to the headers of responses.
+func ModuleVersionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ModuleVersionHeader, ModuleVersion)
+		next.ServeHTTP(w, r)
+	})
+}
+`
+	type TemplateData struct {
+		SystemName string
+		Version    string
+	}
+
+	pkg := ctx.PackageTree.FindPackageForPath(system.Source().Location)
+	if pkg == nil {
+		return errors.Errorf("failed generating code for %s %s, could not find a suitable package", system.Type(), system.Name())
+	}
+
+	tem := NewGoSnippetGenerationContext(
+		ctx,
+		"system version",
+		templateCode,
+		TemplateData{SystemName: string(system.Name()), Version: system.Version},
+		nil,
+		[]string{
+			"context",
+			"net/http",
+
+			"github.com/morebec/misas-go/misas/event/store",
+		},
+	)
+
+	return GenerateCodeInFile(tem, pkg, "version_generated.go")
+}
+
+// chiRouterMethods maps the supported HTTP methods to the method of chi.Router used to register an endpoint.
+var chiRouterMethods = map[string]string{
+	"GET":     "Get",
+	"POST":    "Post",
+	"PUT":     "Put",
+	"PATCH":   "Patch",
+	"DELETE":  "Delete",
+	"HEAD":    "Head",
+	"OPTIONS": "Options",
+}
+
 // generates the Go Code for an HTTP Endpoint.
 func generateHTTPEndpoint(ctx *GoProcessingContext, s MisasSpecification) error {
 	endpoint := s.(*HTTPEndpoint)
 
 	templateCode := `
 // {{ .EndpointFuncName }} {{ .Description }}
-func {{ .EndpointFuncName }}(r chi.Router, bus {{ if eq .Method "POST" }}command.Bus{{ else }}event.Bus{{ end }}) {
-	r.Get("{{ .Path }}", func(w http.ResponseWriter, r *http.Request) {
-		handleError := func(w http.ResponseWriter, r *http.Request, err error) {
-			if !domain.IsDomainError(err) {
-				w.WriteHeader(500)
-				render.JSON(w, r, NewInternalError(err))
-			}
-			derr := err.(domain.Error)
-			conv := map[domain.ErrorTypeName]int {
-				{{ range $response := .FailureResponses }}{{ if ne .Description "" }}// {{ .Description }}{{ end }}
-				"{{ .ErrorType }}": {{ .StatusCode }},
-				{{ end }}
-			}
-			c := conv[derr.TypeName()]
-			w.WriteHeader(c)
-			render.JSON(w, r, NewErrorResponse(derr.TypeName(), derr.Error(), derr.Data()))
-		}
+func {{ .EndpointFuncName }}(r chi.Router, bus {{ .BusPackage }}.Bus) {
+	r.{{ .RouterMethod }}("{{ .Path }}", func(w http.ResponseWriter, r *http.Request) {
 		// Decode request payload
 		var input {{ .Request | AsResolvedGoType }}
+		{{- if .HasBody }}
 		err := json.NewDecoder(r.Body).Decode(&input)
+		{{- else }}
+		err := httpapi.UnmarshalQuery(r.URL.Query(), {{ if .QueryStringFields }}[]string{ {{- range $i, $f := .QueryStringFields }}{{ if $i }}, {{ end }}"{{ $f }}"{{ end -}} }{{ else }}nil{{ end }}, &input)
+		{{- end }}
 		if err != nil {
-			w.WriteHeader(500)
-			render.JSON(w, r, httpapi.NewInternalError(err))
+			response := httpapi.NewFailureResponse("invalid_request", err.Error(), nil).WithStatusCode(http.StatusBadRequest)
+			w.WriteHeader(response.StatusCode)
+			render.JSON(w, r, response)
 			return
 		}
+		{{- range .PathParams }}
+		input.{{ .GoFieldName }} = chi.URLParam(r, "{{ .Name }}")
+		{{- end }}
+		httpapi.Log(r.Context(), "{{ .TypeName }} request received", map[string]any{"request": httpapi.LogSafeValue(input)})
 		// Send to Domain Layer
-		output, err := bus.Send(r.Context(), input)
+		output, err := bus.Send(r.Context(), {{ .BusPackage }}.{{ .BusMessage }}{Payload: input})
 		if err != nil {
-			w.WriteHeader(400)
-			render.JSON(w, r, httpapi.NewErrorResponse(output))
+			response := httpapi.NewErrorResponse(err)
+			{{- if .FailureResponses }}
+			switch response.Error.Type {
+			{{- range $response := .FailureResponses }}
+			case "{{ .ErrorType }}":
+				{{- if ne .Description "" }}
+				// {{ .Description | AsGoComment }}
+				{{- end }}
+				response = response.WithStatusCode({{ .StatusCode }})
+			{{- end }}
+			}
+			{{- end }}
+			w.WriteHeader(response.StatusCode)
+			render.JSON(w, r, response)
+			return
+		}
+		{{ if .SuccessResponse.Type -}}
+		var response any
+		switch o := output.(type) {
+		case {{ .SuccessResponse.Type | AsResolvedGoType }}, nil:
+			response = o
+		case *{{ .SuccessResponse.Type | AsResolvedGoType }}:
+			if o != nil {
+				response = *o
+			}
+		default:
+			internalError := httpapi.NewInternalErrorResponse(fmt.Errorf("unexpected response of type %T", output))
+			w.WriteHeader(internalError.StatusCode)
+			render.JSON(w, r, internalError)
 			return
 		}
+		httpapi.Log(r.Context(), "{{ .TypeName }} response sent", map[string]any{"response": httpapi.LogSafeValue(response)})
+		{{ if .SuccessResponse.StatusCode }}w.WriteHeader({{ .SuccessResponse.StatusCode }}){{ end }}
+		render.JSON(w, r, httpapi.NewSuccessResponse(response))
+		{{- else -}}
+		httpapi.Log(r.Context(), "{{ .TypeName }} response sent", map[string]any{"response": httpapi.LogSafeValue(output)})
 		render.JSON(w, r, httpapi.NewSuccessResponse(output))
+		{{- end }}
 	})
 }
 `
 	type TemplateData struct {
-		EndpointFuncName string
-		TypeName         string
-		Description      string
-		Path             string
-		Method           string
-		Request          DataType
-		SuccessResponse  HTTPEndpointSuccessResponse
-		FailureResponses []HTTPEndpointFailureResponse
+		EndpointFuncName  string
+		TypeName          string
+		Description       string
+		Path              string
+		Method            string
+		RouterMethod      string
+		BusPackage        string
+		BusMessage        string
+		Request           DataType
+		HasBody           bool
+		QueryStringFields []string
+		PathParams        []struct{ Name, GoFieldName string }
+		SuccessResponse   HTTPEndpointSuccessResponse
+		FailureResponses  []HTTPEndpointFailureResponse
+	}
+
+	routerMethod, found := chiRouterMethods[strings.ToUpper(endpoint.Method)]
+	if !found {
+		return errors.Errorf("failed generating code for %s %s, unsupported HTTP method \"%s\"", endpoint.Type(), endpoint.Name(), endpoint.Method)
+	}
+
+	// The request is sent to the bus of its type.
+	var busPackage, busMessage string
+	requestSpec := ctx.Specs().SelectName(specter.SpecificationName(endpoint.Request))
+	switch {
+	case requestSpec != nil && requestSpec.Type() == (&Command{}).Type():
+		busPackage, busMessage = "command", "Command"
+	case requestSpec != nil && requestSpec.Type() == (&Query{}).Type():
+		busPackage, busMessage = "query", "Query"
+	default:
+		return errors.Errorf("failed generating code for %s %s, request type \"%s\" is neither a command nor a query", endpoint.Type(), endpoint.Name(), endpoint.Request)
+	}
+
+	// Path parameters are bound to the request fields of the same name, after the fields sent in the body or the query
+	// string so that they take precedence.
+	pathParams, err := httpEndpointPathParams(endpoint, requestSpec.(MisasSpecification))
+	if err != nil {
+		return errors.Wrapf(err, "failed generating code for %s %s", endpoint.Type(), endpoint.Name())
 	}
 
 	// Generate Go Code Snippet
 	//goland:noinspection GoRedundantConversion
 	templateData := TemplateData{
+		HasBody:          httpMethodHasBody(endpoint.Method),
 		EndpointFuncName: endpoint.Metadata().GetOrDefault("gen:go:name", strcase.ToLowerCamel(string(endpoint.Name()))).AsString(),
 		TypeName:         string(endpoint.Name()),
-		Description:      strings.ReplaceAll(strings.TrimSuffix(endpoint.Description(), "\n"), "\n", "\n// "),
+		Description:      FormatGoCommentText(endpoint.Description()),
 		Path:             endpoint.Path,
 		Method:           endpoint.Method,
+		RouterMethod:     routerMethod,
+		BusPackage:       busPackage,
+		BusMessage:       busMessage,
 		Request:          endpoint.Request,
 		SuccessResponse:  endpoint.Responses.Success,
 		FailureResponses: endpoint.Responses.Failures,
 	}
+	for _, p := range pathParams {
+		templateData.PathParams = append(templateData.PathParams, struct{ Name, GoFieldName string }{
+			Name:        p.Name,
+			GoFieldName: GoFieldName(p.Field.Name, p.Field.Annotations),
+		})
+	}
+	// Without a body, the request is decoded from the query string, where the fields represented as strings are sent
+	// verbatim. See httpapi.MarshalQuery.
+	if !templateData.HasBody {
+		fields, _ := FieldsOfSpecification(requestSpec.(MisasSpecification))
+		for _, f := range fields {
+			if !IsReadonlyRequestField(requestSpec.(MisasSpecification), f) && isJSONStringType(f.Type, ctx.Specs()) {
+				templateData.QueryStringFields = append(templateData.QueryStringFields, jsonFieldName(f.Name))
+			}
+		}
+	}
+
+	staticImports := []string{
+		"net/http",
+
+		"github.com/go-chi/chi/v5",
+		"github.com/go-chi/render",
+		"github.com/morebec/misas-go/misas/httpapi",
+		"github.com/morebec/misas-go/misas/" + busPackage,
+	}
+	if endpoint.Responses.Success.Type != "" {
+		staticImports = append(staticImports, "fmt")
+	}
+	if templateData.HasBody {
+		staticImports = append(staticImports, "encoding/json")
+	}
 
 	//goland:noinspection GoRedundantConversion
 	tem := NewGoSnippetGenerationContext(
@@ -1031,18 +1787,189 @@ func {{ .EndpointFuncName }}(r chi.Router, bus {{ if eq .Method "POST" }}command
 				ImportPath:       "",
 			},
 		},
-		[]string{
-			"encoding/json",
-			"net/http",
-
-			"github.com/go-chi/chi/v5",
-			"github.com/go-chi/render",
-			"github.com/morebec/misas-go/misas/httpapi",
-			"github.com/morebec/misas-go/misas/command",
-			"github.com/morebec/misas-go/misas/domain",
-			"github.com/morebec/misas-go/misas/event",
-		},
+		staticImports,
 	)
 
 	return GenerateCodeForSpec(tem, endpoint)
 }
+
+// Annotations supported on fields to generate a Validate method on structs, commands and queries.
+const (
+	// RequiredAnnotation indicates that a field must not be empty.
+	RequiredAnnotation = "required"
+	// MinLengthAnnotation indicates the minimum length of a field, e.g. "minLength=3".
+	MinLengthAnnotation = "minLength"
+	// MaxLengthAnnotation indicates the maximum length of a field, e.g. "maxLength=255".
+	MaxLengthAnnotation = "maxLength"
+	// PatternAnnotation indicates a regular expression a field must match, e.g. "pattern=^[a-z]+$".
+	PatternAnnotation = "pattern"
+)
+
+// IndexableAnnotation indicates that a field of a struct used as a read model should be indexed in the DocumentStore.
+const IndexableAnnotation = "indexable"
+
+// goValidateMethodTemplate is the template of a Validate method aggregating the violations of the fields of a type.
+// It expects the template data to have a StructName, Receiver, ValidationChecks and ValidationDeclarations fields.
+const goValidateMethodTemplate = `
+{{ if .ValidationChecks }}
+{{ range $declaration := .ValidationDeclarations }}{{ $declaration }}
+{{ end }}
+// Validate validates the fields of {{ .StructName }} and returns all the violations as a single error.
+func ({{ .Receiver }} {{ .StructName }}) Validate() error {
+	var violations []string
+	{{ range $check := .ValidationChecks }}{{ $check }}
+	{{ end }}
+	if len(violations) != 0 {
+		return errors.New(strings.Join(violations, "; "))
+	}
+	return nil
+}
+{{ end }}`
+
+// goLogSafeMethodTemplate is the template of a LogSafe method returning a representation of a type in which
+// the fields annotated as personal data are redacted. It satisfies the httpapi.LogSafe interface.
+// It expects the template data to have a StructName, Receiver and Fields fields.
+const goLogSafeMethodTemplate = `
+// LogSafe returns a representation of {{ .StructName }} that can be logged without exposing personal data.
+func ({{ .Receiver }} {{ .StructName }}) LogSafe() map[string]any {
+	return map[string]any{
+		{{ range $field := .Fields }}"{{ $field.Name | AsJsonFieldName }}": {{ if $field.Annotations.Has "personal_data" }}secret.RedactedValue{{ else }}{{ $.Receiver }}.{{ AsGoFieldName $field.Name $field.Annotations }}{{ end }},
+		{{ end }}
+	}
+}
+`
+
+// goLogSafeImports returns the imports required by the LogSafe method of a type with fields having the given annotations.
+func goLogSafeImports(fieldAnnotations []Annotations) []string {
+	for _, a := range fieldAnnotations {
+		if a.Has("personal_data") {
+			return []string{"github.com/morebec/misas-go/misas/secret"}
+		}
+	}
+	return nil
+}
+
+// goValidatedField represents the information of a field required to generate its validation checks.
+type goValidatedField struct {
+	Name        string
+	Type        DataType
+	Nullable    bool
+	Annotations Annotations
+}
+
+// goValidatedFieldsAnnotations returns the annotations of a list of fields.
+func goValidatedFieldsAnnotations(fields []goValidatedField) []Annotations {
+	var annotations []Annotations
+	for _, f := range fields {
+		annotations = append(annotations, f.Annotations)
+	}
+	return annotations
+}
+
+// generateGoValidationChecks returns the Go statements validating a list of fields of a receiver according to their annotations,
+// the package level declarations these statements use, such as compiled patterns, as well as the imports they require.
+func generateGoValidationChecks(typeName string, receiver string, fields []goValidatedField) ([]string, []string, []string, error) {
+	var checks []string
+	var declarations []string
+	imports := map[string]struct{}{}
+
+	for _, f := range fields {
+		goName := receiver + "." + GoFieldName(f.Name, f.Annotations)
+		value := goName
+		if f.Nullable {
+			value = "*" + goName
+		}
+		isString := !f.Type.IsContainer() && (f.Type == String || f.Type == Identifier)
+
+		lengthFunc := "len"
+		if isString {
+			lengthFunc = "utf8.RuneCountInString"
+		}
+
+		violation := func(msg string) string {
+			return fmt.Sprintf("violations = append(violations, %s)", strconv.Quote(jsonFieldName(f.Name)+" "+msg))
+		}
+
+		// Wraps a condition so that it is only evaluated when a nullable field is set.
+		whenSet := func(condition string) string {
+			if f.Nullable {
+				return goName + " != nil && " + condition
+			}
+			return condition
+		}
+
+		if f.Annotations.Has(RequiredAnnotation) {
+			var condition string
+			switch {
+			case f.Nullable:
+				condition = goName + " == nil"
+			case f.Type.IsContainer():
+				condition = "len(" + goName + ") == 0"
+			case isString:
+				condition = goName + ` == ""`
+			case f.Type == Date || f.Type == DateTime:
+				condition = goName + ".IsZero()"
+			}
+			if condition == "" {
+				return nil, nil, nil, errors.Errorf("annotation %s is not supported on field \"%s\" of type %s", RequiredAnnotation, f.Name, f.Type)
+			}
+			checks = append(checks, fmt.Sprintf("if %s {\n%s\n}", condition, violation("is required")))
+		}
+
+		for _, lengthCheck := range []struct {
+			annotation string
+			operator   string
+			msg        string
+		}{
+			{annotation: MinLengthAnnotation, operator: "<", msg: "must have a length of at least %d"},
+			{annotation: MaxLengthAnnotation, operator: ">", msg: "must have a length of at most %d"},
+		} {
+			v, found := f.Annotations.Value(lengthCheck.annotation)
+			if !found {
+				continue
+			}
+			if !isString && !f.Type.IsContainer() {
+				return nil, nil, nil, errors.Errorf("annotation %s is not supported on field \"%s\" of type %s", lengthCheck.annotation, f.Name, f.Type)
+			}
+			length, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, nil, nil, errors.Wrapf(err, "invalid %s annotation on field \"%s\"", lengthCheck.annotation, f.Name)
+			}
+			if isString {
+				imports["unicode/utf8"] = struct{}{}
+			}
+			condition := fmt.Sprintf("%s(%s) %s %d", lengthFunc, value, lengthCheck.operator, length)
+			checks = append(checks, fmt.Sprintf("if %s {\n%s\n}", whenSet(condition), violation(fmt.Sprintf(lengthCheck.msg, length))))
+		}
+
+		if pattern, found := f.Annotations.Value(PatternAnnotation); found {
+			if !isString {
+				return nil, nil, nil, errors.Errorf("annotation %s is not supported on field \"%s\" of type %s", PatternAnnotation, f.Name, f.Type)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, nil, nil, errors.Wrapf(err, "invalid %s annotation on field \"%s\"", PatternAnnotation, f.Name)
+			}
+			imports["regexp"] = struct{}{}
+			// Patterns are compiled once at package level rather than on every validation.
+			patternVar := strcase.ToLowerCamel(typeName) + GoFieldName(f.Name, f.Annotations) + "Pattern"
+			declarations = append(declarations, fmt.Sprintf("var %s = regexp.MustCompile(%s)", patternVar, strconv.Quote(pattern)))
+			condition := fmt.Sprintf("!%s.MatchString(%s)", patternVar, value)
+			checks = append(checks, fmt.Sprintf("if %s {\n%s\n}", whenSet(condition), violation("must match pattern "+pattern)))
+		}
+	}
+
+	if len(checks) == 0 {
+		return nil, nil, nil, nil
+	}
+
+	imports["errors"] = struct{}{}
+	imports["strings"] = struct{}{}
+
+	var importList []string
+	for i := range imports {
+		importList = append(importList, i)
+	}
+	sort.Strings(importList)
+
+	return checks, declarations, importList, nil
+}
@@ -0,0 +1,1120 @@
+package spectool
+
+import (
+	"encoding/json"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestGoProcessingContext returns a GoProcessingContext with a single package located at /unit.
+func newTestGoProcessingContext() *GoProcessingContext {
+	return &GoProcessingContext{
+		PackageTree: &GoPackage{
+			ModFile:  GoMod{Name: "github.com/morebec/unit", Path: "/unit/go.mod"},
+			Name:     "unit",
+			FilePath: "/unit",
+		},
+	}
+}
+
+// newTestHTTPEndpointContext returns a GoProcessingContext in which the command "user.register_user" and
+// the query "user.get_user" were generated, to be used as the requests of HTTP endpoints.
+func newTestHTTPEndpointContext(t *testing.T) *GoProcessingContext {
+	registerUser := &Command{Nam: "user.register_user", Desc: "Registers a user.", Src: testSource}
+	getUser := &Query{Nam: "user.get_user", Desc: "Returns a user.", Src: testSource, Fields: []QueryField{
+		{Name: "id", Description: "ID of the user.", Type: Identifier},
+	}}
+
+	ctx := newTestGoProcessingContext()
+	ctx.ParentContext.DependencyGraph = specter.ResolvedDependencies{registerUser, getUser}
+	if err := generateCommand(ctx, registerUser); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateQuery(ctx, getUser); err != nil {
+		t.Fatal(err)
+	}
+
+	return ctx
+}
+
+// renderGoCodeForSpec runs a generator function for a given spec and returns the rendered code of all generated files.
+func renderGoCodeForSpec(t *testing.T, generate func(ctx *GoProcessingContext, s MisasSpecification) error, s MisasSpecification) string {
+	return renderGoCodeInContext(t, newTestGoProcessingContext(), generate, s)
+}
+
+// renderGoCodeInContext runs a generator function for a given spec in a context and returns the rendered code of all generated files.
+func renderGoCodeInContext(t *testing.T, ctx *GoProcessingContext, generate func(ctx *GoProcessingContext, s MisasSpecification) error, s MisasSpecification) string {
+	if err := generate(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+
+	code := ""
+	for _, f := range ctx.PackageTree.GeneratedFilesRecursive() {
+		rendered, err := RenderGeneratedFile(*f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code += rendered
+	}
+
+	return code
+}
+
+var testSource = specter.Source{Location: "/unit/system.spec.hcl"}
+
+func TestGenerateCommand_PayloadInterfaceAssertion(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateCommand, &Command{
+		Nam:  "user.register",
+		Desc: "Registers a user.",
+		Src:  testSource,
+	})
+
+	assert.Contains(t, code, "var _ command.Payload = (*UserRegisterCommand)(nil)")
+}
+
+func TestGenerateQuery_PayloadInterfaceAssertion(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateQuery, &Query{
+		Nam:  "user.by_id",
+		Desc: "Returns a user by its ID.",
+		Src:  testSource,
+	})
+
+	assert.Contains(t, code, "var _ query.Payload = (*UserByIdQuery)(nil)")
+}
+
+func TestGenerateEvent_PayloadInterfaceAssertion(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateEvent, &Event{
+		Nam:  "user.registered",
+		Desc: "Indicates that a user was registered.",
+		Src:  testSource,
+	})
+
+	assert.Contains(t, code, "var _ event.Payload = (*UserRegisteredEvent)(nil)")
+}
+
+func TestGenerateCommand_MultilineDescriptions(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateCommand, &Command{
+		Nam:  "user.register",
+		Desc: "Registers a user.\nThe user will need to confirm their email address.\n",
+		Fields: []CommandField{
+			{
+				Name:        "emailAddress",
+				Description: "Email address of the user.\n\nIt must be unique across all users.  \n",
+				Type:        String,
+			},
+		},
+		Src: testSource,
+	})
+
+	_, err := format.Source([]byte(code))
+	assert.NoError(t, err)
+
+	assert.Contains(t, code, `// UserRegisterCommand Registers a user.
+// The user will need to confirm their email address.
+type UserRegisterCommand struct {`)
+
+	assert.Contains(t, code, `	// Email address of the user.
+	//
+	// It must be unique across all users.
+	EmailAddress string `+"`json:\"emailAddress\"`")
+}
+
+func TestFormatGoCommentText(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{
+			name:        "single line",
+			description: "A description.",
+			want:        "A description.",
+		},
+		{
+			name:        "trailing new lines",
+			description: "A description.\n\n",
+			want:        "A description.",
+		},
+		{
+			name:        "multiline",
+			description: "First line.\r\nSecond line.   \n\nFourth line.",
+			want:        "First line.\n// Second line.\n//\n// Fourth line.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatGoCommentText(tt.description))
+		})
+	}
+}
+
+func TestGenerateCommand_Validate(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateCommand, &Command{
+		Nam:  "user.register",
+		Desc: "Registers a user.",
+		Fields: []CommandField{
+			{
+				Name:        "username",
+				Description: "Username of the user.",
+				Type:        String,
+				Annotations: Annotations{"required", "minLength=3", "maxLength=20", "pattern=^[a-z]+$"},
+			},
+			{
+				Name:        "nickname",
+				Description: "Nickname of the user.",
+				Type:        String,
+				Nullable:    true,
+				Annotations: Annotations{"maxLength=20"},
+			},
+			{
+				Name:        "roles",
+				Description: "Roles of the user.",
+				Type:        "[]string",
+				Annotations: Annotations{"required"},
+			},
+		},
+		Src: testSource,
+	})
+
+	_, err := FormatGoSource([]byte(code))
+	assert.NoError(t, err)
+
+	assert.Contains(t, code, "func (u UserRegisterCommand) Validate() error {")
+	assert.Contains(t, code, `if u.Username == "" {`)
+	assert.Contains(t, code, `violations = append(violations, "username is required")`)
+	assert.Contains(t, code, `if utf8.RuneCountInString(u.Username) < 3 {`)
+	assert.Contains(t, code, `if utf8.RuneCountInString(u.Username) > 20 {`)
+	assert.Contains(t, code, `var userRegisterCommandUsernamePattern = regexp.MustCompile("^[a-z]+$")`)
+	assert.Contains(t, code, `if !userRegisterCommandUsernamePattern.MatchString(u.Username) {`)
+	assert.Equal(t, 1, strings.Count(code, "regexp.MustCompile"))
+	assert.Contains(t, code, `if u.Nickname != nil && utf8.RuneCountInString(*u.Nickname) > 20 {`)
+	assert.Contains(t, code, `if len(u.Roles) == 0 {`)
+	assert.Contains(t, code, `return errors.New(strings.Join(violations, "; "))`)
+	for _, i := range []string{`"errors"`, `"regexp"`, `"strings"`, `"unicode/utf8"`} {
+		assert.Contains(t, code, i)
+	}
+}
+
+func TestGenerateStruct_WithoutValidationAnnotations(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateStruct, &Struct{
+		Nam:  "user.address",
+		Desc: "Address of a user.",
+		Fields: []StructField{
+			{
+				Name:        "city",
+				Description: "City of the address.",
+				Type:        String,
+			},
+		},
+		Src: testSource,
+	})
+
+	assert.NotContains(t, code, "Validate()")
+}
+
+func TestGenerateQuery_InvalidValidationAnnotation(t *testing.T) {
+	err := generateQuery(newTestGoProcessingContext(), &Query{
+		Nam:  "user.by_id",
+		Desc: "Returns a user by its ID.",
+		Fields: []QueryField{
+			{
+				Name:        "id",
+				Description: "ID of the user.",
+				Type:        Int,
+				Annotations: Annotations{"pattern=^[0-9]+$"},
+			},
+		},
+		Src: testSource,
+	})
+
+	assert.Error(t, err)
+}
+
+func TestGenerateStruct_IndexableFields(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateStruct, &Struct{
+		Nam:  "user.profile",
+		Desc: "Read model of a user's profile.",
+		Fields: []StructField{
+			{Name: "id", Description: "ID of the user.", Type: Identifier},
+			{Name: "emailAddress", Description: "Email address of the user.", Type: String, Annotations: Annotations{IndexableAnnotation}},
+			{Name: "username", Description: "Username of the user.", Type: String, Annotations: Annotations{"personal_data", IndexableAnnotation}},
+		},
+		Src: testSource,
+	})
+
+	assert.Contains(t, code, `var UserProfileIndexedFields = []string{"emailAddress", "username"}`)
+}
+
+func TestGenerateHTTPEndpoint_Method(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		request DataType
+		want    string
+		wantBus string
+		wantErr bool
+	}{
+		{name: "POST endpoint", method: "POST", request: "user.register_user", want: `r.Post("/users",`, wantBus: "bus command.Bus"},
+		{name: "GET endpoint", method: "GET", request: "user.get_user", want: `r.Get("/users",`, wantBus: "bus query.Bus"},
+		{name: "lower case method", method: "put", request: "user.register_user", want: `r.Put("/users",`, wantBus: "bus command.Bus"},
+		{name: "unsupported method", method: "CONNECT", request: "user.register_user", wantErr: true},
+		{name: "request neither a command nor a query", method: "POST", request: String, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &HTTPEndpoint{
+				Nam:     "user.register",
+				Method:  tt.method,
+				Path:    "/users",
+				Desc:    "Registers a user.",
+				Request: tt.request,
+				Src:     testSource,
+			}
+
+			if tt.wantErr {
+				assert.Error(t, generateHTTPEndpoint(newTestHTTPEndpointContext(t), endpoint))
+				return
+			}
+
+			code := renderGoCodeInContext(t, newTestHTTPEndpointContext(t), generateHTTPEndpoint, endpoint)
+			assert.Contains(t, code, tt.want)
+			assert.Contains(t, code, tt.wantBus)
+		})
+	}
+}
+
+func TestGenerateHTTPEndpoint_SuccessResponseType(t *testing.T) {
+	ctx := newTestHTTPEndpointContext(t)
+
+	err := generateStruct(ctx, &Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Src:  testSource,
+	})
+	assert.NoError(t, err)
+
+	err = generateHTTPEndpoint(ctx, &HTTPEndpoint{
+		Nam:     "user.get_profile",
+		Method:  "GET",
+		Path:    "/users/{id}/profile",
+		Desc:    "Returns the profile of a user.",
+		Request: "user.get_user",
+		Responses: HTTPEndpointResponses{
+			Success: HTTPEndpointSuccessResponse{StatusCode: 200, Description: "The profile of the user.", Type: "user.profile"},
+		},
+		Src: testSource,
+	})
+	assert.NoError(t, err)
+
+	code := ""
+	for _, f := range ctx.PackageTree.GeneratedFilesRecursive() {
+		rendered, err := RenderGeneratedFile(*f)
+		assert.NoError(t, err)
+		code += rendered
+	}
+
+	assert.Contains(t, code, "case UserProfile, nil:")
+	assert.Contains(t, code, "case *UserProfile:")
+	assert.Contains(t, code, "w.WriteHeader(200)")
+	assert.Contains(t, code, "render.JSON(w, r, httpapi.NewSuccessResponse(response))")
+	assert.Contains(t, code, `"fmt"`)
+
+	runGeneratedGoTest(t, ctx, `
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/morebec/misas-go/misas/query"
+)
+
+func TestUserGetProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     any
+		wantStatus int
+	}{
+		{name: "value", output: UserProfile{}, wantStatus: http.StatusOK},
+		{name: "pointer", output: &UserProfile{}, wantStatus: http.StatusOK},
+		{name: "nil pointer", output: (*UserProfile)(nil), wantStatus: http.StatusOK},
+		{name: "nil", output: nil, wantStatus: http.StatusOK},
+		{name: "unexpected type", output: "profile", wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := query.NewInMemoryBus()
+			bus.RegisterHandler(UserGetUserQueryTypeName, query.HandlerFunc(func(ctx context.Context, q query.Query) (any, error) {
+				if q.Payload != (UserGetUserQuery{ID: "u1"}) {
+					t.Errorf("expected the path parameter to be bound to the query, got %v", q.Payload)
+				}
+				return tt.output, nil
+			}))
+			router := chi.NewRouter()
+			userGetProfile(router, bus)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/u1/profile", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+		})
+	}
+}
+`)
+}
+
+func TestGenerateHTTPEndpoint_SuccessResponseTypeImports(t *testing.T) {
+	code := renderGoCodeInContext(t, newTestHTTPEndpointContext(t), generateHTTPEndpoint, &HTTPEndpoint{
+		Nam:     "user.last_login",
+		Method:  "GET",
+		Path:    "/users/{id}/last-login",
+		Desc:    "Returns the date of the last login of a user.",
+		Request: "user.get_user",
+		Responses: HTTPEndpointResponses{
+			Success: HTTPEndpointSuccessResponse{StatusCode: 200, Type: DateTime},
+		},
+		Src: testSource,
+	})
+
+	assert.Contains(t, code, "case time.Time, nil:")
+	assert.Contains(t, code, `"time"`)
+}
+
+func TestGenerateHTTPEndpoint_UnresolvableSuccessResponseType(t *testing.T) {
+	err := generateHTTPEndpoint(newTestHTTPEndpointContext(t), &HTTPEndpoint{
+		Nam:     "user.get_profile",
+		Method:  "GET",
+		Path:    "/users/{id}/profile",
+		Desc:    "Returns the profile of a user.",
+		Request: "user.get_user",
+		Responses: HTTPEndpointResponses{
+			Success: HTTPEndpointSuccessResponse{Type: "user.unknown"},
+		},
+		Src: testSource,
+	})
+
+	assert.Error(t, err)
+}
+
+func TestGenerateStruct_Receiver(t *testing.T) {
+	tests := []struct {
+		name         string
+		meta         Metadata
+		wantReceiver string
+	}{
+		{name: "default receiver", wantReceiver: "u"},
+		{name: "custom receiver", meta: Metadata{{Key: "gen:go:receiver", Value: &hcl.Attribute{Expr: hcl.StaticExpr(cty.StringVal("address"), hcl.Range{})}}}, wantReceiver: "address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := renderGoCodeForSpec(t, generateStruct, &Struct{
+				Nam:  "user.address",
+				Desc: "Address of a user.",
+				Fields: []StructField{
+					{Name: "city", Description: "City of the address.", Type: String, Annotations: Annotations{RequiredAnnotation}},
+				},
+				Meta: tt.meta,
+				Src:  testSource,
+			})
+
+			assert.Contains(t, code, "func ("+tt.wantReceiver+" UserAddress) PayloadTypeName() string {")
+			assert.Contains(t, code, "func ("+tt.wantReceiver+" UserAddress) Validate() error {")
+			assert.Contains(t, code, "if "+tt.wantReceiver+`.City == "" {`)
+		})
+	}
+}
+
+func TestGenerateStruct_InvalidReceiver(t *testing.T) {
+	for _, receiver := range []string{"1address", "user address", "type", "_"} {
+		t.Run(receiver, func(t *testing.T) {
+			err := generateStruct(newTestGoProcessingContext(), &Struct{
+				Nam:  "user.address",
+				Desc: "Address of a user.",
+				Meta: Metadata{{Key: "gen:go:receiver", Value: &hcl.Attribute{Expr: hcl.StaticExpr(cty.StringVal(receiver), hcl.Range{})}}},
+				Src:  testSource,
+			})
+
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "is not a valid receiver name")
+			}
+		})
+	}
+}
+
+func TestGenerateCommand_TypeNameConst(t *testing.T) {
+	typeNameConst := func(name string) Metadata {
+		return Metadata{{Key: "gen:go:typeNameConst", Value: &hcl.Attribute{Expr: hcl.StaticExpr(cty.StringVal(name), hcl.Range{})}}}
+	}
+	tests := []struct {
+		name       string
+		meta       Metadata
+		wantConst  string
+		wantReturn string
+		wantErr    bool
+	}{
+		{
+			name:       "default constant",
+			wantConst:  `const OrderPlaceCommandTypeName command.PayloadTypeName = "order.place"`,
+			wantReturn: "return OrderPlaceCommandTypeName",
+		},
+		{
+			name:       "custom constant",
+			meta:       typeNameConst("OrderPlaceType"),
+			wantConst:  `const OrderPlaceType command.PayloadTypeName = "order.place"`,
+			wantReturn: "return OrderPlaceType",
+		},
+		{
+			name:       "suppressed constant",
+			meta:       typeNameConst("-"),
+			wantReturn: `return "order.place"`,
+		},
+		{
+			name:    "invalid constant name",
+			meta:    typeNameConst("place order"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Command{Nam: "order.place", Desc: "Places an order.", Meta: tt.meta, Src: testSource}
+			if tt.wantErr {
+				assert.Error(t, generateCommand(newTestGoProcessingContext(), cmd))
+				return
+			}
+
+			code := renderGoCodeForSpec(t, generateCommand, cmd)
+			if tt.wantConst != "" {
+				assert.Contains(t, code, tt.wantConst)
+			} else {
+				assert.NotContains(t, code, "const ")
+			}
+			assert.Contains(t, code, tt.wantReturn)
+		})
+	}
+}
+
+// runGeneratedGoTest runs a Go test against the files generated in a context for the package "unit" in a temporary
+// module, in which the packages of this module can be imported. It is skipped when no Go toolchain is available.
+func runGeneratedGoTest(t *testing.T, ctx *GoProcessingContext, testCode string) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no Go toolchain available")
+	}
+
+	moduleRoot, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := os.ReadFile(filepath.Join(moduleRoot, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module github.com/morebec/unit\n\ngo 1.18\n\n" +
+			"require github.com/morebec/misas-go v0.0.0\n\n" +
+			"replace github.com/morebec/misas-go => " + strconv.Quote(moduleRoot) + "\n",
+		"go.sum":       string(goSum),
+		"unit_test.go": "package unit\n\nimport \"testing\"\n\n" + testCode,
+	}
+	for _, f := range ctx.PackageTree.GeneratedFilesRecursive() {
+		rendered, err := RenderGeneratedFile(*f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[filepath.Base(f.Path)] = rendered
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goBin, "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated code test failed: %s\n%s", err, output)
+	}
+}
+
+func TestGenerateStruct_Equal(t *testing.T) {
+	ctx := newTestGoProcessingContext()
+	code := renderGoCodeInContext(t, ctx, generateStruct, &Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Fields: []StructField{
+			{Name: "id", Description: "ID of the user.", Type: Identifier},
+			{Name: "nickname", Description: "Nickname of the user.", Type: String, Nullable: true},
+			{Name: "tags", Description: "Tags of the user.", Type: "[]string"},
+			{Name: "attributes", Description: "Attributes of the user.", Type: "map[string]string"},
+			{Name: "registeredAt", Description: "Date and time at which the user registered.", Type: DateTime},
+		},
+		Src: testSource,
+	})
+
+	assert.Contains(t, code, "func (u UserProfile) Equal(other UserProfile) bool {")
+	assert.Contains(t, code, "if u.ID != other.ID {")
+	assert.Contains(t, code, "if (u.Nickname == nil) != (other.Nickname == nil) || (u.Nickname != nil && (*u.Nickname) != *other.Nickname) {")
+	assert.Contains(t, code, "if !reflect.DeepEqual(u.Tags, other.Tags) {")
+	assert.Contains(t, code, "if !u.RegisteredAt.Equal(other.RegisteredAt) {")
+
+	runGeneratedGoTest(t, ctx, `
+func TestUserProfile_Equal(t *testing.T) {
+	nickname, otherNickname := "jd", "jd"
+	newProfile := func() UserProfile {
+		return UserProfile{
+			ID:         "user-1",
+			Nickname:   &nickname,
+			Tags:       []string{"admin", "beta"},
+			Attributes: map[string]string{"locale": "en"},
+		}
+	}
+
+	same := newProfile()
+	same.Nickname = &otherNickname
+	if !newProfile().Equal(same) {
+		t.Error("profiles with equal slices, maps and pointed values should be equal")
+	}
+
+	differentTags := newProfile()
+	differentTags.Tags = []string{"admin"}
+	differentAttributes := newProfile()
+	differentAttributes.Attributes["locale"] = "fr"
+	noNickname := newProfile()
+	noNickname.Nickname = nil
+	for _, other := range []UserProfile{differentTags, differentAttributes, noNickname} {
+		if newProfile().Equal(other) {
+			t.Errorf("profile should not be equal to %+v", other)
+		}
+	}
+}
+`)
+}
+
+func TestGenerateStruct_EqualNestedStruct(t *testing.T) {
+	address := &Struct{Nam: "user.address", Desc: "Address of a user.", Src: testSource}
+	status := &Enum{Nam: "user.status", Desc: "Status of a user.", BaseType: String, Src: testSource}
+	profile := &Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Fields: []StructField{
+			{Name: "address", Description: "Address of the user.", Type: "user.address"},
+			{Name: "previousAddress", Description: "Previous address of the user.", Type: "user.address", Nullable: true},
+			{Name: "status", Description: "Status of the user.", Type: "user.status"},
+		},
+		Src: testSource,
+	}
+
+	ctx := newTestGoProcessingContext()
+	ctx.ParentContext.DependencyGraph = specter.ResolvedDependencies{address, status, profile}
+	if err := generateStruct(ctx, address); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateEnum(ctx, status); err != nil {
+		t.Fatal(err)
+	}
+	code := renderGoCodeInContext(t, ctx, generateStruct, profile)
+
+	assert.Contains(t, code, "if !u.Address.Equal(other.Address) {")
+	assert.Contains(t, code, "(u.PreviousAddress != nil && !(*u.PreviousAddress).Equal(*other.PreviousAddress))")
+	assert.Contains(t, code, "if u.Status != other.Status {")
+}
+
+func TestGenerateStruct_GoFieldName(t *testing.T) {
+	strct := &Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Fields: []StructField{
+			{Name: "userId", Description: "ID of the user.", Type: Identifier, Annotations: Annotations{GoFieldNameAnnotation + "=LegacyUserID", RequiredAnnotation}},
+			{Name: "username", Description: "Username of the user.", Type: String},
+		},
+		Src: testSource,
+	}
+
+	code := renderGoCodeForSpec(t, generateStruct, strct)
+	assert.Regexp(t, "LegacyUserID\\s+string\\s+`json:\"userId\"`", code)
+	assert.Regexp(t, "Username\\s+string\\s+`json:\"username\"`", code)
+	assert.NotRegexp(t, "\\bUserID\\b", code)
+	assert.Contains(t, code, `if u.LegacyUserID == ""`)
+	assert.Contains(t, code, `"userId":   u.LegacyUserID`)
+}
+
+// decodeIntoGeneratedStruct decodes a JSON body into a struct having the same string fields and tags as a struct of
+// generated code, and returns the value of its fields by Go name.
+func decodeIntoGeneratedStruct(t *testing.T, code string, structName string, body string) map[string]string {
+	file, err := parser.ParseFile(token.NewFileSet(), "generated.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields []reflect.StructField
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != structName {
+			return true
+		}
+		for _, f := range spec.Type.(*ast.StructType).Fields.List {
+			tag, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields = append(fields, reflect.StructField{Name: f.Names[0].Name, Type: reflect.TypeOf(""), Tag: reflect.StructTag(tag)})
+		}
+		return false
+	})
+	if fields == nil {
+		t.Fatalf("struct %s not found in generated code", structName)
+	}
+
+	value := reflect.New(reflect.StructOf(fields))
+	if err := json.Unmarshal([]byte(body), value.Interface()); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := map[string]string{}
+	for _, f := range fields {
+		decoded[f.Name] = value.Elem().FieldByName(f.Name).String()
+	}
+	return decoded
+}
+
+func TestGenerateCommand_Readonly(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateCommand, &Command{
+		Nam:  "user.register",
+		Desc: "Registers a user.",
+		Fields: []CommandField{
+			{Name: "username", Description: "Username of the user.", Type: String},
+			{Name: "registeredBy", Description: "ID of the user performing the registration.", Type: String, Annotations: Annotations{GoReadonlyAnnotation}},
+		},
+		Src: testSource,
+	})
+
+	assert.Regexp(t, "RegisteredBy\\s+string\\s+`json:\"-\"`", code)
+	assert.Regexp(t, "Username\\s+string\\s+`json:\"username\"`", code)
+
+	decoded := decodeIntoGeneratedStruct(t, code, "UserRegisterCommand", `{"username": "jdoe", "registeredBy": "admin"}`)
+	assert.Equal(t, map[string]string{"Username": "jdoe", "RegisteredBy": ""}, decoded)
+}
+
+func TestGenerateQuery_Readonly(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateQuery, &Query{
+		Nam:  "user.by_id",
+		Desc: "Returns a user by its ID.",
+		Fields: []QueryField{
+			{Name: "userId", Description: "ID of the user.", Type: Identifier},
+			{Name: "tenantId", Description: "ID of the tenant of the requester.", Type: Identifier, Annotations: Annotations{GoReadonlyAnnotation}},
+		},
+		Src: testSource,
+	})
+
+	decoded := decodeIntoGeneratedStruct(t, code, "UserByIdQuery", `{"userId": "user-1", "tenantId": "tenant-1"}`)
+	assert.Equal(t, map[string]string{"UserID": "user-1", "TenantID": ""}, decoded)
+}
+
+func TestGenerateStruct_Readonly(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateStruct, &Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Fields: []StructField{
+			{Name: "registeredBy", Description: "ID of the user who performed the registration.", Type: String, Annotations: Annotations{GoReadonlyAnnotation}},
+		},
+		Src: testSource,
+	})
+
+	// Readonly fields remain part of responses.
+	assert.Regexp(t, "RegisteredBy\\s+string\\s+`json:\"registeredBy\"`", code)
+}
+
+func TestGenerateStruct_LogSafe(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateStruct, &Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Fields: []StructField{
+			{Name: "id", Description: "ID of the user.", Type: Identifier},
+			{Name: "emailAddress", Description: "Email address of the user.", Type: String, Annotations: Annotations{"personal_data"}},
+		},
+		Src: testSource,
+	})
+
+	assert.Contains(t, code, "func (u UserProfile) LogSafe() map[string]any {")
+	assert.Contains(t, code, `"id":           u.ID,`)
+	assert.Contains(t, code, `"emailAddress": secret.RedactedValue,`)
+	assert.Contains(t, code, `"github.com/morebec/misas-go/misas/secret"`)
+	assert.NotContains(t, code, `"emailAddress": u.EmailAddress`)
+}
+
+func TestGenerateHTTPEndpoint_LogsSafeValues(t *testing.T) {
+	code := renderGoCodeInContext(t, newTestHTTPEndpointContext(t), generateHTTPEndpoint, &HTTPEndpoint{
+		Nam:     "user.register",
+		Method:  "POST",
+		Path:    "/users",
+		Desc:    "Registers a user.",
+		Request: "user.register_user",
+		Src:     testSource,
+	})
+
+	assert.Contains(t, code, `httpapi.Log(r.Context(), "user.register request received", map[string]any{"request": httpapi.LogSafeValue(input)})`)
+	assert.Contains(t, code, `httpapi.Log(r.Context(), "user.register response sent", map[string]any{"response": httpapi.LogSafeValue(output)})`)
+}
+
+func TestGenerateSystemVersion(t *testing.T) {
+	ctx := newTestGoProcessingContext()
+	code := renderGoCodeInContext(t, ctx, generateSystemVersion, &System{
+		SName:        "unit",
+		SDescription: "System made for unit tests.",
+		Version:      "1.2.3",
+		Src:          testSource,
+	})
+
+	assert.Contains(t, code, `const ModuleVersion = "1.2.3"`)
+	assert.Contains(t, code, "d.Metadata = d.Metadata.Set(ModuleVersionMetadataKey, ModuleVersion)")
+	assert.Contains(t, code, "w.Header().Set(ModuleVersionHeader, ModuleVersion)")
+
+	runGeneratedGoTest(t, ctx, `
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/event/store"
+)
+
+func TestEnrichWithModuleVersion(t *testing.T) {
+	d := EnrichWithModuleVersion(context.Background(), store.EventDescriptor{Metadata: misas.Metadata{"userId": "user-1"}})
+	if got := d.Metadata.Get(ModuleVersionMetadataKey, nil); got != "1.2.3" {
+		t.Errorf("expected module version 1.2.3 in the metadata, got %v", got)
+	}
+	if got := d.Metadata.Get("userId", nil); got != "user-1" {
+		t.Errorf("expected the other metadata to be kept, got userId %v", got)
+	}
+
+	d = EnrichWithModuleVersion(context.Background(), store.EventDescriptor{})
+	if got := d.Metadata.Get(ModuleVersionMetadataKey, nil); got != "1.2.3" {
+		t.Errorf("expected module version 1.2.3 in the metadata of an event without metadata, got %v", got)
+	}
+}
+
+func TestModuleVersionMiddleware(t *testing.T) {
+	called := false
+	handler := ModuleVersionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !called {
+		t.Error("expected the next handler to be called")
+	}
+	if recorder.Code != http.StatusTeapot {
+		t.Errorf("expected the status of the next handler, got %d", recorder.Code)
+	}
+	if got := recorder.Header().Get("X-Module-Version"); got != "1.2.3" {
+		t.Errorf("expected module version 1.2.3 in the headers, got %q", got)
+	}
+}
+`)
+}
+
+func TestGenerateSystemVersion_WithoutVersion(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateSystemVersion, &System{
+		SName:        "unit",
+		SDescription: "System made for unit tests.",
+		Src:          testSource,
+	})
+
+	assert.Empty(t, code)
+}
+
+func TestGenerateStruct_DefaultFields(t *testing.T) {
+	emptyDefault := Metadata{{Key: "gen:go:default", Value: &hcl.Attribute{Expr: hcl.StaticExpr(cty.StringVal(GoEmptyDefault), hcl.Range{})}}}
+
+	tests := []struct {
+		name     string
+		fields   []StructField
+		want     []string
+		wantNone bool
+		wantErr  bool
+	}{
+		{
+			name: "slice and map defaults",
+			fields: []StructField{
+				{Name: "id", Description: "ID of the user.", Type: Identifier},
+				{Name: "roles", Description: "Roles of the user.", Type: "[]string", Meta: emptyDefault},
+				{Name: "settings", Description: "Settings of the user.", Type: "map[string]string", Meta: emptyDefault},
+			},
+			want: []string{
+				"func NewUserProfile() UserProfile {",
+				"Roles:    []string{},",
+				"Settings: map[string]string{},",
+			},
+		},
+		{
+			name: "without defaults",
+			fields: []StructField{
+				{Name: "roles", Description: "Roles of the user.", Type: "[]string"},
+			},
+			wantNone: true,
+		},
+		{
+			name: "default on non container field",
+			fields: []StructField{
+				{Name: "id", Description: "ID of the user.", Type: Identifier, Meta: emptyDefault},
+			},
+			wantErr: true,
+		},
+		{
+			name: "default on nullable field",
+			fields: []StructField{
+				{Name: "roles", Description: "Roles of the user.", Type: "[]string", Nullable: true, Meta: emptyDefault},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strct := &Struct{
+				Nam:    "user.profile",
+				Desc:   "Profile of a user.",
+				Fields: tt.fields,
+				Src:    testSource,
+			}
+
+			if tt.wantErr {
+				assert.Error(t, generateStruct(newTestGoProcessingContext(), strct))
+				return
+			}
+
+			code := renderGoCodeForSpec(t, generateStruct, strct)
+			if tt.wantNone {
+				assert.NotContains(t, code, "func NewUserProfile()")
+			}
+			for _, w := range tt.want {
+				assert.Contains(t, code, w)
+			}
+		})
+	}
+}
+
+func TestCheckOutputFilesUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	upToDatePath := filepath.Join(dir, "up_to_date_generated.go")
+	stalePath := filepath.Join(dir, "stale_generated.go")
+	missingPath := filepath.Join(dir, "missing_generated.go")
+
+	assert.NoError(t, os.WriteFile(upToDatePath, []byte("package unit\n\nconst A = 1\n"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(stalePath, []byte("package unit\n\nconst A = 1\n"), os.ModePerm))
+
+	output := func(path string, data string) specter.ProcessingOutput {
+		return specter.ProcessingOutput{Name: path, Value: specter.FileOutput{Path: path, Data: []byte(data), Mode: os.ModePerm}}
+	}
+
+	t.Run("up-to-date", func(t *testing.T) {
+		err := CheckOutputFilesUpToDate([]specter.ProcessingOutput{
+			output(upToDatePath, "package unit\n\nconst A = 1\n"),
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		err := CheckOutputFilesUpToDate([]specter.ProcessingOutput{
+			output(upToDatePath, "package unit\n\nconst A = 1\n"),
+			output(stalePath, "package unit\n\nconst A = 2\n"),
+			output(missingPath, "package unit\n"),
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), stalePath+": differs at line 3")
+		assert.Contains(t, err.Error(), missingPath+": file does not exist")
+		assert.NotContains(t, err.Error(), upToDatePath)
+
+		// Dry run must not write anything.
+		_, statErr := os.Stat(missingPath)
+		assert.True(t, os.IsNotExist(statErr))
+	})
+}
+
+func TestRenderGeneratedFile_ImportAliases(t *testing.T) {
+	ctx := newTestGoProcessingContext()
+	root := ctx.PackageTree
+	for _, parentName := range []string{"billing", "shipping"} {
+		parent := &GoPackage{Parent: root, Name: parentName, FilePath: "/unit/" + parentName}
+		parent.SubPackages = []*GoPackage{{Parent: parent, Name: "command", FilePath: "/unit/" + parentName + "/command"}}
+		root.SubPackages = append(root.SubPackages, parent)
+	}
+
+	specs := []*Struct{
+		{
+			Nam:    "billing.invoice",
+			Desc:   "Invoice of an order.",
+			Fields: []StructField{{Name: "id", Description: "ID of the invoice.", Type: Identifier}},
+			Src:    specter.Source{Location: "/unit/billing/command/invoice.spec.hcl"},
+		},
+		{
+			Nam:    "shipping.parcel",
+			Desc:   "Parcel of an order.",
+			Fields: []StructField{{Name: "id", Description: "ID of the parcel.", Type: Identifier}},
+			Src:    specter.Source{Location: "/unit/shipping/command/parcel.spec.hcl"},
+		},
+		{
+			Nam:  "order.summary",
+			Desc: "Summary of an order.",
+			Fields: []StructField{
+				{Name: "invoice", Description: "Invoice of the order.", Type: "billing.invoice"},
+				{Name: "parcels", Description: "Parcels of the order.", Type: "[]shipping.parcel"},
+				{Name: "placed_at", Description: "Date at which the order was placed.", Type: DateTime},
+			},
+			Src: testSource,
+		},
+	}
+	for _, s := range specs {
+		assert.NoError(t, generateStruct(ctx, s))
+	}
+
+	file := root.FindGeneratedFileAtPath("/unit/order_generated.go")
+	assert.NotNil(t, file)
+	code, err := RenderGeneratedFile(*file)
+	assert.NoError(t, err)
+
+	assert.Contains(t, code, "\t\"github.com/morebec/unit/billing/command\"\n")
+	assert.Contains(t, code, "\tcommand2 \"github.com/morebec/unit/shipping/command\"\n")
+	assert.Contains(t, code, "\t\"time\"\n")
+	assert.Contains(t, code, "Invoice command.BillingInvoice")
+	assert.Contains(t, code, "Parcels []command2.ShippingParcel")
+	assert.Contains(t, code, "PlacedAt time.Time")
+
+	// Types of the same package are not qualified.
+	invoiceFile := root.FindGeneratedFileAtPath("/unit/billing/command/billing_generated.go")
+	assert.NotNil(t, invoiceFile)
+	invoiceCode, err := RenderGeneratedFile(*invoiceFile)
+	assert.NoError(t, err)
+	assert.NotContains(t, invoiceCode, "github.com/morebec/unit/billing/command")
+}
+
+func TestGenerateStruct_UnresolvableFieldType(t *testing.T) {
+	strct := &Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Fields: []StructField{
+			{Name: "address", Description: "Address of the user.", Type: "user.address"},
+		},
+		Src: testSource,
+	}
+
+	var err error
+	assert.NotPanics(t, func() {
+		err = generateStruct(newTestGoProcessingContext(), strct)
+	})
+	assert.EqualError(t, err, "failed generating code for struct user.profile: failed generating go code: Could not resolve a Go type for \"user.address\"")
+}
+
+func TestGenerateHTTPEndpoint_SendsRequestToBus(t *testing.T) {
+	code := renderGoCodeInContext(t, newTestHTTPEndpointContext(t), generateHTTPEndpoint, &HTTPEndpoint{
+		Nam:     "user.register",
+		Method:  "PUT",
+		Path:    "/users",
+		Desc:    "Registers a user.",
+		Request: "user.register_user",
+		Src:     testSource,
+	})
+
+	assert.Contains(t, code, "func userRegister(r chi.Router, bus command.Bus) {")
+	assert.Contains(t, code, "var input UserRegisterUserCommand")
+	assert.Contains(t, code, "output, err := bus.Send(r.Context(), command.Command{Payload: input})")
+	assert.NotContains(t, code, "event.Bus")
+}
+
+func TestGenerateHTTPEndpoint_Compiles(t *testing.T) {
+	ctx := newTestHTTPEndpointContext(t)
+	code := renderGoCodeInContext(t, ctx, generateHTTPEndpoint, &HTTPEndpoint{
+		Nam:     "user.register",
+		Method:  "POST",
+		Path:    "/users",
+		Desc:    "Registers a user.",
+		Request: "user.register_user",
+		Responses: HTTPEndpointResponses{
+			Success: HTTPEndpointSuccessResponse{StatusCode: 200, Description: "The user was registered."},
+			Failures: []HTTPEndpointFailureResponse{
+				{StatusCode: 409, Description: "The user already exists.", ErrorType: "user.already_exists"},
+			},
+		},
+		Src: testSource,
+	})
+	assert.Contains(t, code, `r.Post("/users",`)
+	assert.NotContains(t, code, "handleError")
+
+	runGeneratedGoTest(t, ctx, `
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/morebec/go-errors/errors"
+	"github.com/morebec/misas-go/misas/command"
+)
+
+func TestUserRegister(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "success", body: "{}", wantStatus: http.StatusOK},
+		{name: "invalid request", body: "{", wantStatus: http.StatusBadRequest},
+		{name: "declared failure", body: "{}", err: errors.New("user.already_exists"), wantStatus: http.StatusConflict},
+		{name: "undeclared failure", body: "{}", err: errors.New("user.unknown"), wantStatus: http.StatusConflict},
+		{name: "internal error", body: "{}", err: errors.New(errors.InternalErrorCode), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := command.NewInMemoryBus()
+			bus.RegisterHandler(UserRegisterUserCommandTypeName, command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+				return nil, tt.err
+			}))
+			router := chi.NewRouter()
+			userRegister(router, bus)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+		})
+	}
+}
+`)
+}
+
+func TestGenerateStruct_RequiredOnUnsupportedType(t *testing.T) {
+	err := generateStruct(newTestGoProcessingContext(), &Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Fields: []StructField{
+			{Name: "active", Description: "Indicates if the user is active.", Type: Bool, Annotations: Annotations{RequiredAnnotation}},
+		},
+		Src: testSource,
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "annotation required is not supported on field \"active\" of type bool")
+}
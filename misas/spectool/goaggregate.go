@@ -0,0 +1,139 @@
+package spectool
+
+import (
+	"github.com/iancoleman/strcase"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// AggregateAnnotation associates an event with the aggregate it is applied to, e.g. "aggregate=account".
+// For every aggregate, an <Aggregate>EventHandlers interface with a method per event is generated, along with an
+// Apply<Aggregate>Event function dispatching the events to it. Since generated files are rewritten on every
+// generation, the interface is meant to be implemented by the aggregate in a file that is not generated.
+const AggregateAnnotation = "aggregate"
+
+// goAggregate represents an aggregate and the events that are applied to it.
+type goAggregate struct {
+	name   string
+	pkg    *GoPackage
+	events []*Event
+}
+
+// generateGoAggregates generates the event handling of every aggregate events are associated with through the AggregateAnnotation.
+// The aggregate is generated in the package of its events, which must all be located in the same package.
+func generateGoAggregates(ctx *GoProcessingContext) error {
+	aggregates := map[string]*goAggregate{}
+	for _, s := range ctx.Specs().SelectType((&Event{}).Type()) {
+		evt := s.(*Event)
+		name, found := evt.Annotations().Value(AggregateAnnotation)
+		if !found {
+			continue
+		}
+		if name == "" {
+			return errors.Errorf("failed generating aggregate for event %s, annotation %s has no value", evt.Name(), AggregateAnnotation)
+		}
+
+		pkg := ctx.PackageTree.FindPackageForPath(evt.Source().Location)
+		if pkg == nil {
+			return errors.Errorf("failed generating aggregate %s for event %s, could not find a suitable package", name, evt.Name())
+		}
+
+		agg, found := aggregates[name]
+		if !found {
+			agg = &goAggregate{name: name, pkg: pkg}
+			aggregates[name] = agg
+		} else if agg.pkg != pkg {
+			return errors.Errorf("failed generating aggregate %s, event %s is not located in the package of the other events of the aggregate", name, evt.Name())
+		}
+		agg.events = append(agg.events, evt)
+	}
+
+	names := make([]string, 0, len(aggregates))
+	for name := range aggregates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := generateGoAggregate(ctx, aggregates[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateGoAggregate generates the interface handling the events of an aggregate, with an On<EventName> method per
+// event, and the function applying the events to it by switching on their type, be they values or pointers.
+func generateGoAggregate(ctx *GoProcessingContext, agg *goAggregate) error {
+	templateCode := `
+// {{ .InterfaceName }} handles the {{ .Name }} events applied to an aggregate.
+// It is meant to be implemented by the aggregate in a file that is not generated.
+type {{ .InterfaceName }} interface {
+	{{ range $event := .Events }}{{ $event.MethodName }}(e {{ $event.StructName }})
+	{{ end }}
+}
+
+// {{ .FuncName }} applies an event to the {{ .InterfaceName }} of an aggregate by dispatching it to the method
+// handling its type. Events that are not {{ .Name }} events are ignored.
+func {{ .FuncName }}(h {{ .InterfaceName }}, e event.Event) {
+	switch p := e.Payload.(type) {
+	{{ range $event := .Events }}case {{ $event.StructName }}:
+		h.{{ $event.MethodName }}(p)
+	case *{{ $event.StructName }}:
+		if p != nil {
+			h.{{ $event.MethodName }}(*p)
+		}
+	{{ end }}}
+}
+`
+
+	type EventData struct {
+		StructName string
+		MethodName string
+	}
+
+	type TemplateData struct {
+		Name          string
+		InterfaceName string
+		FuncName      string
+		Events        []EventData
+	}
+
+	aggregateName := strcase.ToCamel(agg.name)
+	interfaceName := aggregateName + "EventHandlers"
+	templateData := TemplateData{
+		Name:          agg.name,
+		InterfaceName: interfaceName,
+		FuncName:      "Apply" + aggregateName + "Event",
+	}
+	for _, evt := range agg.events {
+		templateData.Events = append(templateData.Events, EventData{
+			StructName: goEventStructName(evt),
+			MethodName: "On" + strcase.ToCamel(string(evt.Name())),
+		})
+	}
+
+	tem := NewGoSnippetGenerationContext(
+		ctx,
+		"aggregate",
+		templateCode,
+		templateData,
+		[]GoType{{TypeName: interfaceName, InternalTypeName: DataType(agg.name + ".aggregate")}},
+		[]string{"github.com/morebec/misas-go/misas/event"},
+	)
+
+	fileName := strcase.ToSnake(agg.name) + "_aggregate_generated.go"
+	if err := GenerateCodeInFile(tem, agg.pkg, fileName); err != nil {
+		return errors.Wrapf(err, "failed generating aggregate %s", agg.name)
+	}
+
+	// The aggregate depends on all of its events.
+	if file := agg.pkg.FindGeneratedFileAtPath(agg.pkg.FilePath + "/" + fileName); file != nil {
+		for _, evt := range agg.events {
+			file.addSpec(evt.Name())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerateGoAggregates(t *testing.T) {
+	opened := &Event{Nam: "account.opened", Desc: "Indicates that an account was opened.", Annots: Annotations{AggregateAnnotation + "=account"}, Src: testSource}
+	closed := &Event{Nam: "account.closed", Desc: "Indicates that an account was closed.", Annots: Annotations{AggregateAnnotation + "=account"}, Src: testSource}
+	audited := &Event{Nam: "audit.logged", Desc: "Indicates that an audit entry was logged.", Src: testSource}
+
+	ctx := newTestGoProcessingContext()
+	ctx.ParentContext.DependencyGraph = specter.ResolvedDependencies{opened, closed, audited}
+	for _, evt := range []*Event{opened, closed, audited} {
+		if err := generateEvent(ctx, evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.NoError(t, generateGoAggregates(ctx))
+
+	file := ctx.PackageTree.FindGeneratedFileAtPath("/unit/account_aggregate_generated.go")
+	if !assert.NotNil(t, file) {
+		return
+	}
+	assert.ElementsMatch(t, []specter.SpecificationName{"account.opened", "account.closed"}, file.Specs)
+
+	code, err := RenderGeneratedFile(*file)
+	assert.NoError(t, err)
+	assert.Contains(t, code, "type AccountEventHandlers interface {")
+	assert.Contains(t, code, "OnAccountOpened(e AccountOpenedEvent)")
+	assert.Contains(t, code, "OnAccountClosed(e AccountClosedEvent)")
+	assert.Contains(t, code, "func ApplyAccountEvent(h AccountEventHandlers, e event.Event) {")
+	assert.Contains(t, code, "case AccountOpenedEvent:\n\t\th.OnAccountOpened(p)")
+	assert.Contains(t, code, "case *AccountOpenedEvent:")
+	assert.Contains(t, code, "case AccountClosedEvent:\n\t\th.OnAccountClosed(p)")
+	assert.Contains(t, code, "case *AccountClosedEvent:")
+	// No state nor behavior is generated, as it would be lost on the next generation.
+	assert.NotContains(t, code, "struct {")
+	assert.NotContains(t, code, "AuditLogged")
+
+	runGeneratedGoTest(t, ctx, `
+import (
+	"github.com/morebec/misas-go/misas/event"
+)
+
+// account implements AccountEventHandlers, as it would in a file that is not generated.
+type account struct {
+	open      bool
+	nbApplied int
+}
+
+func (a *account) OnAccountOpened(e AccountOpenedEvent) {
+	a.open = true
+	a.nbApplied++
+}
+
+func (a *account) OnAccountClosed(e AccountClosedEvent) {
+	a.open = false
+	a.nbApplied++
+}
+
+func TestApplyAccountEvent(t *testing.T) {
+	a := &account{}
+
+	ApplyAccountEvent(a, event.New(AccountOpenedEvent{}))
+	if !a.open || a.nbApplied != 1 {
+		t.Fatal("a value payload should be applied")
+	}
+
+	ApplyAccountEvent(a, event.New(&AccountClosedEvent{}))
+	if a.open || a.nbApplied != 2 {
+		t.Fatal("a pointer payload should be applied")
+	}
+
+	ApplyAccountEvent(a, event.New(AuditLoggedEvent{}))
+	ApplyAccountEvent(a, event.New((*AccountOpenedEvent)(nil)))
+	if a.nbApplied != 2 {
+		t.Fatal("events of other aggregates and nil payloads should be ignored")
+	}
+}
+`)
+}
+
+func TestGenerateGoAggregates_EmptyAggregateName(t *testing.T) {
+	opened := &Event{Nam: "account.opened", Desc: "Indicates that an account was opened.", Annots: Annotations{AggregateAnnotation + "="}, Src: testSource}
+
+	ctx := newTestGoProcessingContext()
+	ctx.ParentContext.DependencyGraph = specter.ResolvedDependencies{opened}
+
+	assert.Error(t, generateGoAggregates(ctx))
+}
@@ -0,0 +1,314 @@
+package spectool
+
+import (
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// goClientFileName is the name of the file in which the client of the HTTP endpoints of a package is generated.
+const goClientFileName = "client_generated.go"
+
+// GoClientGenerator is a specification processor responsible for generating a typed Go client for the HTTP endpoints.
+// A Client type is generated in every package containing HTTP endpoints, with one method per endpoint.
+// It is enabled by setting the "gen:go:client" metadata to true on the system specification.
+type GoClientGenerator struct {
+}
+
+func (g GoClientGenerator) Name() string {
+	return "go-client-generator"
+}
+
+func (g GoClientGenerator) Process(ctx specter.ProcessingContext) ([]specter.ProcessingOutput, error) {
+	// System specification
+	candidates := specter.SpecificationGroup(ctx.DependencyGraph).SelectType((&System{}).Type())
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	systemSpec := candidates[0].(*System)
+	if !systemSpec.Metadata().GetOrDefault("gen:go:client", false).True() {
+		return nil, nil
+	}
+
+	gCtx, err := NewGoProcessingContext(ctx, systemSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	// The types are generated so that the client methods can resolve them, but only the client files are output.
+	processingHandlers := map[specter.SpecificationType]func(ctx *GoProcessingContext, s MisasSpecification) error{
+		(&Command{}).Type():      generateCommand,
+		(&Query{}).Type():        generateQuery,
+		(&Event{}).Type():        generateEvent,
+		(&Struct{}).Type():       generateStruct,
+		(&Enum{}).Type():         generateEnum,
+		(&HTTPEndpoint{}).Type(): generateHTTPClientMethod,
+	}
+
+	if err := generateGoCodeForSpecs(gCtx, processingHandlers); err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.Info("Generating Go client ...")
+	var clientFiles []*GeneratedGoFile
+	for _, f := range gCtx.PackageTree.GeneratedFilesRecursive() {
+		if filepath.Base(f.Path) == goClientFileName {
+			clientFiles = append(clientFiles, f)
+		}
+	}
+
+	outputFiles, err := renderGoOutputFiles(clientFiles)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Logger.Info("Go client generated successfully.")
+
+	return outputFiles, nil
+}
+
+// generates the Client type shared by the methods of the HTTP endpoints of a package.
+func generateHTTPClient(ctx *GoProcessingContext, pkg *GoPackage) error {
+	templateCode := `
+// Client is an HTTP client for the endpoints of the {{ .PackageName }} package.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// Logger is an optional hook logging requests and responses. Personal data is redacted from what is logged.
+	Logger httpapi.Logger
+}
+
+// NewClient creates a new Client sending its requests to a base URL.
+// If httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: httpClient}
+}
+
+// ClientError is returned by a Client when an endpoint responds with a failure.
+type ClientError struct {
+	StatusCode int
+	Type       string
+	Message    string
+	Data       any
+}
+
+func (e ClientError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+// send sends a request to an endpoint and decodes the data of its success response into output.
+// The input is sent as the JSON body of the request, or in its query string for methods without a body such as GET.
+func (c *Client) send(ctx context.Context, method string, path string, input any, output any) error {
+	target := c.BaseURL + path
+	var body io.Reader
+	if method == http.MethodGet || method == http.MethodHead {
+		query, err := httpapi.MarshalQuery(input)
+		if err != nil {
+			return fmt.Errorf("failed encoding request to %s %s: %w", method, path, err)
+		}
+		if len(query) != 0 {
+			target += "?" + query.Encode()
+		}
+	} else {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return fmt.Errorf("failed encoding request to %s %s: %w", method, path, err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed creating request to %s %s: %w", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.Logger != nil {
+		c.Logger(ctx, "sending request", map[string]any{"method": method, "path": path, "request": httpapi.LogSafeValue(input)})
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed sending request to %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Status httpapi.ResponseStatus ` + "`json:\"status\"`" + `
+		Data   json.RawMessage        ` + "`json:\"data\"`" + `
+		Error  *httpapi.Error         ` + "`json:\"error\"`" + `
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed decoding response of %s %s: %w", method, path, err)
+	}
+
+	if response.Error != nil {
+		return ClientError{StatusCode: resp.StatusCode, Type: response.Error.Type, Message: response.Error.Message, Data: response.Error.Data}
+	}
+
+	if resp.StatusCode >= 400 {
+		return ClientError{StatusCode: resp.StatusCode, Type: "http.error", Message: resp.Status}
+	}
+
+	if len(response.Data) == 0 || output == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(response.Data, output); err != nil {
+		return fmt.Errorf("failed decoding response of %s %s: %w", method, path, err)
+	}
+
+	if c.Logger != nil {
+		c.Logger(ctx, "response received", map[string]any{"method": method, "path": path, "response": httpapi.LogSafeValue(output)})
+	}
+
+	return nil
+}
+`
+	type TemplateData struct {
+		PackageName string
+	}
+
+	tem := NewGoSnippetGenerationContext(
+		ctx,
+		"client",
+		templateCode,
+		TemplateData{PackageName: pkg.Name},
+		[]GoType{
+			NewGoType("Client", "", ""),
+			NewGoType("ClientError", "", ""),
+		},
+		[]string{
+			"bytes",
+			"context",
+			"encoding/json",
+			"fmt",
+			"io",
+			"net/http",
+			"strings",
+
+			"github.com/morebec/misas-go/misas/httpapi",
+		},
+	)
+
+	return GenerateCodeInFile(tem, pkg, goClientFileName)
+}
+
+// generates the method of the Client of a package for an HTTP Endpoint.
+func generateHTTPClientMethod(ctx *GoProcessingContext, s MisasSpecification) error {
+	endpoint := s.(*HTTPEndpoint)
+
+	if _, found := chiRouterMethods[strings.ToUpper(endpoint.Method)]; !found {
+		return errors.Errorf("failed generating client for %s %s, unsupported HTTP method \"%s\"", endpoint.Type(), endpoint.Name(), endpoint.Method)
+	}
+
+	pkg := ctx.PackageTree.FindPackageForPath(endpoint.Source().Location)
+	if pkg == nil {
+		return errors.Errorf("failed generating client for %s %s, could not find a suitable package", endpoint.Type(), endpoint.Name())
+	}
+
+	if pkg.FindGeneratedFileAtPath(pkg.FilePath+"/"+goClientFileName) == nil {
+		if err := generateHTTPClient(ctx, pkg); err != nil {
+			return err
+		}
+	}
+
+	templateCode := `{{ $response := "any" }}{{ if .Response }}{{ $response = .Response | AsResolvedGoType }}{{ end }}
+// {{ .MethodName }} {{ .Description }}
+func (c *Client) {{ .MethodName }}(ctx context.Context, {{ range .PathParams }}{{ . }} string, {{ end }}request {{ .Request | AsResolvedGoType }}) ({{ $response }}, error) {
+	var response {{ $response }}
+	err := c.send(ctx, "{{ .Method }}", {{ .PathExpression }}, request, &response)
+	return response, err
+}
+`
+	type TemplateData struct {
+		MethodName     string
+		Description    string
+		Method         string
+		PathParams     []string
+		PathExpression string
+		Request        DataType
+		Response       DataType
+	}
+
+	pathParams, pathExpression, err := goClientPathExpression(endpoint.Path)
+	if err != nil {
+		return errors.Wrapf(err, "failed generating client for %s %s", endpoint.Type(), endpoint.Name())
+	}
+
+	templateData := TemplateData{
+		MethodName:     endpoint.Metadata().GetOrDefault("gen:go:clientMethodName", strcase.ToCamel(string(endpoint.Name()))).AsString(),
+		Description:    FormatGoCommentText(endpoint.Description()),
+		Method:         strings.ToUpper(endpoint.Method),
+		PathParams:     pathParams,
+		PathExpression: pathExpression,
+		Request:        endpoint.Request,
+		Response:       endpoint.Responses.Success.Type,
+	}
+
+	staticImports := []string{"context"}
+	if len(pathParams) != 0 {
+		staticImports = append(staticImports, "net/url")
+	}
+
+	tem := NewGoSnippetGenerationContext(ctx, "client method", templateCode, templateData, nil, staticImports)
+
+	return GenerateCodeInFile(tem, pkg, goClientFileName)
+}
+
+// goClientReservedIdentifiers are the identifiers used by the generated client methods that path parameters cannot shadow.
+var goClientReservedIdentifiers = map[string]struct{}{
+	"c":        {},
+	"ctx":      {},
+	"request":  {},
+	"response": {},
+	"err":      {},
+	"url":      {},
+}
+
+// goClientPathExpression returns the names of the parameters of a chi route path and a go expression
+// building the path from these parameters, e.g. /users/{id} -> "/users/" + url.PathEscape(id).
+// It returns an error if a parameter does not produce a valid Go identifier, collides with another parameter
+// or with an identifier of the generated method.
+func goClientPathExpression(path string) ([]string, string, error) {
+	var params []string
+	var parts []string
+	seen := map[string]string{}
+
+	last := 0
+	for _, match := range chiPathParamRegex.FindAllStringSubmatchIndex(path, -1) {
+		if match[0] > last {
+			parts = append(parts, fmt.Sprintf("%q", path[last:match[0]]))
+		}
+		name := path[match[2]:match[3]]
+		param := strcase.ToLowerCamel(name)
+		if !token.IsIdentifier(param) {
+			return nil, "", errors.Errorf("path parameter \"%s\" does not produce a valid Go identifier", name)
+		}
+		if _, reserved := goClientReservedIdentifiers[param]; reserved {
+			return nil, "", errors.Errorf("path parameter \"%s\" collides with the identifier \"%s\" of the generated client method", name, param)
+		}
+		if other, found := seen[param]; found {
+			return nil, "", errors.Errorf("path parameters \"%s\" and \"%s\" collide as \"%s\"", other, name, param)
+		}
+		seen[param] = name
+		params = append(params, param)
+		parts = append(parts, fmt.Sprintf("url.PathEscape(%s)", param))
+		last = match[1]
+	}
+	if last < len(path) || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%q", path[last:]))
+	}
+
+	return params, strings.Join(parts, " + "), nil
+}
@@ -0,0 +1,228 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerateHTTPClientMethod(t *testing.T) {
+	ctx := newTestGoProcessingContext()
+
+	specs := []struct {
+		generate func(ctx *GoProcessingContext, s MisasSpecification) error
+		spec     MisasSpecification
+	}{
+		{generateStruct, &Struct{Nam: "user.profile", Desc: "Profile of a user.", Src: testSource}},
+		{generateCommand, &Command{Nam: "user.register", Desc: "Registers a user.", Src: testSource}},
+		{generateQuery, &Query{Nam: "user.profile_by_id", Desc: "Returns the profile of a user.", Src: testSource}},
+		{generateHTTPClientMethod, &HTTPEndpoint{
+			Nam:     "user.get_profile",
+			Method:  "GET",
+			Path:    "/users/{user_id}/profile/{section:[a-z]+}",
+			Desc:    "Returns the profile of a user.",
+			Request: "user.profile_by_id",
+			Responses: HTTPEndpointResponses{
+				Success: HTTPEndpointSuccessResponse{StatusCode: 200, Type: "user.profile"},
+			},
+			Src: testSource,
+		}},
+		{generateHTTPClientMethod, &HTTPEndpoint{
+			Nam:     "user.register",
+			Method:  "post",
+			Path:    "/users",
+			Desc:    "Registers a user.",
+			Request: "user.register",
+			Src:     testSource,
+		}},
+	}
+	for _, s := range specs {
+		assert.NoError(t, s.generate(ctx, s.spec))
+	}
+
+	file := ctx.PackageTree.FindGeneratedFileAtPath("/unit/" + goClientFileName)
+	if !assert.NotNil(t, file) {
+		return
+	}
+
+	code, err := RenderGeneratedFile(*file)
+	assert.NoError(t, err)
+
+	assert.Contains(t, code, "type Client struct {")
+	assert.Contains(t, code, "func NewClient(baseURL string, httpClient *http.Client) *Client {")
+
+	assert.Contains(t, code, "func (c *Client) UserGetProfile(ctx context.Context, userId string, section string, request UserProfileByIdQuery) (UserProfile, error) {")
+	assert.Contains(t, code, `err := c.send(ctx, "GET", "/users/"+url.PathEscape(userId)+"/profile/"+url.PathEscape(section), request, &response)`)
+
+	assert.Contains(t, code, "func (c *Client) UserRegister(ctx context.Context, request UserRegisterCommand) (any, error) {")
+	assert.Contains(t, code, `err := c.send(ctx, "POST", "/users", request, &response)`)
+
+	assert.Contains(t, code, `"net/url"`)
+	assert.Contains(t, code, `"github.com/morebec/misas-go/misas/httpapi"`)
+}
+
+func TestGenerateHTTPClientMethod_UnsupportedMethod(t *testing.T) {
+	err := generateHTTPClientMethod(newTestGoProcessingContext(), &HTTPEndpoint{
+		Nam:     "user.register",
+		Method:  "CONNECT",
+		Path:    "/users",
+		Desc:    "Registers a user.",
+		Request: String,
+		Src:     testSource,
+	})
+
+	assert.Error(t, err)
+}
+
+func TestGenerateHTTPClientMethod_CollidingPathParams(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "request argument", path: "/users/{request}"},
+		{name: "context argument", path: "/users/{ctx}"},
+		{name: "imported package", path: "/users/{url}"},
+		{name: "other path parameter", path: "/users/{user_id}/{userId}"},
+		{name: "go keyword", path: "/users/{type}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := generateHTTPClientMethod(newTestGoProcessingContext(), &HTTPEndpoint{
+				Nam:     "user.get",
+				Method:  "GET",
+				Path:    tt.path,
+				Desc:    "Returns a user.",
+				Request: String,
+				Src:     testSource,
+			})
+
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "path parameter")
+			}
+		})
+	}
+}
+
+func TestGenerateHTTPClientMethod_AgreesWithEndpoint(t *testing.T) {
+	profileByID := &Query{Nam: "user.profile_by_id", Desc: "Returns the profile of a user.", Src: testSource, Fields: []QueryField{
+		{Name: "user_id", Description: "ID of the user.", Type: Identifier},
+		{Name: "section", Description: "Section of the profile.", Type: String},
+		{Name: "limit", Description: "Maximum number of entries.", Type: Int},
+		{Name: "since", Description: "Date from which entries are returned.", Type: DateTime, Nullable: true},
+	}}
+	rename := &Command{Nam: "user.rename", Desc: "Renames a user.", Src: testSource, Fields: []CommandField{
+		{Name: "id", Description: "ID of the user.", Type: Identifier},
+		{Name: "name", Description: "New name of the user.", Type: String},
+	}}
+	getProfile := &HTTPEndpoint{
+		Nam:     "user.get_profile",
+		Method:  "GET",
+		Path:    "/users/{user_id}/profile/{section:[a-z]+}",
+		Desc:    "Returns the profile of a user.",
+		Request: "user.profile_by_id",
+		Responses: HTTPEndpointResponses{
+			Success: HTTPEndpointSuccessResponse{StatusCode: 200, Type: "user.profile_by_id"},
+		},
+		Src: testSource,
+	}
+	renameUser := &HTTPEndpoint{
+		Nam:     "user.rename",
+		Method:  "POST",
+		Path:    "/users/{id}/name",
+		Desc:    "Renames a user.",
+		Request: "user.rename",
+		Responses: HTTPEndpointResponses{
+			Success: HTTPEndpointSuccessResponse{StatusCode: 200, Type: "user.rename"},
+		},
+		Src: testSource,
+	}
+
+	ctx := newTestGoProcessingContext()
+	ctx.ParentContext.DependencyGraph = specter.ResolvedDependencies{profileByID, rename, getProfile, renameUser}
+	assert.NoError(t, generateQuery(ctx, profileByID))
+	assert.NoError(t, generateCommand(ctx, rename))
+	for _, endpoint := range []*HTTPEndpoint{getProfile, renameUser} {
+		assert.NoError(t, generateHTTPEndpoint(ctx, endpoint))
+		assert.NoError(t, generateHTTPClientMethod(ctx, endpoint))
+	}
+
+	// The handlers echo the request they receive.
+	runGeneratedGoTest(t, ctx, `
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/morebec/misas-go/misas/command"
+	"github.com/morebec/misas-go/misas/query"
+)
+
+func TestClient(t *testing.T) {
+	queryBus := query.NewInMemoryBus()
+	queryBus.RegisterHandler(UserProfileByIdQueryTypeName, query.HandlerFunc(func(ctx context.Context, q query.Query) (any, error) {
+		return q.Payload, nil
+	}))
+	commandBus := command.NewInMemoryBus()
+	commandBus.RegisterHandler(UserRenameCommandTypeName, command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+		return c.Payload, nil
+	}))
+	router := chi.NewRouter()
+	userGetProfile(router, queryBus)
+	userRename(router, commandBus)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	since := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	profile, err := client.UserGetProfile(context.Background(), "u 1", "main", UserProfileByIdQuery{Limit: 10, Since: &since})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (UserProfileByIdQuery{UserID: "u 1", Section: "main", Limit: 10, Since: &since}); !reflect.DeepEqual(expected, profile) {
+		t.Fatalf("expected %v, got %v", expected, profile)
+	}
+
+	renamed, err := client.UserRename(context.Background(), "u1", UserRenameCommand{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (UserRenameCommand{ID: "u1", Name: "alice"}); expected != renamed {
+		t.Fatalf("expected %v, got %v", expected, renamed)
+	}
+}
+`)
+}
+
+func TestGenerateHTTPEndpoint_UnboundPathParam(t *testing.T) {
+	tests := []struct {
+		name  string
+		field QueryField
+	}{
+		{name: "missing field", field: QueryField{Name: "user_id", Type: Identifier}},
+		{name: "non string field", field: QueryField{Name: "id", Type: Int}},
+		{name: "nullable field", field: QueryField{Name: "id", Type: Identifier, Nullable: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getUser := &Query{Nam: "user.get_user", Desc: "Returns a user.", Src: testSource, Fields: []QueryField{tt.field}}
+			ctx := newTestGoProcessingContext()
+			ctx.ParentContext.DependencyGraph = specter.ResolvedDependencies{getUser}
+			assert.NoError(t, generateQuery(ctx, getUser))
+
+			err := generateHTTPEndpoint(ctx, &HTTPEndpoint{
+				Nam:     "user.get",
+				Method:  "GET",
+				Path:    "/users/{id}",
+				Desc:    "Returns a user.",
+				Request: "user.get_user",
+				Src:     testSource,
+			})
+
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), `path parameter "id"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package spectool
+
+import (
+	"github.com/pkg/errors"
+)
+
+// IdempotentAnnotation marks a command as being delivered at least once, e.g. "gen:idempotent" or "gen:idempotent=requestId".
+// A handler decorator executing the command at most once per idempotency key is generated along with the command,
+// unless its handling fails, in which case the key is released so that the command can be retried.
+// The key is taken from the given field of the command, which must be a non-nullable string or identifier, or from
+// the command.IdempotencyKeyMetadataKey of the metadata of the command when no field is given.
+const IdempotentAnnotation = "gen:idempotent"
+
+// goIdempotentHandler represents the handler decorator generated for a command annotated with IdempotentAnnotation.
+type goIdempotentHandler struct {
+	StructName string
+
+	// KeyFieldName is the name of the Go field holding the idempotency key, empty if the key is taken from the metadata.
+	KeyFieldName string
+}
+
+// goIdempotentHandlerTemplate is the template of the handler decorator of an idempotent command.
+// It expects the template data to have a StructName field and an IdempotentHandler field.
+const goIdempotentHandlerTemplate = `
+{{ with .IdempotentHandler }}
+// {{ .StructName }} decorates a command.Handler of {{ $.StructName }} so that it is executed at most once per idempotency key.
+// The key is reserved in the command.IdempotencyStore before the command is handled, so that duplicates, including the
+// ones delivered concurrently, are skipped. Should the handling fail, the key is released so that the command can be retried.
+type {{ .StructName }} struct {
+	store   command.IdempotencyStore
+	handler command.Handler
+}
+
+// New{{ .StructName }} returns a new {{ .StructName }}.
+func New{{ .StructName }}(store command.IdempotencyStore, handler command.Handler) *{{ .StructName }} {
+	return &{{ .StructName }}{store: store, handler: handler}
+}
+
+func (h *{{ .StructName }}) Handle(ctx context.Context, c command.Command) (any, error) {
+	{{ if .KeyFieldName }}var key string
+	switch p := c.Payload.(type) {
+	case {{ $.StructName }}:
+		key = string(p.{{ .KeyFieldName }})
+	case *{{ $.StructName }}:
+		key = string(p.{{ .KeyFieldName }})
+	}{{ else }}key, _ := c.Metadata.Get(command.IdempotencyKeyMetadataKey, nil).(string){{ end }}
+	if key == "" {
+		return nil, fmt.Errorf("command %s has no idempotency key", c.ID)
+	}
+
+	recorded, err := h.store.TryRecord(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed recording idempotency key of command %s: %w", c.ID, err)
+	}
+	if !recorded {
+		return nil, nil
+	}
+
+	// The key is released when the handler panics, so that a recovered panic does not prevent retries.
+	defer func() {
+		if r := recover(); r != nil {
+			_ = h.store.Release(ctx, key)
+			panic(r)
+		}
+	}()
+
+	response, err := h.handler.Handle(ctx, c)
+	if err != nil {
+		if releaseErr := h.store.Release(ctx, key); releaseErr != nil {
+			return nil, fmt.Errorf("%w (failed releasing idempotency key of command %s: %v)", err, c.ID, releaseErr)
+		}
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Ensures {{ .StructName }} satisfies the command.Handler interface at compile time.
+var _ command.Handler = (*{{ .StructName }})(nil)
+{{ end }}
+`
+
+// goCommandIdempotentHandler returns the handler decorator to generate for a command, or nil if it is not annotated
+// with IdempotentAnnotation, along with the imports it requires.
+func goCommandIdempotentHandler(cmd *Command, structName string) (*goIdempotentHandler, []string, error) {
+	fieldName, found := cmd.Annotations().Value(IdempotentAnnotation)
+	if !found && !cmd.Annotations().Has(IdempotentAnnotation) {
+		return nil, nil, nil
+	}
+
+	handler := &goIdempotentHandler{StructName: "Idempotent" + structName + "Handler"}
+	if fieldName != "" {
+		field, err := idempotencyKeyField(cmd, fieldName)
+		if err != nil {
+			return nil, nil, err
+		}
+		handler.KeyFieldName = GoFieldName(field.Name, field.Annotations)
+	}
+
+	return handler, []string{"context", "fmt"}, nil
+}
+
+// idempotencyKeyField returns the field of a command holding its idempotency key.
+func idempotencyKeyField(cmd *Command, fieldName string) (CommandField, error) {
+	for _, f := range cmd.Fields {
+		if f.Name != fieldName {
+			continue
+		}
+		if f.Nullable || (f.Type != String && f.Type != Identifier) {
+			return CommandField{}, errors.Errorf(
+				"failed generating idempotent handler of command %s, idempotency key field %s must be a non-nullable string or identifier",
+				cmd.Name(),
+				fieldName,
+			)
+		}
+		return f, nil
+	}
+
+	return CommandField{}, errors.Errorf("failed generating idempotent handler of command %s, field %s not found", cmd.Name(), fieldName)
+}
@@ -0,0 +1,224 @@
+package spectool
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerateCommand_Idempotent(t *testing.T) {
+	ctx := newTestGoProcessingContext()
+	code := renderGoCodeInContext(t, ctx, generateCommand, &Command{
+		Nam:  "payment.capture",
+		Desc: "Captures a payment.",
+		Fields: []CommandField{
+			{Name: "paymentId", Description: "ID of the payment.", Type: Identifier},
+			{Name: "requestId", Description: "ID of the request.", Type: String},
+		},
+		Annots: Annotations{IdempotentAnnotation + "=requestId"},
+		Src:    testSource,
+	})
+
+	assert.Contains(t, code, "type IdempotentPaymentCaptureCommandHandler struct {")
+	assert.Contains(t, code, "func NewIdempotentPaymentCaptureCommandHandler(store command.IdempotencyStore, handler command.Handler) *IdempotentPaymentCaptureCommandHandler {")
+	assert.Contains(t, code, "key = string(p.RequestID)")
+
+	runGeneratedGoTest(t, ctx, `
+import (
+	"context"
+	"github.com/morebec/misas-go/misas/command"
+)
+
+func TestIdempotentPaymentCaptureCommandHandler(t *testing.T) {
+	nbHandled := 0
+	h := NewIdempotentPaymentCaptureCommandHandler(command.NewInMemoryIdempotencyStore(), command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+		nbHandled++
+		return "captured", nil
+	}))
+
+	handle := func(requestID string) any {
+		response, err := h.Handle(context.Background(), command.New(PaymentCaptureCommand{PaymentID: "payment-1", RequestID: requestID}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	if response := handle("request-1"); response != "captured" || nbHandled != 1 {
+		t.Fatalf("a fresh key should be handled, got %v after %d handlings", response, nbHandled)
+	}
+	if response := handle("request-1"); response != nil || nbHandled != 1 {
+		t.Fatalf("a duplicate key should be skipped, got %v after %d handlings", response, nbHandled)
+	}
+	if handle("request-2"); nbHandled != 2 {
+		t.Fatalf("another fresh key should be handled, got %d handlings", nbHandled)
+	}
+	if _, err := h.Handle(context.Background(), command.New(&PaymentCaptureCommand{PaymentID: "payment-1"})); err == nil {
+		t.Fatal("a command without idempotency key should fail")
+	}
+}
+`)
+}
+
+func TestGenerateCommand_IdempotentConcurrentDuplicates(t *testing.T) {
+	ctx := newTestGoProcessingContext()
+	_ = renderGoCodeInContext(t, ctx, generateCommand, &Command{
+		Nam:  "payment.capture",
+		Desc: "Captures a payment.",
+		Fields: []CommandField{
+			{Name: "paymentId", Description: "ID of the payment.", Type: Identifier},
+			{Name: "requestId", Description: "ID of the request.", Type: String},
+		},
+		Annots: Annotations{IdempotentAnnotation + "=requestId"},
+		Src:    testSource,
+	})
+
+	runGeneratedGoTest(t, ctx, `
+import (
+	"context"
+	"errors"
+	"github.com/morebec/misas-go/misas/command"
+	"sync"
+	"sync/atomic"
+)
+
+func TestIdempotentPaymentCaptureCommandHandler_ConcurrentDuplicates(t *testing.T) {
+	var nbHandled int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := NewIdempotentPaymentCaptureCommandHandler(command.NewInMemoryIdempotencyStore(), command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+		// Only the first delivery blocks, so that the duplicates are handled while it is still in progress.
+		if atomic.AddInt32(&nbHandled, 1) == 1 {
+			close(started)
+			<-release
+		}
+		return nil, nil
+	}))
+
+	handle := func() {
+		if _, err := h.Handle(context.Background(), command.New(PaymentCaptureCommand{PaymentID: "payment-1", RequestID: "request-1"})); err != nil {
+			t.Error(err)
+		}
+	}
+
+	var first sync.WaitGroup
+	first.Add(1)
+	go func() {
+		defer first.Done()
+		handle()
+	}()
+	<-started
+
+	var duplicates sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		duplicates.Add(1)
+		go func() {
+			defer duplicates.Done()
+			handle()
+		}()
+	}
+	duplicates.Wait()
+	close(release)
+	first.Wait()
+
+	if nbHandled != 1 {
+		t.Fatalf("concurrent duplicates should be handled once, got %d handlings", nbHandled)
+	}
+}
+
+func TestIdempotentPaymentCaptureCommandHandler_ReleasesKeyOnFailure(t *testing.T) {
+	nbHandled := 0
+	h := NewIdempotentPaymentCaptureCommandHandler(command.NewInMemoryIdempotencyStore(), command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+		nbHandled++
+		if nbHandled == 1 {
+			return nil, errors.New("capture failed")
+		}
+		return nil, nil
+	}))
+
+	c := command.New(PaymentCaptureCommand{PaymentID: "payment-1", RequestID: "request-1"})
+	if _, err := h.Handle(context.Background(), c); err == nil {
+		t.Fatal("the failure of the handler should be returned")
+	}
+	if _, err := h.Handle(context.Background(), c); err != nil || nbHandled != 2 {
+		t.Fatalf("a failed command should be retried, got %v after %d handlings", err, nbHandled)
+	}
+	if _, err := h.Handle(context.Background(), c); err != nil || nbHandled != 2 {
+		t.Fatalf("a command handled successfully should be skipped, got %v after %d handlings", err, nbHandled)
+	}
+}
+`)
+}
+
+func TestGenerateCommand_IdempotentMetadataKey(t *testing.T) {
+	ctx := newTestGoProcessingContext()
+	code := renderGoCodeInContext(t, ctx, generateCommand, &Command{
+		Nam:    "payment.capture",
+		Desc:   "Captures a payment.",
+		Fields: []CommandField{{Name: "paymentId", Description: "ID of the payment.", Type: Identifier}},
+		Annots: Annotations{IdempotentAnnotation},
+		Src:    testSource,
+	})
+
+	assert.Contains(t, code, "key, _ := c.Metadata.Get(command.IdempotencyKeyMetadataKey, nil).(string)")
+
+	runGeneratedGoTest(t, ctx, `
+import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/command"
+)
+
+func TestIdempotentPaymentCaptureCommandHandler(t *testing.T) {
+	nbHandled := 0
+	h := NewIdempotentPaymentCaptureCommandHandler(command.NewInMemoryIdempotencyStore(), command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+		nbHandled++
+		return nil, nil
+	}))
+
+	handle := func(key string) {
+		c := command.NewWithMetadata(PaymentCaptureCommand{PaymentID: "payment-1"}, misas.Metadata{command.IdempotencyKeyMetadataKey: key})
+		if _, err := h.Handle(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handle("key-1")
+	handle("key-1")
+	if nbHandled != 1 {
+		t.Fatalf("a duplicate key should be skipped, got %d handlings", nbHandled)
+	}
+	handle("key-2")
+	if nbHandled != 2 {
+		t.Fatalf("a fresh key should be handled, got %d handlings", nbHandled)
+	}
+}
+`)
+}
+
+func TestGenerateCommand_NotIdempotent(t *testing.T) {
+	code := renderGoCodeForSpec(t, generateCommand, &Command{Nam: "payment.capture", Desc: "Captures a payment.", Src: testSource})
+	assert.NotContains(t, code, "Idempotent")
+}
+
+func TestGenerateCommand_IdempotentInvalidKeyField(t *testing.T) {
+	tests := []struct {
+		name  string
+		field CommandField
+	}{
+		{name: "missing field", field: CommandField{Name: "paymentId", Type: Identifier}},
+		{name: "nullable field", field: CommandField{Name: "requestId", Type: String, Nullable: true}},
+		{name: "non string field", field: CommandField{Name: "requestId", Type: Int}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := generateCommand(newTestGoProcessingContext(), &Command{
+				Nam:    "payment.capture",
+				Desc:   "Captures a payment.",
+				Fields: []CommandField{tt.field},
+				Annots: Annotations{IdempotentAnnotation + "=requestId"},
+				Src:    testSource,
+			})
+			assert.Error(t, err)
+		})
+	}
+}
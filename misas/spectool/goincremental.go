@@ -0,0 +1,179 @@
+package spectool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+	"os"
+	"sort"
+)
+
+// GoGenerationManifestFileName is the name of the manifest file, located next to the go.mod file, in which
+// the hashes of the inputs of the generated files are persisted between runs of an incremental generation.
+const GoGenerationManifestFileName = ".spectool.go.json"
+
+// SpecificationInputHashes returns, for every specification, a hash of its source and of the hashes of its dependencies.
+// A specification whose hash did not change since a previous run generates the same code.
+// Note that a change in the templates of the generator is not reflected by these hashes: deleting the manifest
+// forces a full generation.
+func SpecificationInputHashes(specs []specter.Specification) map[specter.SpecificationName]string {
+	specsByName := map[specter.SpecificationName]specter.Specification{}
+	for _, s := range specs {
+		specsByName[s.Name()] = s
+	}
+
+	hashes := map[specter.SpecificationName]string{}
+	var hashSpec func(s specter.Specification) string
+	hashSpec = func(s specter.Specification) string {
+		if h, found := hashes[s.Name()]; found {
+			return h
+		}
+		// Guards against cycles, which are reported before generation.
+		hashes[s.Name()] = ""
+
+		hash := sha256.New()
+		hash.Write([]byte(s.Type()))
+		hash.Write([]byte(s.Name()))
+		hash.Write([]byte(s.Source().Location))
+		hash.Write(s.Source().Data)
+
+		deps := s.Dependencies()
+		sort.Slice(deps, func(i, j int) bool { return deps[i] < deps[j] })
+		for _, d := range deps {
+			if dep, found := specsByName[d]; found {
+				hash.Write([]byte(hashSpec(dep)))
+			}
+		}
+
+		h := hex.EncodeToString(hash.Sum(nil))
+		hashes[s.Name()] = h
+		return h
+	}
+
+	for _, s := range specs {
+		hashSpec(s)
+	}
+
+	return hashes
+}
+
+// InputHash returns a hash of the inputs of this file, that is its path and the input hashes of the specifications
+// that generated code in it, as returned by SpecificationInputHashes.
+func (f *GeneratedGoFile) InputHash(specHashes map[specter.SpecificationName]string) string {
+	hash := sha256.New()
+	hash.Write([]byte(f.Path))
+
+	specs := append([]specter.SpecificationName{}, f.Specs...)
+	sort.Slice(specs, func(i, j int) bool { return specs[i] < specs[j] })
+	for _, s := range specs {
+		hash.Write([]byte(s))
+		hash.Write([]byte(specHashes[s]))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// GoGenerationManifest tracks the input hashes of the files generated by the last run of an incremental generation.
+type GoGenerationManifest struct {
+	FilePath string            `json:"-"`
+	Hashes   map[string]string `json:"hashes"`
+}
+
+// LoadGoGenerationManifest loads the manifest at a given path. If the file does not exist, an empty manifest is returned.
+func LoadGoGenerationManifest(path string) (*GoGenerationManifest, error) {
+	manifest := &GoGenerationManifest{FilePath: path, Hashes: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return manifest, nil
+		}
+		return nil, errors.Wrapf(err, "failed loading go generation manifest \"%s\"", path)
+	}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed loading go generation manifest \"%s\"", path)
+	}
+	if manifest.Hashes == nil {
+		manifest.Hashes = map[string]string{}
+	}
+
+	return manifest, nil
+}
+
+// Update updates the hashes of this manifest with a list of generated files and returns the ones
+// whose inputs changed since the last run or which are missing on disk. The returned boolean indicates
+// if the manifest itself changed.
+func (m *GoGenerationManifest) Update(files []*GeneratedGoFile, specHashes map[specter.SpecificationName]string) ([]*GeneratedGoFile, bool) {
+	var changed []*GeneratedGoFile
+	hashes := map[string]string{}
+	manifestChanged := false
+
+	for _, f := range files {
+		hash := f.InputHash(specHashes)
+		hashes[f.Path] = hash
+
+		if m.Hashes[f.Path] != hash {
+			manifestChanged = true
+			changed = append(changed, f)
+			continue
+		}
+
+		if _, err := os.Stat(f.Path); err != nil {
+			changed = append(changed, f)
+		}
+	}
+
+	if len(hashes) != len(m.Hashes) {
+		manifestChanged = true
+	}
+
+	m.Hashes = hashes
+
+	return changed, manifestChanged
+}
+
+// Output returns this manifest as a file output.
+func (m *GoGenerationManifest) Output() (specter.ProcessingOutput, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return specter.ProcessingOutput{}, errors.Wrapf(err, "failed generating go generation manifest \"%s\"", m.FilePath)
+	}
+
+	return specter.ProcessingOutput{
+		Name: m.FilePath,
+		Value: specter.FileOutput{
+			Path: m.FilePath,
+			Data: data,
+			Mode: os.ModePerm,
+		},
+	}, nil
+}
+
+// renderIncrementalGoOutputFiles renders as processing outputs only the generated files whose inputs changed
+// since the last run according to the manifest at a given path. The manifest is also output if it changed.
+func renderIncrementalGoOutputFiles(manifestPath string, files []*GeneratedGoFile, specHashes map[specter.SpecificationName]string) ([]specter.ProcessingOutput, error) {
+	manifest, err := LoadGoGenerationManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	changedFiles, manifestChanged := manifest.Update(files, specHashes)
+
+	outputs, err := renderGoOutputFiles(changedFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifestChanged {
+		manifestOutput, err := manifest.Output()
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, manifestOutput)
+	}
+
+	return outputs, nil
+}
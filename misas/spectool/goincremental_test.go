@@ -0,0 +1,93 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderIncrementalGoOutputFiles(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, GoGenerationManifestFileName)
+	pkg := &GoPackage{
+		ModFile:  GoMod{Name: "github.com/morebec/unit", Path: filepath.Join(dir, "go.mod")},
+		Name:     "unit",
+		FilePath: dir,
+	}
+
+	newFiles := func() []*GeneratedGoFile {
+		return []*GeneratedGoFile{
+			{Package: pkg, Path: filepath.Join(dir, "user_generated.go"), Specs: []specter.SpecificationName{"user.profile"}, Snippets: []GoSnippet{{Code: "const UserProfileTypeName = \"user.profile\""}}},
+			{Package: pkg, Path: filepath.Join(dir, "account_generated.go"), Specs: []specter.SpecificationName{"account.settings"}, Snippets: []GoSnippet{{Code: "const AccountSettingsTypeName = \"account.settings\""}}},
+		}
+	}
+
+	// specHashes computes the hashes of the input specifications from their sources.
+	specHashes := func(userSource string) map[specter.SpecificationName]string {
+		return SpecificationInputHashes([]specter.Specification{
+			&Struct{Nam: "user.profile", Src: specter.Source{Location: filepath.Join(dir, "user.spec.hcl"), Data: []byte(userSource)}},
+			&Struct{Nam: "account.settings", Src: specter.Source{Location: filepath.Join(dir, "account.spec.hcl"), Data: []byte(`struct "account.settings" {}`)}},
+		})
+	}
+
+	// write simulates the output processor writing the files to disk.
+	write := func(outputs []specter.ProcessingOutput) {
+		for _, o := range outputs {
+			file := o.Value.(specter.FileOutput)
+			assert.NoError(t, os.WriteFile(file.Path, file.Data, file.Mode))
+		}
+	}
+
+	// First run outputs every file and the manifest.
+	outputs, err := renderIncrementalGoOutputFiles(manifestPath, newFiles(), specHashes(`struct "user.profile" {}`))
+	assert.NoError(t, err)
+	assert.Len(t, outputs, 3)
+	write(outputs)
+
+	// Second run without changes produces zero writes.
+	outputs, err = renderIncrementalGoOutputFiles(manifestPath, newFiles(), specHashes(`struct "user.profile" {}`))
+	assert.NoError(t, err)
+	assert.Empty(t, outputs)
+
+	// Changing a specification only outputs the file it generates code in and the manifest.
+	outputs, err = renderIncrementalGoOutputFiles(manifestPath, newFiles(), specHashes(`struct "user.profile" { description = "changed" }`))
+	assert.NoError(t, err)
+	assert.Len(t, outputs, 2)
+	assert.Equal(t, filepath.Join(dir, "user_generated.go"), outputs[0].Name)
+	assert.Equal(t, manifestPath, outputs[1].Name)
+	write(outputs)
+
+	// A file deleted from disk is output again even if its inputs did not change.
+	assert.NoError(t, os.Remove(filepath.Join(dir, "account_generated.go")))
+	outputs, err = renderIncrementalGoOutputFiles(manifestPath, newFiles(), specHashes(`struct "user.profile" { description = "changed" }`))
+	assert.NoError(t, err)
+	assert.Len(t, outputs, 1)
+	assert.Equal(t, filepath.Join(dir, "account_generated.go"), outputs[0].Name)
+}
+
+func TestSpecificationInputHashes(t *testing.T) {
+	address := &Struct{Nam: "user.address", Src: specter.Source{Location: "/unit/user.spec.hcl", Data: []byte(`struct "user.address" {}`)}}
+	profile := &Struct{Nam: "user.profile", Fields: []StructField{{Name: "address", Type: "user.address"}}, Src: specter.Source{Location: "/unit/profile.spec.hcl", Data: []byte(`struct "user.profile" {}`)}}
+	hashes := SpecificationInputHashes([]specter.Specification{address, profile})
+
+	changedAddress := &Struct{Nam: "user.address", Src: specter.Source{Location: "/unit/user.spec.hcl", Data: []byte(`struct "user.address" { description = "changed" }`)}}
+	changedHashes := SpecificationInputHashes([]specter.Specification{changedAddress, profile})
+
+	// A change in a dependency changes the hash of the specifications depending on it.
+	assert.NotEqual(t, hashes["user.address"], changedHashes["user.address"])
+	assert.NotEqual(t, hashes["user.profile"], changedHashes["user.profile"])
+	assert.Equal(t, hashes, SpecificationInputHashes([]specter.Specification{address, profile}))
+}
+
+func TestRunGoSpecHandler_RecordsFileSpecs(t *testing.T) {
+	ctx := newTestGoProcessingContext()
+	strct := &Struct{Nam: "user.profile", Desc: "Profile of a user.", Src: testSource}
+
+	assert.NoError(t, runGoSpecHandler(ctx, generateStruct, strct))
+
+	file := ctx.PackageTree.FindGeneratedFileAtPath("/unit/user_generated.go")
+	assert.NotNil(t, file)
+	assert.Equal(t, []specter.SpecificationName{"user.profile"}, file.Specs)
+}
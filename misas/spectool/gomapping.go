@@ -0,0 +1,234 @@
+package spectool
+
+import (
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// goMappingConversion represents the conversion of a value from one type to another when mapping a field.
+type goMappingConversion struct {
+	// Format of the expression converting a value, the value being substituted to %s.
+	Format string
+
+	// Fallible indicates if the expression also returns an error.
+	Fallible bool
+
+	// Import required by the expression, if any.
+	Import string
+}
+
+// goMappingConversions lists the supported conversions between different built-in types, by source and target type.
+// Strings and identifiers are both represented as Go strings, and as such need no conversion.
+var goMappingConversions = map[DataType]map[DataType]goMappingConversion{
+	Int: {
+		Float:      {Format: "float64(%s)"},
+		String:     {Format: "strconv.FormatInt(%s, 10)", Import: "strconv"},
+		Identifier: {Format: "strconv.FormatInt(%s, 10)", Import: "strconv"},
+	},
+	Float: {
+		Int:    {Format: "int64(%s)"},
+		String: {Format: "strconv.FormatFloat(%s, 'f', -1, 64)", Import: "strconv"},
+	},
+	Bool: {
+		String: {Format: "strconv.FormatBool(%s)", Import: "strconv"},
+	},
+	String: {
+		Int:      {Format: "strconv.ParseInt(%s, 10, 64)", Fallible: true, Import: "strconv"},
+		Float:    {Format: "strconv.ParseFloat(%s, 64)", Fallible: true, Import: "strconv"},
+		Bool:     {Format: "strconv.ParseBool(%s)", Fallible: true, Import: "strconv"},
+		Date:     {Format: `time.Parse("2006-01-02", %s)`, Fallible: true, Import: "time"},
+		DateTime: {Format: "time.Parse(time.RFC3339, %s)", Fallible: true, Import: "time"},
+	},
+	Identifier: {
+		Int: {Format: "strconv.ParseInt(%s, 10, 64)", Fallible: true, Import: "strconv"},
+	},
+	Date: {
+		String: {Format: `%s.Format("2006-01-02")`},
+	},
+	DateTime: {
+		String: {Format: "%s.Format(time.RFC3339)", Import: "time"},
+	},
+}
+
+// resolveGoMappingConversion returns the conversion of a value of a type to another type.
+// Returns false if values of the source type cannot be mapped to the target type.
+func resolveGoMappingConversion(from, to DataType) (goMappingConversion, bool) {
+	isString := func(t DataType) bool { return t == String || t == Identifier }
+	if from == to || (isString(from) && isString(to)) {
+		return goMappingConversion{Format: "%s"}, true
+	}
+
+	conversion, found := goMappingConversions[from][to]
+	return conversion, found
+}
+
+// goMappingAssignment represents the assignment of a field of the target of a mapping from a field of its source.
+type goMappingAssignment struct {
+	SourceName     string
+	TargetName     string
+	Source         string
+	Target         string
+	SourceNullable bool
+	TargetNullable bool
+	Fallible       bool
+
+	// Value is the expression of the converted value of the source, dereferenced if it is nullable.
+	Value string
+}
+
+// Direct indicates if the field can be assigned in a single statement.
+func (a goMappingAssignment) Direct() bool {
+	return !a.SourceNullable && !a.TargetNullable && !a.Fallible
+}
+
+// generates the Go Code of the function of a Mapping.
+func generateMapping(ctx *GoProcessingContext, s MisasSpecification) error {
+	mapping := s.(*Mapping)
+
+	templateCode := `
+// {{ .FuncName }} {{ .Description }}
+func {{ .FuncName }}(source {{ .SourceType | AsResolvedGoType }}) ({{ .TargetType | AsResolvedGoType }}, error) {
+	var target {{ .TargetType | AsResolvedGoType }}
+	{{ range $a := .Assignments }}{{ if $a.Direct }}
+	{{ $a.Target }} = {{ $a.Value }}
+	{{ else }}
+	{{ if $a.SourceNullable }}if {{ $a.Source }} != nil {{ end }}{
+		value{{ if $a.Fallible }}, err{{ end }} := {{ $a.Value }}
+		{{ if $a.Fallible }}if err != nil {
+			return {{ $.TargetType | AsResolvedGoType }}{}, fmt.Errorf("failed mapping field \"{{ $a.SourceName }}\" to \"{{ $a.TargetName }}\": %w", err)
+		}
+		{{ end }}{{ $a.Target }} = {{ if $a.TargetNullable }}&{{ end }}value
+	}
+	{{ end }}{{ end }}
+	return target, nil
+}
+`
+	type TemplateData struct {
+		FuncName    string
+		Description string
+		SourceType  DataType
+		TargetType  DataType
+		Assignments []goMappingAssignment
+	}
+
+	fail := func(err error) error {
+		return errors.Wrapf(err, "failed generating code for %s %s", mapping.Type(), mapping.Name())
+	}
+
+	sourceFields, err := mappingSpecificationFields(ctx, mapping.SourceType)
+	if err != nil {
+		return fail(errors.Wrap(err, "invalid source"))
+	}
+	targetFields, err := mappingSpecificationFields(ctx, mapping.TargetType)
+	if err != nil {
+		return fail(errors.Wrap(err, "invalid target"))
+	}
+
+	// Fields are mapped from the fields of the source with the same name, unless mapped explicitly.
+	fromByTarget := map[string]string{}
+	for _, f := range targetFields {
+		if _, found := sourceFields[f.Name]; found {
+			fromByTarget[f.Name] = f.Name
+		}
+	}
+	for _, f := range mapping.Fields {
+		if _, found := targetFields[f.Name]; !found {
+			return fail(errors.Errorf("target %s has no field \"%s\"", mapping.TargetType, f.Name))
+		}
+		if _, found := sourceFields[f.From]; !found {
+			return fail(errors.Errorf("source %s has no field \"%s\"", mapping.SourceType, f.From))
+		}
+		fromByTarget[f.Name] = f.From
+	}
+
+	targetNames := make([]string, 0, len(fromByTarget))
+	for name := range fromByTarget {
+		targetNames = append(targetNames, name)
+	}
+	sort.Strings(targetNames)
+
+	var assignments []goMappingAssignment
+	imports := map[string]struct{}{}
+	for _, name := range targetNames {
+		source, target := sourceFields[fromByTarget[name]], targetFields[name]
+
+		conversion, ok := resolveGoMappingConversion(source.Type, target.Type)
+		if !ok {
+			return fail(errors.Errorf("field \"%s\" of type %s cannot be mapped to field \"%s\" of type %s", source.Name, source.Type, target.Name, target.Type))
+		}
+		if conversion.Fallible {
+			imports["fmt"] = struct{}{}
+		}
+		if conversion.Import != "" {
+			imports[conversion.Import] = struct{}{}
+		}
+
+		sourceExpr := "source." + GoFieldName(source.Name, source.Annotations)
+		value := sourceExpr
+		if source.Nullable {
+			value = "*" + sourceExpr
+			if strings.HasPrefix(conversion.Format, "%s.") {
+				// The value is dereferenced before calling one of its methods.
+				value = "(" + value + ")"
+			}
+		}
+
+		assignments = append(assignments, goMappingAssignment{
+			SourceName:     source.Name,
+			TargetName:     target.Name,
+			Source:         sourceExpr,
+			Target:         "target." + GoFieldName(target.Name, target.Annotations),
+			SourceNullable: source.Nullable,
+			TargetNullable: target.Nullable,
+			Fallible:       conversion.Fallible,
+			Value:          fmt.Sprintf(conversion.Format, value),
+		})
+	}
+
+	var staticImports []string
+	for i := range imports {
+		staticImports = append(staticImports, i)
+	}
+	sort.Strings(staticImports)
+
+	tem := NewGoSnippetGenerationContext(
+		ctx,
+		"mapping",
+		templateCode,
+		TemplateData{
+			FuncName:    mapping.Metadata().GetOrDefault("gen:go:name", "Map"+strcase.ToCamel(string(mapping.Name()))).AsString(),
+			Description: FormatGoCommentText(mapping.Description()),
+			SourceType:  mapping.SourceType,
+			TargetType:  mapping.TargetType,
+			Assignments: assignments,
+		},
+		nil,
+		staticImports,
+	)
+
+	return GenerateCodeForSpec(tem, s)
+}
+
+// mappingSpecificationFields returns the fields of the specification of the source or target of a mapping by name.
+func mappingSpecificationFields(ctx *GoProcessingContext, t DataType) (map[string]SpecificationField, error) {
+	spec, ok := ctx.Specs().SelectName(specter.SpecificationName(t)).(MisasSpecification)
+	if !ok {
+		return nil, errors.Errorf("type \"%s\" is not defined", t)
+	}
+
+	fields, ok := FieldsOfSpecification(spec)
+	if !ok {
+		return nil, errors.Errorf("%s \"%s\" has no fields", spec.Type(), t)
+	}
+
+	fieldsByName := map[string]SpecificationField{}
+	for _, f := range fields {
+		fieldsByName[f.Name] = f
+	}
+
+	return fieldsByName, nil
+}
@@ -0,0 +1,154 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newTestMappingContext returns a GoProcessingContext in which the struct "billing.external_invoice" and the command
+// "payment.charge" were generated, to be used as the source and target of mappings.
+func newTestMappingContext(t *testing.T) *GoProcessingContext {
+	invoice := &Struct{
+		Nam:  "billing.external_invoice",
+		Desc: "Invoice as sent by the billing provider.",
+		Fields: []StructField{
+			{Name: "invoiceNumber", Description: "Number of the invoice.", Type: String},
+			{Name: "amount", Description: "Amount of the invoice in cents.", Type: String},
+			{Name: "currency", Description: "Currency of the invoice.", Type: String},
+			{Name: "paidAt", Description: "Date and time at which the invoice was paid.", Type: String, Nullable: true},
+			{Name: "memo", Description: "Memo of the invoice.", Type: String, Nullable: true},
+			{Name: "attempts", Description: "Number of payment attempts.", Type: Int},
+			{Name: "refunded", Description: "Indicates if the invoice was refunded.", Type: Bool},
+		},
+		Src: testSource,
+	}
+	charge := &Command{
+		Nam:  "payment.charge",
+		Desc: "Charges a payment.",
+		Fields: []CommandField{
+			{Name: "id", Description: "ID of the payment.", Type: Identifier},
+			{Name: "amount", Description: "Amount of the payment in cents.", Type: Int},
+			{Name: "currency", Description: "Currency of the payment.", Type: String},
+			{Name: "paidAt", Description: "Date and time at which the payment was made.", Type: DateTime, Nullable: true},
+			{Name: "memo", Description: "Memo of the payment.", Type: String},
+			{Name: "attempts", Description: "Number of payment attempts.", Type: Float, Nullable: true},
+			{Name: "refunded", Description: "Indicates if the payment was refunded.", Type: DateTime},
+		},
+		Src: testSource,
+	}
+
+	ctx := newTestGoProcessingContext()
+	ctx.ParentContext.DependencyGraph = specter.ResolvedDependencies{invoice, charge}
+	if err := generateStruct(ctx, invoice); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateCommand(ctx, charge); err != nil {
+		t.Fatal(err)
+	}
+
+	return ctx
+}
+
+func TestGenerateMapping(t *testing.T) {
+	ctx := newTestMappingContext(t)
+	code := renderGoCodeInContext(t, ctx, generateMapping, &Mapping{
+		Nam:        "billing.invoice_to_charge",
+		Desc:       "Maps the invoices of the billing provider to charges.",
+		SourceType: "billing.external_invoice",
+		TargetType: "payment.charge",
+		Fields: []MappingField{
+			{Name: "id", From: "invoiceNumber"},
+			// Explicit mappings take precedence over the mapping by name.
+			{Name: "refunded", From: "paidAt"},
+		},
+		Src: testSource,
+	})
+
+	assert.Contains(t, code, "func MapBillingInvoiceToCharge(source BillingExternalInvoice) (PaymentChargeCommand, error) {")
+	assert.Contains(t, code, "target.ID = source.InvoiceNumber")
+	assert.Contains(t, code, "target.Currency = source.Currency")
+	assert.Contains(t, code, "value, err := strconv.ParseInt(source.Amount, 10, 64)")
+	assert.Contains(t, code, `return PaymentChargeCommand{}, fmt.Errorf("failed mapping field \"amount\" to \"amount\": %w", err)`)
+	assert.Contains(t, code, "value, err := time.Parse(time.RFC3339, *source.PaidAt)")
+	assert.Contains(t, code, "target.PaidAt = &value")
+
+	runGeneratedGoTest(t, ctx, `
+func TestMapBillingInvoiceToCharge(t *testing.T) {
+	paidAt, memo := "2022-01-02T03:04:05Z", "first invoice"
+	charge, err := MapBillingInvoiceToCharge(BillingExternalInvoice{
+		InvoiceNumber: "INV-1",
+		Amount:        "1250",
+		Currency:      "CAD",
+		PaidAt:        &paidAt,
+		Memo:          &memo,
+		Attempts:      2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if charge.ID != "INV-1" || charge.Amount != 1250 || charge.Currency != "CAD" || charge.Memo != memo {
+		t.Errorf("unexpected charge %+v", charge)
+	}
+	if charge.PaidAt == nil || charge.PaidAt.Format("2006-01-02T15:04:05Z07:00") != paidAt || charge.Refunded != *charge.PaidAt {
+		t.Errorf("unexpected paid at of charge %+v", charge)
+	}
+	if charge.Attempts == nil || *charge.Attempts != 2 {
+		t.Errorf("unexpected attempts of charge %+v", charge)
+	}
+
+	charge, err = MapBillingInvoiceToCharge(BillingExternalInvoice{Amount: "1250"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if charge.PaidAt != nil || charge.Memo != "" {
+		t.Errorf("unset nullable fields should not be mapped, got %+v", charge)
+	}
+
+	if _, err := MapBillingInvoiceToCharge(BillingExternalInvoice{Amount: "twelve"}); err == nil {
+		t.Error("invalid amounts should fail the mapping")
+	}
+}
+`)
+}
+
+func TestGenerateMapping_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []MappingField
+		wantErr string
+	}{
+		{
+			name:    "unknown target field",
+			fields:  []MappingField{{Name: "reference", From: "invoiceNumber"}},
+			wantErr: `target payment.charge has no field "reference"`,
+		},
+		{
+			name:    "unknown source field",
+			fields:  []MappingField{{Name: "id", From: "reference"}},
+			wantErr: `source billing.external_invoice has no field "reference"`,
+		},
+		{
+			name:    "incompatible types",
+			fields:  []MappingField{{Name: "refunded", From: "refunded"}},
+			wantErr: `field "refunded" of type bool cannot be mapped to field "refunded" of type dateTime`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := generateMapping(newTestMappingContext(t), &Mapping{
+				Nam:        "billing.invoice_to_charge",
+				Desc:       "Maps the invoices of the billing provider to charges.",
+				SourceType: "billing.external_invoice",
+				TargetType: "payment.charge",
+				Fields:     tt.fields,
+				Src:        testSource,
+			})
+
+			assert.Error(t, err)
+			if err != nil {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
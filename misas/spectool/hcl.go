@@ -10,6 +10,8 @@ type HCLFileConfig struct {
 	Events    []*Event                    `hcl:"event,block"`
 	Enums     []*Enum                     `hcl:"enum,block"`
 	Structs   []*Struct                   `hcl:"struct,block"`
+	Mappings  []*Mapping                  `hcl:"mapping,block"`
+	Endpoints []*HTTPEndpoint             `hcl:"http_endpoint,block"`
 }
 
 func (c HCLFileConfig) Specifications() []specter.Specification {
@@ -39,5 +41,13 @@ func (c HCLFileConfig) Specifications() []specter.Specification {
 		grp = append(grp, s)
 	}
 
+	for _, s := range c.Mappings {
+		grp = append(grp, s)
+	}
+
+	for _, s := range c.Endpoints {
+		grp = append(grp, s)
+	}
+
 	return grp
 }
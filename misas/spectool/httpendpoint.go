@@ -1,6 +1,11 @@
 package spectool
 
-import "github.com/morebec/specter"
+import (
+	"fmt"
+	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+	"strings"
+)
 
 type HTTPEndpointFailureResponse struct {
 	StatusCode  int    `hcl:"statusCode"`
@@ -12,23 +17,26 @@ type HTTPEndpointFailureResponse struct {
 type HTTPEndpointSuccessResponse struct {
 	StatusCode  int    `hcl:"statusCode"`
 	Description string `hcl:"description"`
-	Example     string `hcl:"example"`
+	Example     string `hcl:"example,optional"`
 	// The DataType returned by this response
-	Type DataType `hcl:"type"`
+	Type DataType `hcl:"type,optional"`
 }
 
 type HTTPEndpointResponses struct {
-	Success  HTTPEndpointSuccessResponse   `hcl:"success,optional"`
-	Failures []HTTPEndpointFailureResponse `hcl:"failures,optional"`
+	Success  HTTPEndpointSuccessResponse   `hcl:"success,block"`
+	Failures []HTTPEndpointFailureResponse `hcl:"failure,block"`
 }
 
+// HTTPEndpoint represents an HTTP endpoint dispatching its request to the command or query bus. The path parameters
+// of the endpoint are bound to the request fields of the same name, while the other fields are sent in the JSON body
+// of the request, or in its query string for methods without a body such as GET.
 type HTTPEndpoint struct {
 	Nam    string `hcl:"name,label"`
 	Method string `hcl:"method,label"`
 	Path   string `hcl:"path,label"`
 	Desc   string `hcl:"description"`
 
-	Request   DataType              `hcl:"request,block"`
+	Request   DataType              `hcl:"request"`
 	Responses HTTPEndpointResponses `hcl:"responses,block"`
 
 	Annots Annotations `hcl:"annotations,optional"`
@@ -73,9 +81,97 @@ func (he *HTTPEndpoint) Dependencies() []specter.SpecificationName {
 	}
 
 	// success response
-	if he.Responses.Success.Type.IsUserDefined() {
-		deps = append(deps, specter.SpecificationName(he.Responses.Success.Type.ExtractUserDefined()))
+	responseType := he.Responses.Success.Type.ExtractUserDefined()
+	if responseType != "" {
+		deps = append(deps, specter.SpecificationName(responseType))
 	}
 
 	return deps
 }
+
+// HTTPEndpointRequestMustBeCommandOrQuery returns a linter reporting an error for every HTTP endpoint whose request type
+// is not a command or a query, since only those can be dispatched to the domain layer.
+func HTTPEndpointRequestMustBeCommandOrQuery() specter.SpecificationLinterFunc {
+	return func(specs specter.SpecificationGroup) specter.LinterResultSet {
+		var result specter.LinterResultSet
+		for _, s := range specs.SelectType(specter.SpecificationType("http_endpoint")) {
+			endpoint := s.(*HTTPEndpoint)
+
+			if endpoint.Request.IsUserDefined() {
+				requestSpec := specs.SelectName(specter.SpecificationName(endpoint.Request))
+				// Undefined names are reported by specter.SpecificationMustNotHaveUndefinedNames.
+				if requestSpec == nil || requestSpec.Type() == "command" || requestSpec.Type() == "query" {
+					continue
+				}
+			}
+
+			result = append(result, specter.LinterResult{
+				Severity: specter.ErrorSeverity,
+				Message: fmt.Sprintf(
+					"http endpoint \"%s\" has request type \"%s\" which is neither a command nor a query at \"%s\"",
+					endpoint.Name(),
+					endpoint.Request,
+					endpoint.Source().Location,
+				),
+			})
+		}
+
+		return result
+	}
+}
+
+// httpMethodHasBody indicates if the requests of an HTTP method carry a body. The fields of the requests of methods
+// without a body, such as GET, are sent in the query string instead.
+func httpMethodHasBody(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD":
+		return false
+	}
+	return true
+}
+
+// httpEndpointPathParam represents a path parameter of an HTTP endpoint along with the request field it is bound to.
+type httpEndpointPathParam struct {
+	Name  string
+	Field SpecificationField
+}
+
+// httpEndpointPathParams returns the path parameters of an HTTP endpoint along with the fields of its request they
+// are bound to, that is the fields having the same JSON name. It returns an error if a parameter does not match any
+// field or matches a field that cannot hold the text of a path segment.
+func httpEndpointPathParams(endpoint *HTTPEndpoint, request MisasSpecification) ([]httpEndpointPathParam, error) {
+	fields, _ := FieldsOfSpecification(request)
+
+	var params []httpEndpointPathParam
+	for _, match := range chiPathParamRegex.FindAllStringSubmatch(endpoint.Path, -1) {
+		name := match[1]
+		var field *SpecificationField
+		for i, f := range fields {
+			if jsonFieldName(f.Name) == jsonFieldName(name) {
+				field = &fields[i]
+				break
+			}
+		}
+		if field == nil {
+			return nil, errors.Errorf("path parameter \"%s\" does not match any field of %s %s", name, request.Type(), request.Name())
+		}
+		if field.Nullable || (field.Type != Identifier && field.Type != String) {
+			return nil, errors.Errorf("path parameter \"%s\" is bound to field \"%s\" of %s %s which is not a non-nullable string or identifier", name, field.Name, request.Type(), request.Name())
+		}
+		params = append(params, httpEndpointPathParam{Name: name, Field: *field})
+	}
+
+	return params, nil
+}
+
+// isJSONStringType indicates if the values of a data type are represented as JSON strings.
+func isJSONStringType(t DataType, specs specter.SpecificationGroup) bool {
+	switch t {
+	case Identifier, String, Date, DateTime:
+		return true
+	}
+	if enum, ok := specs.SelectName(specter.SpecificationName(t)).(*Enum); ok {
+		return isJSONStringType(enum.BaseType, specs)
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHTTPEndpointRequestMustBeCommandOrQuery(t *testing.T) {
+	specs := specter.SpecificationGroup{
+		&Command{Nam: "user.register", Desc: "Registers a user.", Src: testSource},
+		&Query{Nam: "user.by_id", Desc: "Returns a user by its ID.", Src: testSource},
+		&Struct{Nam: "user.address", Desc: "Address of a user.", Src: testSource},
+	}
+
+	tests := []struct {
+		name    string
+		request DataType
+		wantErr bool
+	}{
+		{name: "command request", request: "user.register", wantErr: false},
+		{name: "query request", request: "user.by_id", wantErr: false},
+		{name: "struct request", request: "user.address", wantErr: true},
+		{name: "builtin request", request: String, wantErr: true},
+		{name: "undefined request", request: "user.unknown", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &HTTPEndpoint{
+				Nam:     "user.endpoint",
+				Method:  "POST",
+				Path:    "/users",
+				Desc:    "An endpoint.",
+				Request: tt.request,
+				Src:     testSource,
+			}
+
+			results := HTTPEndpointRequestMustBeCommandOrQuery()(append(specter.SpecificationGroup{endpoint}, specs...))
+
+			if tt.wantErr {
+				assert.Len(t, results, 1)
+				assert.Equal(t, specter.ErrorSeverity, results[0].Severity)
+				assert.Contains(t, results[0].Message, `http endpoint "user.endpoint"`)
+			} else {
+				assert.Empty(t, results)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package spectool
+
+import (
+	"fmt"
+	"github.com/morebec/specter"
+)
+
+// MappingField maps a field of the target of a Mapping from a field of its source.
+type MappingField struct {
+	// Name of the field of the target.
+	Name string `hcl:"name,label"`
+
+	// From is the name of the field of the source it is mapped from.
+	From string `hcl:"from"`
+}
+
+// Mapping describes how the payloads of an external system, described as a struct, are mapped to an internal command
+// or event, acting as an anti-corruption layer. The fields of the target are mapped from the fields of the source with
+// the same name unless mapped explicitly, values being coerced when the types of the fields differ.
+type Mapping struct {
+	Nam  string `hcl:"name,label"`
+	Desc string `hcl:"description"`
+
+	// SourceType is the struct describing the payloads of the external system.
+	SourceType DataType `hcl:"source"`
+
+	// TargetType is the command or event the payloads are mapped to.
+	TargetType DataType `hcl:"target"`
+
+	Fields []MappingField `hcl:"field,block"`
+
+	Annots Annotations `hcl:"annotations,optional"`
+	Meta   Metadata    `hcl:"meta,block"`
+	Src    specter.Source
+}
+
+func (m *Mapping) Metadata() Metadata {
+	return m.Meta
+}
+
+func (m *Mapping) Annotations() Annotations {
+	return m.Annots
+}
+
+func (m *Mapping) Name() specter.SpecificationName {
+	return specter.SpecificationName(m.Nam)
+}
+
+func (m *Mapping) Type() specter.SpecificationType {
+	return "mapping"
+}
+
+func (m *Mapping) Description() string {
+	return m.Desc
+}
+
+func (m *Mapping) Source() specter.Source {
+	return m.Src
+}
+
+func (m *Mapping) SetSource(s specter.Source) {
+	m.Src = s
+}
+
+func (m *Mapping) Dependencies() []specter.SpecificationName {
+	var deps []specter.SpecificationName
+	for _, t := range []DataType{m.SourceType, m.TargetType} {
+		if t.IsUserDefined() {
+			deps = append(deps, specter.SpecificationName(t.ExtractUserDefined()))
+		}
+	}
+	return deps
+}
+
+// MappingTargetMustBeCommandOrEvent returns a linter reporting an error for every mapping whose target is not a command
+// or an event, since mappings translate external payloads to the messages of the domain layer.
+func MappingTargetMustBeCommandOrEvent() specter.SpecificationLinterFunc {
+	return func(specs specter.SpecificationGroup) specter.LinterResultSet {
+		var result specter.LinterResultSet
+		for _, s := range specs.SelectType((&Mapping{}).Type()) {
+			mapping := s.(*Mapping)
+
+			if mapping.TargetType.IsUserDefined() {
+				targetSpec := specs.SelectName(specter.SpecificationName(mapping.TargetType))
+				// Undefined names are reported by specter.SpecificationMustNotHaveUndefinedNames.
+				if targetSpec == nil || targetSpec.Type() == (&Command{}).Type() || targetSpec.Type() == (&Event{}).Type() {
+					continue
+				}
+			}
+
+			result = append(result, specter.LinterResult{
+				Severity: specter.ErrorSeverity,
+				Message: fmt.Sprintf(
+					"mapping \"%s\" has target type \"%s\" which is neither a command nor an event at \"%s\"",
+					mapping.Name(),
+					mapping.TargetType,
+					mapping.Source().Location,
+				),
+			})
+		}
+
+		return result
+	}
+}
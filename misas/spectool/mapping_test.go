@@ -0,0 +1,47 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMappingTargetMustBeCommandOrEvent(t *testing.T) {
+	invoice := &Struct{Nam: "billing.invoice", Desc: "Invoice of the billing provider.", Src: testSource}
+	charge := &Command{Nam: "payment.charge", Desc: "Charges a payment.", Src: testSource}
+	charged := &Event{Nam: "payment.charged", Desc: "Indicates that a payment was charged.", Src: testSource}
+
+	tests := []struct {
+		name         string
+		target       DataType
+		wantMessages []string
+	}{
+		{name: "command target", target: "payment.charge"},
+		{name: "event target", target: "payment.charged"},
+		{name: "undefined target", target: "payment.unknown"},
+		{
+			name:         "struct target",
+			target:       "billing.invoice",
+			wantMessages: []string{`mapping "billing.invoice_to_payment" has target type "billing.invoice" which is neither a command nor an event at "/unit/system.spec.hcl"`},
+		},
+		{
+			name:         "built-in target",
+			target:       String,
+			wantMessages: []string{`mapping "billing.invoice_to_payment" has target type "string" which is neither a command nor an event at "/unit/system.spec.hcl"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping := &Mapping{Nam: "billing.invoice_to_payment", Desc: "Maps invoices to payments.", SourceType: "billing.invoice", TargetType: tt.target, Src: testSource}
+
+			results := MappingTargetMustBeCommandOrEvent()(specter.SpecificationGroup{invoice, charge, charged, mapping})
+
+			var messages []string
+			for _, r := range results {
+				assert.Equal(t, specter.ErrorSeverity, r.Severity)
+				messages = append(messages, r.Message)
+			}
+			assert.Equal(t, tt.wantMessages, messages)
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package spectool
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIGenerator is a specification processor responsible for aggregating all the HTTP endpoints specifications
+// into a single OpenAPI 3.0 document.
+// It is enabled by setting the "gen:openapi:fileName" metadata on the system specification, the path being relative to the system specification.
+type OpenAPIGenerator struct {
+}
+
+func (g OpenAPIGenerator) Name() string {
+	return "openapi-generator"
+}
+
+func (g OpenAPIGenerator) Process(ctx specter.ProcessingContext) ([]specter.ProcessingOutput, error) {
+	// System specification
+	candidates := specter.SpecificationGroup(ctx.DependencyGraph).SelectType((&System{}).Type())
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	systemSpec := candidates[0].(*System)
+	if !systemSpec.Metadata().HasKey("gen:openapi:fileName") {
+		return nil, nil
+	}
+
+	ctx.Logger.Info("Generating OpenAPI document ...")
+	var specs []MisasSpecification
+	for _, s := range ctx.DependencyGraph {
+		if ms, ok := s.(MisasSpecification); ok {
+			specs = append(specs, ms)
+		}
+	}
+
+	doc, err := GenerateOpenAPIDocument(systemSpec, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := systemSpec.Metadata().GetOrDefault("gen:openapi:fileName", "").AsString()
+	filePath := filepath.Join(filepath.Dir(systemSpec.Source().Location), fileName)
+	ctx.Logger.Info("OpenAPI document generated successfully.")
+
+	return []specter.ProcessingOutput{
+		{
+			Name: filePath,
+			Value: specter.FileOutput{
+				Path: filePath,
+				Data: doc,
+				Mode: os.ModePerm,
+			},
+		},
+	}, nil
+}
+
+// openAPISchemaNameSuffixes indicates the suffix to add to the default OpenAPI schema name of a type of specification.
+var openAPISchemaNameSuffixes = map[specter.SpecificationType]string{
+	(&Command{}).Type(): "Command",
+	(&Query{}).Type():   "Query",
+	(&Event{}).Type():   "Event",
+}
+
+// OpenAPISchemaName returns the name of the schema component generated for a specification.
+func OpenAPISchemaName(s MisasSpecification) string {
+	defaultName := strcase.ToCamel(string(s.Name())) + openAPISchemaNameSuffixes[s.Type()]
+	return s.Metadata().GetOrDefault("gen:openapi:name", defaultName).AsString()
+}
+
+// openAPISchema represents a schema object of an OpenAPI document.
+type openAPISchema map[string]any
+
+// openAPIErrorSchemaName is the name of the component describing the errors returned by failure responses.
+const openAPIErrorSchemaName = "Error"
+
+// openAPIDefaultVersion returns the version of the system to use in the OpenAPI document when none is specified.
+func openAPIDefaultVersion(system *System) string {
+	if system.Version != "" {
+		return system.Version
+	}
+	return "1.0.0"
+}
+
+// chiPathParamRegex matches the path parameters of a chi route, e.g. {id} or {id:[0-9]+}.
+var chiPathParamRegex = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?}`)
+
+// GenerateOpenAPIDocument generates an OpenAPI 3.0 JSON document describing the HTTP endpoints of a system.
+// Structs, commands, queries, events and enums are exposed as schema components that the endpoints reference.
+func GenerateOpenAPIDocument(system *System, specs []MisasSpecification) ([]byte, error) {
+	schemaNames := map[DataType]string{}
+	for _, s := range specs {
+		schemaNames[DataType(s.Name())] = OpenAPISchemaName(s)
+	}
+
+	resolveSchema := func(t DataType) (openAPISchema, error) {
+		return ResolveOpenAPISchema(t, schemaNames)
+	}
+
+	schemas := map[string]openAPISchema{
+		openAPIErrorSchemaName: {
+			"type": "object",
+			"properties": map[string]openAPISchema{
+				"type":    {"type": "string"},
+				"message": {"type": "string"},
+				"data":    {},
+			},
+			"required": []string{"type", "message"},
+		},
+	}
+	paths := map[string]map[string]any{}
+
+	for _, s := range specs {
+		fields, isObject := FieldsOfSpecification(s)
+		switch spec := s.(type) {
+		case *Enum:
+			schema, err := resolveSchema(spec.BaseType)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed generating OpenAPI schema for enum %s", spec.Name())
+			}
+			var values []any
+			for _, v := range spec.Values {
+				values = append(values, v.Value)
+			}
+			schema["enum"] = values
+			schema["description"] = strings.TrimSpace(spec.Description())
+			schemas[OpenAPISchemaName(spec)] = schema
+			continue
+		case *HTTPEndpoint:
+			var request MisasSpecification
+			for _, r := range specs {
+				if DataType(r.Name()) == spec.Request && (r.Type() == (&Command{}).Type() || r.Type() == (&Query{}).Type()) {
+					request = r
+					break
+				}
+			}
+			path, operation, err := generateOpenAPIOperation(spec, request, resolveSchema)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed generating OpenAPI operation for endpoint %s", spec.Name())
+			}
+			if paths[path] == nil {
+				paths[path] = map[string]any{}
+			}
+			paths[path][strings.ToLower(spec.Method)] = operation
+			continue
+		}
+		if !isObject {
+			continue
+		}
+
+		properties := map[string]openAPISchema{}
+		var required []string
+		for _, f := range fields {
+			if IsReadonlyRequestField(s, f) {
+				continue
+			}
+			schema, err := resolveSchema(f.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed generating OpenAPI schema for %s %s", s.Type(), s.Name())
+			}
+			if f.Nullable {
+				schema = openAPISchema{"allOf": []openAPISchema{schema}, "nullable": true}
+			} else {
+				required = append(required, jsonFieldName(f.Name))
+			}
+			if description := strings.TrimSpace(f.Description); description != "" {
+				schema["description"] = description
+			}
+			properties[jsonFieldName(f.Name)] = schema
+		}
+
+		schema := openAPISchema{
+			"type":        "object",
+			"description": strings.TrimSpace(s.Description()),
+			"properties":  properties,
+		}
+		if len(required) != 0 {
+			schema["required"] = required
+		}
+		schemas[OpenAPISchemaName(s)] = schema
+	}
+
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       string(system.Name()),
+			"description": strings.TrimSpace(system.Description()),
+			"version":     system.Metadata().GetOrDefault("gen:openapi:version", openAPIDefaultVersion(system)).AsString(),
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+		},
+	}
+
+	output, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed generating OpenAPI document")
+	}
+
+	return output, nil
+}
+
+// generateOpenAPIOperation generates the path and the OpenAPI operation object of an HTTP endpoint.
+// The request is the specification of the request type of the endpoint, if it is known. For methods without a body,
+// its fields are described as query parameters instead of a request body.
+func generateOpenAPIOperation(endpoint *HTTPEndpoint, request MisasSpecification, resolveSchema func(t DataType) (openAPISchema, error)) (string, map[string]any, error) {
+	switch strings.ToUpper(endpoint.Method) {
+	case "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS":
+	default:
+		return "", nil, errors.Errorf("unsupported HTTP method \"%s\"", endpoint.Method)
+	}
+
+	operation := map[string]any{
+		"operationId": strcase.ToLowerCamel(string(endpoint.Name())),
+		"description": strings.TrimSpace(endpoint.Description()),
+	}
+
+	// Path parameters
+	var parameters []map[string]any
+	pathParams := map[string]bool{}
+	for _, match := range chiPathParamRegex.FindAllStringSubmatch(endpoint.Path, -1) {
+		pathParams[jsonFieldName(match[1])] = true
+		parameters = append(parameters, map[string]any{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   openAPISchema{"type": "string"},
+		})
+	}
+
+	// Query parameters
+	if !httpMethodHasBody(endpoint.Method) && request != nil {
+		fields, _ := FieldsOfSpecification(request)
+		for _, f := range fields {
+			if IsReadonlyRequestField(request, f) || pathParams[jsonFieldName(f.Name)] {
+				continue
+			}
+			schema, err := resolveSchema(f.Type)
+			if err != nil {
+				return "", nil, err
+			}
+			parameter := map[string]any{
+				"name":     jsonFieldName(f.Name),
+				"in":       "query",
+				"required": !f.Nullable,
+				"schema":   schema,
+			}
+			if description := strings.TrimSpace(f.Description); description != "" {
+				parameter["description"] = description
+			}
+			parameters = append(parameters, parameter)
+		}
+	}
+	if parameters != nil {
+		operation["parameters"] = parameters
+	}
+
+	// Request
+	if endpoint.Request != "" && httpMethodHasBody(endpoint.Method) {
+		schema, err := resolveSchema(endpoint.Request)
+		if err != nil {
+			return "", nil, err
+		}
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schema},
+			},
+		}
+	}
+
+	// Responses
+	responses := map[string]any{}
+	success := endpoint.Responses.Success
+	successCode := success.StatusCode
+	if successCode == 0 {
+		successCode = 200
+	}
+	dataSchema := openAPISchema{"nullable": true}
+	if success.Type != "" {
+		schema, err := resolveSchema(success.Type)
+		if err != nil {
+			return "", nil, err
+		}
+		dataSchema = schema
+	}
+	responses[strconv.Itoa(successCode)] = openAPIResponse(success.Description, openAPISchema{
+		"type": "object",
+		"properties": map[string]openAPISchema{
+			"status": {"type": "string", "enum": []string{"success"}},
+			"data":   dataSchema,
+			"error":  {"nullable": true},
+		},
+	})
+
+	// Failures sharing the same status code are grouped under the same response.
+	failuresByStatusCode := map[int][]HTTPEndpointFailureResponse{}
+	var statusCodes []int
+	for _, f := range endpoint.Responses.Failures {
+		if _, found := failuresByStatusCode[f.StatusCode]; !found {
+			statusCodes = append(statusCodes, f.StatusCode)
+		}
+		failuresByStatusCode[f.StatusCode] = append(failuresByStatusCode[f.StatusCode], f)
+	}
+	sort.Ints(statusCodes)
+	for _, code := range statusCodes {
+		var descriptions []string
+		var errorTypes []string
+		for _, f := range failuresByStatusCode[code] {
+			if description := strings.TrimSpace(f.Description); description != "" {
+				descriptions = append(descriptions, description)
+			}
+			errorTypes = append(errorTypes, f.ErrorType)
+		}
+		responses[strconv.Itoa(code)] = openAPIResponse(strings.Join(descriptions, "\n"), openAPISchema{
+			"type": "object",
+			"properties": map[string]openAPISchema{
+				"status": {"type": "string", "enum": []string{"failure"}},
+				"data":   {"nullable": true},
+				"error": {
+					"allOf": []openAPISchema{
+						{"$ref": "#/components/schemas/" + openAPIErrorSchemaName},
+						{"properties": map[string]openAPISchema{"type": {"type": "string", "enum": errorTypes}}},
+					},
+				},
+			},
+		})
+	}
+	operation["responses"] = responses
+
+	return chiPathParamRegex.ReplaceAllString(endpoint.Path, "{$1}"), operation, nil
+}
+
+func openAPIResponse(description string, schema openAPISchema) map[string]any {
+	if description == "" {
+		description = "No description."
+	}
+	return map[string]any{
+		"description": description,
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": schema},
+		},
+	}
+}
+
+// ResolveOpenAPISchema resolves the OpenAPI schema corresponding to a DataType.
+// User defined types are resolved as references to schema components using a map of internal type names to schema names.
+func ResolveOpenAPISchema(t DataType, schemaNames map[DataType]string) (openAPISchema, error) {
+	switch t {
+	case Null:
+		return openAPISchema{"nullable": true}, nil
+	case Identifier, String, Char:
+		return openAPISchema{"type": "string"}, nil
+	case Date:
+		return openAPISchema{"type": "string", "format": "date"}, nil
+	case DateTime:
+		return openAPISchema{"type": "string", "format": "date-time"}, nil
+	case Int, Duration:
+		return openAPISchema{"type": "integer", "format": "int64"}, nil
+	case Float:
+		return openAPISchema{"type": "number", "format": "double"}, nil
+	case Bool:
+		return openAPISchema{"type": "boolean"}, nil
+	case Any:
+		return openAPISchema{}, nil
+	}
+
+	if t.IsContainer() {
+		valueSchema, err := ResolveOpenAPISchema(t.ContainerInfo().ValueType, schemaNames)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed resolving container type %s", t)
+		}
+		if t.IsMap() {
+			return openAPISchema{"type": "object", "additionalProperties": valueSchema}, nil
+		}
+		return openAPISchema{"type": "array", "items": valueSchema}, nil
+	}
+
+	if name, found := schemaNames[t]; found {
+		return openAPISchema{"$ref": fmt.Sprintf("#/components/schemas/%s", name)}, nil
+	}
+
+	return nil, errors.Errorf("Could not resolve an OpenAPI schema for \"%s\"", t)
+}
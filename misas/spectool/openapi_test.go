@@ -0,0 +1,166 @@
+package spectool
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerateOpenAPIDocument(t *testing.T) {
+	output, err := GenerateOpenAPIDocument(&System{SName: "unit test", SDescription: "System made for unit tests."}, []MisasSpecification{
+		&Struct{
+			Nam:  "user.profile",
+			Desc: "Profile of a user.",
+			Fields: []StructField{
+				{Name: "id", Description: "ID of the user.", Type: Identifier},
+				{Name: "nickname", Description: "Nickname of the user.", Type: String, Nullable: true},
+			},
+		},
+		&Command{
+			Nam:    "user.register",
+			Desc:   "Registers a user.",
+			Fields: []CommandField{{Name: "emailAddress", Description: "Email address of the user.", Type: String}},
+		},
+		&HTTPEndpoint{
+			Nam:     "register_user",
+			Method:  "POST",
+			Path:    "/users",
+			Desc:    "Registers a user.",
+			Request: "user.register",
+			Responses: HTTPEndpointResponses{
+				Success: HTTPEndpointSuccessResponse{StatusCode: 201, Description: "User registered.", Type: Identifier},
+				Failures: []HTTPEndpointFailureResponse{
+					{StatusCode: 409, Description: "Email address already in use.", ErrorType: "user.email_address_in_use"},
+					{StatusCode: 400, Description: "Invalid email address.", ErrorType: "user.invalid_email_address"},
+				},
+			},
+		},
+		&HTTPEndpoint{
+			Nam:    "get_user_profile",
+			Method: "GET",
+			Path:   "/users/{id:[a-z0-9-]+}",
+			Desc:   "Returns the profile of a user.",
+			Responses: HTTPEndpointResponses{
+				Success: HTTPEndpointSuccessResponse{StatusCode: 200, Description: "Profile of the user.", Type: "user.profile"},
+				Failures: []HTTPEndpointFailureResponse{
+					{StatusCode: 404, Description: "User not found.", ErrorType: "user.not_found"},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			RequestBody struct {
+				Content map[string]struct {
+					Schema map[string]any `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]any `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(output, &doc))
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	register := doc.Paths["/users"]["post"]
+	assert.Equal(t, "#/components/schemas/UserRegisterCommand", register.RequestBody.Content["application/json"].Schema["$ref"])
+	assert.Len(t, register.Responses, 3)
+	assert.Contains(t, register.Responses, "201")
+	assert.Contains(t, register.Responses, "400")
+	assert.Contains(t, register.Responses, "409")
+
+	getProfile := doc.Paths["/users/{id}"]["get"]
+	assert.Len(t, getProfile.Responses, 2)
+	assert.Contains(t, getProfile.Responses, "200")
+	assert.Contains(t, getProfile.Responses, "404")
+
+	assert.Contains(t, doc.Components.Schemas, "UserProfile")
+	assert.Contains(t, doc.Components.Schemas, "UserRegisterCommand")
+	assert.Equal(t, []any{"id"}, doc.Components.Schemas["UserProfile"]["required"])
+}
+
+func TestGenerateOpenAPIDocument_UnsupportedMethod(t *testing.T) {
+	_, err := GenerateOpenAPIDocument(&System{SName: "unit test"}, []MisasSpecification{
+		&HTTPEndpoint{Nam: "unit_test", Method: "CONNECT", Path: "/"},
+	})
+	assert.Error(t, err)
+}
+
+func TestGenerateOpenAPIDocument_SystemVersion(t *testing.T) {
+	doc, err := GenerateOpenAPIDocument(&System{SName: "unit test", Version: "1.2.3"}, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), `"version": "1.2.3"`)
+}
+
+func TestGenerateOpenAPIDocument_ReadonlyFields(t *testing.T) {
+	output, err := GenerateOpenAPIDocument(&System{SName: "unit test"}, []MisasSpecification{
+		&Command{
+			Nam:  "user.register",
+			Desc: "Registers a user.",
+			Fields: []CommandField{
+				{Name: "emailAddress", Description: "Email address of the user.", Type: String},
+				{Name: "registeredBy", Description: "ID of the authenticated user.", Type: Identifier, Annotations: Annotations{GoReadonlyAnnotation}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+				Required   []string       `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(output, &doc))
+
+	command := doc.Components.Schemas["UserRegisterCommand"]
+	assert.Contains(t, command.Properties, "emailAddress")
+	assert.NotContains(t, command.Properties, "registeredBy")
+	assert.Equal(t, []string{"emailAddress"}, command.Required)
+}
+
+func TestGenerateOpenAPIDocument_QueryParameters(t *testing.T) {
+	output, err := GenerateOpenAPIDocument(&System{SName: "unit test"}, []MisasSpecification{
+		&Query{
+			Nam:  "user.list_logins",
+			Desc: "Returns the logins of a user.",
+			Fields: []QueryField{
+				{Name: "id", Description: "ID of the user.", Type: Identifier},
+				{Name: "limit", Description: "Maximum number of logins.", Type: Int},
+				{Name: "since", Type: DateTime, Nullable: true},
+				{Name: "requestedBy", Type: Identifier, Annotations: Annotations{GoReadonlyAnnotation}},
+			},
+		},
+		&HTTPEndpoint{
+			Nam:     "user.list_logins",
+			Method:  "GET",
+			Path:    "/users/{id}/logins",
+			Request: "user.list_logins",
+		},
+	})
+	assert.NoError(t, err)
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters  []map[string]any `json:"parameters"`
+			RequestBody any              `json:"requestBody"`
+		} `json:"paths"`
+	}
+	assert.NoError(t, json.Unmarshal(output, &doc))
+
+	operation := doc.Paths["/users/{id}/logins"]["get"]
+	assert.Nil(t, operation.RequestBody)
+	assert.Equal(t, []map[string]any{
+		{"name": "id", "in": "path", "required": true, "schema": map[string]any{"type": "string"}},
+		{"name": "limit", "in": "query", "required": true, "schema": map[string]any{"type": "integer", "format": "int64"}, "description": "Maximum number of logins."},
+		{"name": "since", "in": "query", "required": false, "schema": map[string]any{"type": "string", "format": "date-time"}},
+	}, operation.Parameters)
+}
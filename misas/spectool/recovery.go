@@ -0,0 +1,50 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+)
+
+// PanicRecoveringProcessor is a decorator of specter.SpecificationProcessor converting panics occurring
+// while processing specifications into errors, so that the tool fails with a clean error instead of a stack trace.
+type PanicRecoveringProcessor struct {
+	Processor specter.SpecificationProcessor
+}
+
+// RecoverProcessorPanics decorates a list of processors with PanicRecoveringProcessor.
+func RecoverProcessorPanics(processors ...specter.SpecificationProcessor) []specter.SpecificationProcessor {
+	var decorated []specter.SpecificationProcessor
+	for _, p := range processors {
+		decorated = append(decorated, PanicRecoveringProcessor{Processor: p})
+	}
+	return decorated
+}
+
+func (p PanicRecoveringProcessor) Name() string {
+	return p.Processor.Name()
+}
+
+func (p PanicRecoveringProcessor) Process(ctx specter.ProcessingContext) (outputs []specter.ProcessingOutput, err error) {
+	defer recoverAsError(&err, "processor \"%s\" panicked", p.Processor.Name())
+
+	return p.Processor.Process(ctx)
+}
+
+// recoverAsError recovers from a panic and converts it into an error assigned to err.
+// It must be called directly by a deferred statement.
+func recoverAsError(err *error, format string, args ...any) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	var cause error
+	switch v := r.(type) {
+	case error:
+		cause = v
+	default:
+		cause = errors.Errorf("%v", v)
+	}
+
+	*err = errors.Wrapf(cause, format, args...)
+}
@@ -0,0 +1,42 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type panickingProcessor struct {
+}
+
+func (p panickingProcessor) Name() string {
+	return "panicking-processor"
+}
+
+func (p panickingProcessor) Process(specter.ProcessingContext) ([]specter.ProcessingOutput, error) {
+	panic("something went wrong")
+}
+
+func TestPanicRecoveringProcessor_Process(t *testing.T) {
+	processor := RecoverProcessorPanics(panickingProcessor{})[0]
+
+	assert.Equal(t, "panicking-processor", processor.Name())
+
+	var outputs []specter.ProcessingOutput
+	var err error
+	assert.NotPanics(t, func() {
+		outputs, err = processor.Process(specter.ProcessingContext{})
+	})
+	assert.Nil(t, outputs)
+	assert.EqualError(t, err, "processor \"panicking-processor\" panicked: something went wrong")
+}
+
+func TestRunGoSpecHandler(t *testing.T) {
+	strct := &Struct{Nam: "user.profile", Desc: "Profile of a user.", Src: testSource}
+
+	err := runGoSpecHandler(newTestGoProcessingContext(), func(ctx *GoProcessingContext, s MisasSpecification) error {
+		panic("something went wrong")
+	}, strct)
+
+	assert.EqualError(t, err, "failed generating go code for struct user.profile: something went wrong")
+}
@@ -1,10 +1,14 @@
 package spectool
 
 import (
+	"fmt"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/iancoleman/strcase"
 	"github.com/morebec/specter"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/gocty"
+	"go/token"
+	"strings"
 )
 
 type MetadataEntry struct {
@@ -84,3 +88,126 @@ func (a Annotations) Has(value string) bool {
 
 	return false
 }
+
+// Value returns the value of an annotation of the form "key=value" and indicates if it was found.
+func (a Annotations) Value(key string) (string, bool) {
+	for _, v := range a {
+		if k, value, found := strings.Cut(v, "="); found && k == key {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// SpecificationField represents the information common to the fields of structs, commands, queries and events.
+type SpecificationField struct {
+	Name        string
+	Description string
+	Type        DataType
+	Nullable    bool
+	Annotations Annotations
+}
+
+// FieldsOfSpecification returns the fields of a struct, command, query or event specification.
+// For other types of specifications, returns false.
+func FieldsOfSpecification(s MisasSpecification) ([]SpecificationField, bool) {
+	var fields []SpecificationField
+	switch spec := s.(type) {
+	case *Struct:
+		for _, f := range spec.Fields {
+			fields = append(fields, SpecificationField{Name: f.Name, Description: f.Description, Type: f.Type, Nullable: f.Nullable, Annotations: f.Annotations})
+		}
+	case *Command:
+		for _, f := range spec.Fields {
+			fields = append(fields, SpecificationField{Name: f.Name, Description: f.Description, Type: f.Type, Nullable: f.Nullable, Annotations: f.Annotations})
+		}
+	case *Query:
+		for _, f := range spec.Fields {
+			fields = append(fields, SpecificationField{Name: f.Name, Description: f.Description, Type: f.Type, Nullable: f.Nullable, Annotations: f.Annotations})
+		}
+	case *Event:
+		for _, f := range spec.Fields {
+			fields = append(fields, SpecificationField{Name: f.Name, Description: f.Description, Type: f.Type, Nullable: f.Nullable, Annotations: f.Annotations})
+		}
+	default:
+		return nil, false
+	}
+
+	return fields, true
+}
+
+// FieldsShouldBeUnique returns a linter reporting an error for every struct, command, query or event having multiple fields
+// with the same name. Names are compared once normalized to the Go name they would generate, e.g. "email_address" and "emailAddress".
+func FieldsShouldBeUnique() specter.SpecificationLinterFunc {
+	return func(specs specter.SpecificationGroup) specter.LinterResultSet {
+		var result specter.LinterResultSet
+		for _, s := range specs {
+			ms, ok := s.(MisasSpecification)
+			if !ok {
+				continue
+			}
+
+			fields, ok := FieldsOfSpecification(ms)
+			if !ok {
+				continue
+			}
+
+			seen := map[string]struct{}{}
+			for _, f := range fields {
+				normalized := GoFieldName(f.Name, f.Annotations)
+				if _, found := seen[normalized]; found {
+					result = append(result, specter.LinterResult{
+						Severity: specter.ErrorSeverity,
+						Message:  fmt.Sprintf("%s \"%s\" has a duplicate field \"%s\" at \"%s\"", s.Type(), s.Name(), f.Name, s.Source().Location),
+					})
+					continue
+				}
+				seen[normalized] = struct{}{}
+			}
+		}
+
+		return result
+	}
+}
+
+// FieldNamesMustProduceValidGoIdentifiers returns a linter reporting an error for every field of a struct, command, query or
+// event whose name does not convert to a non-empty and valid exported Go identifier, such as names starting with a digit
+// or only made of symbols, which would otherwise generate code that does not compile or silently drop characters.
+// Fields whose Go name is overridden with the GoFieldNameAnnotation are validated against the override instead.
+func FieldNamesMustProduceValidGoIdentifiers() specter.SpecificationLinterFunc {
+	return func(specs specter.SpecificationGroup) specter.LinterResultSet {
+		var result specter.LinterResultSet
+		for _, s := range specs {
+			ms, ok := s.(MisasSpecification)
+			if !ok {
+				continue
+			}
+
+			fields, ok := FieldsOfSpecification(ms)
+			if !ok {
+				continue
+			}
+
+			for _, f := range fields {
+				goName := ExportedGoName(f.Name)
+				camelName := strcase.ToCamel(f.Name)
+				if override, found := f.Annotations.Value(GoFieldNameAnnotation); found {
+					goName, camelName = override, override
+				}
+				if goName != "" && token.IsIdentifier(camelName) && token.IsExported(goName) && strings.EqualFold(goName, camelName) {
+					continue
+				}
+				result = append(result, specter.LinterResult{
+					Severity: specter.ErrorSeverity,
+					Message: fmt.Sprintf(
+						"%s \"%s\" has a field \"%s\" that does not produce a valid Go identifier (got \"%s\") at \"%s\"",
+						s.Type(), s.Name(), f.Name, goName, s.Source().Location,
+					),
+				})
+			}
+		}
+
+		return result
+	}
+}
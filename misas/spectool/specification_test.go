@@ -0,0 +1,145 @@
+package spectool
+
+import (
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFieldsShouldBeUnique(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         specter.Specification
+		wantMessages []string
+	}{
+		{
+			name: "unique fields",
+			spec: &Struct{
+				Nam:  "user.address",
+				Desc: "Address of a user.",
+				Fields: []StructField{
+					{Name: "city", Type: String},
+					{Name: "street", Type: String},
+				},
+				Src: testSource,
+			},
+		},
+		{
+			name: "duplicate field",
+			spec: &Command{
+				Nam:  "user.register",
+				Desc: "Registers a user.",
+				Fields: []CommandField{
+					{Name: "username", Type: String},
+					{Name: "username", Type: String},
+				},
+				Src: testSource,
+			},
+			wantMessages: []string{`command "user.register" has a duplicate field "username"`},
+		},
+		{
+			name: "duplicate field after normalization",
+			spec: &Event{
+				Nam:  "user.registered",
+				Desc: "Indicates that a user was registered.",
+				Fields: []EventField{
+					{Name: "emailAddress", Type: String},
+					{Name: "email_address", Type: String},
+				},
+				Src: testSource,
+			},
+			wantMessages: []string{`event "user.registered" has a duplicate field "email_address"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := FieldsShouldBeUnique()(specter.SpecificationGroup{tt.spec})
+
+			assert.Len(t, results, len(tt.wantMessages))
+			for i, r := range results {
+				assert.Equal(t, specter.ErrorSeverity, r.Severity)
+				assert.Contains(t, r.Message, tt.wantMessages[i])
+			}
+		})
+	}
+}
+
+func TestFieldNamesMustProduceValidGoIdentifiers(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         specter.Specification
+		wantMessages []string
+	}{
+		{
+			name: "valid field names",
+			spec: &Struct{
+				Nam:  "user.address",
+				Desc: "Address of a user.",
+				Fields: []StructField{
+					{Name: "city", Type: String},
+					{Name: "street_name", Type: String},
+					{Name: "line2", Type: String},
+				},
+				Src: testSource,
+			},
+		},
+		{
+			name: "digit leading field name",
+			spec: &Command{
+				Nam:  "user.enable_two_factor",
+				Desc: "Enables two factor authentication.",
+				Fields: []CommandField{
+					{Name: "2faCode", Type: String},
+				},
+				Src: testSource,
+			},
+			wantMessages: []string{`command "user.enable_two_factor" has a field "2faCode" that does not produce a valid Go identifier (got "FaCode")`},
+		},
+		{
+			name: "field name overridden with a valid identifier",
+			spec: &Struct{
+				Nam:  "user.address",
+				Desc: "Address of a user.",
+				Fields: []StructField{
+					{Name: "2ndLine", Type: String, Annotations: Annotations{GoFieldNameAnnotation + "=SecondLine"}},
+				},
+				Src: testSource,
+			},
+		},
+		{
+			name: "field name overridden with an invalid identifier",
+			spec: &Struct{
+				Nam:  "user.address",
+				Desc: "Address of a user.",
+				Fields: []StructField{
+					{Name: "city", Type: String, Annotations: Annotations{GoFieldNameAnnotation + "=city name"}},
+				},
+				Src: testSource,
+			},
+			wantMessages: []string{`struct "user.address" has a field "city" that does not produce a valid Go identifier (got "city name")`},
+		},
+		{
+			name: "symbol only field name",
+			spec: &Query{
+				Nam:  "user.find",
+				Desc: "Finds a user.",
+				Fields: []QueryField{
+					{Name: "$$$", Type: String},
+				},
+				Src: testSource,
+			},
+			wantMessages: []string{`query "user.find" has a field "$$$" that does not produce a valid Go identifier (got "")`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := FieldNamesMustProduceValidGoIdentifiers()(specter.SpecificationGroup{tt.spec})
+
+			assert.Len(t, results, len(tt.wantMessages))
+			for i, r := range results {
+				assert.Equal(t, specter.ErrorSeverity, r.Severity)
+				assert.Contains(t, r.Message, tt.wantMessages[i])
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package spectool
+
+import (
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectionAnnotation indicates that a struct is a read model projected in a table of a SQL database.
+const ProjectionAnnotation = "projection"
+
+// PrimaryKeyAnnotation indicates that a field of a projection struct is the primary key of its table.
+const PrimaryKeyAnnotation = "primaryKey"
+
+// sqlMigrationsDirectory is the name of the directory in which migrations are generated, relative to the specification of the projection.
+const sqlMigrationsDirectory = "migrations"
+
+// SQLMigrationGenerator is a specification processor responsible for generating the SQL migrations creating the tables
+// of the structs annotated as projections, with one column per field and an index per indexable field.
+// The name of the table defaults to the snake case name of the struct and can be changed with the "gen:sql:table" metadata.
+type SQLMigrationGenerator struct {
+}
+
+func (g SQLMigrationGenerator) Name() string {
+	return "sql-migration-generator"
+}
+
+func (g SQLMigrationGenerator) Process(ctx specter.ProcessingContext) ([]specter.ProcessingOutput, error) {
+	enums := map[DataType]*Enum{}
+	for _, s := range specter.SpecificationGroup(ctx.DependencyGraph).SelectType((&Enum{}).Type()) {
+		enums[DataType(s.Name())] = s.(*Enum)
+	}
+
+	var outputs []specter.ProcessingOutput
+	for _, s := range specter.SpecificationGroup(ctx.DependencyGraph).SelectType((&Struct{}).Type()) {
+		strct := s.(*Struct)
+		if !strct.Annotations().Has(ProjectionAnnotation) {
+			continue
+		}
+
+		ctx.Logger.Info(fmt.Sprintf("Generating SQL migration for %s ...", strct.Name()))
+		migration, err := GenerateSQLMigration(strct, enums)
+		if err != nil {
+			return nil, err
+		}
+
+		filePath := filepath.Join(filepath.Dir(strct.Source().Location), sqlMigrationsDirectory, fmt.Sprintf("create_%s.sql", SQLTableName(strct)))
+		outputs = append(outputs, specter.ProcessingOutput{
+			Name: filePath,
+			Value: specter.FileOutput{
+				Path: filePath,
+				Data: []byte(migration),
+				Mode: os.ModePerm,
+			},
+		})
+	}
+
+	if len(outputs) != 0 {
+		ctx.Logger.Info("SQL migrations generated successfully.")
+	}
+
+	return outputs, nil
+}
+
+// SQLTableName returns the name of the table of a projection struct.
+func SQLTableName(s *Struct) string {
+	defaultName := strcase.ToSnake(strings.ReplaceAll(string(s.Name()), ".", "_"))
+	return s.Metadata().GetOrDefault("gen:sql:table", defaultName).AsString()
+}
+
+// SQLColumnName returns the name of the column of a field of a projection struct.
+func SQLColumnName(f StructField) string {
+	return strcase.ToSnake(f.Name)
+}
+
+// GenerateSQLMigration generates the DDL statements creating the table of a projection struct and the indexes of its indexable fields.
+// Enums are used to resolve the column type of fields typed with an enum to the one of its base type.
+func GenerateSQLMigration(s *Struct, enums map[DataType]*Enum) (string, error) {
+	table := SQLTableName(s)
+
+	var columns []string
+	var indexes []string
+	var primaryKeys []string
+	for _, f := range s.Fields {
+		column := SQLColumnName(f)
+		columnType, err := ResolveSQLColumnType(f.Type, enums)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed generating SQL migration for %s %s", s.Type(), s.Name())
+		}
+
+		definition := fmt.Sprintf("    %s %s", quoteSQLIdentifier(column), columnType)
+		if !f.Nullable {
+			definition += " NOT NULL"
+		}
+		columns = append(columns, definition)
+
+		if f.Annotations.Has(PrimaryKeyAnnotation) {
+			primaryKeys = append(primaryKeys, quoteSQLIdentifier(column))
+		}
+
+		if f.Annotations.Has(IndexableAnnotation) {
+			indexes = append(indexes, fmt.Sprintf(
+				"CREATE INDEX IF NOT EXISTS %s ON %s (%s);",
+				quoteSQLIdentifier(fmt.Sprintf("%s_%s_idx", table, column)),
+				quoteSQLIdentifier(table),
+				quoteSQLIdentifier(column),
+			))
+		}
+	}
+
+	if len(columns) == 0 {
+		return "", errors.Errorf("failed generating SQL migration for %s %s, a projection must have at least one field", s.Type(), s.Name())
+	}
+
+	if len(primaryKeys) != 0 {
+		columns = append(columns, fmt.Sprintf("    PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+	}
+
+	migration := fmt.Sprintf("-- Code generated by spectool. DO NOT EDIT.\n-- %s\n\nCREATE TABLE IF NOT EXISTS %s (\n%s\n);\n",
+		strings.TrimSpace(s.Description()),
+		quoteSQLIdentifier(table),
+		strings.Join(columns, ",\n"),
+	)
+	if len(indexes) != 0 {
+		migration += "\n" + strings.Join(indexes, "\n") + "\n"
+	}
+
+	return migration, nil
+}
+
+// ResolveSQLColumnType resolves the PostgreSQL column type corresponding to a DataType.
+// Containers, as well as user defined types other than enums, are stored as JSON.
+func ResolveSQLColumnType(t DataType, enums map[DataType]*Enum) (string, error) {
+	switch t {
+	case Identifier, String:
+		return "TEXT", nil
+	case Char:
+		return "CHAR(1)", nil
+	case Bool:
+		return "BOOLEAN", nil
+	case Int, Duration:
+		return "BIGINT", nil
+	case Float:
+		return "DOUBLE PRECISION", nil
+	case Date:
+		return "DATE", nil
+	case DateTime:
+		return "TIMESTAMP WITH TIME ZONE", nil
+	case Any:
+		return "JSONB", nil
+	case Null:
+		return "", errors.Errorf("Could not resolve a SQL column type for \"%s\"", t)
+	}
+
+	if t.IsContainer() {
+		return "JSONB", nil
+	}
+
+	if enum, found := enums[t]; found {
+		return ResolveSQLColumnType(enum.BaseType, enums)
+	}
+
+	if t.IsUserDefined() {
+		return "JSONB", nil
+	}
+
+	return "", errors.Errorf("Could not resolve a SQL column type for \"%s\"", t)
+}
+
+// quoteSQLIdentifier quotes an identifier so that it can be used in a SQL statement.
+func quoteSQLIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,92 @@
+package spectool
+
+import (
+	"bytes"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/morebec/specter"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+	"testing"
+)
+
+func TestGenerateSQLMigration(t *testing.T) {
+	enums := map[DataType]*Enum{
+		"user.status": {Nam: "user.status", Desc: "Status of a user.", BaseType: String},
+	}
+
+	migration, err := GenerateSQLMigration(&Struct{
+		Nam:  "user.profile",
+		Desc: "Profile of a user.",
+		Fields: []StructField{
+			{Name: "id", Description: "ID of the user.", Type: Identifier, Annotations: Annotations{PrimaryKeyAnnotation}},
+			{Name: "emailAddress", Description: "Email address of the user.", Type: String, Annotations: Annotations{IndexableAnnotation}},
+			{Name: "status", Description: "Status of the user.", Type: "user.status", Annotations: Annotations{IndexableAnnotation}},
+			{Name: "nbLogins", Description: "Number of logins of the user.", Type: Int},
+			{Name: "registeredAt", Description: "Date at which the user registered.", Type: DateTime},
+			{Name: "nickname", Description: "Nickname of the user.", Type: String, Nullable: true},
+			{Name: "roles", Description: "Roles of the user.", Type: "[]string"},
+		},
+		Annots: Annotations{ProjectionAnnotation},
+		Src:    testSource,
+	}, enums)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `-- Code generated by spectool. DO NOT EDIT.
+-- Profile of a user.
+
+CREATE TABLE IF NOT EXISTS "user_profile" (
+    "id" TEXT NOT NULL,
+    "email_address" TEXT NOT NULL,
+    "status" TEXT NOT NULL,
+    "nb_logins" BIGINT NOT NULL,
+    "registered_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "nickname" TEXT,
+    "roles" JSONB NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "user_profile_email_address_idx" ON "user_profile" ("email_address");
+CREATE INDEX IF NOT EXISTS "user_profile_status_idx" ON "user_profile" ("status");
+`, migration)
+}
+
+func TestGenerateSQLMigration_UnresolvableFieldType(t *testing.T) {
+	_, err := GenerateSQLMigration(&Struct{
+		Nam:    "user.profile",
+		Desc:   "Profile of a user.",
+		Fields: []StructField{{Name: "nothing", Description: "Nothing.", Type: Null}},
+		Annots: Annotations{ProjectionAnnotation},
+		Src:    testSource,
+	}, nil)
+
+	assert.Error(t, err)
+}
+
+func TestSQLMigrationGenerator_Process(t *testing.T) {
+	projection := &Struct{
+		Nam:    "user.profile",
+		Desc:   "Profile of a user.",
+		Fields: []StructField{{Name: "id", Description: "ID of the user.", Type: Identifier}},
+		Annots: Annotations{ProjectionAnnotation},
+		Meta: Metadata{
+			{Key: "gen:sql:table", Value: &hcl.Attribute{Expr: hcl.StaticExpr(cty.StringVal("profiles"), hcl.Range{})}},
+		},
+		Src: testSource,
+	}
+	notAProjection := &Struct{
+		Nam:    "user.address",
+		Desc:   "Address of a user.",
+		Fields: []StructField{{Name: "city", Description: "City of the address.", Type: String}},
+		Src:    testSource,
+	}
+
+	outputs, err := SQLMigrationGenerator{}.Process(specter.ProcessingContext{
+		DependencyGraph: specter.ResolvedDependencies{projection, notAProjection},
+		Logger:          specter.NewColoredOutputLogger(specter.ColoredOutputLoggerConfig{Writer: &bytes.Buffer{}}),
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, outputs, 1) {
+		assert.Equal(t, "/unit/migrations/create_profiles.sql", outputs[0].Name)
+		assert.Contains(t, string(outputs[0].Value.(specter.FileOutput).Data), `CREATE TABLE IF NOT EXISTS "profiles" (`)
+	}
+}
@@ -6,7 +6,12 @@ type System struct {
 	SName        string   `hcl:"name,label"`
 	SDescription string   `hcl:"description"`
 	SpecSources  []string `hcl:"sources"`
-	Src          specter.Source
+
+	// Version of the specifications of the system, e.g. "1.2.0".
+	// When provided, a ModuleVersion constant is generated in the package of the system.
+	Version string `hcl:"version,optional"`
+
+	Src specter.Source
 
 	Annots Annotations `hcl:"annotations,optional"`
 	Meta   Metadata    `hcl:"meta,block"`
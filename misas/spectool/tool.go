@@ -2,11 +2,52 @@ package spectool
 
 import (
 	"github.com/morebec/specter"
+	"github.com/pkg/errors"
 	"os"
+	"path/filepath"
 )
 
-func New(mode specter.ExecutionMode) *specter.Specter {
-	return specter.New(
+// Tool is the specter pipeline of the spectool.
+// It checks the specifications for dependency cycles before resolving their dependencies,
+// so that a cycle is reported with the names of the specifications forming it.
+type Tool struct {
+	*specter.Specter
+}
+
+// Options of the spectool.
+type Options struct {
+	// Incremental indicates if the Go code is generated incrementally, see GoCodeGenerator.Incremental.
+	Incremental bool
+
+	// Linters run in addition to the default ones.
+	Linters []specter.SpecificationLinter
+}
+
+type Option func(o *Options)
+
+// WithIncrementalGeneration allows generating only the Go files whose input specifications changed since the last run.
+// Since the files which are not generated must be kept, the output files are written without a registry, which means
+// the files of deleted specifications are not cleaned up.
+func WithIncrementalGeneration() Option {
+	return func(o *Options) {
+		o.Incremental = true
+	}
+}
+
+// WithLinters allows running additional linters, such as the ones not enabled by default like CommandsShouldUseImperativeNaming.
+func WithLinters(linters ...specter.SpecificationLinter) Option {
+	return func(o *Options) {
+		o.Linters = append(o.Linters, linters...)
+	}
+}
+
+func New(mode specter.ExecutionMode, opts ...Option) *Tool {
+	options := Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &Tool{Specter: specter.New(
 		specter.WithLogger(specter.NewColoredOutputLogger(specter.ColoredOutputLoggerConfig{
 			EnableColors: true,
 			Writer:       os.Stdout,
@@ -22,11 +63,93 @@ func New(mode specter.ExecutionMode) *specter.Specter {
 			specter.SpecificationsMustHaveUniqueNames(),
 
 			EventsMustHaveDateTimeField(),
+			EventsShouldBeReferenced(),
+			FieldsShouldBeUnique(),
+			FieldNamesMustProduceValidGoIdentifiers(),
+			HTTPEndpointRequestMustBeCommandOrQuery(),
+			MappingTargetMustBeCommandOrEvent(),
 		),
-		specter.WithProcessors(GoCodeGenerator{}),
-		specter.WithOutputProcessors(specter.NewWriteFilesProcessor(specter.WriteFileOutputsProcessorConfig{
-			UseRegistry: true,
+		specter.WithLinters(options.Linters...),
+		specter.WithProcessors(RecoverProcessorPanics(GoCodeGenerator{Incremental: options.Incremental}, GoClientGenerator{}, TypeScriptCodeGenerator{}, OpenAPIGenerator{}, SQLMigrationGenerator{})...),
+		specter.WithOutputProcessors(outputDirectoriesCreator{}, specter.NewWriteFilesProcessor(specter.WriteFileOutputsProcessorConfig{
+			// The registry deletes the files of the previous run, including the ones not generated by an incremental run.
+			UseRegistry: !options.Incremental,
 		})),
 		specter.WithExecutionMode(mode),
-	)
+	)}
+}
+
+// Run the pipeline from start to finish.
+func (t *Tool) Run(sourceLocations []string) error {
+	sources, err := t.LoadSources(sourceLocations)
+	if err != nil {
+		return t.fail(err, "failed loading sources")
+	}
+
+	specifications, err := t.LoadSpecifications(sources)
+	if err != nil {
+		return t.fail(err, "failed loading specifications")
+	}
+
+	if err := CheckDependencyCycles(specifications); err != nil {
+		return t.fail(err, "dependency resolution failed")
+	}
+
+	deps, err := t.ResolveDependencies(specifications)
+	if err != nil {
+		return t.fail(err, "dependency resolution failed")
+	}
+
+	if lr := t.LintSpecifications(deps); lr.HasErrors() {
+		return errors.Wrap(lr.Errors(), "linting errors encountered")
+	}
+	if t.ExecutionMode == specter.LintMode {
+		return nil
+	}
+
+	outputs, err := t.ProcessSpecifications(deps)
+	if err != nil {
+		return t.fail(err, "failed processing specifications")
+	}
+	if t.ExecutionMode == specter.PreviewMode {
+		return nil
+	}
+
+	if err := t.ProcessOutputs(deps, outputs); err != nil {
+		return t.fail(err, "failed processing outputs")
+	}
+
+	t.Logger.Success("\nProcessing completed successfully.")
+	return nil
+}
+
+// fail logs an error that stopped the pipeline and returns it wrapped with a message.
+func (t *Tool) fail(err error, message string) error {
+	err = errors.Wrap(err, message)
+	t.Logger.Error(err.Error())
+	return err
+}
+
+// outputDirectoriesCreator is an output processor creating the missing directories of the file outputs,
+// such as the one of the SQL migrations, since the specter.WriteFileOutputsProcessor expects them to exist.
+type outputDirectoriesCreator struct {
+}
+
+func (c outputDirectoriesCreator) Name() string {
+	return "output-directories-creator"
+}
+
+func (c outputDirectoriesCreator) Process(ctx specter.OutputProcessingContext) error {
+	for _, o := range ctx.Outputs {
+		file, ok := o.Value.(specter.FileOutput)
+		if !ok {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file.Path), os.ModePerm); err != nil {
+			return errors.Wrapf(err, "failed creating directory of output %s", file.Path)
+		}
+	}
+
+	return nil
 }
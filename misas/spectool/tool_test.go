@@ -2,12 +2,171 @@ package spectool
 
 import (
 	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestSpecificationTool(t *testing.T) {
+	// The specifications are copied to a temporary directory so that running the tool leaves the sources untouched.
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	root := t.TempDir()
+	dir := filepath.Join(root, "test_data")
+	assert.NoError(t, os.Mkdir(dir, os.ModePerm))
+	for _, name := range []string{"go.mod", "system.spec.hcl"} {
+		data, err := os.ReadFile(filepath.Join("test_data", name))
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), data, os.ModePerm))
+	}
+
+	// The registry of the generated files is written to the working directory.
+	assert.NoError(t, os.Chdir(root))
+	defer func() {
+		assert.NoError(t, os.Chdir(wd))
+	}()
+
 	tool := New(specter.FullMode)
 	if err := tool.Run([]string{"./test_data"}); err != nil {
 		panic(err)
 	}
+	assert.FileExists(t, filepath.Join(dir, "user_generated.go"))
+}
+
+func TestTool_Run_SQLMigration(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	root := t.TempDir()
+	dir := filepath.Join(root, "order")
+	assert.NoError(t, os.Mkdir(dir, os.ModePerm))
+	spec := `
+struct "order.view" {
+  description = "View of an order."
+  annotations = ["projection"]
+
+  field "id" {
+    description = "ID of the order."
+    type = "identifier"
+    annotations = ["primaryKey"]
+  }
+}
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "order.spec.hcl"), []byte(spec), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/morebec/order\n\ngo 1.18\n"), os.ModePerm))
+
+	// The registry of the generated files is written to the working directory.
+	assert.NoError(t, os.Chdir(root))
+	defer func() {
+		assert.NoError(t, os.Chdir(wd))
+	}()
+
+	assert.NoError(t, New(specter.FullMode).Run([]string{"./order"}))
+
+	migration, err := os.ReadFile(filepath.Join(dir, "migrations", "create_order_view.sql"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(migration), `CREATE TABLE IF NOT EXISTS "order_view" (`)
+}
+
+func TestTool_Run_HTTPEndpoint(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	root := t.TempDir()
+	dir := filepath.Join(root, "user")
+	assert.NoError(t, os.Mkdir(dir, os.ModePerm))
+	spec := `
+system "user" {
+  description = "System of the users."
+  sources = ["."]
+
+  meta "gen:go:client" {
+    value = true
+  }
+}
+
+query "user.get_user" {
+  description = "Returns a user."
+
+  field "id" {
+    description = "ID of the user."
+    type = "identifier"
+  }
+}
+
+http_endpoint "user.get" "GET" "/users/{id}" {
+  description = "Returns a user."
+  request = "user.get_user"
+
+  responses {
+    success {
+      statusCode = 200
+      description = "The user."
+    }
+
+    failure {
+      statusCode = 404
+      description = "The user was not found."
+      errorType = "user.not_found"
+    }
+  }
+}
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "user.spec.hcl"), []byte(spec), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/morebec/user\n\ngo 1.18\n"), os.ModePerm))
+
+	// The registry of the generated files is written to the working directory.
+	assert.NoError(t, os.Chdir(root))
+	defer func() {
+		assert.NoError(t, os.Chdir(wd))
+	}()
+
+	assert.NoError(t, New(specter.FullMode).Run([]string{"./user"}))
+
+	generated, err := filepath.Glob(filepath.Join(dir, "*_generated.go"))
+	assert.NoError(t, err)
+	code := ""
+	for _, f := range generated {
+		content, err := os.ReadFile(f)
+		assert.NoError(t, err)
+		code += string(content)
+	}
+	assert.Contains(t, code, "func userGet(r chi.Router, bus query.Bus) {")
+	assert.Contains(t, code, `input.ID = chi.URLParam(r, "id")`)
+	assert.Contains(t, code, `case "user.not_found":`)
+	assert.Contains(t, code, "func (c *Client) UserGet(ctx context.Context, id string, request UserGetUserQuery) (any, error) {")
+}
+
+func TestTool_Run_DependencyCycle(t *testing.T) {
+	dir := t.TempDir()
+	spec := `
+struct "user.profile" {
+  description = "Profile of a user."
+
+  field "account" {
+    description = "Account of the user."
+    type = "user.account"
+  }
+}
+
+struct "user.account" {
+  description = "Account of a user."
+
+  field "profile" {
+    description = "Profile of the user."
+    type = "user.profile"
+  }
+}
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "user.spec.hcl"), []byte(spec), os.ModePerm))
+
+	err := New(specter.LintMode).Run([]string{dir})
+
+	assert.Error(t, err)
+	assert.True(t, errors.As(err, &DependencyCycleError{}))
+	assert.Contains(t, err.Error(), "user.profile -> user.account -> user.profile")
+}
+
+func TestNew_WithLinters(t *testing.T) {
+	assert.Len(t, New(specter.LintMode, WithLinters(CommandsShouldUseImperativeNaming())).Linters, len(New(specter.LintMode).Linters)+1)
 }
@@ -0,0 +1,251 @@
+package spectool
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/morebec/specter"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TypeScriptCodeGenerator is a specification processor responsible for generating TypeScript type definitions from misas specifications.
+// It is enabled by setting the "gen:ts:fileName" metadata on the system specification, the path being relative to the system specification.
+type TypeScriptCodeGenerator struct {
+}
+
+func (g TypeScriptCodeGenerator) Name() string {
+	return "typescript-code-generator"
+}
+
+func (g TypeScriptCodeGenerator) Process(ctx specter.ProcessingContext) ([]specter.ProcessingOutput, error) {
+	// System specification
+	candidates := specter.SpecificationGroup(ctx.DependencyGraph).SelectType((&System{}).Type())
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	systemSpec := candidates[0].(*System)
+	if !systemSpec.Metadata().HasKey("gen:ts:fileName") {
+		return nil, nil
+	}
+
+	ctx.Logger.Info("Generating TypeScript code ...")
+	var specs []MisasSpecification
+	for _, s := range ctx.DependencyGraph {
+		if ms, ok := s.(MisasSpecification); ok {
+			specs = append(specs, ms)
+		}
+	}
+
+	code, err := GenerateTypeScriptCode(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := systemSpec.Metadata().GetOrDefault("gen:ts:fileName", "").AsString()
+	filePath := filepath.Join(filepath.Dir(systemSpec.Source().Location), fileName)
+	ctx.Logger.Info("TypeScript code generated successfully.")
+
+	return []specter.ProcessingOutput{
+		{
+			Name: filePath,
+			Value: specter.FileOutput{
+				Path: filePath,
+				Data: []byte(code),
+				Mode: os.ModePerm,
+			},
+		},
+	}, nil
+}
+
+// typeScriptTypeNameSuffixes indicates the suffix to add to the default TypeScript name of a type of specification.
+var typeScriptTypeNameSuffixes = map[specter.SpecificationType]string{
+	(&Command{}).Type(): "Command",
+	(&Query{}).Type():   "Query",
+	(&Event{}).Type():   "Event",
+}
+
+// TypeScriptTypeName returns the name of the TypeScript type generated for a specification.
+func TypeScriptTypeName(s MisasSpecification) string {
+	defaultName := strcase.ToCamel(string(s.Name())) + typeScriptTypeNameSuffixes[s.Type()]
+	return s.Metadata().GetOrDefault("gen:ts:name", defaultName).AsString()
+}
+
+// typeScriptSpec represents the data required to render the TypeScript code of a specification.
+type typeScriptSpec struct {
+	Kind        specter.SpecificationType
+	TypeName    string
+	Description string
+	Fields      []SpecificationField
+	Values      []string
+
+	// HTTP endpoints
+	Request  DataType
+	Response DataType
+}
+
+// GenerateTypeScriptCode generates TypeScript definitions for a list of specifications:
+// interfaces for structs, commands, queries and events, union literal types for enums and
+// request/response types for HTTP endpoints. Other types of specifications are ignored.
+// The definitions are sorted by type name so that the generated code does not depend on the order of the specifications.
+func GenerateTypeScriptCode(specs []MisasSpecification) (string, error) {
+	typeNames := map[DataType]string{}
+	for _, s := range specs {
+		typeNames[DataType(s.Name())] = TypeScriptTypeName(s)
+	}
+
+	var tsSpecs []typeScriptSpec
+	for _, s := range specs {
+		tsSpec := typeScriptSpec{
+			Kind:        s.Type(),
+			TypeName:    TypeScriptTypeName(s),
+			Description: s.Description(),
+		}
+		if fields, ok := FieldsOfSpecification(s); ok {
+			for _, f := range fields {
+				if !IsReadonlyRequestField(s, f) {
+					tsSpec.Fields = append(tsSpec.Fields, f)
+				}
+			}
+			tsSpecs = append(tsSpecs, tsSpec)
+			continue
+		}
+
+		switch spec := s.(type) {
+		case *Enum:
+			for _, v := range spec.Values {
+				value := fmt.Sprint(v.Value)
+				if spec.BaseType == String {
+					value = strconv.Quote(value)
+				}
+				tsSpec.Values = append(tsSpec.Values, value)
+			}
+		case *HTTPEndpoint:
+			tsSpec.Request = spec.Request
+			tsSpec.Response = spec.Responses.Success.Type
+		default:
+			continue
+		}
+		tsSpecs = append(tsSpecs, tsSpec)
+	}
+
+	sort.SliceStable(tsSpecs, func(i, j int) bool {
+		return tsSpecs[i].TypeName < tsSpecs[j].TypeName
+	})
+
+	resolveType := func(t DataType) (string, error) {
+		return ResolveTypeScriptType(t, typeNames)
+	}
+
+	tmpl, err := template.New("typescript").Funcs(map[string]any{
+		"AsTypeScriptType": func(t DataType) (string, error) {
+			if t == "" {
+				return "void", nil
+			}
+			return resolveType(t)
+		},
+		"AsJsonFieldName":        jsonFieldName,
+		"AsTypeScriptDocComment": FormatTypeScriptDocComment,
+	}).Parse(typeScriptTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "failed generating typescript code")
+	}
+
+	b := bytes.Buffer{}
+	if err := tmpl.Execute(&b, tsSpecs); err != nil {
+		return "", errors.Wrap(err, "failed generating typescript code")
+	}
+
+	return b.String(), nil
+}
+
+const typeScriptTemplate = `// IMPORTANT: This file was auto-generated by the morebec/spectool program. Do not edit manually.
+{{ range $spec := . }}
+{{ with AsTypeScriptDocComment $spec.Description "" }}{{ . }}
+{{ end -}}
+{{ if eq $spec.Kind "enum" -}}
+export type {{ $spec.TypeName }} = {{ range $i, $v := $spec.Values }}{{ if $i }} | {{ end }}{{ $v }}{{ end }};
+{{ else if eq $spec.Kind "http_endpoint" -}}
+export type {{ $spec.TypeName }}Request = {{ AsTypeScriptType $spec.Request }};
+
+export type {{ $spec.TypeName }}Response = {{ AsTypeScriptType $spec.Response }};
+{{ else -}}
+export interface {{ $spec.TypeName }} {
+{{- range $field := $spec.Fields }}
+{{ with AsTypeScriptDocComment $field.Description "  " }}{{ . }}
+{{ end }}  {{ AsJsonFieldName $field.Name }}: {{ AsTypeScriptType $field.Type }}{{ if $field.Nullable }} | null{{ end }};
+{{- end }}
+}
+{{ end }}{{ end }}`
+
+// ResolveTypeScriptType resolves the TypeScript type corresponding to a DataType.
+// User defined types are resolved using a map of internal type names to TypeScript type names.
+func ResolveTypeScriptType(t DataType, typeNames map[DataType]string) (string, error) {
+	switch t {
+	case Null:
+		return "null", nil
+	case Identifier, String, Char, Date, DateTime:
+		return "string", nil
+	case Int, Float, Duration:
+		return "number", nil
+	case Bool:
+		return "boolean", nil
+	case Any:
+		return "any", nil
+	}
+
+	if t.IsMap() {
+		info := t.ContainerInfo()
+		keyType, err := ResolveTypeScriptType(info.KeyType, typeNames)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed resolving key of container type %s", t)
+		}
+		valueType, err := ResolveTypeScriptType(info.ValueType, typeNames)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed resolving container type %s", t)
+		}
+		return fmt.Sprintf("Record<%s, %s>", keyType, valueType), nil
+	}
+
+	if t.IsArray() {
+		valueType, err := ResolveTypeScriptType(t.ContainerInfo().ValueType, typeNames)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed resolving container type %s", t)
+		}
+		return fmt.Sprintf("Array<%s>", valueType), nil
+	}
+
+	if name, found := typeNames[t]; found {
+		return name, nil
+	}
+
+	return "", errors.Errorf("Could not resolve a TypeScript type for \"%s\"", t)
+}
+
+// FormatTypeScriptDocComment formats a description as a TypeScript doc comment indented with a given prefix.
+// An empty description results in an empty string.
+func FormatTypeScriptDocComment(description string, indent string) string {
+	description = strings.TrimSpace(strings.ReplaceAll(description, "\r\n", "\n"))
+	if description == "" {
+		return ""
+	}
+
+	comment := indent + "/**\n"
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			comment += indent + " *\n"
+			continue
+		}
+		comment += indent + " * " + line + "\n"
+	}
+	comment += indent + " */"
+
+	return comment
+}
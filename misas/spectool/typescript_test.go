@@ -0,0 +1,141 @@
+package spectool
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypeScriptCode(t *testing.T) {
+	code, err := GenerateTypeScriptCode([]MisasSpecification{
+		&Enum{
+			Nam:      "user.status",
+			Desc:     "Status of a user.",
+			BaseType: String,
+			Values: []EnumValue{
+				{Name: "active", Value: "ACTIVE"},
+				{Name: "disabled", Value: "DISABLED"},
+			},
+		},
+		&Struct{
+			Nam:  "user.profile",
+			Desc: "Profile of a user.",
+			Fields: []StructField{
+				{Name: "id", Description: "ID of the user.", Type: Identifier},
+				{Name: "displayName", Description: "Display name of the user.\nShown publicly.", Type: String, Nullable: true},
+				{Name: "status", Description: "Status of the user.", Type: "user.status"},
+				{Name: "tags", Description: "Tags of the user.", Type: "[]string"},
+				{Name: "scores", Description: "Scores of the user.", Type: "map[string]int"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Contains(t, code, `/**
+ * Status of a user.
+ */
+export type UserStatus = "ACTIVE" | "DISABLED";`)
+
+	assert.Contains(t, code, `/**
+ * Profile of a user.
+ */
+export interface UserProfile {
+  /**
+   * ID of the user.
+   */
+  id: string;
+  /**
+   * Display name of the user.
+   * Shown publicly.
+   */
+  displayName: string | null;
+  /**
+   * Status of the user.
+   */
+  status: UserStatus;
+  /**
+   * Tags of the user.
+   */
+  tags: Array<string>;
+  /**
+   * Scores of the user.
+   */
+  scores: Record<string, number>;
+}`)
+}
+
+func TestGenerateTypeScriptCode_HTTPEndpoint(t *testing.T) {
+	code, err := GenerateTypeScriptCode([]MisasSpecification{
+		&Command{Nam: "user.register", Desc: "Registers a user."},
+		&HTTPEndpoint{
+			Nam:     "register_user",
+			Method:  "POST",
+			Path:    "/users",
+			Desc:    "Registers a user.",
+			Request: "user.register",
+			Responses: HTTPEndpointResponses{
+				Success: HTTPEndpointSuccessResponse{StatusCode: 200, Type: Identifier},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Contains(t, code, "export interface UserRegisterCommand {\n}")
+	assert.Contains(t, code, "export type RegisterUserRequest = UserRegisterCommand;")
+	assert.Contains(t, code, "export type RegisterUserResponse = string;")
+}
+
+func TestGenerateTypeScriptCode_UnresolvedType(t *testing.T) {
+	_, err := GenerateTypeScriptCode([]MisasSpecification{
+		&Struct{
+			Nam:    "user.profile",
+			Desc:   "Profile of a user.",
+			Fields: []StructField{{Name: "address", Description: "Address of the user.", Type: "user.address"}},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestGenerateTypeScriptCode_ReadonlyFields(t *testing.T) {
+	readonly := Annotations{GoReadonlyAnnotation}
+	code, err := GenerateTypeScriptCode([]MisasSpecification{
+		&Command{
+			Nam:  "user.register",
+			Desc: "Registers a user.",
+			Fields: []CommandField{
+				{Name: "emailAddress", Description: "Email address of the user.", Type: String},
+				{Name: "registeredBy", Description: "ID of the authenticated user.", Type: Identifier, Annotations: readonly},
+			},
+		},
+		&Struct{
+			Nam:    "user.profile",
+			Desc:   "Profile of a user.",
+			Fields: []StructField{{Name: "registeredBy", Description: "ID of the user who registered it.", Type: Identifier, Annotations: readonly}},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Contains(t, code, "emailAddress: string;")
+	assert.NotContains(t, code, "ID of the authenticated user.")
+	// The annotation only applies to requests, structs keep their fields.
+	assert.Contains(t, code, "ID of the user who registered it.")
+}
+
+func TestGenerateTypeScriptCode_SortedByTypeName(t *testing.T) {
+	specs := []MisasSpecification{
+		&Struct{Nam: "user.profile", Desc: "Profile of a user."},
+		&Command{Nam: "user.register", Desc: "Registers a user."},
+		&Struct{Nam: "account", Desc: "Account of a user."},
+	}
+	code, err := GenerateTypeScriptCode(specs)
+	assert.NoError(t, err)
+
+	reversed, err := GenerateTypeScriptCode([]MisasSpecification{specs[2], specs[1], specs[0]})
+	assert.NoError(t, err)
+	assert.Equal(t, code, reversed)
+
+	account := strings.Index(code, "export interface Account ")
+	profile := strings.Index(code, "export interface UserProfile ")
+	register := strings.Index(code, "export interface UserRegisterCommand ")
+	assert.True(t, account < profile && profile < register, code)
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 Morébec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// DocumentStoreServiceName is the name of the service under which the DocumentStore of a scenario is registered with
+// the system under test. A scenario uses the DocumentStore the system registers under this name, or registers an
+// InMemoryDocumentStore otherwise, so that query handlers can resolve it as a service.
+const DocumentStoreServiceName = "documentStore"
+
+// DocumentStore represents the store of the read models of a system, in which documents can be seeded using GivenDocuments.
+// It is implemented by the InMemoryDocumentStore and the postgresql.DocumentStore.
+type DocumentStore interface {
+	// InsertDocuments inserts documents in a collection.
+	InsertDocuments(ctx context.Context, collection string, docs ...any) error
+
+	// FindDocument returns the document of a collection having a given id and indicates if it was found.
+	FindDocument(ctx context.Context, collection string, id string) (any, bool, error)
+}
+
+// IdentifiableDocument represents a document exposing its id.
+// The InMemoryDocumentStore uses it to find documents, falling back to their exported string ID field.
+type IdentifiableDocument interface {
+	DocumentID() string
+}
+
+// InMemoryDocumentStore is an implementation of a DocumentStore keeping documents in memory.
+// It is the document store used by scenarios whose system does not register one.
+type InMemoryDocumentStore struct {
+	mu          sync.Mutex
+	collections map[string][]any
+}
+
+func NewInMemoryDocumentStore() *InMemoryDocumentStore {
+	return &InMemoryDocumentStore{collections: map[string][]any{}}
+}
+
+func (ds *InMemoryDocumentStore) InsertDocuments(_ context.Context, collection string, docs ...any) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	ds.collections[collection] = append(ds.collections[collection], docs...)
+	return nil
+}
+
+func (ds *InMemoryDocumentStore) FindDocument(_ context.Context, collection string, id string) (any, bool, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	for _, doc := range ds.collections[collection] {
+		if docID, ok := documentID(doc); ok && docID == id {
+			return doc, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// documentID returns the id of a document, either through IdentifiableDocument or its exported string ID field.
+func documentID(doc any) (string, bool) {
+	if d, ok := doc.(IdentifiableDocument); ok {
+		return d.DocumentID(), true
+	}
+
+	v := reflect.ValueOf(doc)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	field := v.FieldByName("ID")
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return "", false
+	}
+
+	return field.String(), true
+}
+
+// Documents returns the documents of a collection in the order they were inserted.
+func (ds *InMemoryDocumentStore) Documents(collection string) []any {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	docs := make([]any, len(ds.collections[collection]))
+	copy(docs, ds.collections[collection])
+	return docs
+}
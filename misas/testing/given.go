@@ -16,9 +16,11 @@ package testing
 
 import (
 	"fmt"
+	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/clock"
 	"github.com/morebec/misas-go/misas/event"
 	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"time"
 )
@@ -39,16 +41,33 @@ func Given(opts ...GivenOption) ScenarioOption {
 }
 
 // CurrentDateIs allows specifying a step where the date of the clock will be set to the provided value.
-// Note that the clock must be a clock.FixedClock for this to take effect. otherwise it will return an error.
+// Note that the clock must be a clock.SettableClock, otherwise the test fails.
 func CurrentDateIs(dt time.Time) GivenOption {
 	return func(scenario *Scenario, s *Stage) {
 		s.addStep(NewStep("setCurrentClockDateTime", func(t assert.TestingT, scenario *Scenario, s *Stage) error {
-			serviceClock := scenario.Clock()
-			c, _ := serviceClock.(clock.FixedClock)
-			//if !ok {
-			//	return errors.New("Can only set the current date on a clock.FixedClock")
-			//}
-			c.CurrentDate = dt
+			c, ok := scenario.Clock().(clock.SettableClock)
+			if !assert.True(t, ok, "the clock of the scenario is a %T, not a clock.SettableClock", scenario.Clock()) {
+				return errors.New("can only set the date of a clock.SettableClock")
+			}
+			c.Set(dt)
+			return nil
+		}))
+	}
+}
+
+// GivenDocuments allows specifying a step where documents are inserted in a collection of the scenario's DocumentStore,
+// so that query handlers have read models to read from.
+func GivenDocuments(collection string, docs ...any) GivenOption {
+	return func(scenario *Scenario, s *Stage) {
+		s.addStep(NewStep(fmt.Sprintf("insertDocumentsInCollection->%s", collection), func(t assert.TestingT, scenario *Scenario, s *Stage) error {
+			if scenario.DocumentStore == nil {
+				return errors.Errorf("failed inserting documents in collection \"%s\": the scenario has no document store", collection)
+			}
+
+			if err := scenario.DocumentStore.InsertDocuments(scenario.Execution.Context, collection, docs...); err != nil {
+				return errors.Wrapf(err, "failed inserting documents in collection \"%s\"", collection)
+			}
+
 			return nil
 		}))
 	}
@@ -57,8 +76,10 @@ func CurrentDateIs(dt time.Time) GivenOption {
 type EventStreamOption func(id store.StreamID, scenario *Scenario, stage *Stage)
 
 // EventStream allows configuring a certain EventStream Given Stage.
+// The id is resolved to a stream id unique to the scenario using Scenario.ResolveStreamID.
 func EventStream(id store.StreamID, opts ...EventStreamOption) GivenOption {
 	return func(scenario *Scenario, s *Stage) {
+		id := scenario.ResolveStreamID(id)
 		for _, opt := range opts {
 			opt(id, scenario, s)
 		}
@@ -74,6 +95,13 @@ func RecordedEvent(event event.Event, opts ...store.AppendToStreamOption) EventS
 			if err != nil {
 				return err
 			}
+			// The converter drops the metadata of events, however given events are recorded with the metadata they were given.
+			if event.Metadata != nil {
+				descriptor.Metadata = make(misas.Metadata, len(event.Metadata))
+				for key, value := range event.Metadata {
+					descriptor.Metadata[key] = value
+				}
+			}
 
 			return scenario.EventStore().AppendToStream(scenario.Execution.Context, id, []store.EventDescriptor{descriptor}, opts...)
 		}))
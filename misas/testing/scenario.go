@@ -31,9 +31,14 @@ import (
 // Scenario represents a test scenario. A Test scenario is made out of Stage (Given, When Then).
 // These stages are further divided up into Step.
 type Scenario struct {
-	stages    []Stage
-	Service   *system.System
-	Execution *ScenarioExecution
+	stages        []Stage
+	Service       *system.System
+	DocumentStore DocumentStore
+	Execution     *ScenarioExecution
+
+	// streamIDs are the stream ids allocated to the stream ids of the stages of this scenario.
+	streamIDs      map[store.StreamID]store.StreamID
+	exactStreamIDs bool
 }
 
 type ScenarioExecution struct {
@@ -112,7 +117,7 @@ func (e *ScenarioExecution) trackEvents() error {
 		e.Context,
 		eventStore.GlobalStreamID(),
 		store.InForwardDirection(),
-		store.From(store.Position(e.lastSequenceNumberRead)),
+		store.From(store.PositionFromSequenceNumber(e.lastSequenceNumberRead)),
 	)
 
 	if err != nil {
@@ -157,12 +162,24 @@ func NewScenario(options ...ScenarioOption) *Scenario {
 			system.WithEnvironment(system.Test),
 			system.WithClock(clock.NewFixedClock(time.Now())),
 		),
+		streamIDs: map[store.StreamID]store.StreamID{},
 	}
 
 	for _, opt := range options {
 		opt(s)
 	}
 
+	// The document store is shared with the system under test, so that the documents seeded by GivenDocuments are
+	// the ones its query handlers read.
+	if s.DocumentStore == nil {
+		if ds, ok := s.Service.Service(DocumentStoreServiceName).(DocumentStore); ok {
+			s.DocumentStore = ds
+		} else {
+			s.DocumentStore = NewInMemoryDocumentStore()
+		}
+	}
+	system.WithService(DocumentStoreServiceName, s.DocumentStore)(s.Service)
+
 	return s
 }
 
@@ -183,6 +200,26 @@ func (s *Scenario) Run(t assert.TestingT) error {
 	return exec.Run(t)
 }
 
+// ResolveStreamID returns the stream id a scenario uses for a stream id of its stages. By default, every stream id is
+// replaced by one allocated with store.UniqueStreamID the first time it is resolved, so that scenarios sharing an event
+// store never write to the same streams, and the same id is returned for every subsequent resolution. The global stream
+// id and the stream ids of scenarios created with WithExactStreamIDs are returned as is.
+func (s *Scenario) ResolveStreamID(id store.StreamID) store.StreamID {
+	if s.exactStreamIDs || id == s.EventStore().GlobalStreamID() {
+		return id
+	}
+
+	if s.streamIDs == nil {
+		s.streamIDs = map[store.StreamID]store.StreamID{}
+	}
+	resolved, found := s.streamIDs[id]
+	if !found {
+		resolved = store.UniqueStreamID(string(id))
+		s.streamIDs[id] = resolved
+	}
+	return resolved
+}
+
 func (s *Scenario) Clock() clock.Clock {
 	return s.Service.Clock
 }
@@ -225,6 +262,23 @@ func WithEventStore(e store.EventStore) ScenarioOption {
 	}
 }
 
+// WithDocumentStore allows specifying the DocumentStore in which documents are seeded by GivenDocuments.
+// It takes precedence over the DocumentStore registered with the system under test.
+func WithDocumentStore(ds DocumentStore) ScenarioOption {
+	return func(s *Scenario) {
+		s.DocumentStore = ds
+	}
+}
+
+// WithExactStreamIDs makes the stages of a scenario use their stream ids as is instead of resolving them to stream ids
+// unique to the scenario, e.g. to expect events appended by handlers to known streams. See Scenario.ResolveStreamID.
+// It must be specified before the stages of the scenario.
+func WithExactStreamIDs() ScenarioOption {
+	return func(s *Scenario) {
+		s.exactStreamIDs = true
+	}
+}
+
 func WithEnvironment(e system.Environment) ScenarioOption {
 	return func(s *Scenario) {
 		s.Service.Environment = e
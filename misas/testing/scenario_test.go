@@ -20,6 +20,8 @@ import (
 	"github.com/morebec/misas-go/misas/command"
 	"github.com/morebec/misas-go/misas/event"
 	"github.com/morebec/misas-go/misas/event/store"
+	"github.com/morebec/misas-go/misas/postgresql"
+	"github.com/morebec/misas-go/misas/query"
 	"github.com/morebec/misas-go/misas/system"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -43,6 +45,7 @@ func (a accountCreated) TypeName() event.PayloadTypeName {
 func TestScenario(t *testing.T) {
 	aClock := clock.NewFixedClock(time.Now())
 	sys := system.New(
+		system.WithClock(aClock),
 		system.WithSubsystems(
 			func(m *system.Subsystem) {
 				// m.RegisterEventHandler().Handles(accountCreated{})
@@ -91,3 +94,205 @@ func TestScenario(t *testing.T) {
 
 	assert.NoError(t, s.Run(t))
 }
+
+type accountView struct {
+	ID string
+}
+
+type accountById struct {
+	ID string
+}
+
+func (a accountById) TypeName() query.PayloadTypeName {
+	return "account.by_id"
+}
+
+// The postgresql.DocumentStore can be used as the DocumentStore of scenarios.
+var _ DocumentStore = (*postgresql.DocumentStore)(nil)
+
+func TestGivenDocuments(t *testing.T) {
+	// The query handler resolves the document store from the system, as query handlers of a system under test do.
+	registerAccountByIdHandler := func(m *system.Subsystem) {
+		m.RegisterQueryHandler(accountById{}.TypeName(), query.HandlerFunc(func(ctx context.Context, q query.Query) (any, error) {
+			documentStore := m.Service(DocumentStoreServiceName).(DocumentStore)
+			doc, _, err := documentStore.FindDocument(ctx, "accounts", q.Payload.(accountById).ID)
+			return doc, err
+		}))
+	}
+
+	tests := []struct {
+		name    string
+		service *system.System
+	}{
+		{
+			name:    "document store registered by the scenario",
+			service: system.New(system.WithSubsystems(registerAccountByIdHandler)),
+		},
+		{
+			name: "document store registered by the system",
+			service: system.New(
+				system.WithService(DocumentStoreServiceName, NewInMemoryDocumentStore()),
+				system.WithSubsystems(registerAccountByIdHandler),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScenario(
+				UsingService(tt.service),
+				Given(
+					GivenDocuments("accounts", accountView{ID: "account-1"}, accountView{ID: "account-2"}),
+				),
+				When(
+					Query(query.Query{Payload: accountById{ID: "account-2"}}),
+				),
+				Then(
+					LastQueryBusErrorShouldBe(nil),
+					LastQueryBusResponseShouldBe(accountView{ID: "account-2"}),
+				),
+			)
+
+			assert.NoError(t, s.Run(t))
+			assert.Same(t, s.DocumentStore, tt.service.Service(DocumentStoreServiceName))
+		})
+	}
+}
+
+func TestEventStream_UniqueStreamIDs(t *testing.T) {
+	sys := system.New(
+		system.WithSubsystems(
+			func(m *system.Subsystem) {
+				m.RegisterEvent(accountCreated{})
+			},
+		),
+	)
+
+	newScenario := func() *Scenario {
+		return NewScenario(
+			UsingService(sys),
+			Given(
+				EventStream("account", RecordedEvent(event.New(accountCreated{}))),
+			),
+			Then(
+				ExpectEventStream("account",
+					HasRecorded(
+						ExactlyTheseEvents(
+							event.New(accountCreated{}),
+						),
+					),
+				),
+			),
+		)
+	}
+
+	// Scenarios sharing an event store should not record events to the same streams.
+	first := newScenario()
+	assert.NoError(t, first.Run(t))
+
+	second := newScenario()
+	assert.NoError(t, second.Run(t))
+
+	assert.NotEqual(t, first.ResolveStreamID("account"), second.ResolveStreamID("account"))
+	assert.Equal(t, first.ResolveStreamID("account"), first.ResolveStreamID("account"))
+	assert.Equal(t, first.EventStore().GlobalStreamID(), first.ResolveStreamID(first.EventStore().GlobalStreamID()))
+}
+
+func TestEventStream_ExactStreamIDs(t *testing.T) {
+	sys := system.New(
+		system.WithSubsystems(
+			func(m *system.Subsystem) {
+				m.RegisterEvent(accountCreated{})
+				m.RegisterCommandHandler(createAccount{}.TypeName(), command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+					descriptor, err := m.System.EventConverter.ConvertEventToDescriptor(event.New(accountCreated{}))
+					if err != nil {
+						return nil, err
+					}
+					return nil, m.System.EventStore.AppendToStream(ctx, "account", []store.EventDescriptor{descriptor})
+				}))
+			},
+		),
+	)
+
+	s := NewScenario(
+		UsingService(sys),
+		WithExactStreamIDs(),
+		When(
+			Command(command.Command{Payload: createAccount{}}),
+		),
+		Then(
+			LastCommandBusErrorShouldBe(nil),
+			ExpectEventStream("account",
+				HasRecorded(
+					ExactlyTheseEvents(
+						event.New(accountCreated{}),
+					),
+				),
+			),
+		),
+	)
+
+	assert.NoError(t, s.Run(t))
+	assert.Equal(t, store.StreamID("account"), s.ResolveStreamID("account"))
+}
+
+type getCurrentDate struct{}
+
+func (c getCurrentDate) TypeName() command.PayloadTypeName {
+	return "clock.get_current_date"
+}
+
+func TestTimePasses(t *testing.T) {
+	currentDate := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	sys := system.New(
+		system.WithClock(clock.NewFixedClock(time.Now())),
+		system.WithSubsystems(
+			func(m *system.Subsystem) {
+				m.RegisterCommandHandler(getCurrentDate{}.TypeName(), command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+					return m.System.Clock.Now(), nil
+				}))
+			},
+		),
+	)
+
+	s := NewScenario(
+		UsingService(sys),
+		Given(
+			CurrentDateIs(currentDate),
+		),
+		When(
+			Command(command.Command{Payload: getCurrentDate{}}),
+		),
+		Then(
+			LastCommandBusResponseShouldBe(currentDate),
+		),
+		When(
+			TimePasses(2*time.Hour),
+			Command(command.Command{Payload: getCurrentDate{}}),
+		),
+		Then(
+			LastCommandBusResponseShouldBe(currentDate.Add(2*time.Hour)),
+		),
+	)
+
+	assert.NoError(t, s.Run(t))
+}
+
+func TestClockSteps_NonSettableClock(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage ScenarioOption
+	}{
+		{name: "current date", stage: Given(CurrentDateIs(time.Now()))},
+		{name: "time passes", stage: When(TimePasses(time.Hour))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScenario(
+				WithClock(clock.UTCClock{}),
+				tt.stage,
+			)
+
+			assertScenarioOutcome(t, s, true, "")
+		})
+	}
+}
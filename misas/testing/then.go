@@ -15,11 +15,15 @@
 package testing
 
 import (
+	"encoding/json"
 	"fmt"
+	"github.com/morebec/misas-go/misas"
 	"github.com/morebec/misas-go/misas/event"
 	"github.com/morebec/misas-go/misas/event/store"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"reflect"
+	"strings"
 )
 
 type ThenOption func(scenario *Scenario, stage *Stage)
@@ -40,8 +44,10 @@ func Then(opts ...ThenOption) ScenarioOption {
 type ExpectEventStreamOption func(id store.StreamID, scenario Scenario, stage *Stage)
 
 // ExpectEventStream allows specifying expectations about a certain event stream.
+// The id is resolved to a stream id unique to the scenario using Scenario.ResolveStreamID.
 func ExpectEventStream(id store.StreamID, opts ...ExpectEventStreamOption) ThenOption {
 	return func(scenario *Scenario, stage *Stage) {
+		id := scenario.ResolveStreamID(id)
 		for _, opt := range opts {
 			opt(id, *scenario, stage)
 		}
@@ -113,14 +119,135 @@ func ExactlyTheseEvents(expectedEvents ...event.Event) HasRecordedOption {
 				return fmt.Errorf("%d events recorded, when %d expected in stream %s", len(actualEvents), len(expectedEvents), id)
 			}
 
-			for _, expectedEvent := range expectedEvents {
-				for _, actualEventDescriptor := range actualEvents {
-					actualEvent, err := scenario.Service.EventConverter.ConvertDescriptorToEvent(actualEventDescriptor)
-					if err != nil {
-						return err
-					}
-					assert.Equal(t, expectedEvent, actualEvent)
+			for i, expectedEvent := range expectedEvents {
+				actualEvent, err := scenario.Service.EventConverter.ConvertDescriptorToEvent(actualEvents[i])
+				if err != nil {
+					return err
 				}
+				if !assert.Equal(t, expectedEvent, actualEvent, "event at index %d of stream %s", i, id) {
+					return errors.Errorf("failed asserting that event at index %d of stream %s was %s, got %s: %s", i, id, expectedEvent.Payload.TypeName(), actualEvent.Payload.TypeName(), eventDiff(expectedEvent, actualEvent))
+				}
+			}
+
+			return nil
+		}))
+	}
+}
+
+// eventDiff describes how an actual event differs from an expected one. For payloads of the same struct type, it lists
+// the fields whose values differ.
+func eventDiff(expected event.Event, actual event.Event) string {
+	var diffs []string
+
+	expectedPayload, actualPayload := reflect.ValueOf(expected.Payload), reflect.ValueOf(actual.Payload)
+	switch {
+	case assert.ObjectsAreEqual(expected.Payload, actual.Payload):
+	case expectedPayload.Kind() == reflect.Struct && expectedPayload.Type() == actualPayload.Type():
+		for i := 0; i < expectedPayload.NumField(); i++ {
+			// Values are compared through their representation since unexported fields cannot be accessed as interfaces.
+			expectedField := fmt.Sprintf("%#v", expectedPayload.Field(i))
+			actualField := fmt.Sprintf("%#v", actualPayload.Field(i))
+			if expectedField != actualField {
+				diffs = append(diffs, fmt.Sprintf("%s: expected %s, got %s", expectedPayload.Type().Field(i).Name, expectedField, actualField))
+			}
+		}
+	default:
+		diffs = append(diffs, fmt.Sprintf("payload: expected %#v, got %#v", expected.Payload, actual.Payload))
+	}
+
+	if !assert.ObjectsAreEqual(expected.Metadata, actual.Metadata) {
+		diffs = append(diffs, fmt.Sprintf("metadata: expected %v, got %v", expected.Metadata, actual.Metadata))
+	}
+
+	return strings.Join(diffs, "; ")
+}
+
+// MetadataMatchMode indicates how the metadata of a recorded event is compared to the expected metadata.
+type MetadataMatchMode string
+
+const (
+	// MetadataSubset indicates that the metadata must contain the expected keys and values, other keys being ignored.
+	MetadataSubset MetadataMatchMode = "SUBSET"
+
+	// MetadataExact indicates that the metadata must be equal to the expected metadata.
+	MetadataExact MetadataMatchMode = "EXACT"
+)
+
+type EventWithMetadataOption func(mode *MetadataMatchMode)
+
+// WithExactMetadata allows specifying that the metadata of the event must be equal to the expected metadata.
+func WithExactMetadata() EventWithMetadataOption {
+	return func(mode *MetadataMatchMode) {
+		*mode = MetadataExact
+	}
+}
+
+// EventWithMetadata allows specifying the expectation that a given stream has recorded an event of a given type
+// whose metadata contains the expected keys and values. Keys of the metadata that are not expected are ignored,
+// unless WithExactMetadata is used.
+func EventWithMetadata(typeName event.PayloadTypeName, expected misas.Metadata, opts ...EventWithMetadataOption) HasRecordedOption {
+	mode := MetadataSubset
+	for _, opt := range opts {
+		opt(&mode)
+	}
+
+	return func(id store.StreamID, scenario Scenario, stage *Stage) {
+		stage.addStep(NewStep(fmt.Sprintf("ExpectEventWithMetadata->%s", typeName), func(t assert.TestingT, scenario *Scenario, stage *Stage) error {
+			var candidates []misas.Metadata
+			for _, d := range scenario.Execution.RecordedEventsByStreamId(id) {
+				if d.TypeName == typeName {
+					candidates = append(candidates, d.Metadata)
+				}
+			}
+
+			if !assert.NotEmpty(t, candidates, "no event %s recorded in stream %s", typeName, id) {
+				return errors.Errorf("failed asserting that an event %s with metadata %v was recorded in stream %s, no event of this type was recorded", typeName, expected, id)
+			}
+
+			for _, actual := range candidates {
+				if mode == MetadataExact && len(actual) != len(expected) {
+					continue
+				}
+				if metadataContains(actual, expected) {
+					return nil
+				}
+			}
+
+			assert.Fail(t, fmt.Sprintf("no event %s recorded in stream %s has metadata matching %v (%s)", typeName, id, expected, mode), "recorded metadata: %v", candidates)
+			return errors.Errorf("failed asserting that an event %s with metadata %v was recorded in stream %s, got %v", typeName, expected, id, candidates)
+		}))
+	}
+}
+
+// metadataContains indicates if the metadata contains all the keys of the expected metadata with equal values.
+func metadataContains(actual misas.Metadata, expected misas.Metadata) bool {
+	for key, expectedValue := range expected {
+		actualValue, found := actual[key]
+		if !found || !assert.ObjectsAreEqual(expectedValue, actualValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpectDocument allows specifying the expectation that a collection of the scenario's DocumentStore contains a document
+// with a given id equal to the expected value. Documents of a DocumentStore representing them with its own type, such
+// as the postgresql.DocumentStore, are compared through their JSON representation.
+func ExpectDocument(collection string, id string, expected any) ThenOption {
+	return func(scenario *Scenario, stage *Stage) {
+		stage.addStep(NewStep(fmt.Sprintf("ExpectDocument->%s/%s", collection, id), func(t assert.TestingT, scenario *Scenario, stage *Stage) error {
+			actual, found, err := findScenarioDocument(scenario, collection, id)
+			if err != nil {
+				return err
+			}
+
+			if !assert.True(t, found, "document \"%s\" not found in collection \"%s\"", id, collection) {
+				return errors.Errorf("failed asserting that collection \"%s\" contains document \"%s\", document not found", collection, id)
+			}
+
+			if !documentsAreEqual(expected, actual) {
+				assert.Equal(t, expected, actual)
+				return errors.Errorf("failed asserting that document \"%s\" of collection \"%s\" was %v, got %v", id, collection, expected, actual)
 			}
 
 			return nil
@@ -128,6 +255,66 @@ func ExactlyTheseEvents(expectedEvents ...event.Event) HasRecordedOption {
 	}
 }
 
+// ExpectNoDocument allows specifying the expectation that a collection of the scenario's DocumentStore does not contain
+// a document with a given id.
+func ExpectNoDocument(collection string, id string) ThenOption {
+	return func(scenario *Scenario, stage *Stage) {
+		stage.addStep(NewStep(fmt.Sprintf("ExpectNoDocument->%s/%s", collection, id), func(t assert.TestingT, scenario *Scenario, stage *Stage) error {
+			actual, found, err := findScenarioDocument(scenario, collection, id)
+			if err != nil {
+				return err
+			}
+
+			if !assert.False(t, found, "document \"%s\" found in collection \"%s\"", id, collection) {
+				return errors.Errorf("failed asserting that collection \"%s\" does not contain document \"%s\", got %v", collection, id, actual)
+			}
+
+			return nil
+		}))
+	}
+}
+
+// documentsAreEqual indicates if an actual document is equal to an expected one, comparing their JSON representation
+// when they are of different types.
+func documentsAreEqual(expected any, actual any) bool {
+	if assert.ObjectsAreEqual(expected, actual) {
+		return true
+	}
+	if reflect.TypeOf(expected) == reflect.TypeOf(actual) {
+		return false
+	}
+
+	expectedJSON, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	actualJSON, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+
+	var expectedValue, actualValue any
+	if json.Unmarshal(expectedJSON, &expectedValue) != nil || json.Unmarshal(actualJSON, &actualValue) != nil {
+		return false
+	}
+
+	return assert.ObjectsAreEqual(expectedValue, actualValue)
+}
+
+// findScenarioDocument finds a document in the DocumentStore of a scenario.
+func findScenarioDocument(scenario *Scenario, collection string, id string) (any, bool, error) {
+	if scenario.DocumentStore == nil {
+		return nil, false, errors.Errorf("failed finding document \"%s\" in collection \"%s\": the scenario has no document store", id, collection)
+	}
+
+	doc, found, err := scenario.DocumentStore.FindDocument(scenario.Execution.Context, collection, id)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed finding document \"%s\" in collection \"%s\"", id, collection)
+	}
+
+	return doc, found, nil
+}
+
 // LastCommandBusResponseShouldBe allows specifying an expectation for the last command bus response.
 func LastCommandBusResponseShouldBe(expectedResponse any) ThenOption {
 	return func(scenario *Scenario, stage *Stage) {
@@ -156,6 +343,26 @@ func LastCommandBusResponseShouldBeString() ThenOption {
 	})
 }
 
+// LastCommandBusResponseShouldBeOfType allows specifying an expectation for the last command bus response to be of type T
+// and to satisfy a typed check, sparing expectations from type asserting the response.
+func LastCommandBusResponseShouldBeOfType[T any](check func(response T) error) ThenOption {
+	return LastCommandBusResponseShould(func(t assert.TestingT, scenario Scenario, actualResponse any) error {
+		response, ok := actualResponse.(T)
+		if !ok {
+			var expected T
+			assert.Fail(t, fmt.Sprintf("last command bus response is not of type %T", expected), "got %T: %v", actualResponse, actualResponse)
+			return errors.Errorf("failed asserting that the last command bus response was of type %T, got %T", expected, actualResponse)
+		}
+
+		if err := check(response); err != nil {
+			assert.Fail(t, "last command bus response check failed", err.Error())
+			return errors.Wrap(err, "failed asserting the last command bus response")
+		}
+
+		return nil
+	})
+}
+
 // LastCommandBusErrorShouldBe  allows specifying an expectation for the command bus to have responses with an error
 func LastCommandBusErrorShouldBe(expectedError error) ThenOption {
 	return func(scenario *Scenario, stage *Stage) {
@@ -240,7 +447,7 @@ func LastQueryBusErrorShouldBe(expectedError error) ThenOption {
 func LastEventBusErrorShould(expectation func(t assert.TestingT, scenario Scenario, actualError any) error) ThenOption {
 	return func(scenario *Scenario, stage *Stage) {
 		stage.addStep(NewStep("LastEventBusErrorShould", func(t assert.TestingT, scenario *Scenario, stage *Stage) error {
-			return expectation(t, *scenario, scenario.Execution.LastCommandBusError)
+			return expectation(t, *scenario, scenario.Execution.LastEventBusError)
 		}))
 	}
 }
@@ -15,9 +15,329 @@
 package testing
 
 import (
+	"context"
+	"github.com/morebec/misas-go/misas"
+	"github.com/morebec/misas-go/misas/command"
+	"github.com/morebec/misas-go/misas/event"
+	"github.com/morebec/misas-go/misas/system"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
 func TestExactlyOneEvent(t *testing.T) {
 
 }
+
+// recordingT is an assert.TestingT recording whether an assertion failed.
+type recordingT struct {
+	failed bool
+}
+
+func (t *recordingT) Errorf(string, ...interface{}) {
+	t.failed = true
+}
+
+// assertScenarioOutcome runs a scenario with a recordingT and asserts whether its assertions failed. When they are
+// expected to fail, the scenario must return an error containing wantMessage, otherwise it must return no error.
+func assertScenarioOutcome(t *testing.T, s *Scenario, wantFailed bool, wantMessage string) {
+	recorder := &recordingT{}
+	err := s.Run(recorder)
+	assert.Equal(t, wantFailed, recorder.failed)
+	if !wantFailed {
+		assert.NoError(t, err)
+		return
+	}
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), wantMessage)
+	}
+}
+
+func TestExpectDocument(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectation ThenOption
+		wantFailed  bool
+	}{
+		{
+			name:        "present document matching",
+			expectation: ExpectDocument("accounts", "account-1", accountView{ID: "account-1"}),
+			wantFailed:  false,
+		},
+		{
+			name:        "present document not matching",
+			expectation: ExpectDocument("accounts", "account-1", accountView{ID: "account-2"}),
+			wantFailed:  true,
+		},
+		{
+			name:        "present document of another type with the same JSON representation",
+			expectation: ExpectDocument("accounts", "account-1", map[string]any{"ID": "account-1"}),
+			wantFailed:  false,
+		},
+		{
+			name:        "present document of another type with another JSON representation",
+			expectation: ExpectDocument("accounts", "account-1", map[string]any{"ID": "account-2"}),
+			wantFailed:  true,
+		},
+		{
+			name:        "absent document",
+			expectation: ExpectDocument("accounts", "account-3", accountView{ID: "account-3"}),
+			wantFailed:  true,
+		},
+		{
+			name:        "no document expected on absent document",
+			expectation: ExpectNoDocument("accounts", "account-3"),
+			wantFailed:  false,
+		},
+		{
+			name:        "no document expected on present document",
+			expectation: ExpectNoDocument("accounts", "account-1"),
+			wantFailed:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScenario(
+				Given(
+					GivenDocuments("accounts", accountView{ID: "account-1"}),
+				),
+				Then(tt.expectation),
+			)
+
+			assertScenarioOutcome(t, s, tt.wantFailed, "")
+		})
+	}
+}
+
+func TestEventWithMetadata(t *testing.T) {
+	tests := []struct {
+		name       string
+		expected   misas.Metadata
+		opts       []EventWithMetadataOption
+		wantFailed bool
+	}{
+		{
+			name:       "matching subset",
+			expected:   misas.Metadata{"correlationId": "correlation-1"},
+			wantFailed: false,
+		},
+		{
+			name:       "missing key",
+			expected:   misas.Metadata{"causationId": "causation-1"},
+			wantFailed: true,
+		},
+		{
+			name:       "different value",
+			expected:   misas.Metadata{"correlationId": "correlation-2"},
+			wantFailed: true,
+		},
+		{
+			name:       "exact match",
+			expected:   misas.Metadata{"correlationId": "correlation-1", "userId": "user-1"},
+			opts:       []EventWithMetadataOption{WithExactMetadata()},
+			wantFailed: false,
+		},
+		{
+			name:       "subset when exact match expected",
+			expected:   misas.Metadata{"correlationId": "correlation-1"},
+			opts:       []EventWithMetadataOption{WithExactMetadata()},
+			wantFailed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sys := system.New(
+				system.WithSubsystems(
+					func(m *system.Subsystem) {
+						m.RegisterEvent(accountCreated{})
+					},
+				),
+			)
+			s := NewScenario(
+				UsingService(sys),
+				Given(
+					EventStream("account", RecordedEvent(event.NewWithMetadata(accountCreated{}, misas.Metadata{
+						"correlationId": "correlation-1",
+						"userId":        "user-1",
+					}))),
+				),
+				Then(
+					ExpectEventStream("account", HasRecorded(EventWithMetadata(accountCreated{}.TypeName(), tt.expected, tt.opts...))),
+				),
+			)
+
+			assertScenarioOutcome(t, s, tt.wantFailed, "")
+		})
+	}
+}
+
+type accountClosed struct {
+	Reason string
+}
+
+func (a accountClosed) TypeName() event.PayloadTypeName {
+	return "account.closed"
+}
+
+func TestExactlyTheseEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    []event.Event
+		wantFailed  bool
+		wantMessage string
+	}{
+		{
+			name:       "same events in same order",
+			expected:   []event.Event{event.New(accountCreated{}), event.New(accountClosed{})},
+			wantFailed: false,
+		},
+		{
+			name:       "same events in different order",
+			expected:   []event.Event{event.New(accountClosed{}), event.New(accountCreated{})},
+			wantFailed: true,
+		},
+		{
+			name:       "duplicated event",
+			expected:   []event.Event{event.New(accountCreated{}), event.New(accountCreated{})},
+			wantFailed: true,
+		},
+		{
+			name:       "missing event",
+			expected:   []event.Event{event.New(accountCreated{})},
+			wantFailed: true,
+		},
+		{
+			name:        "event with a different payload",
+			expected:    []event.Event{event.New(accountCreated{}), event.New(accountClosed{Reason: "fraud"})},
+			wantFailed:  true,
+			wantMessage: `Reason: expected "fraud", got ""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sys := system.New(
+				system.WithSubsystems(
+					func(m *system.Subsystem) {
+						m.RegisterEvent(accountCreated{})
+						m.RegisterEvent(accountClosed{})
+					},
+				),
+			)
+			s := NewScenario(
+				UsingService(sys),
+				Given(
+					EventStream("account",
+						RecordedEvent(event.New(accountCreated{})),
+						RecordedEvent(event.New(accountClosed{})),
+					),
+				),
+				Then(
+					ExpectEventStream("account", HasRecorded(ExactlyTheseEvents(tt.expected...))),
+				),
+			)
+
+			assertScenarioOutcome(t, s, tt.wantFailed, tt.wantMessage)
+		})
+	}
+}
+
+type accountRegistered struct {
+	ID string
+}
+
+func TestLastCommandBusResponseShouldBeOfType(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    any
+		check       func(response accountRegistered) error
+		wantFailed  bool
+		wantMessage string
+	}{
+		{
+			name:     "matching type",
+			response: accountRegistered{ID: "account-1"},
+			check: func(response accountRegistered) error {
+				return nil
+			},
+		},
+		{
+			name:     "failing check",
+			response: accountRegistered{ID: "account-1"},
+			check: func(response accountRegistered) error {
+				return errors.Errorf("expected account-2, got %s", response.ID)
+			},
+			wantFailed:  true,
+			wantMessage: "expected account-2, got account-1",
+		},
+		{
+			name:     "type mismatch",
+			response: "account-1",
+			check: func(response accountRegistered) error {
+				return nil
+			},
+			wantFailed:  true,
+			wantMessage: "failed asserting that the last command bus response was of type testing.accountRegistered, got string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sys := system.New(
+				system.WithSubsystems(
+					func(m *system.Subsystem) {
+						m.RegisterCommandHandler(createAccount{}.TypeName(), command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+							return tt.response, nil
+						}))
+					},
+				),
+			)
+			s := NewScenario(
+				UsingService(sys),
+				When(Command(command.Command{Payload: createAccount{}})),
+				Then(LastCommandBusResponseShouldBeOfType(tt.check)),
+			)
+
+			assertScenarioOutcome(t, s, tt.wantFailed, tt.wantMessage)
+		})
+	}
+}
+
+func TestLastBusErrorShould(t *testing.T) {
+	commandBusError := errors.New("command bus error")
+	eventBusError := errors.New("event bus error")
+
+	sys := system.New(
+		system.WithSubsystems(
+			func(m *system.Subsystem) {
+				m.RegisterCommandHandler(createAccount{}.TypeName(), command.HandlerFunc(func(ctx context.Context, c command.Command) (any, error) {
+					return nil, commandBusError
+				}))
+				m.RegisterEventHandler(event.HandlerFunc(func(ctx context.Context, e event.Event) error {
+					return eventBusError
+				})).Handles(accountCreated{})
+			},
+		),
+	)
+
+	var actualCommandBusError, actualEventBusError any
+	s := NewScenario(
+		UsingService(sys),
+		When(
+			Command(command.Command{Payload: createAccount{}}),
+			Event(event.New(accountCreated{})),
+		),
+		Then(
+			LastCommandBusErrorShould(func(t assert.TestingT, scenario Scenario, actualError any) error {
+				actualCommandBusError = actualError
+				return nil
+			}),
+			LastEventBusErrorShould(func(t assert.TestingT, scenario Scenario, actualError any) error {
+				actualEventBusError = actualError
+				return nil
+			}),
+		),
+	)
+
+	assert.NoError(t, s.Run(t))
+	assert.ErrorIs(t, actualCommandBusError.(error), commandBusError)
+	assert.ErrorIs(t, actualEventBusError.(error), eventBusError)
+}
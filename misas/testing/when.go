@@ -15,11 +15,15 @@
 package testing
 
 import (
+	"fmt"
+	"github.com/morebec/misas-go/misas/clock"
 	"github.com/morebec/misas-go/misas/command"
 	"github.com/morebec/misas-go/misas/event"
 	"github.com/morebec/misas-go/misas/prediction"
 	"github.com/morebec/misas-go/misas/query"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"time"
 )
 
 type WhenOption func(scenario *Scenario, stage *Stage)
@@ -37,6 +41,22 @@ func When(opts ...WhenOption) ScenarioOption {
 	}
 }
 
+// TimePasses Allows adding a step advancing the clock of the scenario by a given duration,
+// so that the following steps observe the new date and time.
+// Note that the clock must be a clock.SettableClock, otherwise the test fails.
+func TimePasses(d time.Duration) WhenOption {
+	return func(scenario *Scenario, stage *Stage) {
+		stage.addStep(NewStep(fmt.Sprintf("advanceClockBy->%s", d), func(t assert.TestingT, scenario *Scenario, stage *Stage) error {
+			c, ok := scenario.Clock().(clock.SettableClock)
+			if !assert.True(t, ok, "the clock of the scenario is a %T, not a clock.SettableClock", scenario.Clock()) {
+				return errors.New("can only advance the time of a clock.SettableClock")
+			}
+			c.Advance(d)
+			return nil
+		}))
+	}
+}
+
 // Command Allows adding a step to send a Command to the command.Bus
 func Command(c command.Command) WhenOption {
 	return func(scenario *Scenario, stage *Stage) {